@@ -96,7 +96,7 @@ var apikeyListCmd = &cobra.Command{
 			rows[i] = []string{k.KeyID, k.Name, k.Status, k.MaskedKey, k.CreatedAt}
 		}
 
-		return output.PrintTable(headers, rows)
+		return output.NewFormatter().PrintList(headers, rows, keys, nil)
 	},
 }
 
@@ -10,7 +10,6 @@ import (
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
-	"github.com/TencentCloudAgentRuntime/ags-cli/internal/token"
 )
 
 var (
@@ -186,7 +185,7 @@ func browserVNCCommand(cmd *cobra.Command, args []string) error {
 // It first checks the token cache, then tries to acquire from the control plane API.
 func acquireInstanceToken(ctx context.Context, instanceID string) (string, error) {
 	// Try to get token from cache first
-	tokenCache, err := token.NewCache()
+	tokenCache, err := newTokenCache()
 	if err == nil {
 		if cachedToken, ok := tokenCache.Get(instanceID); ok && cachedToken != "" {
 			return cachedToken, nil
@@ -0,0 +1,517 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	configShowSecrets    bool
+	configSkipValidation bool
+)
+
+// configValidateTimeout bounds the ListInstances call made by config init.
+const configValidateTimeout = 30 * time.Second
+
+func init() {
+	addConfigCommand(rootCmd)
+}
+
+// addConfigCommand adds the config command to a parent command
+func addConfigCommand(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage CLI configuration",
+		Long: `Manage the AGS CLI configuration file (~/.ags/config.toml, or --config).
+
+Keys use dotted names such as region, cloud.secret_id or retry.max_delay.
+Keys of a profile are written as profiles.<name>.<key>. set and unset edit
+the file in place, keeping comments and layout where possible.
+
+Profiles are [profiles.<name>] sections that override top-level fields
+(backend, region, domain, internal, e2b, cloud, sandbox). Select one with
+--profile or AGS_PROFILE, or set a default with the top-level "profile" field.`,
+	}
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Interactively create or update the configuration",
+		Long: `Interactively choose the backend, region and credentials, check them with a
+live instance list request and save them to the config file.
+
+With --profile the answers are saved to that profile ([profiles.<name>]),
+which is created if it does not exist. Current values are offered as
+defaults, so running init again only changes what you type.
+
+For the cloud backend, choosing the keyring or file credential source stores
+the keys in the OS keyring or the passphrase-encrypted credentials file
+instead of the config file, and removes plaintext keys from the section.`,
+		Example: `  ags config init
+  ags --profile prod config init`,
+		Args: cobra.NoArgs,
+		RunE: runConfigInit,
+	}
+	initCmd.Flags().BoolVar(&configSkipValidation, "skip-validation", false, "Save without checking the credentials against the API")
+	cmd.AddCommand(initCmd)
+
+	getCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a configuration value",
+		Long: `Print the value in effect for a key, after applying the selected profile,
+environment variables and flags. Keys of a profile (profiles.<name>.<key>)
+are read from the config file. Secrets are masked unless --show-secrets is given.`,
+		Example: `  ags config get region
+  ags config get profiles.prod.region
+  ags config get cloud.secret_key --show-secrets`,
+		Args: cobra.ExactArgs(1),
+		RunE: runConfigGet,
+	}
+	getCmd.Flags().BoolVar(&configShowSecrets, "show-secrets", false, "Print secrets in clear text")
+	cmd.AddCommand(getCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Write a value to the config file",
+		Long: `Write a value to the config file, creating the file if needed. The value is
+checked against the key's type (string, bool, int or duration). Use "-" as
+the value to read it from stdin, which keeps secrets out of shell history.`,
+		Example: `  ags config set region ap-shanghai
+  ags config set profiles.prod.backend cloud
+  ags config set cloud.secret_key -`,
+		Args: cobra.ExactArgs(2),
+		RunE: runConfigSet,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a value from the config file",
+		Example: `  ags config unset debug
+  ags config unset profiles.prod.region`,
+		Args: cobra.ExactArgs(1),
+		RunE: runConfigUnset,
+	})
+
+	viewCmd := &cobra.Command{
+		Use:   "view",
+		Short: "Show the effective configuration",
+		Long: `Show every configuration value in effect and where it came from: a flag, an
+environment variable, the selected profile, the config file or the default.
+Secrets are masked unless --show-secrets is given.`,
+		Args: cobra.NoArgs,
+		RunE: runConfigView,
+	}
+	viewCmd.Flags().BoolVar(&configShowSecrets, "show-secrets", false, "Print secrets in clear text")
+	cmd.AddCommand(viewCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "profiles",
+		Aliases: []string{"profile"},
+		Short:   "List configured profiles",
+		Long: `List the [profiles.<name>] sections of the config file with the backend and
+region each one uses. The active profile is marked with '*'.`,
+		Args: cobra.NoArgs,
+		RunE: runConfigProfiles,
+	})
+
+	parent.AddCommand(cmd)
+}
+
+func runConfigProfiles(_ *cobra.Command, _ []string) error {
+	profiles := config.Profiles()
+	if len(profiles) == 0 {
+		output.PrintInfo("No profiles configured; add [profiles.<name>] sections to the config file")
+		return nil
+	}
+
+	headers := []string{"CURRENT", "NAME", "BACKEND", "REGION"}
+	rows := make([][]string, len(profiles))
+	for i, p := range profiles {
+		active := ""
+		if p.Active {
+			active = "*"
+		}
+		rows[i] = []string{active, p.Name, p.Backend, p.Region}
+	}
+	return output.NewFormatter().PrintList(headers, rows, profiles, nil)
+}
+
+func runConfigView(_ *cobra.Command, _ []string) error {
+	values := config.EffectiveValues(configShowSecrets)
+	headers := []string{"KEY", "VALUE", "SOURCE"}
+	rows := make([][]string, len(values))
+	for i, v := range values {
+		rows[i] = []string{v.Key, v.Value, v.Source}
+	}
+	if path, err := config.FilePath(); err == nil && !output.NewFormatter().IsJSON() {
+		fmt.Fprintf(os.Stderr, "Config file: %s\n", path)
+	}
+	return output.NewFormatter().PrintList(headers, rows, values, nil)
+}
+
+func runConfigGet(_ *cobra.Command, args []string) error {
+	setting, profile, err := config.LookupSetting(args[0])
+	if err != nil {
+		return err
+	}
+	key := strings.ToLower(args[0])
+
+	var value string
+	if profile == "" && !setting.Legacy {
+		for _, v := range config.EffectiveValues(configShowSecrets) {
+			if v.Key == key {
+				value = v.Value
+			}
+		}
+	} else {
+		raw, ok := config.FileValue(key)
+		if !ok {
+			return fmt.Errorf("%s is not set in the config file", key)
+		}
+		value = fmt.Sprint(raw)
+		if setting.Secret && !configShowSecrets {
+			value = config.MaskSecret(value)
+		}
+	}
+
+	f := output.NewFormatter()
+	if f.IsJSON() {
+		return f.PrintJSON(map[string]string{"key": key, "value": value})
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(_ *cobra.Command, args []string) error {
+	key := strings.ToLower(args[0])
+	setting, _, err := config.LookupSetting(key)
+	if err != nil {
+		return err
+	}
+	raw := args[1]
+	if raw == "-" {
+		if raw, err = readValue(bufio.NewReader(os.Stdin), setting.Key, setting.Secret); err != nil {
+			return err
+		}
+		if raw == "" {
+			return fmt.Errorf("no value read from stdin for %s", key)
+		}
+	}
+	value, err := config.ParseValue(setting, raw)
+	if err != nil {
+		return err
+	}
+
+	preserved, err := config.SetFileValue(key, value)
+	if err != nil {
+		return fmt.Errorf("failed to update config file: %w", err)
+	}
+	reportConfigWrite(preserved)
+	output.PrintSuccess(fmt.Sprintf("Set %s", key))
+	return nil
+}
+
+func runConfigUnset(_ *cobra.Command, args []string) error {
+	key := strings.ToLower(args[0])
+	if _, _, err := config.LookupSetting(key); err != nil {
+		return err
+	}
+	preserved, err := config.UnsetFileValue(key)
+	if err != nil {
+		return err
+	}
+	reportConfigWrite(preserved)
+	output.PrintSuccess(fmt.Sprintf("Unset %s", key))
+	return nil
+}
+
+// reportConfigWrite warns when the config file had to be rewritten.
+func reportConfigWrite(preserved bool) {
+	if !preserved {
+		output.PrintWarning("The config file was rewritten; comments and formatting were not preserved")
+	}
+}
+
+func runConfigInit(_ *cobra.Command, _ []string) error {
+	in := bufio.NewReader(os.Stdin)
+	profile := config.GetProfile()
+	prefix := ""
+	if profile != config.DefaultProfile {
+		prefix = "profiles." + strings.ToLower(profile) + "."
+		fmt.Fprintf(os.Stderr, "Configuring profile %q\n", profile)
+	}
+
+	backendName, err := askChoice(in, "Backend", []string{"e2b", "cloud", "local"}, config.GetBackend())
+	if err != nil {
+		return err
+	}
+	config.SetBackend(backendName)
+	updates := [][2]any{{"backend", backendName}}
+
+	if backendName != "local" {
+		region, err := askString(in, "Region", config.GetRegion())
+		if err != nil {
+			return err
+		}
+		config.SetRegion(region)
+		updates = append(updates, [2]any{"region", region})
+	}
+
+	switch backendName {
+	case "e2b":
+		apiKey, err := askSecret(in, "E2B API key", config.GetE2BConfig().APIKey)
+		if err != nil {
+			return err
+		}
+		config.SetE2BAPIKey(apiKey)
+		updates = append(updates, [2]any{"e2b.api_key", apiKey})
+	case "cloud":
+		cloudUpdates, err := askCloudCredentials(in)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, cloudUpdates...)
+	}
+
+	// Keys bound for the keyring or the encrypted file are checked as
+	// static keys and only stored once they work
+	source := config.GetCloudConfig().CredentialSource
+	stored := backendName == "cloud" && (source == config.CredentialSourceKeyring || source == config.CredentialSourceFile)
+	if stored {
+		config.SetCloudCredentialSource(config.CredentialSourceStatic)
+	}
+	if err := config.ValidateConfig(config.Get()); err != nil {
+		return err
+	}
+	// Every value the profile needs was just set, so a profile that does
+	// not exist yet can be used for the credential check.
+	config.AcceptProfile()
+	if !configSkipValidation {
+		if err := checkCredentials(backendName); err != nil {
+			return fmt.Errorf("%w\nConfiguration was not saved; fix the values or re-run with --skip-validation", err)
+		}
+		output.PrintSuccess("Credentials verified")
+	}
+	if stored {
+		cloud := config.GetCloudConfig()
+		if err := config.StoreCloudCredential(source, cloud.SecretID, cloud.SecretKey); err != nil {
+			return err
+		}
+		output.PrintSuccess(fmt.Sprintf("Keys stored in the %s credential store", source))
+	}
+
+	preserved := true
+	for _, u := range updates {
+		var ok bool
+		var err error
+		if u[1] == nil {
+			if _, set := config.FileValue(prefix + u[0].(string)); !set {
+				continue
+			}
+			ok, err = config.UnsetFileValue(prefix + u[0].(string))
+		} else {
+			ok, err = config.SetFileValue(prefix+u[0].(string), u[1])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update config file: %w", err)
+		}
+		preserved = preserved && ok
+	}
+	reportConfigWrite(preserved)
+	if prefix != "" && backendName == "cloud" && source != config.CredentialSourceStatic {
+		_, hasID := config.FileValue("cloud.secret_id")
+		_, hasKey := config.FileValue("cloud.secret_key")
+		if hasID || hasKey {
+			output.PrintWarning("The top-level cloud.secret_id/cloud.secret_key are inherited by this profile and conflict with its credential source; remove them with 'ags config unset'")
+		}
+	}
+	path, _ := config.FilePath()
+	output.PrintSuccess(fmt.Sprintf("Configuration saved to %s", path))
+	return nil
+}
+
+// askCloudCredentials prompts for the credential source of the cloud backend
+// and its settings. It returns the config file updates, where a nil value
+// removes a key, so that plaintext keys do not remain next to another source.
+func askCloudCredentials(in *bufio.Reader) ([][2]any, error) {
+	cloud := config.GetCloudConfig()
+	current := cloud.CredentialSource
+	if current == "" {
+		current = config.CredentialSourceStatic
+		if cloud.SecretID == "" && cloud.CredentialProcess != "" {
+			current = config.CredentialSourceProcess
+		}
+	}
+	source, err := askChoice(in, "Credential source", config.CredentialSources, current)
+	if err != nil {
+		return nil, err
+	}
+	config.SetCloudCredentialSource(source)
+
+	noKeys := [][2]any{{"cloud.secret_id", nil}, {"cloud.secret_key", nil}, {"cloud.token", nil}}
+	switch source {
+	case config.CredentialSourceProcess:
+		command, err := askString(in, "Credential process command", cloud.CredentialProcess)
+		if err != nil {
+			return nil, err
+		}
+		config.SetCloudCredentialProcess(command)
+		config.SetCloudSecretID("")
+		config.SetCloudSecretKey("")
+		return append(noKeys, [2]any{"cloud.credential_source", source}, [2]any{"cloud.credential_process", command}), nil
+	case config.CredentialSourceCVMRole:
+		fmt.Fprintf(os.Stderr, "CAM role name [%s]: ", cmp.Or(cloud.CVMRole, "detect"))
+		role, err := readValue(in, "CAM role name", false)
+		if err != nil {
+			return nil, err
+		}
+		role = cmp.Or(role, cloud.CVMRole)
+		config.SetCloudCVMRole(role)
+		config.SetCloudSecretID("")
+		config.SetCloudSecretKey("")
+		updates := append(noKeys, [2]any{"cloud.credential_source", source})
+		if role != "" {
+			updates = append(updates, [2]any{"cloud.cvm_role", role})
+		}
+		return updates, nil
+	}
+
+	secretID, err := askSecret(in, "Tencent Cloud SecretID", cloud.SecretID)
+	if err != nil {
+		return nil, err
+	}
+	secretKey, err := askSecret(in, "Tencent Cloud SecretKey", cloud.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	config.SetCloudSecretID(secretID)
+	config.SetCloudSecretKey(secretKey)
+	if source == config.CredentialSourceStatic {
+		return [][2]any{{"cloud.secret_id", secretID}, {"cloud.secret_key", secretKey}, {"cloud.credential_source", nil}}, nil
+	}
+	return append(noKeys, [2]any{"cloud.credential_source", source}), nil
+}
+
+// checkCredentials makes a live ListInstances call with the configuration
+// being initialized.
+func checkCredentials(backendName string) error {
+	apiClient, err := client.NewControlPlaneClient(backendName)
+	if err != nil {
+		return fmt.Errorf("failed to create API client: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), configValidateTimeout)
+	defer cancel()
+	if _, err := apiClient.ListInstances(ctx, &client.ListInstancesOptions{Limit: 1}); err != nil {
+		return fmt.Errorf("credential check failed: %w", err)
+	}
+	return nil
+}
+
+func askString(in *bufio.Reader, label, current string) (string, error) {
+	if current != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", label, current)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", label)
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read %s: %w", label, err)
+	}
+	if v := strings.TrimSpace(line); v != "" {
+		return v, nil
+	}
+	if current == "" {
+		return "", fmt.Errorf("%s is required", label)
+	}
+	return current, nil
+}
+
+func askChoice(in *bufio.Reader, label string, choices []string, current string) (string, error) {
+	for {
+		v, err := askString(in, fmt.Sprintf("%s (%s)", label, strings.Join(choices, ", ")), current)
+		if err != nil {
+			return "", err
+		}
+		for _, c := range choices {
+			if strings.EqualFold(v, c) {
+				return c, nil
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Please enter one of: %s\n", strings.Join(choices, ", "))
+	}
+}
+
+// askSecret prompts for a secret without echo when stdin is a terminal. An
+// empty answer keeps the current value.
+func askSecret(in *bufio.Reader, label, current string) (string, error) {
+	if current != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s, Enter to keep]: ", label, config.MaskSecret(current))
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", label)
+	}
+	v, err := readValue(in, label, true)
+	if err != nil {
+		return "", err
+	}
+	if v != "" {
+		return v, nil
+	}
+	if current == "" {
+		return "", fmt.Errorf("%s is required", label)
+	}
+	return current, nil
+}
+
+// promptPassphrase asks for the passphrase of the encrypted credentials file
+// on the terminal, twice when the file is being created.
+func promptPassphrase(confirm bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	fmt.Fprint(os.Stderr, "Credentials file passphrase: ")
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if string(again) != string(p) {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return string(p), nil
+}
+
+// readValue reads one line from stdin, without echo for secrets typed on a
+// terminal.
+func readValue(in *bufio.Reader, label string, secret bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if secret && term.IsTerminal(fd) {
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", label, err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read %s: %w", label, err)
+	}
+	return strings.TrimSpace(line), nil
+}
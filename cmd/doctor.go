@@ -0,0 +1,546 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/constant"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/command"
+	"github.com/spf13/cobra"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/token"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/tunnelstore"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/utils"
+)
+
+// Doctor check results. Only failures make the command exit non-zero;
+// warnings cover optional tools and problems that do not block commands.
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+	doctorSkip = "skip"
+)
+
+// doctorCertWarning is how long before expiry an endpoint certificate is
+// reported as a warning.
+const doctorCertWarning = 14 * 24 * time.Hour
+
+var doctorTimeout time.Duration
+
+// doctorCheck is the outcome of one diagnostic check.
+type doctorCheck struct {
+	Name        string `json:"name"`
+	Target      string `json:"target"`
+	Status      string `json:"status"`
+	Detail      string `json:"detail,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// doctorEndpoint is a host that is checked for DNS and TLS.
+type doctorEndpoint struct {
+	name   string // control-plane or data-plane
+	host   string // host name resolved and dialed
+	target string // name shown in the report
+}
+
+// doctor runs the checks and collects their results.
+type doctor struct {
+	backend string
+	timeout time.Duration
+	checks  []doctorCheck
+}
+
+func init() {
+	addDoctorCommand(rootCmd)
+}
+
+// addDoctorCommand adds the doctor command to a parent command
+func addDoctorCommand(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose configuration and connectivity problems",
+		Long: `Check the configuration and the environment of the CLI and print a
+remediation for every problem found.
+
+Checks:
+  - config: the configuration is valid for the selected backend
+  - credentials: cloud API credentials resolve (cloud backend)
+  - dns, tls: the control plane and data plane endpoints resolve and
+    present a trusted certificate; the endpoints of the other network
+    variant (internal or public) are resolved for comparison
+  - auth: the control plane accepts the credentials, and the data plane
+    accepts the access token of a running instance when one is available
+  - token-cache, tunnel-store: ~/.ags/tokens.json and ~/.ags/tunnels.json
+    can be locked and parsed and are private to the user
+  - adb, browser: the tools used by 'ags mobile' and webshell logins are
+    installed
+
+Network checks are skipped for the local backend. The command exits with
+code 1 when any check fails; warnings do not affect the exit code.
+
+Examples:
+  ags doctor
+  ags doctor --backend cloud --profile prod
+  ags doctor -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			d := &doctor{backend: config.GetBackend(), timeout: doctorTimeout}
+			d.run(ctx)
+			return printDoctorReport(d.checks)
+		},
+	}
+	cmd.Flags().DurationVar(&doctorTimeout, "timeout", 10*time.Second, "Timeout of each network check")
+	parent.AddCommand(cmd)
+}
+
+func (d *doctor) add(c doctorCheck) {
+	d.checks = append(d.checks, c)
+}
+
+// run runs every check in order. Checks that depend on an earlier one are
+// reported as skipped when it failed.
+func (d *doctor) run(ctx context.Context) {
+	configOK := d.checkConfig()
+	credentialsOK := true
+	if d.backend == "cloud" {
+		credentialsOK = d.checkCredentials(configOK)
+	}
+
+	reachable := d.backend == "local"
+	if !reachable {
+		reachable = d.checkEndpoints(ctx)
+	}
+
+	var instances []client.Instance
+	if configOK && credentialsOK && reachable {
+		instances = d.checkControlPlaneAuth(ctx)
+	} else {
+		d.add(doctorCheck{Name: "auth", Target: "control-plane", Status: doctorSkip, Detail: "configuration, credentials or endpoints failed"})
+	}
+	if instances != nil {
+		d.checkDataPlaneAuth(ctx, instances)
+	}
+
+	d.checkTokenCache()
+	d.checkTunnelStore()
+	d.checkADB(ctx)
+	d.checkBrowser()
+}
+
+func (d *doctor) checkConfig() bool {
+	target := "-"
+	if path, err := config.FilePath(); err == nil {
+		target = path
+	}
+	target = fmt.Sprintf("%s (backend %s, profile %s)", target, d.backend, config.GetProfile())
+	if err := config.Validate(); err != nil {
+		d.add(doctorCheck{Name: "config", Target: target, Status: doctorFail, Detail: err.Error(),
+			Remediation: "Run 'ags config init' to set up the backend, or inspect the effective values with 'ags config view'"})
+		return false
+	}
+	d.add(doctorCheck{Name: "config", Target: target, Status: doctorOK})
+	return true
+}
+
+func (d *doctor) checkCredentials(configOK bool) bool {
+	if !configOK {
+		d.add(doctorCheck{Name: "credentials", Target: "cloud", Status: doctorSkip, Detail: "configuration is invalid"})
+		return false
+	}
+	_, source, err := config.CloudCredential()
+	if err != nil {
+		remediation := "Run 'ags config init' to store the cloud API keys again"
+		if config.GetCloudConfig().CredentialSource == config.CredentialSourceCVMRole {
+			remediation = "Bind a CAM role to this CVM instance, or choose another cloud.credential_source"
+		}
+		d.add(doctorCheck{Name: "credentials", Target: "cloud", Status: doctorFail, Detail: err.Error(), Remediation: remediation})
+		return false
+	}
+	d.add(doctorCheck{Name: "credentials", Target: "cloud", Status: doctorOK, Detail: "source: " + source})
+	return true
+}
+
+// doctorEndpoints returns the control plane and data plane endpoints of cfg.
+// The data plane is probed through the host of the envd port of a made-up
+// instance, which is served by the same wildcard record as real instances.
+func doctorEndpoints(cfg *config.Config, backend string) []doctorEndpoint {
+	control := cfg.ControlPlaneEndpoint()
+	if backend == "e2b" {
+		control = strings.TrimPrefix(cfg.E2BControlPlaneEndpoint(), "https://")
+	}
+	return []doctorEndpoint{
+		{name: "control-plane", host: control, target: control},
+		{name: "data-plane", host: fmt.Sprintf("%d-doctor.%s", constant.EnvdPort, cfg.DataPlaneRegionDomain()), target: "*." + cfg.DataPlaneRegionDomain()},
+	}
+}
+
+// alternateConfig returns a copy of cfg that uses the other network variant:
+// the public endpoints when internal endpoints are configured, and the
+// internal ones otherwise.
+func alternateConfig(cfg *config.Config) *config.Config {
+	alt := *cfg
+	alt.Internal = !cfg.Internal
+	if alt.Internal {
+		alt.Domain = "internal." + cfg.Domain
+	} else {
+		alt.Domain = strings.TrimPrefix(cfg.Domain, "internal.")
+	}
+	return &alt
+}
+
+func variantName(internal bool) string {
+	if internal {
+		return "internal"
+	}
+	return "public"
+}
+
+// checkEndpoints checks DNS and TLS of the configured endpoints and reports
+// whether the control plane is reachable.
+func (d *doctor) checkEndpoints(ctx context.Context) bool {
+	cfg := config.Get()
+	alt := alternateConfig(cfg)
+	altEndpoints := doctorEndpoints(alt, d.backend)
+
+	reachable := map[string]bool{}
+	for i, ep := range doctorEndpoints(cfg, d.backend) {
+		_, altErr := d.lookup(ctx, altEndpoints[i].host)
+		addrs, err := d.lookup(ctx, ep.host)
+		if err != nil {
+			remediation := "Check the network connection and DNS settings, and that region and domain are correct"
+			switch {
+			case altErr == nil:
+				remediation = fmt.Sprintf("The %s endpoints resolve from this network; set 'internal = %t' with 'ags config set internal %t' or use --internal=%t",
+					variantName(alt.Internal), alt.Internal, alt.Internal, alt.Internal)
+			case cfg.Internal:
+				remediation = "Internal endpoints only resolve inside Tencent Cloud VPCs; set 'internal = false' when running elsewhere"
+			}
+			d.add(doctorCheck{Name: "dns", Target: ep.target, Status: doctorFail, Detail: err.Error(), Remediation: remediation})
+			d.add(doctorCheck{Name: "tls", Target: ep.target, Status: doctorSkip, Detail: "DNS lookup failed"})
+		} else {
+			d.add(doctorCheck{Name: "dns", Target: ep.target, Status: doctorOK, Detail: strings.Join(firstN(addrs, 3), ", ")})
+			reachable[ep.name] = d.checkTLS(ctx, ep)
+		}
+
+		altCheck := doctorCheck{Name: "dns (" + variantName(alt.Internal) + ")", Target: altEndpoints[i].target, Status: doctorOK, Detail: "resolves from this network"}
+		if altErr != nil {
+			altCheck.Status, altCheck.Detail = doctorSkip, "does not resolve from this network"
+		}
+		d.add(altCheck)
+	}
+	return reachable["control-plane"]
+}
+
+func (d *doctor) lookup(ctx context.Context, host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	return net.DefaultResolver.LookupHost(ctx, host)
+}
+
+// checkTLS completes a TLS handshake with the endpoint and checks its
+// certificate. HTTPS_PROXY is not used, so a failure may not affect commands
+// that go through a proxy.
+func (d *doctor) checkTLS(ctx context.Context, ep doctorEndpoint) bool {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: ep.host}}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ep.host, "443"))
+	if err != nil {
+		d.add(doctorCheck{Name: "tls", Target: ep.target, Status: doctorFail, Detail: err.Error(), Remediation: tlsRemediation(err)})
+		return false
+	}
+	defer func() { _ = conn.Close() }()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	cert := state.PeerCertificates[0]
+	c := doctorCheck{Name: "tls", Target: ep.target, Status: doctorOK,
+		Detail: fmt.Sprintf("%s, certificate valid until %s", tls.VersionName(state.Version), cert.NotAfter.Format(time.DateOnly))}
+	if time.Until(cert.NotAfter) < doctorCertWarning {
+		c.Status = doctorWarn
+		c.Remediation = "The certificate expires soon; if it is not renewed in time, check whether a proxy replaces it"
+	}
+	d.add(c)
+	return true
+}
+
+func tlsRemediation(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "The certificate is not trusted; if a corporate proxy inspects TLS, add its CA certificate to the system trust store"
+	case errors.As(err, &hostname):
+		return "The certificate does not match the host; check that domain is correct and that no proxy intercepts the connection"
+	case os.Getenv("HTTPS_PROXY") != "" || os.Getenv("https_proxy") != "":
+		return "This check connects directly; HTTPS_PROXY is set, so commands may still work through the proxy"
+	}
+	return "Check that outbound HTTPS (port 443) is allowed by the firewall"
+}
+
+// checkControlPlaneAuth lists running instances, which requires valid
+// credentials. It returns the instances, or nil when the check failed.
+func (d *doctor) checkControlPlaneAuth(ctx context.Context) []client.Instance {
+	apiClient, err := client.NewControlPlaneClient(d.backend)
+	if err != nil {
+		d.add(doctorCheck{Name: "auth", Target: "control-plane", Status: doctorFail, Detail: err.Error(), Remediation: authRemediation(d.backend, err)})
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	result, err := apiClient.ListInstances(ctx, &client.ListInstancesOptions{Status: "RUNNING", Limit: 20})
+	if err != nil {
+		d.add(doctorCheck{Name: "auth", Target: "control-plane", Status: doctorFail, Detail: err.Error(), Remediation: authRemediation(d.backend, err)})
+		return nil
+	}
+	detail := fmt.Sprintf("%d running instance(s)", len(result.Instances))
+	if d.backend != "local" {
+		detail = "credentials accepted, " + detail
+	}
+	d.add(doctorCheck{Name: "auth", Target: "control-plane", Status: doctorOK, Detail: detail})
+	if result.Instances == nil {
+		return []client.Instance{}
+	}
+	return result.Instances
+}
+
+func authRemediation(backend string, err error) string {
+	switch client.KindOf(err) {
+	case client.KindAuth:
+		if backend == "e2b" {
+			return "Check e2b.api_key; set a valid key with 'ags config set e2b.api_key <key>' or AGS_E2B_API_KEY"
+		}
+		return "Check that the cloud API keys are valid and allowed to use AGS by their CAM policy; store new keys with 'ags config init'"
+	case client.KindNetwork, client.KindTimeout:
+		return "The control plane did not answer in time; retry with a longer --timeout, or run with --debug to see the requests"
+	}
+	return "Run the command again with --debug to see the failing request"
+}
+
+// checkDataPlaneAuth runs a command in a running instance with its access
+// token. Instances with a cached token are preferred; the cloud backend can
+// acquire a token for any instance.
+func (d *doctor) checkDataPlaneAuth(ctx context.Context, instances []client.Instance) {
+	cache, err := newTokenCache()
+	if err != nil {
+		d.add(doctorCheck{Name: "auth", Target: "data-plane", Status: doctorSkip, Detail: err.Error()})
+		return
+	}
+	var instanceID, accessToken string
+	for _, inst := range instances {
+		if t, ok := cache.Get(inst.ID); ok {
+			instanceID, accessToken = inst.ID, t
+			break
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	if instanceID == "" && d.backend == "cloud" && len(instances) > 0 {
+		instanceID = instances[0].ID
+		if accessToken, err = GetCachedTokenOrAcquire(ctx, instanceID); err != nil {
+			d.add(doctorCheck{Name: "auth", Target: "data-plane (" + instanceID + ")", Status: doctorFail, Detail: err.Error(),
+				Remediation: "Check that the cloud API keys are allowed to call AcquireSandboxInstanceToken"})
+			return
+		}
+	}
+	if instanceID == "" {
+		d.add(doctorCheck{Name: "auth", Target: "data-plane", Status: doctorSkip, Detail: "no running instance with a cached access token"})
+		return
+	}
+
+	target := "data-plane (" + instanceID + ")"
+	sandbox, err := ConnectWithToken(ctx, instanceID, accessToken)
+	if err == nil {
+		_, err = sandbox.Commands.Run(ctx, "true", &command.ProcessConfig{User: resolveUser("")}, nil)
+	}
+	if err != nil {
+		remediation := fmt.Sprintf("Check the instance with 'ags instance get %s'", instanceID)
+		if code := connect.CodeOf(err); code == connect.CodeUnauthenticated || code == connect.CodePermissionDenied {
+			remediation = fmt.Sprintf("The cached access token of %s was rejected; remove its entry from %s", instanceID, cache.Path())
+		}
+		d.add(doctorCheck{Name: "auth", Target: target, Status: doctorFail, Detail: err.Error(), Remediation: remediation})
+		return
+	}
+	d.add(doctorCheck{Name: "auth", Target: target, Status: doctorOK, Detail: "access token accepted"})
+}
+
+func (d *doctor) checkTokenCache() {
+	cache, err := newTokenCache()
+	if err != nil {
+		d.add(doctorCheck{Name: "token-cache", Target: "~/.ags", Status: doctorFail, Detail: err.Error(), Remediation: "Make sure ~/.ags is a directory owned by the current user"})
+		return
+	}
+	n, err := cache.Check()
+	c := doctorCheck{Name: "token-cache", Target: cache.Path(), Status: doctorOK, Detail: fmt.Sprintf("%d token(s) for %s", n, cache.Scope())}
+	switch {
+	case errors.Is(err, token.ErrCorrupt):
+		c.Status, c.Detail = doctorFail, err.Error()
+		c.Remediation = fmt.Sprintf("Delete %s; the cloud backend acquires tokens again, e2b instances have to be recreated to access them", cache.Path())
+	case err != nil:
+		c.Status, c.Detail, c.Remediation = doctorFail, err.Error(), lockRemediation(cache.Path())
+	default:
+		checkPrivateFile(&c, cache.Path())
+	}
+	d.add(c)
+}
+
+func (d *doctor) checkTunnelStore() {
+	store, err := newTunnelStore()
+	if err != nil {
+		d.add(doctorCheck{Name: "tunnel-store", Target: "~/.ags", Status: doctorFail, Detail: err.Error(), Remediation: "Make sure ~/.ags is a directory owned by the current user"})
+		return
+	}
+	entries, stale, err := store.Check()
+	c := doctorCheck{Name: "tunnel-store", Target: store.Path(), Status: doctorOK, Detail: fmt.Sprintf("%d tunnel(s) for %s", entries, config.StoreScope())}
+	switch {
+	case errors.Is(err, tunnelstore.ErrCorrupt):
+		c.Status, c.Detail = doctorFail, err.Error()
+		c.Remediation = fmt.Sprintf("Stop running tunnels and delete %s", store.Path())
+	case err != nil:
+		c.Status, c.Detail, c.Remediation = doctorFail, err.Error(), lockRemediation(store.Path())
+	default:
+		if stale > 0 {
+			c.Detail += fmt.Sprintf(", %d stale", stale)
+		}
+		checkPrivateFile(&c, store.Path())
+	}
+	d.add(c)
+}
+
+func lockRemediation(path string) string {
+	return fmt.Sprintf("Another ags process may hold the lock; wait for it to finish, or delete %s.lock if none is running", path)
+}
+
+// checkPrivateFile downgrades c to a warning when the file at path can be
+// read by other users. Windows permissions are not checked.
+func checkPrivateFile(c *doctorCheck, path string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode().Perm()&0o077 == 0 {
+		return
+	}
+	c.Status = doctorWarn
+	c.Detail += fmt.Sprintf(", mode %04o", info.Mode().Perm())
+	c.Remediation = fmt.Sprintf("Restrict the file to the current user with 'chmod 600 %s'", path)
+}
+
+func (d *doctor) checkADB(ctx context.Context) {
+	path, err := requireAdb()
+	if err != nil {
+		d.add(doctorCheck{Name: "adb", Target: "ags mobile", Status: doctorWarn, Detail: err.Error(),
+			Remediation: "Install Android SDK Platform-Tools and add adb to PATH, or set ADB_PATH"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "version").Output()
+	if err != nil {
+		d.add(doctorCheck{Name: "adb", Target: "ags mobile", Status: doctorWarn, Detail: fmt.Sprintf("%s: %v", path, err),
+			Remediation: "Reinstall Android SDK Platform-Tools, or point ADB_PATH to a working adb"})
+		return
+	}
+	version, _, _ := strings.Cut(string(out), "\n")
+	d.add(doctorCheck{Name: "adb", Target: "ags mobile", Status: doctorOK, Detail: fmt.Sprintf("%s (%s)", path, strings.TrimSpace(version))})
+}
+
+func (d *doctor) checkBrowser() {
+	if !utils.IsBrowserAvailable() {
+		d.add(doctorCheck{Name: "browser", Target: "webshell", Status: doctorWarn, Detail: "no browser launcher found",
+			Remediation: "Install xdg-utils or a browser to open webshell logins automatically; otherwise copy the printed URL"})
+		return
+	}
+	d.add(doctorCheck{Name: "browser", Target: "webshell", Status: doctorOK})
+}
+
+func firstN(s []string, n int) []string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// printDoctorReport prints the checks and returns an error when any failed.
+func printDoctorReport(checks []doctorCheck) error {
+	failed, warned := 0, 0
+	for _, c := range checks {
+		switch c.Status {
+		case doctorFail:
+			failed++
+		case doctorWarn:
+			warned++
+		}
+	}
+	var result error
+	if failed > 0 {
+		result = fmt.Errorf("%d of %d check(s) failed", failed, len(checks))
+	}
+
+	f := output.NewFormatter()
+	if f.IsJSON() {
+		status := "passed"
+		if failed > 0 {
+			status = "failed"
+		}
+		if err := f.PrintJSON(map[string]any{
+			"status":   status,
+			"failed":   failed,
+			"warnings": warned,
+			"checks":   checks,
+		}); err != nil {
+			return err
+		}
+		if result != nil {
+			return reportedError(result)
+		}
+		return nil
+	}
+
+	headers := []string{"CHECK", "TARGET", "STATUS", "DETAIL"}
+	rows := make([][]string, len(checks))
+	for i, c := range checks {
+		rows[i] = []string{c.Name, c.Target, strings.ToUpper(c.Status), valueOrDefault(c.Detail, "-")}
+	}
+	if err := f.PrintTable(headers, rows, nil); err != nil {
+		return err
+	}
+
+	if failed+warned > 0 {
+		fmt.Println()
+		fmt.Println("Remediation:")
+		for _, c := range checks {
+			if c.Remediation != "" {
+				fmt.Printf("  [%s] %s %s: %s\n", strings.ToUpper(c.Status), c.Name, c.Target, c.Remediation)
+			}
+		}
+	}
+	if result != nil {
+		return result
+	}
+	if warned > 0 {
+		output.PrintSuccess(fmt.Sprintf("No failures, %d warning(s)", warned))
+		return nil
+	}
+	output.PrintSuccess(fmt.Sprintf("All %d check(s) passed", len(checks)))
+	return nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+)
+
+func TestDoctorEndpoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		backend     string
+		wantControl string
+		wantData    string
+		wantAlt     string
+	}{
+		{
+			name:        "e2b public",
+			cfg:         config.Config{Region: "ap-guangzhou", Domain: "tencentags.com"},
+			backend:     "e2b",
+			wantControl: "api.ap-guangzhou.tencentags.com",
+			wantData:    "49983-doctor.ap-guangzhou.tencentags.com",
+			wantAlt:     "api.ap-guangzhou.internal.tencentags.com",
+		},
+		{
+			name:        "e2b internal",
+			cfg:         config.Config{Region: "ap-beijing", Domain: "internal.tencentags.com", Internal: true},
+			backend:     "e2b",
+			wantControl: "api.ap-beijing.internal.tencentags.com",
+			wantData:    "49983-doctor.ap-beijing.internal.tencentags.com",
+			wantAlt:     "api.ap-beijing.tencentags.com",
+		},
+		{
+			name:        "cloud public",
+			cfg:         config.Config{Region: "ap-guangzhou", Domain: "tencentags.com"},
+			backend:     "cloud",
+			wantControl: "ags.tencentcloudapi.com",
+			wantData:    "49983-doctor.ap-guangzhou.tencentags.com",
+			wantAlt:     "ags.internal.tencentcloudapi.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eps := doctorEndpoints(&tt.cfg, tt.backend)
+			if eps[0].host != tt.wantControl || eps[1].host != tt.wantData {
+				t.Errorf("doctorEndpoints() = %s, %s; want %s, %s", eps[0].host, eps[1].host, tt.wantControl, tt.wantData)
+			}
+			alt := doctorEndpoints(alternateConfig(&tt.cfg), tt.backend)
+			if alt[0].host != tt.wantAlt {
+				t.Errorf("alternate control plane = %s, want %s", alt[0].host, tt.wantAlt)
+			}
+			if tt.cfg.Domain == alternateConfig(&tt.cfg).Domain {
+				t.Error("alternateConfig() must not change the original config")
+			}
+		})
+	}
+}
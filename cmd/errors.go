@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+)
+
+// Process exit codes. These are part of the CLI contract (see docs/ags.md)
+// and must not be renumbered.
+const (
+	exitGeneral        = 1
+	exitUpstreamFailed = 2 // mobile tunnel: upstream probe failed
+	exitTunnelFailed   = 3 // mobile tunnel: local listener failed to start
+	exitNotFound       = 4
+	exitAuth           = 5
+	exitQuota          = 6
+	exitConflict       = 7
+	exitUnsupported    = 8
+	exitNetwork        = 9
+	exitTimeout        = 10
+)
+
+var exitCodesByKind = map[client.ErrorKind]int{
+	client.KindNotFound:    exitNotFound,
+	client.KindAuth:        exitAuth,
+	client.KindQuota:       exitQuota,
+	client.KindConflict:    exitConflict,
+	client.KindUnsupported: exitUnsupported,
+	client.KindNetwork:     exitNetwork,
+	client.KindTimeout:     exitTimeout,
+}
+
+// exitCodeError carries an explicit exit code, overriding the one derived
+// from the error kind. A reported error has already been written as the
+// command's JSON result and is not printed again.
+type exitCodeError struct {
+	code     int
+	err      error
+	reported bool
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+func (e *exitCodeError) Unwrap() error { return e.err }
+
+func exitError(code int, err error) error {
+	return &exitCodeError{code: code, err: err}
+}
+
+// reportedError marks err as already reported in the command's JSON output,
+// so that the process exits with a failure code without a second JSON object.
+func reportedError(err error) error {
+	return &exitCodeError{code: exitCodeFor(err), err: err, reported: true}
+}
+
+// exitCodeFor returns the process exit code for err.
+func exitCodeFor(err error) int {
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	if code, ok := exitCodesByKind[client.KindOf(err)]; ok {
+		return code
+	}
+	return exitGeneral
+}
+
+// printCommandError reports a failed command: a JSON object on stdout under
+// -o json, otherwise "Error: ..." on stderr.
+func printCommandError(err error) {
+	if config.GetOutput() != output.FormatJSON {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return
+	}
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) && exitErr.reported {
+		return
+	}
+	_ = output.NewFormatter().PrintJSON(&output.ErrorResult{
+		Status:   "error",
+		Message:  err.Error(),
+		Kind:     string(client.KindOf(err)),
+		Code:     client.CodeOf(err),
+		ExitCode: exitCodeFor(err),
+	})
+}
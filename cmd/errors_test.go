@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	notFound := &client.Error{Kind: client.KindNotFound, Message: "instance not found: sbi-1"}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "plain error", err: errors.New("boom"), want: exitGeneral},
+		{name: "not found", err: notFound, want: exitNotFound},
+		{name: "wrapped", err: fmt.Errorf("failed to delete instance: %w", notFound), want: exitNotFound},
+		{name: "auth", err: &client.Error{Kind: client.KindAuth}, want: exitAuth},
+		{name: "metrics unavailable", err: client.ErrMetricsUnavailable, want: exitUnsupported},
+		{name: "unclassified client error", err: &client.Error{Message: "500"}, want: exitGeneral},
+		{name: "explicit code wins", err: exitError(exitUpstreamFailed, notFound), want: exitUpstreamFailed},
+		{name: "reported keeps kind", err: reportedError(fmt.Errorf("check failed: %w", notFound)), want: exitNotFound},
+		{name: "reported plain", err: reportedError(errors.New("2 of 3 check(s) failed")), want: exitGeneral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCodeFor(tt.err); got != tt.want {
+				t.Errorf("exitCodeFor() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -105,7 +105,7 @@ func getSandboxForExec(ctx context.Context) (*code.Sandbox, func(), time.Duratio
 	}
 
 	createStart := time.Now()
-	sandbox, err := code.Create(ctx, execTool, getCreateOptions()...)
+	sandbox, err := createSandbox(ctx, execTool)
 	createDuration := time.Since(createStart)
 	if err != nil {
 		return nil, nil, 0, fmt.Errorf("failed to create sandbox: %w", err)
@@ -116,7 +116,7 @@ func getSandboxForExec(ctx context.Context) (*code.Sandbox, func(), time.Duratio
 		output.PrintInfo(fmt.Sprintf("Created instance: %s (kept alive)", sandbox.SandboxId))
 	} else {
 		cleanup = func() {
-			_ = sandbox.Kill(ctx)
+			_ = killSandbox(ctx, sandbox)
 		}
 	}
 
@@ -188,6 +188,7 @@ func execCommand(cmd *cobra.Command, args []string) error {
 			if result.Error != nil {
 				return fmt.Errorf("command failed with exit code %d: %s", result.ExitCode, *result.Error)
 			}
+			cleanup() // os.Exit skips deferred calls
 			os.Exit(int(result.ExitCode))
 		}
 
@@ -234,6 +235,7 @@ func execCommand(cmd *cobra.Command, args []string) error {
 	}
 
 	if result.ExitCode != 0 {
+		cleanup() // os.Exit skips deferred calls
 		os.Exit(int(result.ExitCode))
 	}
 
@@ -289,7 +291,15 @@ func execPsCommand(cmd *cobra.Command, args []string) error {
 
 	headers := []string{"PID", "CMD", "ARGS", "CWD"}
 	rows := make([][]string, len(processes))
+	items := make([]map[string]any, len(processes))
 	for i, p := range processes {
+		items[i] = map[string]any{
+			"pid":  p.Pid,
+			"tag":  p.Tag,
+			"cmd":  p.Cmd,
+			"args": p.Args,
+			"cwd":  p.Cwd,
+		}
 		cwd := "-"
 		if p.Cwd != nil {
 			cwd = *p.Cwd
@@ -306,7 +316,7 @@ func execPsCommand(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := f.PrintTable(headers, rows, nil); err != nil {
+	if err := f.PrintList(headers, rows, items, nil); err != nil {
 		return err
 	}
 
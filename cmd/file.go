@@ -154,7 +154,7 @@ func getSandboxForFile(ctx context.Context) (*code.Sandbox, func(), time.Duratio
 	}
 
 	createStart := time.Now()
-	sandbox, err := code.Create(ctx, fileTool, getCreateOptions()...)
+	sandbox, err := createSandbox(ctx, fileTool)
 	createDuration := time.Since(createStart)
 	if err != nil {
 		return nil, nil, 0, fmt.Errorf("failed to create sandbox: %w", err)
@@ -165,7 +165,7 @@ func getSandboxForFile(ctx context.Context) (*code.Sandbox, func(), time.Duratio
 		output.PrintInfo(fmt.Sprintf("Created instance: %s (kept alive)", sandbox.SandboxId))
 	} else {
 		cleanup = func() {
-			_ = sandbox.Kill(ctx)
+			_ = killSandbox(ctx, sandbox)
 		}
 	}
 
@@ -227,7 +227,7 @@ func fileListCommand(cmd *cobra.Command, args []string) error {
 		rows[i] = []string{fileType, output.FormatSize(e.Size), e.Permissions, modified, e.Name}
 	}
 
-	if err := f.PrintTable(headers, rows, nil); err != nil {
+	if err := f.PrintList(headers, rows, entries, nil); err != nil {
 		return err
 	}
 
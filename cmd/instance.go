@@ -2,15 +2,19 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/pty"
-	"github.com/TencentCloudAgentRuntime/ags-cli/internal/token"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/utils"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/webshell"
 	"github.com/spf13/cobra"
@@ -23,6 +27,7 @@ var (
 	instanceTime         bool
 	instanceMountOptions []string
 	instanceAuthMode     string
+	instanceDryRun       bool
 
 	// list command flags
 	instanceListTool     string
@@ -32,11 +37,23 @@ var (
 	instanceListOffset   int
 	instanceListLimit    int
 
+	instanceListIDs              []string
+	instanceListCreatedSince     string
+	instanceListCreatedSinceTime string
+	instanceListAll              bool
+	instanceListSortBy           string
+	instanceListWatch            bool
+	instanceListWatchInterval    time.Duration
+	instanceListWide             bool
+
+	// resume command flags
+	instanceResumeTimeout int
+
 	// login command flags
-	instanceLoginMode           string
-	instanceLoginNoBrowser      bool
-	instanceLoginTTYDBinary     string
-	instanceLoginUser           string
+	instanceLoginMode            string
+	instanceLoginNoBrowser       bool
+	instanceLoginTTYDBinary      string
+	instanceLoginUser            string
 	instanceLoginSkipStatusCheck bool
 )
 
@@ -52,6 +69,12 @@ Use --tool-name/-t for tool name (e2b/cloud backend) or --tool-id for tool ID (c
 Mount option format (--mount-option):
   name=<name>[,dst=<target-path>][,subpath=<sub-path>][,readonly]
 
+Mount options are checked against the tool's storage mounts before the
+instance is created: the name must match a mount of the tool, subpath must
+stay inside the mount source, a read-only mount cannot be made writable and
+mount paths must not collide. --dry-run performs these checks and prints the
+effective mounts without creating an instance.
+
 Auth mode (--auth-mode):
   DEFAULT  Use backend default (currently TOKEN)
   TOKEN    All ports require X-Access-Token
@@ -65,7 +88,8 @@ Examples:
   ags instance create --tool-id sdt-xxxx
   ags instance create -t my-tool --timeout 600
   ags instance create --tool-id sdt-xxxx --mount-option "name=data,dst=/workspace,subpath=user-123"
-  ags instance create -t my-tool --auth-mode NONE`,
+  ags instance create -t my-tool --auth-mode NONE
+  ags instance create --tool-id sdt-xxxx --mount-option "name=data,readonly" --dry-run`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 		start := time.Now()
@@ -103,6 +127,25 @@ Examples:
 			return fmt.Errorf("failed to create API client: %w", err)
 		}
 
+		// Check mount options against the tool's storage mounts. Backends
+		// that cannot look up tools leave the checks to the server.
+		var mounts []client.EffectiveMount
+		validated := false
+		if len(mountOptions) > 0 || instanceDryRun {
+			tool, err := lookupInstanceTool(ctx, apiClient)
+			switch {
+			case errors.Is(err, client.ErrUnsupported):
+			case err != nil:
+				return err
+			default:
+				mounts, err = client.MergeMountOptions(tool.StorageMounts, mountOptions)
+				if err != nil {
+					return fmt.Errorf("invalid --mount-option for tool %s: %w", tool.Name, err)
+				}
+				validated = true
+			}
+		}
+
 		opts := &client.CreateInstanceOptions{
 			ToolID:       instanceToolID,
 			ToolName:     instanceTool,
@@ -111,6 +154,10 @@ Examples:
 			AuthMode:     authMode,
 		}
 
+		if instanceDryRun {
+			return printInstanceCreateDryRun(opts, mounts, validated)
+		}
+
 		instance, err := apiClient.CreateInstance(ctx, opts)
 		if err != nil {
 			return fmt.Errorf("failed to create instance: %w", err)
@@ -175,6 +222,83 @@ Examples:
 	},
 }
 
+// lookupInstanceTool fetches the tool selected by --tool-id or --tool-name.
+func lookupInstanceTool(ctx context.Context, apiClient client.ControlPlaneClient) (*client.Tool, error) {
+	if instanceToolID != "" {
+		tool, err := apiClient.GetTool(ctx, instanceToolID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tool: %w", err)
+		}
+		return tool, nil
+	}
+	tool, err := findToolByName(ctx, apiClient, instanceTool)
+	if err != nil {
+		return nil, err
+	}
+	if tool == nil {
+		return nil, &client.Error{Kind: client.KindNotFound, Message: fmt.Sprintf("tool not found: %s", instanceTool)}
+	}
+	return tool, nil
+}
+
+// printInstanceCreateDryRun prints the instance that would be created and
+// its effective storage mounts.
+func printInstanceCreateDryRun(opts *client.CreateInstanceOptions, mounts []client.EffectiveMount, validated bool) error {
+	f := output.NewFormatter()
+	authMode := valueOrDefault(opts.AuthMode, client.AuthModeDefault)
+
+	if f.IsJSON() {
+		data := map[string]any{
+			"status":          "success",
+			"dryRun":          true,
+			"tool":            opts.ToolName,
+			"toolId":          opts.ToolID,
+			"timeout":         opts.Timeout,
+			"authMode":        authMode,
+			"mountsValidated": validated,
+			"effectiveMounts": mounts,
+		}
+		if len(opts.MountOptions) > 0 {
+			data["mountOptions"] = opts.MountOptions
+		}
+		return f.PrintJSON(data)
+	}
+
+	output.PrintInfo("Dry run: no instance was created")
+	if err := f.PrintKeyValue([]output.KeyValue{
+		{Key: "Tool", Value: valueOrDefault(opts.ToolName, opts.ToolID)},
+		{Key: "Timeout", Value: fmt.Sprintf("%ds", opts.Timeout)},
+		{Key: "AuthMode", Value: authMode},
+	}); err != nil {
+		return err
+	}
+
+	if !validated {
+		output.PrintWarning("The backend cannot look up tools; mount options were not checked")
+		return nil
+	}
+	if len(mounts) == 0 {
+		output.PrintInfo("The tool has no storage mounts")
+		return nil
+	}
+
+	fmt.Println()
+	headers := []string{"NAME", "SOURCE", "MOUNT PATH", "SUBPATH", "MODE", "OVERRIDDEN"}
+	rows := make([][]string, len(mounts))
+	for i, m := range mounts {
+		mode := "rw"
+		if m.ReadOnly {
+			mode = "ro"
+		}
+		overridden := "no"
+		if m.Overridden {
+			overridden = "yes"
+		}
+		rows[i] = []string{m.Name, m.Source, m.MountPath, valueOrDefault(m.SubPath, "-"), mode, overridden}
+	}
+	return f.PrintTable(headers, rows, nil)
+}
+
 // formatMountOptionsSummary formats mount options for display
 func formatMountOptionsSummary(opts []client.MountOption) string {
 	if len(opts) == 0 {
@@ -224,10 +348,22 @@ var instanceListCmd = &cobra.Command{
 	Short:   "List instances",
 	Long: `List sandbox instances with optional filters.
 
+By default a single page is returned. Use --all to follow pagination until
+every matching instance has been fetched.
+
+Use --watch to keep refreshing the list; the table is redrawn whenever an
+instance appears, disappears or changes status. Press Ctrl+C to stop.
+
 Examples:
   ags instance list
   ags instance list --tool-id sdt-xxx
   ags instance list --status RUNNING
+  ags instance list --instance-id sbi-aaa --instance-id sbi-bbb
+  ags instance list --created-since 1h
+  ags instance list --created-since-time 2024-01-15T10:30:00Z
+  ags instance list --all --sort-by expires
+  ags instance list --wide
+  ags instance list --watch --status RUNNING
   ags instance list --short
   ags instance list --no-header
   ags instance list --offset 0 --limit 50`,
@@ -235,6 +371,10 @@ Examples:
 		ctx := context.Background()
 		start := time.Now()
 
+		if err := validateInstanceSortKey(instanceListSortBy); err != nil {
+			return err
+		}
+
 		if err := config.Validate(); err != nil {
 			return err
 		}
@@ -245,13 +385,22 @@ Examples:
 		}
 
 		opts := &client.ListInstancesOptions{
-			ToolID: instanceListTool,
-			Status: instanceListStatus,
-			Offset: instanceListOffset,
-			Limit:  instanceListLimit,
+			InstanceIDs:      instanceListIDs,
+			ToolID:           instanceListTool,
+			Status:           instanceListStatus,
+			Offset:           instanceListOffset,
+			Limit:            instanceListLimit,
+			CreatedSince:     instanceListCreatedSince,
+			CreatedSinceTime: instanceListCreatedSinceTime,
+		}
+
+		f := output.NewFormatter()
+
+		if instanceListWatch {
+			return watchInstances(ctx, apiClient, opts, f)
 		}
 
-		result, err := apiClient.ListInstances(ctx, opts)
+		result, err := fetchInstances(ctx, apiClient, opts, instanceListAll)
 		if err != nil {
 			return fmt.Errorf("failed to list instances: %w", err)
 		}
@@ -262,87 +411,286 @@ Examples:
 			timing = output.NewTiming(totalDuration)
 		}
 
-		f := output.NewFormatter()
+		return printInstanceList(f, result, timing)
+	},
+}
 
-		if len(result.Instances) == 0 {
-			output.PrintInfo("No instances found")
-			if instanceTime && !f.IsJSON() {
-				f.PrintTiming(timing)
-			}
-			return nil
+// fetchInstances lists instances, following pagination until TotalCount is
+// reached when all is set.
+func fetchInstances(ctx context.Context, apiClient client.ControlPlaneClient, opts *client.ListInstancesOptions, all bool) (*client.ListInstancesResult, error) {
+	if !all || len(opts.InstanceIDs) > 0 {
+		return apiClient.ListInstances(ctx, opts)
+	}
+
+	pageOpts := *opts
+	pageOpts.Offset = 0
+	pageOpts.Limit = instanceListMaxPageSize
+
+	merged := &client.ListInstancesResult{}
+	for {
+		page, err := apiClient.ListInstances(ctx, &pageOpts)
+		if err != nil {
+			return nil, err
 		}
+		merged.Instances = append(merged.Instances, page.Instances...)
+		merged.TotalCount = page.TotalCount
 
-		if instanceListShort {
-			// Short format: only ID
-			if f.IsJSON() {
-				ids := make([]string, len(result.Instances))
-				for i, inst := range result.Instances {
-					ids[i] = inst.ID
-				}
-				data := map[string]any{"ids": ids}
-				if timing != nil {
-					data["timing"] = timing
-				}
-				return f.PrintJSON(data)
-			}
-			for _, inst := range result.Instances {
-				fmt.Println(inst.ID)
-			}
-			if instanceTime {
-				f.PrintTiming(timing)
-			}
-			return nil
+		// Backends without server-side pagination (e.g. E2B) return
+		// everything in the first page, so an empty page or reaching the
+		// reported total both mean we're done.
+		if len(page.Instances) == 0 || len(merged.Instances) >= page.TotalCount {
+			break
 		}
+		pageOpts.Offset += len(page.Instances)
+	}
+
+	return merged, nil
+}
+
+// instanceListMaxPageSize is the largest page size accepted by the list API
+const instanceListMaxPageSize = 100
+
+// printInstanceList renders a list result honoring the --short, --wide,
+// --sort-by and --no-header flags.
+func printInstanceList(f *output.Formatter, result *client.ListInstancesResult, timing *output.Timing) error {
+	if len(result.Instances) == 0 {
+		output.PrintInfo("No instances found")
+		if timing != nil && !f.IsJSON() {
+			f.PrintTiming(timing)
+		}
+		return nil
+	}
+
+	sortInstances(result.Instances, instanceListSortBy)
 
-		headers := []string{"ID", "TOOL", "STATUS", "TIMEOUT", "EXPIRES", "MOUNTS", "CREATED"}
-		rows := make([][]string, len(result.Instances))
-		for i, inst := range result.Instances {
-			timeout := "-"
-			if inst.TimeoutSeconds != nil {
-				timeout = formatTimeout(*inst.TimeoutSeconds)
+	if instanceListShort {
+		// Short format: only ID
+		if f.IsJSON() {
+			ids := make([]string, len(result.Instances))
+			for i, inst := range result.Instances {
+				ids[i] = inst.ID
 			}
-			expires := "-"
-			if inst.ExpiresAt != "" {
-				expires = formatTimeShort(inst.ExpiresAt)
+			data := map[string]any{"ids": ids}
+			if timing != nil {
+				data["timing"] = timing
 			}
-			mounts := formatMountOptionsSummary(inst.MountOptions)
+			return f.PrintJSON(data)
+		}
+		for _, inst := range result.Instances {
+			fmt.Println(inst.ID)
+		}
+		if timing != nil {
+			f.PrintTiming(timing)
+		}
+		return nil
+	}
+
+	headers := []string{"ID", "TOOL", "STATUS", "TIMEOUT", "EXPIRES", "MOUNTS", "CREATED"}
+	if instanceListWide {
+		headers = []string{"ID", "TOOL", "TOOL ID", "STATUS", "TIMEOUT", "EXPIRES", "MOUNTS", "ENDPOINTS", "CREATED"}
+	}
+	rows := make([][]string, len(result.Instances))
+	for i, inst := range result.Instances {
+		timeout := "-"
+		if inst.TimeoutSeconds != nil {
+			timeout = formatTimeout(*inst.TimeoutSeconds)
+		}
+		expires := "-"
+		if inst.ExpiresAt != "" {
+			expires = formatTimeShort(inst.ExpiresAt)
+		}
+		if instanceListWide {
 			rows[i] = []string{
 				inst.ID,
 				inst.ToolName,
+				valueOrDefault(inst.ToolID, "-"),
 				inst.Status,
 				timeout,
 				expires,
-				mounts,
+				formatMountOptionsWide(inst.MountOptions),
+				formatEndpointsWide(inst.Endpoints),
 				formatTimeShort(inst.CreatedAt),
 			}
+			continue
+		}
+		rows[i] = []string{
+			inst.ID,
+			inst.ToolName,
+			inst.Status,
+			timeout,
+			expires,
+			formatMountOptionsSummary(inst.MountOptions),
+			formatTimeShort(inst.CreatedAt),
 		}
+	}
 
-		// Build pagination info
-		var pagination *output.Pagination
-		if result.TotalCount > 0 {
-			pagination = &output.Pagination{
-				Offset: instanceListOffset,
-				Limit:  instanceListLimit,
-				Total:  result.TotalCount,
-			}
+	// Build pagination info
+	var pagination *output.Pagination
+	if result.TotalCount > 0 {
+		pagination = &output.Pagination{
+			Offset: instanceListOffset,
+			Limit:  instanceListLimit,
+			Total:  result.TotalCount,
+		}
+		if instanceListAll {
+			pagination.Offset = 0
+			pagination.Limit = len(result.Instances)
 		}
+	}
 
-		if instanceListNoHeader {
-			if err := f.PrintTableNoHeader(rows); err != nil {
-				return err
+	if instanceListNoHeader && !f.IsJSON() {
+		if err := f.PrintTableNoHeader(rows); err != nil {
+			return err
+		}
+	} else {
+		if err := f.PrintList(headers, rows, result.Instances, pagination); err != nil {
+			return err
+		}
+	}
+
+	if timing != nil && !f.IsJSON() {
+		f.PrintTiming(timing)
+	}
+
+	return nil
+}
+
+// watchInstances polls the instance list and redraws it whenever the set of
+// instances or any of their statuses changes. It returns when interrupted.
+func watchInstances(ctx context.Context, apiClient client.ControlPlaneClient, opts *client.ListInstancesOptions, f *output.Formatter) error {
+	if instanceListWatchInterval <= 0 {
+		return fmt.Errorf("--watch-interval must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(instanceListWatchInterval)
+	defer ticker.Stop()
+
+	lastSnapshot := ""
+	for {
+		result, err := fetchInstances(ctx, apiClient, opts, instanceListAll)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
 			}
-		} else {
-			if err := f.PrintTable(headers, rows, pagination); err != nil {
+			// Transient failures shouldn't end the watch; report and retry.
+			output.PrintWarning(fmt.Sprintf("Failed to list instances: %v", err))
+		} else if snapshot := instanceStatusSnapshot(result.Instances); snapshot != lastSnapshot {
+			lastSnapshot = snapshot
+			if !f.IsJSON() {
+				// Clear the screen and move the cursor home before redrawing
+				fmt.Print("\033[H\033[2J")
+				fmt.Printf("Every %s: ags instance list    %s\n\n", instanceListWatchInterval, time.Now().Format("15:04:05"))
+			}
+			if err := printInstanceList(f, result, nil); err != nil {
 				return err
 			}
 		}
 
-		if instanceTime && !f.IsJSON() {
-			f.PrintTiming(timing)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
+	}
+}
+
+// instanceStatusSnapshot returns a stable fingerprint of instance IDs and
+// statuses, used by --watch to decide whether a redraw is needed.
+func instanceStatusSnapshot(instances []client.Instance) string {
+	parts := make([]string, len(instances))
+	for i, inst := range instances {
+		parts[i] = inst.ID + "=" + inst.Status
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// validInstanceSortKeys lists the accepted values for --sort-by
+var validInstanceSortKeys = []string{"created", "expires", "status"}
 
+// validateInstanceSortKey checks the --sort-by value (empty keeps API order)
+func validateInstanceSortKey(key string) error {
+	if key == "" {
 		return nil
-	},
+	}
+	for _, k := range validInstanceSortKeys {
+		if key == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --sort-by %q: must be one of %s", key, strings.Join(validInstanceSortKeys, ", "))
+}
+
+// sortInstances sorts instances in place by the given key. Instances are
+// ordered oldest/soonest first; those missing the sort field go last.
+func sortInstances(instances []client.Instance, key string) {
+	switch key {
+	case "created":
+		sort.SliceStable(instances, func(i, j int) bool {
+			return timeLess(instances[i].CreatedAt, instances[j].CreatedAt)
+		})
+	case "expires":
+		sort.SliceStable(instances, func(i, j int) bool {
+			return timeLess(instances[i].ExpiresAt, instances[j].ExpiresAt)
+		})
+	case "status":
+		sort.SliceStable(instances, func(i, j int) bool {
+			si, sj := strings.ToUpper(instances[i].Status), strings.ToUpper(instances[j].Status)
+			if si != sj {
+				return si < sj
+			}
+			return timeLess(instances[i].CreatedAt, instances[j].CreatedAt)
+		})
+	}
+}
+
+// timeLess compares two RFC3339 timestamps; unparsable or empty values sort last.
+func timeLess(a, b string) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	switch {
+	case errA != nil && errB != nil:
+		return false
+	case errA != nil:
+		return false
+	case errB != nil:
+		return true
+	}
+	return ta.Before(tb)
+}
+
+// formatMountOptionsWide formats mount options as name:path[:ro] for the wide table
+func formatMountOptionsWide(opts []client.MountOption) string {
+	if len(opts) == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, len(opts))
+	for _, opt := range opts {
+		part := opt.Name
+		if opt.MountPath != "" {
+			part += ":" + opt.MountPath
+		}
+		if opt.ReadOnly != nil && *opt.ReadOnly {
+			part += ":ro"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatEndpointsWide formats endpoint URLs on a single line for the wide table
+func formatEndpointsWide(endpoints []client.Endpoint) string {
+	if len(endpoints) == 0 {
+		return "-"
+	}
+	urls := make([]string, 0, len(endpoints))
+	for _, ep := range endpoints {
+		urls = append(urls, ep.URL)
+	}
+	return strings.Join(urls, ",")
 }
 
 // formatTimeout formats timeout seconds to human readable format
@@ -513,24 +861,14 @@ var instanceDeleteCmd = &cobra.Command{
 			return fmt.Errorf("failed to create API client: %w", err)
 		}
 
-		// Initialize token cache for cleanup
-		tokenCache, cacheErr := token.NewCache()
-		if cacheErr != nil {
-			output.PrintWarning(fmt.Sprintf("Failed to initialize token cache: %v", cacheErr))
-		}
-
 		f := output.NewFormatter()
 		var failed []string
 
 		for _, instanceID := range args {
-			if err := apiClient.DeleteInstance(ctx, instanceID); err != nil {
+			if err := deleteInstance(ctx, apiClient, instanceID); err != nil {
 				output.PrintWarning(fmt.Sprintf("Failed to delete instance %s: %v", instanceID, err))
 				failed = append(failed, instanceID)
 			} else {
-				// Clean up cached token
-				if tokenCache != nil {
-					_ = tokenCache.Delete(instanceID)
-				}
 				if !f.IsJSON() {
 					output.PrintSuccess(fmt.Sprintf("Instance deleted: %s", instanceID))
 				}
@@ -570,6 +908,149 @@ var instanceDeleteCmd = &cobra.Command{
 	},
 }
 
+// instancePauseCmd represents the instance pause command
+var instancePauseCmd = &cobra.Command{
+	Use:   "pause <instance-id> [instance-id...]",
+	Short: "Pause instances",
+	Long: `Pause one or more running sandbox instances (E2B backend only).
+
+The sandbox filesystem and memory are snapshotted and compute is released
+until the instance is resumed with 'ags instance resume'.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		start := time.Now()
+
+		if err := config.Validate(); err != nil {
+			return err
+		}
+
+		apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		f := output.NewFormatter()
+		var failed []string
+
+		for _, instanceID := range args {
+			if err := apiClient.PauseInstance(ctx, instanceID); err != nil {
+				output.PrintWarning(fmt.Sprintf("Failed to pause instance %s: %v", instanceID, err))
+				failed = append(failed, instanceID)
+			} else if !f.IsJSON() {
+				output.PrintSuccess(fmt.Sprintf("Instance paused: %s", instanceID))
+			}
+		}
+
+		totalDuration := time.Since(start)
+		var timing *output.Timing
+		if instanceTime {
+			timing = output.NewTiming(totalDuration)
+		}
+
+		if f.IsJSON() {
+			data := map[string]any{
+				"status": "success",
+				"paused": len(args) - len(failed),
+				"failed": len(failed),
+			}
+			if len(failed) > 0 {
+				data["status"] = "partial"
+				data["failed_ids"] = failed
+			}
+			if timing != nil {
+				data["timing"] = timing
+			}
+			return f.PrintJSON(data)
+		}
+
+		if instanceTime {
+			f.PrintTiming(timing)
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to pause %d instance(s)", len(failed))
+		}
+		return nil
+	},
+}
+
+// instanceResumeCmd represents the instance resume command
+var instanceResumeCmd = &cobra.Command{
+	Use:   "resume <instance-id> [instance-id...]",
+	Short: "Resume paused instances",
+	Long: `Resume one or more paused sandbox instances (E2B backend only).
+
+The instance is restored from its snapshot with a fresh timeout (--timeout).
+The cached access token is refreshed from the resume response.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		start := time.Now()
+
+		if err := config.Validate(); err != nil {
+			return err
+		}
+
+		apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		f := output.NewFormatter()
+		var failed []string
+
+		for _, instanceID := range args {
+			instance, err := apiClient.ResumeInstance(ctx, instanceID, instanceResumeTimeout)
+			if err != nil {
+				output.PrintWarning(fmt.Sprintf("Failed to resume instance %s: %v", instanceID, err))
+				failed = append(failed, instanceID)
+				continue
+			}
+
+			// Resuming may rotate the access token, refresh the cached one
+			if err := cacheInstanceToken(ctx, apiClient, instance); err != nil {
+				output.PrintWarning(fmt.Sprintf("Failed to cache access token: %v", err))
+			}
+
+			if !f.IsJSON() {
+				output.PrintSuccess(fmt.Sprintf("Instance resumed: %s", instanceID))
+			}
+		}
+
+		totalDuration := time.Since(start)
+		var timing *output.Timing
+		if instanceTime {
+			timing = output.NewTiming(totalDuration)
+		}
+
+		if f.IsJSON() {
+			data := map[string]any{
+				"status":  "success",
+				"resumed": len(args) - len(failed),
+				"failed":  len(failed),
+			}
+			if len(failed) > 0 {
+				data["status"] = "partial"
+				data["failed_ids"] = failed
+			}
+			if timing != nil {
+				data["timing"] = timing
+			}
+			return f.PrintJSON(data)
+		}
+
+		if instanceTime {
+			f.PrintTiming(timing)
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to resume %d instance(s)", len(failed))
+		}
+		return nil
+	},
+}
+
 // instanceLoginCmd represents the instance login command
 var instanceLoginCmd = &cobra.Command{
 	Use:   "login <instance-id>",
@@ -626,11 +1107,19 @@ and you already have a cached access token from a previous session.
 			output.PrintInfo(fmt.Sprintf("Connecting to instance %s...", instanceID))
 			instance, err = apiClient.GetInstance(ctx, instanceID)
 			if err != nil {
-				if strings.Contains(err.Error(), "not found") {
-					return fmt.Errorf("instance %s not found. Please check the instance ID and try again", instanceID)
-				}
-				if strings.Contains(err.Error(), "permission") || strings.Contains(err.Error(), "access") {
-					return fmt.Errorf("access denied to instance %s. Please check your permissions", instanceID)
+				switch client.KindOf(err) {
+				case client.KindNotFound:
+					return &client.Error{
+						Kind:    client.KindNotFound,
+						Code:    client.CodeOf(err),
+						Message: fmt.Sprintf("instance %s not found. Please check the instance ID and try again", instanceID),
+					}
+				case client.KindAuth:
+					return &client.Error{
+						Kind:    client.KindAuth,
+						Code:    client.CodeOf(err),
+						Message: fmt.Sprintf("access denied to instance %s. Please check your permissions", instanceID),
+					}
 				}
 				return fmt.Errorf("failed to get instance %s: %w\n\nTip: Use --skip-status-check to bypass this check if you have a cached token", instanceID, err)
 			}
@@ -641,6 +1130,8 @@ and you already have a cached access token from a previous session.
 				switch status {
 				case "CREATING", "STARTING":
 					return fmt.Errorf("instance %s is still being created. Please wait for it to finish and try again", instanceID)
+				case "PAUSED", "PAUSING":
+					return fmt.Errorf("instance %s is paused. Resume it first using 'ags instance resume %s'", instanceID, instanceID)
 				case "STOPPED", "STOPPING":
 					return fmt.Errorf("instance %s is stopped. Please start it first using 'ags instance create' or contact support", instanceID)
 				case "ERROR", "FAILED":
@@ -683,6 +1174,10 @@ and you already have a cached access token from a previous session.
 			session := pty.NewSession(accessToken, domain)
 			return session.Connect(ctx, instanceID, resolveUser(instanceLoginUser))
 		case "webshell":
+			// A browser cannot resolve the host name of a local instance
+			if config.GetBackend() == "local" {
+				return fmt.Errorf("webshell mode is not supported by local backend, use --mode pty")
+			}
 			// fall through to webshell logic below
 		default:
 			return fmt.Errorf("unsupported login mode %q: must be \"pty\" or \"webshell\"", instanceLoginMode)
@@ -856,6 +1351,7 @@ func addInstanceCommand(parent *cobra.Command) {
 	createCmd.Flags().BoolVar(&instanceTime, "time", false, "Print elapsed time to stderr")
 	createCmd.Flags().StringArrayVar(&instanceMountOptions, "mount-option", nil, "Mount option to override tool storage config\n"+client.FormatMountOptionHelp())
 	createCmd.Flags().StringVar(&instanceAuthMode, "auth-mode", client.AuthModeDefault, "Auth mode: DEFAULT, TOKEN, NONE, PUBLIC")
+	createCmd.Flags().BoolVar(&instanceDryRun, "dry-run", false, "Check mount options and print the effective mounts without creating an instance")
 	cmd.AddCommand(createCmd)
 
 	// start is an alias for create, but shown as separate command
@@ -872,6 +1368,7 @@ func addInstanceCommand(parent *cobra.Command) {
 	startCmd.Flags().BoolVar(&instanceTime, "time", false, "Print elapsed time to stderr")
 	startCmd.Flags().StringArrayVar(&instanceMountOptions, "mount-option", nil, "Mount option to override tool storage config\n"+client.FormatMountOptionHelp())
 	startCmd.Flags().StringVar(&instanceAuthMode, "auth-mode", client.AuthModeDefault, "Auth mode: DEFAULT, TOKEN, NONE, PUBLIC")
+	startCmd.Flags().BoolVar(&instanceDryRun, "dry-run", false, "Check mount options and print the effective mounts without creating an instance")
 	cmd.AddCommand(startCmd)
 
 	listCmd := &cobra.Command{
@@ -887,6 +1384,14 @@ func addInstanceCommand(parent *cobra.Command) {
 	listCmd.Flags().BoolVar(&instanceListNoHeader, "no-header", false, "Hide table header")
 	listCmd.Flags().IntVar(&instanceListOffset, "offset", 0, "Pagination offset")
 	listCmd.Flags().IntVar(&instanceListLimit, "limit", 20, "Pagination limit (max 100)")
+	listCmd.Flags().StringArrayVar(&instanceListIDs, "instance-id", nil, "Specific instance IDs to query (can be specified multiple times)")
+	listCmd.Flags().StringVar(&instanceListCreatedSince, "created-since", "", "Filter by relative time, e.g., 5m, 1h, 24h")
+	listCmd.Flags().StringVar(&instanceListCreatedSinceTime, "created-since-time", "", "Filter by absolute time (RFC3339)")
+	listCmd.Flags().BoolVar(&instanceListAll, "all", false, "Fetch all pages (ignores --offset and --limit)")
+	listCmd.Flags().StringVar(&instanceListSortBy, "sort-by", "", "Sort by: created, expires, status")
+	listCmd.Flags().BoolVarP(&instanceListWatch, "watch", "w", false, "Watch for changes and redraw when instance status changes")
+	listCmd.Flags().DurationVar(&instanceListWatchInterval, "watch-interval", 2*time.Second, "Polling interval for --watch")
+	listCmd.Flags().BoolVar(&instanceListWide, "wide", false, "Show additional columns (tool ID, mount paths, endpoints)")
 	listCmd.Flags().BoolVar(&instanceTime, "time", false, "Print elapsed time")
 	cmd.AddCommand(listCmd)
 
@@ -922,6 +1427,67 @@ func addInstanceCommand(parent *cobra.Command) {
 	stopCmd.Flags().BoolVar(&instanceTime, "time", false, "Print elapsed time")
 	cmd.AddCommand(stopCmd)
 
+	pauseCmd := &cobra.Command{
+		Use:   "pause <instance-id> [instance-id...]",
+		Short: "Pause instances",
+		Long:  instancePauseCmd.Long,
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  instancePauseCmd.RunE,
+	}
+	pauseCmd.Flags().BoolVar(&instanceTime, "time", false, "Print elapsed time")
+	cmd.AddCommand(pauseCmd)
+
+	resumeCmd := &cobra.Command{
+		Use:   "resume <instance-id> [instance-id...]",
+		Short: "Resume paused instances",
+		Long:  instanceResumeCmd.Long,
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  instanceResumeCmd.RunE,
+	}
+	resumeCmd.Flags().IntVar(&instanceResumeTimeout, "timeout", 300, "Instance timeout in seconds after resume")
+	resumeCmd.Flags().BoolVar(&instanceTime, "time", false, "Print elapsed time")
+	cmd.AddCommand(resumeCmd)
+
+	metricsCmd := &cobra.Command{
+		Use:   "metrics <instance-id>",
+		Short: "Show instance resource usage",
+		Long:  instanceMetricsCmd.Long,
+		Args:  cobra.ExactArgs(1),
+		RunE:  instanceMetricsCmd.RunE,
+	}
+	metricsCmd.Flags().BoolVarP(&instanceMetricsWatch, "watch", "w", false, "Refresh metrics continuously")
+	metricsCmd.Flags().DurationVar(&instanceMetricsInterval, "interval", 2*time.Second, "Refresh interval for --watch")
+	cmd.AddCommand(metricsCmd)
+
+	topCmd := &cobra.Command{
+		Use:   "top",
+		Short: "Live resource usage of running instances",
+		Long:  instanceTopCmd.Long,
+		Args:  cobra.NoArgs,
+		RunE:  instanceTopCmd.RunE,
+	}
+	topCmd.Flags().DurationVar(&instanceTopInterval, "interval", 5*time.Second, "Refresh interval")
+	topCmd.Flags().BoolVar(&instanceTopOnce, "once", false, "Print a single snapshot and exit")
+	topCmd.Flags().IntVar(&instanceTopParallel, "parallel", 8, "Maximum number of concurrent metrics requests")
+	cmd.AddCommand(topCmd)
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete instances matching filters",
+		Long:  instancePruneCmd.Long,
+		Args:  cobra.NoArgs,
+		RunE:  instancePruneCmd.RunE,
+	}
+	pruneCmd.Flags().StringArrayVarP(&instancePruneStatus, "status", "s", nil, "Filter by status (can be specified multiple times)")
+	pruneCmd.Flags().StringVar(&instancePruneTool, "tool-id", "", "Filter by tool ID")
+	pruneCmd.Flags().StringVar(&instancePruneCreatedBefore, "created-before", "", "Only instances created before this age or time, e.g., 2h, 7d, RFC3339")
+	pruneCmd.Flags().StringVar(&instancePruneCreatedAfter, "created-after", "", "Only instances created after this age or time, e.g., 2h, 7d, RFC3339")
+	pruneCmd.Flags().StringArrayVar(&instancePruneStopReason, "stop-reason", nil, "Filter by stop reason: manual, timeout, lifetime_expired, error, system (can be specified multiple times)")
+	pruneCmd.Flags().BoolVarP(&instancePruneYes, "yes", "y", false, "Delete matching instances (default is a dry run)")
+	pruneCmd.Flags().IntVar(&instancePruneParallel, "parallel", 5, "Maximum number of concurrent deletions")
+	pruneCmd.Flags().BoolVar(&instanceTime, "time", false, "Print elapsed time")
+	cmd.AddCommand(pruneCmd)
+
 	// login command
 	loginCmd := &cobra.Command{
 		Use:   "login <instance-id>",
@@ -952,7 +1518,7 @@ func cacheInstanceToken(ctx context.Context, apiClient client.ControlPlaneClient
 		return nil
 	}
 
-	tokenCache, err := token.NewCache()
+	tokenCache, err := newTokenCache()
 	if err != nil {
 		return fmt.Errorf("failed to create token cache: %w", err)
 	}
@@ -0,0 +1,468 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/command"
+	"github.com/spf13/cobra"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+)
+
+var (
+	// metrics command flags
+	instanceMetricsWatch    bool
+	instanceMetricsInterval time.Duration
+
+	// top command flags
+	instanceTopInterval time.Duration
+	instanceTopOnce     bool
+	instanceTopParallel int
+)
+
+// procSampleScript prints CPU, memory and disk figures from inside the sandbox.
+// Two /proc/stat snapshots half a second apart give the CPU utilization.
+const procSampleScript = `echo "cpus $(nproc)"
+echo "stat1 $(head -n1 /proc/stat)"
+sleep 0.5
+echo "stat2 $(head -n1 /proc/stat)"
+grep -E '^(MemTotal|MemAvailable):' /proc/meminfo
+df -kP / | awk 'NR==2 {print "disk", $2, $3}'`
+
+// instanceMetricsCmd represents the instance metrics command
+var instanceMetricsCmd = &cobra.Command{
+	Use:   "metrics <instance-id>",
+	Short: "Show instance resource usage",
+	Long: `Show CPU, memory and disk usage of a sandbox instance.
+
+Metrics come from the control plane when the backend provides them (E2B).
+Otherwise they are sampled from /proc inside the sandbox through the data
+plane, which requires an access token (cached or acquired automatically).
+
+Use --watch to refresh continuously. Press Ctrl+C to stop.
+
+Examples:
+  ags instance metrics sbi-xxx
+  ags instance metrics sbi-xxx --watch
+  ags instance metrics sbi-xxx --watch --interval 5s`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		instanceID := args[0]
+
+		if err := config.Validate(); err != nil {
+			return err
+		}
+
+		apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		f := output.NewFormatter()
+
+		if !instanceMetricsWatch {
+			metrics, err := getInstanceMetrics(ctx, apiClient, instanceID)
+			if err != nil {
+				return fmt.Errorf("failed to get instance metrics: %w", err)
+			}
+			return printInstanceMetrics(f, instanceID, metrics)
+		}
+
+		if instanceMetricsInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		ticker := time.NewTicker(instanceMetricsInterval)
+		defer ticker.Stop()
+
+		for {
+			metrics, err := getInstanceMetrics(ctx, apiClient, instanceID)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				output.PrintWarning(fmt.Sprintf("Failed to get instance metrics: %v", err))
+			} else {
+				if !f.IsJSON() {
+					fmt.Print("\033[H\033[2J")
+					fmt.Printf("Every %s: ags instance metrics %s    %s\n\n", instanceMetricsInterval, instanceID, time.Now().Format("15:04:05"))
+				}
+				if err := printInstanceMetrics(f, instanceID, metrics); err != nil {
+					return err
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// instanceTopCmd represents the instance top command
+var instanceTopCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Live resource usage of running instances",
+	Long: `Display a continuously refreshing table of running instances with their
+CPU, memory and disk usage, status and remaining time to live.
+
+Rows are sorted by CPU usage. Press Ctrl+C to exit, or use --once to print a
+single snapshot (useful for scripts and JSON output).
+
+Examples:
+  ags instance top
+  ags instance top --interval 10s
+  ags instance top --once -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		if instanceTopParallel < 1 {
+			return fmt.Errorf("--parallel must be at least 1")
+		}
+
+		if err := config.Validate(); err != nil {
+			return err
+		}
+
+		apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		f := output.NewFormatter()
+
+		if instanceTopOnce {
+			rows, err := collectTopRows(ctx, apiClient)
+			if err != nil {
+				return err
+			}
+			return printTopRows(f, rows)
+		}
+
+		if instanceTopInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		ticker := time.NewTicker(instanceTopInterval)
+		defer ticker.Stop()
+
+		for {
+			rows, err := collectTopRows(ctx, apiClient)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				output.PrintWarning(err.Error())
+			} else {
+				if !f.IsJSON() {
+					fmt.Print("\033[H\033[2J")
+					fmt.Printf("ags instance top - %s, %d running, refresh every %s\n\n", time.Now().Format("15:04:05"), len(rows), instanceTopInterval)
+				}
+				if err := printTopRows(f, rows); err != nil {
+					return err
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// getInstanceMetrics asks the control plane for metrics and falls back to
+// sampling /proc through the data plane when the backend has none.
+func getInstanceMetrics(ctx context.Context, apiClient client.ControlPlaneClient, instanceID string) (*client.InstanceMetrics, error) {
+	metrics, err := apiClient.GetInstanceMetrics(ctx, instanceID)
+	if err == nil {
+		return metrics, nil
+	}
+	if !errors.Is(err, client.ErrMetricsUnavailable) {
+		return nil, err
+	}
+
+	sandbox, err := ConnectSandboxWithCache(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := sandbox.Commands.Run(ctx, procSampleScript, &command.ProcessConfig{User: resolveUser("")}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample metrics: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("failed to sample metrics: exit code %d: %s", result.ExitCode, strings.TrimSpace(string(result.Stderr)))
+	}
+
+	return parseProcSample(string(result.Stdout), time.Now())
+}
+
+// parseProcSample parses the output of procSampleScript
+func parseProcSample(out string, now time.Time) (*client.InstanceMetrics, error) {
+	metrics := &client.InstanceMetrics{
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Source:    "proc",
+	}
+
+	var stat1, stat2 []uint64
+	var memAvailable int64
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "cpus":
+			metrics.CPUCount, _ = strconv.Atoi(fields[1])
+		case "stat1":
+			stat1 = parseCPUStat(fields[1:])
+		case "stat2":
+			stat2 = parseCPUStat(fields[1:])
+		case "MemTotal:":
+			kb, _ := strconv.ParseInt(fields[1], 10, 64)
+			metrics.MemTotal = kb * 1024
+		case "MemAvailable:":
+			kb, _ := strconv.ParseInt(fields[1], 10, 64)
+			memAvailable = kb * 1024
+		case "disk":
+			if len(fields) >= 3 {
+				total, _ := strconv.ParseInt(fields[1], 10, 64)
+				used, _ := strconv.ParseInt(fields[2], 10, 64)
+				metrics.DiskTotal = total * 1024
+				metrics.DiskUsed = used * 1024
+			}
+		}
+	}
+
+	if metrics.MemTotal == 0 || stat1 == nil || stat2 == nil {
+		return nil, fmt.Errorf("unexpected metrics output from sandbox")
+	}
+	metrics.MemUsed = metrics.MemTotal - memAvailable
+	metrics.CPUUsedPct = cpuUsedPct(stat1, stat2)
+
+	return metrics, nil
+}
+
+// parseCPUStat parses the aggregate "cpu ..." line of /proc/stat into counters
+func parseCPUStat(fields []string) []uint64 {
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return nil
+	}
+	counters := make([]uint64, 0, len(fields)-1)
+	for _, f := range fields[1:] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return nil
+		}
+		counters = append(counters, v)
+	}
+	return counters
+}
+
+// cpuUsedPct computes utilization between two /proc/stat samples.
+// Idle time is the idle and iowait columns.
+func cpuUsedPct(before, after []uint64) float64 {
+	var total, idle uint64
+	for i := range after {
+		if i >= len(before) || after[i] < before[i] {
+			continue
+		}
+		delta := after[i] - before[i]
+		total += delta
+		if i == 3 || i == 4 {
+			idle += delta
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(total-idle) / float64(total) * 100
+}
+
+// printInstanceMetrics prints a single metrics sample
+func printInstanceMetrics(f *output.Formatter, instanceID string, m *client.InstanceMetrics) error {
+	if f.IsJSON() {
+		return f.PrintJSON(map[string]any{
+			"instanceId": instanceID,
+			"timestamp":  m.Timestamp,
+			"cpuCount":   m.CPUCount,
+			"cpuUsedPct": m.CPUUsedPct,
+			"memUsed":    m.MemUsed,
+			"memTotal":   m.MemTotal,
+			"diskUsed":   m.DiskUsed,
+			"diskTotal":  m.DiskTotal,
+			"source":     m.Source,
+		})
+	}
+
+	return f.PrintKeyValue([]output.KeyValue{
+		{Key: "Instance", Value: instanceID},
+		{Key: "CPU", Value: fmt.Sprintf("%.1f%% of %d core(s)", m.CPUUsedPct, m.CPUCount)},
+		{Key: "Memory", Value: formatUsage(m.MemUsed, m.MemTotal)},
+		{Key: "Disk", Value: formatUsage(m.DiskUsed, m.DiskTotal)},
+		{Key: "Sampled", Value: m.Timestamp},
+		{Key: "Source", Value: m.Source},
+	})
+}
+
+// formatUsage formats used/total bytes with a percentage, or "-" when unknown
+func formatUsage(used, total int64) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s / %s (%.1f%%)", output.FormatSize(used), output.FormatSize(total), float64(used)/float64(total)*100)
+}
+
+// formatTTL returns the time left until expiresAt, relative to now
+func formatTTL(expiresAt string, now time.Time) string {
+	if expiresAt == "" {
+		return "-"
+	}
+	t, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return "-"
+	}
+	remaining := t.Sub(now)
+	if remaining <= 0 {
+		return "expired"
+	}
+	return remaining.Truncate(time.Second).String()
+}
+
+// topRow is one instance line in 'ags instance top'
+type topRow struct {
+	Instance client.Instance
+	Metrics  *client.InstanceMetrics
+	Err      error
+}
+
+// collectTopRows lists running instances and fetches their metrics concurrently
+func collectTopRows(ctx context.Context, apiClient client.ControlPlaneClient) ([]topRow, error) {
+	result, err := fetchInstances(ctx, apiClient, &client.ListInstancesOptions{Status: "RUNNING"}, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+
+	var rows []topRow
+	for _, inst := range result.Instances {
+		if strings.EqualFold(inst.Status, "RUNNING") {
+			rows = append(rows, topRow{Instance: inst})
+		}
+	}
+
+	sem := make(chan struct{}, instanceTopParallel)
+	var wg sync.WaitGroup
+	for i := range rows {
+		wg.Add(1)
+		go func(row *topRow) {
+			defer wg.Done()
+
+			sem <- struct{}{}        // Acquire
+			defer func() { <-sem }() // Release
+
+			row.Metrics, row.Err = getInstanceMetrics(ctx, apiClient, row.Instance.ID)
+		}(&rows[i])
+	}
+	wg.Wait()
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rowCPU(rows[i]) > rowCPU(rows[j])
+	})
+	return rows, nil
+}
+
+// rowCPU returns the CPU usage used for sorting; rows without metrics sort last
+func rowCPU(row topRow) float64 {
+	if row.Metrics == nil {
+		return -1
+	}
+	return row.Metrics.CPUUsedPct
+}
+
+// printTopRows renders the top table
+func printTopRows(f *output.Formatter, rows []topRow) error {
+	now := time.Now()
+
+	if f.IsJSON() {
+		items := make([]map[string]any, len(rows))
+		for i, row := range rows {
+			item := map[string]any{
+				"id":       row.Instance.ID,
+				"toolName": row.Instance.ToolName,
+				"status":   row.Instance.Status,
+				"ttl":      formatTTL(row.Instance.ExpiresAt, now),
+			}
+			if row.Instance.ExpiresAt != "" {
+				item["expiresAt"] = row.Instance.ExpiresAt
+			}
+			if row.Metrics != nil {
+				item["metrics"] = row.Metrics
+			}
+			if row.Err != nil {
+				item["error"] = row.Err.Error()
+			}
+			items[i] = item
+		}
+		return f.PrintJSON(map[string]any{"instances": items})
+	}
+
+	if len(rows) == 0 {
+		output.PrintInfo("No running instances")
+		return nil
+	}
+
+	headers := []string{"ID", "TOOL", "STATUS", "CPU", "MEM", "DISK", "TTL"}
+	tableRows := make([][]string, len(rows))
+	for i, row := range rows {
+		cpu, mem, disk := "-", "-", "-"
+		if row.Metrics != nil {
+			cpu = fmt.Sprintf("%.1f%%", row.Metrics.CPUUsedPct)
+			mem = formatUsagePct(row.Metrics.MemUsed, row.Metrics.MemTotal)
+			disk = formatUsagePct(row.Metrics.DiskUsed, row.Metrics.DiskTotal)
+		} else if row.Err != nil {
+			cpu = "error"
+		}
+		tableRows[i] = []string{
+			row.Instance.ID,
+			row.Instance.ToolName,
+			row.Instance.Status,
+			cpu,
+			mem,
+			disk,
+			formatTTL(row.Instance.ExpiresAt, now),
+		}
+	}
+	return f.PrintTable(headers, tableRows, nil)
+}
+
+// formatUsagePct formats used bytes with a percentage, compact enough for a table cell
+func formatUsagePct(used, total int64) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%.0f%%)", output.FormatSize(used), float64(used)/float64(total)*100)
+}
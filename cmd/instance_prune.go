@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// prune command flags
+	instancePruneStatus        []string
+	instancePruneTool          string
+	instancePruneCreatedBefore string
+	instancePruneCreatedAfter  string
+	instancePruneStopReason    []string
+	instancePruneYes           bool
+	instancePruneParallel      int
+)
+
+// instancePruneCmd represents the instance prune command
+var instancePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete instances matching filters",
+	Long: `Delete every instance matching the given filters.
+
+At least one filter is required. Without --yes the command only prints the
+instances that would be deleted (dry run). With --yes they are deleted
+concurrently (bounded by --parallel), and their cached access tokens and
+local tunnels are cleaned up as well.
+
+--created-before and --created-after accept either a relative age (e.g. 30m,
+12h, 7d) or an absolute RFC3339 timestamp.
+
+Examples:
+  # Preview instances older than one day
+  ags instance prune --created-before 24h
+
+  # Delete leaked CI instances of a tool
+  ags instance prune --tool-id sdt-xxx --created-before 2h --yes
+
+  # Delete failed instances with limited concurrency
+  ags instance prune --status FAILED --status STARTING_FAILED --parallel 2 --yes
+
+  # Clean up instances stopped by timeout
+  ags instance prune --stop-reason timeout --yes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		start := time.Now()
+
+		filter, err := newPruneFilter(time.Now())
+		if err != nil {
+			return err
+		}
+		if instancePruneParallel < 1 {
+			return fmt.Errorf("--parallel must be at least 1")
+		}
+
+		if err := config.Validate(); err != nil {
+			return err
+		}
+
+		apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		result, err := fetchInstances(ctx, apiClient, filter.listOptions(), true)
+		if err != nil {
+			return fmt.Errorf("failed to list instances: %w", err)
+		}
+
+		var matched []client.Instance
+		for _, inst := range result.Instances {
+			if filter.match(inst) {
+				matched = append(matched, inst)
+			}
+		}
+		sortInstances(matched, "created")
+
+		f := output.NewFormatter()
+
+		if len(matched) == 0 {
+			output.PrintInfo("No instances match the given filters")
+			return nil
+		}
+
+		if !instancePruneYes {
+			return printPruneDryRun(f, matched)
+		}
+
+		results := pruneInstances(ctx, apiClient, matched, instancePruneParallel, func(r pruneResult) {
+			if f.IsJSON() {
+				return
+			}
+			if r.Error != "" {
+				output.PrintWarning(fmt.Sprintf("Failed to delete instance %s: %s", r.ID, r.Error))
+			} else {
+				output.PrintSuccess(fmt.Sprintf("Instance deleted: %s", r.ID))
+			}
+		})
+
+		var failed int
+		for _, r := range results {
+			if r.Error != "" {
+				failed++
+			}
+		}
+
+		var timing *output.Timing
+		if instanceTime {
+			timing = output.NewTiming(time.Since(start))
+		}
+
+		if f.IsJSON() {
+			data := map[string]any{
+				"status":  "success",
+				"deleted": len(results) - failed,
+				"failed":  failed,
+				"results": results,
+			}
+			if failed > 0 {
+				data["status"] = "partial"
+			}
+			if timing != nil {
+				data["timing"] = timing
+			}
+			return f.PrintJSON(data)
+		}
+
+		output.PrintInfo(fmt.Sprintf("Pruned %d instance(s), %d failed", len(results)-failed, failed))
+		if instanceTime {
+			f.PrintTiming(timing)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("failed to delete %d instance(s)", failed)
+		}
+		return nil
+	},
+}
+
+// pruneFilter selects instances for pruning. Status and tool are pushed down
+// to the list API when possible; everything is re-checked client-side.
+type pruneFilter struct {
+	statuses      []string
+	toolID        string
+	createdBefore time.Time
+	createdAfter  time.Time
+	stopReasons   []string
+}
+
+// newPruneFilter builds a filter from the prune flags, resolving relative
+// ages against now.
+func newPruneFilter(now time.Time) (*pruneFilter, error) {
+	filter := &pruneFilter{
+		toolID: instancePruneTool,
+	}
+	for _, s := range instancePruneStatus {
+		filter.statuses = append(filter.statuses, strings.ToUpper(s))
+	}
+	for _, r := range instancePruneStopReason {
+		filter.stopReasons = append(filter.stopReasons, strings.ToLower(r))
+	}
+
+	var err error
+	if instancePruneCreatedBefore != "" {
+		if filter.createdBefore, err = parseTimeBound(instancePruneCreatedBefore, now); err != nil {
+			return nil, fmt.Errorf("invalid --created-before: %w", err)
+		}
+	}
+	if instancePruneCreatedAfter != "" {
+		if filter.createdAfter, err = parseTimeBound(instancePruneCreatedAfter, now); err != nil {
+			return nil, fmt.Errorf("invalid --created-after: %w", err)
+		}
+	}
+
+	if len(filter.statuses) == 0 && filter.toolID == "" && filter.createdBefore.IsZero() &&
+		filter.createdAfter.IsZero() && len(filter.stopReasons) == 0 {
+		return nil, fmt.Errorf("at least one filter is required (--status, --tool-id, --created-before, --created-after, --stop-reason)")
+	}
+
+	return filter, nil
+}
+
+// listOptions returns the server-side subset of the filter
+func (p *pruneFilter) listOptions() *client.ListInstancesOptions {
+	opts := &client.ListInstancesOptions{ToolID: p.toolID}
+	if len(p.statuses) == 1 {
+		opts.Status = p.statuses[0]
+	}
+	if !p.createdAfter.IsZero() {
+		opts.CreatedSinceTime = p.createdAfter.UTC().Format(time.RFC3339)
+	}
+	return opts
+}
+
+// match reports whether an instance satisfies every configured filter.
+// Instances with an unparsable creation time never match a time filter.
+func (p *pruneFilter) match(inst client.Instance) bool {
+	if len(p.statuses) > 0 && !slices.Contains(p.statuses, strings.ToUpper(inst.Status)) {
+		return false
+	}
+	if p.toolID != "" && inst.ToolID != p.toolID {
+		return false
+	}
+	if len(p.stopReasons) > 0 && !slices.Contains(p.stopReasons, strings.ToLower(inst.StopReason)) {
+		return false
+	}
+	if !p.createdBefore.IsZero() || !p.createdAfter.IsZero() {
+		created, err := time.Parse(time.RFC3339, inst.CreatedAt)
+		if err != nil {
+			return false
+		}
+		if !p.createdBefore.IsZero() && !created.Before(p.createdBefore) {
+			return false
+		}
+		if !p.createdAfter.IsZero() && created.Before(p.createdAfter) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTimeBound parses an RFC3339 timestamp or a relative age such as
+// "30m", "12h" or "7d" (interpreted as that long before now).
+func parseTimeBound(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("%q is not a valid age or RFC3339 time", value)
+		}
+		return now.Add(-time.Duration(n) * 24 * time.Hour), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("%q is not a valid age or RFC3339 time", value)
+	}
+	return now.Add(-d), nil
+}
+
+// printPruneDryRun lists the instances that would be deleted
+func printPruneDryRun(f *output.Formatter, instances []client.Instance) error {
+	if f.IsJSON() {
+		ids := make([]string, len(instances))
+		for i, inst := range instances {
+			ids[i] = inst.ID
+		}
+		return f.PrintJSON(map[string]any{
+			"status":    "dry-run",
+			"message":   fmt.Sprintf("%d instance(s) would be deleted", len(instances)),
+			"instances": ids,
+		})
+	}
+
+	headers := []string{"ID", "TOOL", "STATUS", "STOP REASON", "CREATED"}
+	rows := make([][]string, len(instances))
+	for i, inst := range instances {
+		rows[i] = []string{
+			inst.ID,
+			inst.ToolName,
+			inst.Status,
+			valueOrDefault(inst.StopReason, "-"),
+			formatTimeShort(inst.CreatedAt),
+		}
+	}
+	if err := f.PrintTable(headers, rows, nil); err != nil {
+		return err
+	}
+	output.PrintInfo(fmt.Sprintf("%d instance(s) would be deleted. Re-run with --yes to delete them.", len(instances)))
+	return nil
+}
+
+// pruneResult is the outcome of deleting a single instance
+type pruneResult struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+// pruneInstances deletes instances with at most parallel requests in flight.
+// Local state (cached token, tunnel process) is cleaned up for each instance
+// that was deleted. onResult is called as each deletion finishes; the
+// returned results are ordered like the input.
+func pruneInstances(ctx context.Context, apiClient client.ControlPlaneClient, instances []client.Instance, parallel int, onResult func(pruneResult)) []pruneResult {
+	store, storeErr := newTunnelStore()
+	if storeErr != nil {
+		output.PrintWarning(fmt.Sprintf("Failed to initialize tunnel store: %v", storeErr))
+	}
+
+	results := make([]pruneResult, len(instances))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for i, inst := range instances {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+
+			sem <- struct{}{}        // Acquire
+			defer func() { <-sem }() // Release
+
+			result := pruneResult{ID: id}
+			if err := deleteInstance(ctx, apiClient, id); err != nil {
+				result.Error = err.Error()
+			} else {
+				if store != nil {
+					_ = store.Cleanup(id)
+				}
+			}
+
+			mu.Lock()
+			results[i] = result
+			if onResult != nil {
+				onResult(result)
+			}
+			mu.Unlock()
+		}(i, inst.ID)
+	}
+	wg.Wait()
+
+	return results
+}
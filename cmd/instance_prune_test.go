@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "hours", value: "2h", want: now.Add(-2 * time.Hour)},
+		{name: "minutes", value: "30m", want: now.Add(-30 * time.Minute)},
+		{name: "days", value: "7d", want: now.Add(-7 * 24 * time.Hour)},
+		{name: "rfc3339", value: "2024-01-01T00:00:00Z", want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "invalid", value: "yesterday", wantErr: true},
+		{name: "invalid days", value: "xd", wantErr: true},
+		{name: "negative", value: "-1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimeBound(tt.value, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseTimeBound(%q) expected error", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTimeBound(%q) unexpected error: %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTimeBound(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneFilterMatch(t *testing.T) {
+	filter := &pruneFilter{
+		statuses:      []string{"RUNNING", "FAILED"},
+		toolID:        "sdt-ci",
+		createdBefore: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name string
+		inst client.Instance
+		want bool
+	}{
+		{
+			name: "matches",
+			inst: client.Instance{ToolID: "sdt-ci", Status: "Running", CreatedAt: "2024-01-09T00:00:00Z"},
+			want: true,
+		},
+		{
+			name: "too new",
+			inst: client.Instance{ToolID: "sdt-ci", Status: "RUNNING", CreatedAt: "2024-01-11T00:00:00Z"},
+		},
+		{
+			name: "other tool",
+			inst: client.Instance{ToolID: "sdt-prod", Status: "RUNNING", CreatedAt: "2024-01-09T00:00:00Z"},
+		},
+		{
+			name: "other status",
+			inst: client.Instance{ToolID: "sdt-ci", Status: "STOPPED", CreatedAt: "2024-01-09T00:00:00Z"},
+		},
+		{
+			name: "unparsable created time",
+			inst: client.Instance{ToolID: "sdt-ci", Status: "RUNNING", CreatedAt: "unknown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.match(tt.inst); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	reasonFilter := &pruneFilter{stopReasons: []string{"timeout"}}
+	if !reasonFilter.match(client.Instance{StopReason: "TIMEOUT"}) {
+		t.Error("stop reason filter should match case-insensitively")
+	}
+	if reasonFilter.match(client.Instance{StopReason: "manual"}) {
+		t.Error("stop reason filter should not match other reasons")
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+)
+
+// pagedLister serves ListInstances from a fixed slice, honoring Offset/Limit.
+type pagedLister struct {
+	client.ControlPlaneClient
+	instances []client.Instance
+	calls     int
+}
+
+func (p *pagedLister) ListInstances(ctx context.Context, opts *client.ListInstancesOptions) (*client.ListInstancesResult, error) {
+	p.calls++
+	end := opts.Offset + opts.Limit
+	if end > len(p.instances) {
+		end = len(p.instances)
+	}
+	var page []client.Instance
+	if opts.Offset < end {
+		page = p.instances[opts.Offset:end]
+	}
+	return &client.ListInstancesResult{Instances: page, TotalCount: len(p.instances)}, nil
+}
+
+func TestFetchInstancesAll(t *testing.T) {
+	instances := make([]client.Instance, 250)
+	for i := range instances {
+		instances[i] = client.Instance{ID: fmt.Sprintf("sbi-%03d", i)}
+	}
+	lister := &pagedLister{instances: instances}
+
+	result, err := fetchInstances(context.Background(), lister, &client.ListInstancesOptions{Offset: 40, Limit: 5}, true)
+	if err != nil {
+		t.Fatalf("fetchInstances() error = %v", err)
+	}
+	if len(result.Instances) != 250 {
+		t.Errorf("got %d instances, want 250", len(result.Instances))
+	}
+	if lister.calls != 3 {
+		t.Errorf("got %d ListInstances calls, want 3", lister.calls)
+	}
+}
+
+func TestSortInstances(t *testing.T) {
+	instances := []client.Instance{
+		{ID: "a", Status: "STOPPED", CreatedAt: "2024-01-03T00:00:00Z", ExpiresAt: ""},
+		{ID: "b", Status: "RUNNING", CreatedAt: "2024-01-01T00:00:00Z", ExpiresAt: "2024-01-05T00:00:00Z"},
+		{ID: "c", Status: "RUNNING", CreatedAt: "2024-01-02T00:00:00Z", ExpiresAt: "2024-01-04T00:00:00Z"},
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "created", want: "bca"},
+		{key: "expires", want: "cba"},
+		{key: "status", want: "bca"},
+		{key: "", want: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got := append([]client.Instance(nil), instances...)
+			sortInstances(got, tt.key)
+			order := ""
+			for _, inst := range got {
+				order += inst.ID
+			}
+			if order != tt.want {
+				t.Errorf("sortInstances(%q) order = %s, want %s", tt.key, order, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateInstanceSortKey(t *testing.T) {
+	for _, key := range []string{"", "created", "expires", "status"} {
+		if err := validateInstanceSortKey(key); err != nil {
+			t.Errorf("validateInstanceSortKey(%q) unexpected error: %v", key, err)
+		}
+	}
+	if err := validateInstanceSortKey("name"); err == nil {
+		t.Error("validateInstanceSortKey(\"name\") expected error")
+	}
+}
+
+func TestParseProcSample(t *testing.T) {
+	out := `cpus 2
+stat1 cpu  100 0 100 700 100 0 0 0 0 0
+stat2 cpu  150 0 150 750 150 0 0 0 0 0
+MemTotal:        2048000 kB
+MemAvailable:    1536000 kB
+disk 10000000 2500000
+`
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, err := parseProcSample(out, now)
+	if err != nil {
+		t.Fatalf("parseProcSample() error = %v", err)
+	}
+	if m.CPUCount != 2 {
+		t.Errorf("CPUCount = %d, want 2", m.CPUCount)
+	}
+	// 200 ticks elapsed, 100 of them idle+iowait
+	if m.CPUUsedPct != 50 {
+		t.Errorf("CPUUsedPct = %v, want 50", m.CPUUsedPct)
+	}
+	if m.MemTotal != 2048000*1024 || m.MemUsed != 512000*1024 {
+		t.Errorf("memory = %d/%d, want %d/%d", m.MemUsed, m.MemTotal, 512000*1024, 2048000*1024)
+	}
+	if m.DiskTotal != 10000000*1024 || m.DiskUsed != 2500000*1024 {
+		t.Errorf("disk = %d/%d", m.DiskUsed, m.DiskTotal)
+	}
+	if m.Source != "proc" || m.Timestamp != "2024-01-01T00:00:00Z" {
+		t.Errorf("source/timestamp = %s/%s", m.Source, m.Timestamp)
+	}
+
+	if _, err := parseProcSample("garbage", now); err == nil {
+		t.Error("parseProcSample(garbage) expected error")
+	}
+}
+
+func TestFormatTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expiresAt string
+		want      string
+	}{
+		{expiresAt: "", want: "-"},
+		{expiresAt: "invalid", want: "-"},
+		{expiresAt: "2024-01-01T11:00:00Z", want: "expired"},
+		{expiresAt: "2024-01-01T12:05:30Z", want: "5m30s"},
+	}
+	for _, tt := range tests {
+		if got := formatTTL(tt.expiresAt, now); got != tt.want {
+			t.Errorf("formatTTL(%q) = %q, want %q", tt.expiresAt, got, tt.want)
+		}
+	}
+}
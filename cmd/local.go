@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/localsandbox"
+)
+
+// localSandboxCmd runs the daemon of a local backend instance. It is started
+// by the local control plane when an instance is created, not by users.
+var localSandboxCmd = &cobra.Command{
+	Use:    localsandbox.ServeCommand + " <instance_id>",
+	Short:  "Serve a local backend instance",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return localsandbox.Serve(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(localSandboxCmd)
+}
@@ -156,7 +156,7 @@ func runMobileTunnel(_ *cobra.Command, args []string) error {
 	sandboxID := args[0]
 
 	if err := config.Validate(); err != nil {
-		return exitError(1, err)
+		return exitError(exitGeneral, err)
 	}
 
 	// Build the token provider using the same pattern as acquireInstanceToken
@@ -184,7 +184,7 @@ func runMobileTunnel(_ *cobra.Command, args []string) error {
 			errMsg := readyMessage{Status: "error", Message: fmt.Sprintf("failed to create tunnel: %v", err)}
 			_ = json.NewEncoder(os.Stdout).Encode(errMsg)
 		}
-		return exitError(1, fmt.Errorf("failed to create tunnel: %w", err))
+		return exitError(exitGeneral, fmt.Errorf("failed to create tunnel: %w", err))
 	}
 
 	addr, err := tunnel.Start()
@@ -193,7 +193,7 @@ func runMobileTunnel(_ *cobra.Command, args []string) error {
 			errMsg := readyMessage{Status: "error", Message: fmt.Sprintf("failed to start tunnel: %v", err)}
 			_ = json.NewEncoder(os.Stdout).Encode(errMsg)
 		}
-		return exitError(3, fmt.Errorf("failed to start tunnel: %w", err))
+		return exitError(exitTunnelFailed, fmt.Errorf("failed to start tunnel: %w", err))
 	}
 
 	// Probe upstream to verify full connectivity before declaring ready
@@ -203,7 +203,7 @@ func runMobileTunnel(_ *cobra.Command, args []string) error {
 			errMsg := readyMessage{Status: "error", Message: err.Error()}
 			_ = json.NewEncoder(os.Stdout).Encode(errMsg)
 		}
-		return exitError(2, fmt.Errorf("upstream probe failed: %w", err))
+		return exitError(exitUpstreamFailed, fmt.Errorf("upstream probe failed: %w", err))
 	}
 
 	_, portStr, _ := strings.Cut(addr, ":")
@@ -217,7 +217,7 @@ func runMobileTunnel(_ *cobra.Command, args []string) error {
 		}
 		if err := json.NewEncoder(os.Stdout).Encode(msg); err != nil {
 			tunnel.Stop()
-			return exitError(1, fmt.Errorf("failed to write ready message: %w", err))
+			return exitError(exitGeneral, fmt.Errorf("failed to write ready message: %w", err))
 		}
 	} else {
 		// Interactive mode: human-readable output
@@ -267,7 +267,7 @@ func runMobileConnect(_ *cobra.Command, args []string) error {
 	}
 
 	// Initialize tunnel store
-	store, err := tunnelstore.NewStore()
+	store, err := newTunnelStore()
 	if err != nil {
 		return fmt.Errorf("failed to initialize tunnel store: %w", err)
 	}
@@ -283,28 +283,10 @@ func runMobileConnect(_ *cobra.Command, args []string) error {
 	}
 
 	// Spawn background tunnel process
-	selfPath, err := os.Executable()
+	cmd, selfPath, err := newSelfCommand("mobile", "tunnel", sandboxID, "--daemon", "--port=0")
 	if err != nil {
-		return fmt.Errorf("failed to get executable path: %w", err)
-	}
-
-	// Build the command with the same global flags
-	tunnelArgs := []string{"mobile", "tunnel", sandboxID, "--daemon", "--port=0"}
-	// Pass through essential global flags (non-sensitive only via CLI args)
-	if backend != "" {
-		tunnelArgs = append(tunnelArgs, "--backend", backend)
-	}
-	if region != "" {
-		tunnelArgs = append(tunnelArgs, "--region", region)
-	}
-	if domain != "" {
-		tunnelArgs = append(tunnelArgs, "--domain", domain)
-	}
-	if internal {
-		tunnelArgs = append(tunnelArgs, "--internal")
+		return err
 	}
-
-	cmd := exec.Command(selfPath, tunnelArgs...)
 	// Redirect tunnel stderr to a log file instead of parent terminal
 	// to avoid background reconnection logs polluting the user's shell.
 	if homeDir, homeErr := os.UserHomeDir(); homeErr == nil {
@@ -319,20 +301,6 @@ func runMobileConnect(_ *cobra.Command, args []string) error {
 		}
 	}
 
-	// Pass sensitive credentials via environment variables instead of CLI args
-	// to avoid exposure in process listing (ps aux).
-	// The child process reads these via viper.BindEnv in config.go.
-	cmd.Env = os.Environ()
-	if e2bAPIKey != "" {
-		cmd.Env = append(cmd.Env, "AGS_E2B_API_KEY="+e2bAPIKey)
-	}
-	if cloudSecretID != "" {
-		cmd.Env = append(cmd.Env, "AGS_CLOUD_SECRET_ID="+cloudSecretID)
-	}
-	if cloudSecretKey != "" {
-		cmd.Env = append(cmd.Env, "AGS_CLOUD_SECRET_KEY="+cloudSecretKey)
-	}
-
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stdout pipe: %w", err)
@@ -412,7 +380,7 @@ func runMobileConnect(_ *cobra.Command, args []string) error {
 
 // runMobileDisconnect stops a tunnel and runs adb disconnect.
 func runMobileDisconnect(_ *cobra.Command, args []string) error {
-	store, err := tunnelstore.NewStore()
+	store, err := newTunnelStore()
 	if err != nil {
 		return fmt.Errorf("failed to initialize tunnel store: %w", err)
 	}
@@ -483,7 +451,7 @@ func disconnectAllTunnels(store *tunnelstore.Store) error {
 
 // runMobileList displays active tunnel connections.
 func runMobileList(_ *cobra.Command, _ []string) error {
-	store, err := tunnelstore.NewStore()
+	store, err := newTunnelStore()
 	if err != nil {
 		return fmt.Errorf("failed to initialize tunnel store: %w", err)
 	}
@@ -542,7 +510,7 @@ func runMobileAdb(_ *cobra.Command, args []string) error {
 		return err
 	}
 
-	store, err := tunnelstore.NewStore()
+	store, err := newTunnelStore()
 	if err != nil {
 		return fmt.Errorf("failed to initialize tunnel store: %w", err)
 	}
@@ -646,16 +614,3 @@ func mustAtoi(s string) int {
 	_, _ = fmt.Sscanf(s, "%d", &n)
 	return n
 }
-
-// exitError is a sentinel error type that carries an exit code.
-type exitCodeError struct {
-	code int
-	err  error
-}
-
-func (e *exitCodeError) Error() string { return e.err.Error() }
-func (e *exitCodeError) Unwrap() error { return e.err }
-
-func exitError(code int, err error) error {
-	return &exitCodeError{code: code, err: err}
-}
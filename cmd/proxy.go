@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -12,7 +16,9 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/proxy"
 )
 
@@ -29,7 +35,10 @@ func addProxyCommand(parent *cobra.Command) {
 
 This creates a local HTTP/WebSocket reverse proxy that forwards all requests to
 the specified port on the remote sandbox. Access tokens are automatically injected
-into all proxied requests.
+into all proxied requests. A token the gateway rejects is re-acquired and
+idempotent requests are retried with it; WebSocket connections whose upstream
+drops are reconnected with backoff. The proxy exits when the sandbox no longer
+exists.
 
 Both HTTP and WebSocket protocols are fully supported, making this suitable for
 web development servers (e.g., Vite, webpack-dev-server), API servers, and any
@@ -40,6 +49,18 @@ use. Navigate to your sandbox instance -> Network -> Open Port, and add the remo
 port number to the allowlist. Requests to ports that are not configured will be
 rejected by the gateway.
 
+Traffic can be captured with --access-log, one line per request, WebSocket
+session and message in Apache Combined Log Format or JSON, and with --har,
+a HAR 1.2 archive with bodies and WebSocket messages truncated to
+--har-max-body bytes. Access tokens are redacted from both.
+
+Binding to a non-loopback address shares the forwarded port, and with it the
+sandbox access token, with the network. Protect it with --basic-auth or a
+bearer token (--bearer-token, --generate-token), limit clients with --allow,
+and serve HTTPS with --tls (self-signed certificate) or --tls-cert/--tls-key.
+The credentials can also be given in AGS_PROXY_BASIC_AUTH and
+AGS_PROXY_BEARER_TOKEN, which keeps them out of the process list.
+
 Port Syntax:
   <remote_port>                Forward remote port to the same local port
   <local_port>:<remote_port>   Forward remote port to a specific local port
@@ -52,13 +73,30 @@ Examples:
   ags proxy sandbox-xxx 3000:8080
 
   # Forward with explicit address
-  ags proxy sandbox-xxx 3000:8080 --address 0.0.0.0`,
+  ags proxy sandbox-xxx 3000:8080 --address 0.0.0.0
+
+  # Share with the local network over HTTPS, behind a generated token
+  ags proxy sandbox-xxx 8080 --address 0.0.0.0 --generate-token --allow 192.168.1.0/24 --tls
+
+  # Log requests as JSON and record a HAR archive
+  ags proxy sandbox-xxx 8080 --access-log access.log --access-log-format json --har session.har`,
 		Args: cobra.ExactArgs(2),
 		RunE: runProxy,
 	}
 
 	proxyCmd.Flags().String("address", "127.0.0.1", "Local address to bind to")
 	proxyCmd.Flags().Bool("verbose", false, "Enable verbose request logging")
+	proxyCmd.Flags().String("access-log", "", "Append an access log to this file")
+	proxyCmd.Flags().String("access-log-format", proxy.AccessLogCombined, "Access log format: combined or json")
+	proxyCmd.Flags().String("har", "", "Record traffic to this HAR file")
+	proxyCmd.Flags().String("basic-auth", "", "Require HTTP basic auth (user:password)")
+	proxyCmd.Flags().String("bearer-token", "", "Require this bearer token")
+	proxyCmd.Flags().Bool("generate-token", false, "Require a randomly generated bearer token and print it")
+	proxyCmd.Flags().StringArray("allow", nil, "Only serve clients from this IP or CIDR range, besides loopback (can be specified multiple times)")
+	proxyCmd.Flags().Bool("tls", false, "Serve HTTPS with a self-signed certificate")
+	proxyCmd.Flags().String("tls-cert", "", "Serve HTTPS with this PEM certificate (requires --tls-key)")
+	proxyCmd.Flags().String("tls-key", "", "PEM private key for --tls-cert")
+	proxyCmd.Flags().Int("har-max-body", proxy.DefaultMaxBodySize, "Truncate HAR bodies and WebSocket messages to this many bytes")
 
 	parent.AddCommand(proxyCmd)
 }
@@ -126,27 +164,42 @@ func runProxy(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Warn when binding to a non-loopback address: the proxy automatically
-	// injects the sandbox access token, so any host that can reach this
-	// address will have full access to the sandbox service.
-	// Loopback addresses: 127.0.0.1 (IPv4), ::1 (IPv6), localhost (hostname).
-	if address != "127.0.0.1" && address != "localhost" && address != "::1" {
-		fmt.Fprintf(os.Stderr, "Warning: binding to %s exposes the proxy (and the sandbox access token) to the network.\n", address)
+	access, generatedToken, err := accessOptions(cmd, address)
+	if err != nil {
+		return err
 	}
+	warnPublicBind(address, sandboxID, access)
+
 	verbose, err := cmd.Flags().GetBool("verbose")
 	if err != nil {
 		return fmt.Errorf("failed to get verbose flag: %w", err)
 	}
 
-	// Acquire a token once upfront. The access token's lifetime is bound to the
-	// sandbox instance lifecycle — it remains valid as long as the sandbox is
-	// running and becomes invalid only when the sandbox is destroyed. There is
-	// no need to refresh the token or handle 401 responses during the proxy's
-	// lifetime.
+	capture, closeCapture, err := captureOptions(cmd)
+	if err != nil {
+		return err
+	}
+	defer closeCapture()
+
+	// Acquire a token upfront. When the gateway rejects it later (e.g. the
+	// cached token was stale), the proxy asks for a new one through the
+	// token provider, which replaces the cached token.
 	token, err := acquireInstanceToken(context.Background(), sandboxID)
 	if err != nil {
 		return fmt.Errorf("failed to acquire access token: %w", err)
 	}
+	current := token
+	tokenProvider := func() (string, error) {
+		fresh, err := refreshInstanceToken(context.Background(), sandboxID, current)
+		if errors.Is(err, client.ErrNotFound) {
+			return "", fmt.Errorf("%w: %s (%w)", proxy.ErrInstanceGone, sandboxID, err)
+		}
+		if err != nil {
+			return "", err
+		}
+		current = fresh
+		return fresh, nil
+	}
 
 	cfg := config.Get()
 	domain := cfg.DataPlaneRegionDomain()
@@ -156,13 +209,17 @@ func runProxy(cmd *cobra.Command, args []string) error {
 	listenAddr := net.JoinHostPort(address, strconv.Itoa(localPort))
 
 	p, err := proxy.New(proxy.Options{
-		InstanceID:    sandboxID,
-		Domain:        domain,
-		RemotePort:    remotePort,
-		Token:         token,
-		ListenAddress: listenAddr,
-		Insecure:      false,
-		Verbose:       verbose,
+		InstanceID:     sandboxID,
+		Domain:         domain,
+		RemotePort:     remotePort,
+		Token:          token,
+		TokenProvider:  tokenProvider,
+		ListenAddress:  listenAddr,
+		Insecure:       false,
+		Verbose:        verbose,
+		DialTLSContext: dataPlaneDialTLS(),
+		Capture:        capture,
+		Access:         access,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create proxy: %w", err)
@@ -173,19 +230,206 @@ func runProxy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to start proxy: %w", err)
 	}
 
+	scheme := "http"
+	if access.Certificate != nil {
+		scheme = "https"
+	}
 	fmt.Printf("Forwarding from %s -> %d\n", addr, remotePort)
-	fmt.Printf("  Local:  http://%s\n", addr)
+	fmt.Printf("  Local:  %s://%s\n", scheme, addr)
 	fmt.Printf("  Remote: https://%d-%s.%s\n", remotePort, sandboxID, domain)
+	printAccess(access, generatedToken)
 	fmt.Println("\nPress Ctrl+C to stop.")
 
-	// Block until SIGINT/SIGTERM, then gracefully shut down.
-	// p.Stop() internally waits up to 5 s for in-flight requests to finish.
+	// Block until SIGINT/SIGTERM or until the instance is gone, then
+	// gracefully shut down. p.Stop() internally waits up to 5 s for in-flight
+	// requests to finish.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
-	<-ctx.Done()
+	select {
+	case <-ctx.Done():
+	case <-p.Gone():
+		p.Stop()
+		return fmt.Errorf("stopping proxy: %w", p.Err())
+	}
 
 	fmt.Println("\nStopping proxy...")
 	p.Stop()
 
 	return nil
 }
+
+// captureOptions builds the traffic capture options from the flags. The
+// returned function closes the access log file.
+func captureOptions(cmd *cobra.Command) (proxy.CaptureOptions, func(), error) {
+	opts := proxy.CaptureOptions{Creator: Version}
+	closeLog := func() {}
+
+	logPath, _ := cmd.Flags().GetString("access-log")
+	opts.AccessLogFormat, _ = cmd.Flags().GetString("access-log-format")
+	opts.HARFile, _ = cmd.Flags().GetString("har")
+	opts.MaxBodySize, _ = cmd.Flags().GetInt("har-max-body")
+
+	switch opts.AccessLogFormat {
+	case proxy.AccessLogCombined, proxy.AccessLogJSON:
+	default:
+		return opts, closeLog, fmt.Errorf("invalid --access-log-format %q: must be %s or %s", opts.AccessLogFormat, proxy.AccessLogCombined, proxy.AccessLogJSON)
+	}
+	if opts.MaxBodySize <= 0 {
+		return opts, closeLog, fmt.Errorf("--har-max-body must be positive, got %d", opts.MaxBodySize)
+	}
+
+	if logPath != "" {
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return opts, closeLog, fmt.Errorf("failed to open access log: %w", err)
+		}
+		opts.AccessLog = f
+		closeLog = func() { _ = f.Close() }
+	}
+	return opts, closeLog, nil
+}
+
+// isLoopbackAddress reports whether a bind address is only reachable from
+// this host.
+func isLoopbackAddress(address string) bool {
+	if address == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsLoopback()
+}
+
+// accessOptions builds the listener protection from the flags and their
+// environment variables. It returns the bearer token when it was generated.
+func accessOptions(cmd *cobra.Command, address string) (proxy.AccessOptions, string, error) {
+	var opts proxy.AccessOptions
+
+	basicAuth, _ := cmd.Flags().GetString("basic-auth")
+	if basicAuth == "" {
+		basicAuth = os.Getenv("AGS_PROXY_BASIC_AUTH")
+	}
+	if basicAuth != "" {
+		user, password, ok := strings.Cut(basicAuth, ":")
+		if !ok || user == "" || password == "" {
+			return opts, "", fmt.Errorf("invalid basic auth credentials: expected user:password")
+		}
+		opts.BasicAuthUser, opts.BasicAuthPassword = user, password
+	}
+
+	opts.BearerToken, _ = cmd.Flags().GetString("bearer-token")
+	if opts.BearerToken == "" {
+		opts.BearerToken = os.Getenv("AGS_PROXY_BEARER_TOKEN")
+	}
+	var generated string
+	if generate, _ := cmd.Flags().GetBool("generate-token"); generate {
+		if opts.BearerToken != "" {
+			return opts, "", fmt.Errorf("--generate-token cannot be combined with a bearer token")
+		}
+		b := make([]byte, 24)
+		if _, err := rand.Read(b); err != nil {
+			return opts, "", fmt.Errorf("failed to generate bearer token: %w", err)
+		}
+		generated = hex.EncodeToString(b)
+		opts.BearerToken = generated
+	}
+
+	allow, _ := cmd.Flags().GetStringArray("allow")
+	nets, err := proxy.ParseAllowList(allow)
+	if err != nil {
+		return opts, "", fmt.Errorf("invalid --allow: %w", err)
+	}
+	opts.AllowedNets = nets
+
+	useTLS, _ := cmd.Flags().GetBool("tls")
+	certFile, _ := cmd.Flags().GetString("tls-cert")
+	keyFile, _ := cmd.Flags().GetString("tls-key")
+	switch {
+	case certFile != "" || keyFile != "":
+		if certFile == "" || keyFile == "" {
+			return opts, "", fmt.Errorf("--tls-cert and --tls-key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return opts, "", fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		opts.Certificate = &cert
+	case useTLS:
+		cert, err := proxy.SelfSignedCertificate(certificateHosts(address))
+		if err != nil {
+			return opts, "", fmt.Errorf("failed to generate TLS certificate: %w", err)
+		}
+		opts.Certificate = &cert
+	}
+	return opts, generated, nil
+}
+
+// certificateHosts returns the names a self-signed certificate is issued
+// for: loopback, the host name and the bind address, or every interface
+// address when binding to all of them.
+func certificateHosts(address string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if name, err := os.Hostname(); err == nil {
+		hosts = append(hosts, name)
+	}
+	ip := net.ParseIP(address)
+	if ip == nil || !ip.IsUnspecified() {
+		return append(hosts, address)
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return hosts
+	}
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok && !n.IP.IsLoopback() {
+			hosts = append(hosts, n.IP.String())
+		}
+	}
+	return hosts
+}
+
+// warnPublicBind warns when a non-loopback bind lets anyone who can reach
+// it use the sandbox access token, and when credentials travel unencrypted.
+func warnPublicBind(address, sandboxID string, access proxy.AccessOptions) {
+	if isLoopbackAddress(address) {
+		return
+	}
+	if !access.AuthEnabled() && len(access.AllowedNets) == 0 {
+		fmt.Fprintf(os.Stderr, `
+WARNING: the proxy listens on %s without authentication or an IP allowlist.
+WARNING: Anyone who can reach this address can use %s with its access token.
+WARNING: Protect it with --basic-auth, --bearer-token, --generate-token or --allow.
+
+`, address, sandboxID)
+		return
+	}
+	if access.AuthEnabled() && access.Certificate == nil {
+		output.PrintWarning("Credentials are sent unencrypted; add --tls to serve HTTPS.")
+	}
+}
+
+// printAccess prints how the listener is protected.
+func printAccess(access proxy.AccessOptions, generatedToken string) {
+	var auth []string
+	if access.BasicAuthUser != "" {
+		auth = append(auth, fmt.Sprintf("basic (user %s)", access.BasicAuthUser))
+	}
+	if access.BearerToken != "" {
+		auth = append(auth, "bearer token")
+	}
+	if len(auth) > 0 {
+		fmt.Printf("  Auth:   %s\n", strings.Join(auth, ", "))
+	}
+	if generatedToken != "" {
+		fmt.Printf("  Token:  %s\n", generatedToken)
+	}
+	if len(access.AllowedNets) > 0 {
+		nets := make([]string, 0, len(access.AllowedNets)+1)
+		for _, n := range access.AllowedNets {
+			nets = append(nets, n.String())
+		}
+		fmt.Printf("  Allow:  %s, loopback\n", strings.Join(nets, ", "))
+	}
+	if access.Certificate != nil {
+		fmt.Printf("  TLS:    SHA-256 %s\n", proxy.Fingerprint(*access.Certificate))
+	}
+}
@@ -1,20 +1,24 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/localsandbox"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/repl"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
 	cfgFile     string
+	profileName string
 	backend     string
 	outputFmt   string
 	showVersion bool
+	debug       bool
 	// Unified flags
 	region   string
 	domain   string
@@ -97,20 +101,99 @@ func executeREPLCommand(args []string) error {
 	addBrowserCommand(newRoot)
 	addMobileCommand(newRoot)
 	addProxyCommand(newRoot)
+	addUpCommand(newRoot)
+	addConfigCommand(newRoot)
+	addDoctorCommand(newRoot)
+	addTokenCommand(newRoot)
 
 	newRoot.SetArgs(args)
 	return newRoot.Execute()
 }
 
+// newSelfCommand builds a command that re-executes the CLI binary with the
+// given arguments, used to spawn background helpers (tunnels, port forwards).
+// Non-sensitive global flags are passed as arguments; credentials are passed
+// via environment variables instead of CLI args to avoid exposure in process
+// listing (ps aux). The child reads them via viper.BindEnv in config.go.
+// The executable path is returned for PID reuse protection.
+func newSelfCommand(args ...string) (*exec.Cmd, string, error) {
+	selfPath, err := os.Executable()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	if cfgFile != "" {
+		args = append(args, "--config", cfgFile)
+	}
+	if profileName != "" {
+		args = append(args, "--profile", profileName)
+	}
+	if backend != "" {
+		args = append(args, "--backend", backend)
+	}
+	if region != "" {
+		args = append(args, "--region", region)
+	}
+	if domain != "" {
+		args = append(args, "--domain", domain)
+	}
+	if internal {
+		args = append(args, "--internal")
+	}
+
+	cmd := exec.Command(selfPath, args...)
+	cmd.Env = os.Environ()
+	if e2bAPIKey != "" {
+		cmd.Env = append(cmd.Env, "AGS_E2B_API_KEY="+e2bAPIKey)
+	}
+	if cloudSecretID != "" {
+		cmd.Env = append(cmd.Env, "AGS_CLOUD_SECRET_ID="+cloudSecretID)
+	}
+	if cloudSecretKey != "" {
+		cmd.Env = append(cmd.Env, "AGS_CLOUD_SECRET_KEY="+cloudSecretKey)
+	}
+	credEnv, err := childCredentialEnv()
+	if err != nil {
+		return nil, "", err
+	}
+	cmd.Env = append(cmd.Env, credEnv...)
+	return cmd, selfPath, nil
+}
+
+// childCredentialEnv returns the environment that hands the cloud credentials
+// resolved here to a spawned helper. Helpers run without a terminal, so keys
+// in the encrypted credentials file could not be unlocked there unless
+// AGS_CREDENTIALS_PASSPHRASE is set; they are passed as static keys instead.
+func childCredentialEnv() ([]string, error) {
+	if config.GetBackend() != "cloud" || config.GetCloudConfig().CredentialSource != config.CredentialSourceFile {
+		return nil, nil
+	}
+	if os.Getenv("AGS_CREDENTIALS_PASSPHRASE") != "" {
+		return nil, nil
+	}
+	cred, _, err := config.CloudCredential()
+	if err != nil {
+		return nil, err
+	}
+	secretID, secretKey, token := cred.GetCredential()
+	env := []string{
+		"AGS_CLOUD_CREDENTIAL_SOURCE=" + config.CredentialSourceStatic,
+		"AGS_CLOUD_SECRET_ID=" + secretID,
+		"AGS_CLOUD_SECRET_KEY=" + secretKey,
+	}
+	if token != "" {
+		env = append(env, "AGS_CLOUD_TOKEN="+token)
+	}
+	return env, nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
+	// Errors are printed by printCommandError so that -o json gets a JSON object
+	rootCmd.SilenceErrors = true
 	if err := rootCmd.Execute(); err != nil {
-		// Respect custom exit codes from exitCodeError (used by mobile tunnel daemon)
-		var exitErr *exitCodeError
-		if errors.As(err, &exitErr) {
-			os.Exit(exitErr.code)
-		}
-		os.Exit(1)
+		printCommandError(err)
+		os.Exit(exitCodeFor(err))
 	}
 }
 
@@ -119,8 +202,10 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ags/config.toml)")
-	rootCmd.PersistentFlags().StringVar(&backend, "backend", "", "API backend: e2b or cloud")
-	rootCmd.PersistentFlags().StringVarP(&outputFmt, "output", "o", "", "output format: text or json")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "config profile to use (overrides AGS_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&backend, "backend", "", "API backend: e2b, cloud or local")
+	rootCmd.PersistentFlags().StringVarP(&outputFmt, "output", "o", "", "output format: text, json, yaml, csv, jsonpath=<template>, go-template=<template> or custom-columns=<spec>")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Print diagnostic messages such as request retries to stderr")
 
 	// Version flag (local to root command only)
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "Print version information")
@@ -153,6 +238,13 @@ func initConfig() {
 	if cfgFile != "" {
 		config.SetConfigFile(cfgFile)
 	}
+	if profileName != "" {
+		config.SetProfile(profileName)
+	}
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		config.PassphraseFunc = promptPassphrase
+	}
 
 	// Initialize config
 	if err := config.Init(); err != nil {
@@ -166,6 +258,9 @@ func initConfig() {
 	if outputFmt != "" {
 		config.SetOutput(outputFmt)
 	}
+	if debug {
+		config.SetDebug(true)
+	}
 
 	// Credential overrides (not subject to priority ordering)
 	if e2bAPIKey != "" {
@@ -202,4 +297,10 @@ func initConfig() {
 	if rootCmd.PersistentFlags().Changed("internal") {
 		config.SetInternal(internal)
 	}
+
+	// Local instances are reached through the same https URLs as remote
+	// ones; route those to the instance daemons on this host
+	if config.GetBackend() == "local" {
+		localsandbox.InstallTransport()
+	}
 }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+)
+
+func TestUnknownProfileFailsCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("AGS_PROFILE", "")
+	configPath := filepath.Join(home, "config.toml")
+	content := "backend = \"local\"\n\n[profiles.dev]\nbackend = \"local\"\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cfgFile, profileName = "", ""
+		config.AcceptProfile()
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+	})
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+
+	rootCmd.SetArgs([]string{"--config", configPath, "--profile", "typo", "tool", "delete", "tool-123"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), `profile "typo" not found`) {
+		t.Fatalf("Execute() error = %v, want unknown profile error", err)
+	}
+}
+
+func TestSelfCommandPassesFileCredentials(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("AGS_PROFILE", "")
+	t.Setenv("AGS_CREDENTIALS_PASSPHRASE", "")
+	configPath := filepath.Join(home, "config.toml")
+	content := "backend = \"cloud\"\n\n[cloud]\ncredential_source = \"file\"\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The passphrase is prompted for on the terminal, which helpers lack
+	config.PassphraseFunc = func(bool) (string, error) { return "secret", nil }
+	t.Cleanup(func() {
+		config.PassphraseFunc = nil
+		config.SetConfigFile("")
+	})
+	config.SetConfigFile(configPath)
+	if err := config.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.StoreCloudCredential(config.CredentialSourceFile, "AKIDfile", "keyfile"); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, _, err := newSelfCommand("proxy", "sb-1", "8080")
+	if err != nil {
+		t.Fatalf("newSelfCommand() error = %v", err)
+	}
+	for _, want := range []string{"AGS_CLOUD_CREDENTIAL_SOURCE=static", "AGS_CLOUD_SECRET_ID=AKIDfile", "AGS_CLOUD_SECRET_KEY=keyfile"} {
+		if !slices.Contains(cmd.Env, want) {
+			t.Errorf("child environment lacks %s", want)
+		}
+	}
+
+	// With the passphrase in the environment the helper unlocks the file itself
+	t.Setenv("AGS_CREDENTIALS_PASSPHRASE", "secret")
+	cmd, _, err = newSelfCommand("proxy", "sb-1", "8080")
+	if err != nil {
+		t.Fatalf("newSelfCommand() error = %v", err)
+	}
+	if slices.Contains(cmd.Env, "AGS_CLOUD_SECRET_ID=AKIDfile") {
+		t.Error("keys passed although the passphrase is in the environment")
+	}
+}
@@ -13,7 +13,6 @@ import (
 
 	"github.com/TencentCloudAgentRuntime/ags-go-sdk/sandbox/code"
 	toolcode "github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/code"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
@@ -54,24 +53,22 @@ type taskResult struct {
 	totalDuration  time.Duration
 }
 
-// getCredential returns the credential from config
-func getCredential() common.CredentialIface {
-	cloudCfg := config.GetCloudConfig()
-	return common.NewCredential(cloudCfg.SecretID, cloudCfg.SecretKey)
-}
-
 // getCreateOptions returns the common create options for sandbox
-func getCreateOptions() []code.CreateOption {
+func getCreateOptions() ([]code.CreateOption, error) {
+	credential, _, err := config.CloudCredential()
+	if err != nil {
+		return nil, err
+	}
 	cfg := config.Get()
 	opts := []code.CreateOption{
-		code.WithCredential(getCredential()),
+		code.WithCredential(credential),
 		code.WithRegion(cfg.Region),
 		code.WithSandboxTimeout(300 * time.Second),
 	}
 	if cfg.Internal {
 		opts = append(opts, code.WithDataPlaneDomain(cfg.DataPlaneDomain()))
 	}
-	return opts
+	return opts, nil
 }
 
 func runCommand(cmd *cobra.Command, args []string) error {
@@ -223,7 +220,7 @@ func runSingleTask(ctx context.Context, task executionTask) error {
 	} else {
 		// Create new sandbox
 		createStart := time.Now()
-		sandbox, err = code.Create(ctx, runTool, getCreateOptions()...)
+		sandbox, err = createSandbox(ctx, runTool)
 		createDuration = time.Since(createStart)
 		if err != nil {
 			return fmt.Errorf("failed to create sandbox: %w", err)
@@ -233,7 +230,7 @@ func runSingleTask(ctx context.Context, task executionTask) error {
 			output.PrintInfo(fmt.Sprintf("Created instance: %s (kept alive)", sandbox.SandboxId))
 		} else {
 			defer func() {
-				_ = sandbox.Kill(ctx)
+				_ = killSandbox(ctx, sandbox)
 			}()
 		}
 	}
@@ -417,7 +414,7 @@ func runTasksSequential(ctx context.Context, tasks []executionTask) []taskResult
 		}
 	} else {
 		createStart := time.Now()
-		sandbox, err = code.Create(ctx, runTool, getCreateOptions()...)
+		sandbox, err = createSandbox(ctx, runTool)
 		sandboxCreateDuration = time.Since(createStart)
 		if err != nil {
 			for i, task := range tasks {
@@ -433,7 +430,7 @@ func runTasksSequential(ctx context.Context, tasks []executionTask) []taskResult
 			output.PrintInfo(fmt.Sprintf("Created instance: %s (kept alive)", sandbox.SandboxId))
 		} else {
 			defer func() {
-				_ = sandbox.Kill(ctx)
+				_ = killSandbox(ctx, sandbox)
 			}()
 		}
 	}
@@ -496,7 +493,7 @@ func runTasksParallel(ctx context.Context, tasks []executionTask) []taskResult {
 	sem := make(chan struct{}, maxParallel)
 
 	// Channel for streaming results as they complete (text mode only)
-	isTextMode := config.GetOutput() != "json"
+	isTextMode := !output.IsJSON()
 	var resultChan chan taskResult
 	var printWg sync.WaitGroup
 	if isTextMode && !runStream {
@@ -532,7 +529,7 @@ func runTasksParallel(ctx context.Context, tasks []executionTask) []taskResult {
 
 			// Each parallel task needs its own sandbox
 			createStart := time.Now()
-			sandbox, err := code.Create(ctx, runTool, getCreateOptions()...)
+			sandbox, err := createSandbox(ctx, runTool)
 			createDuration := time.Since(createStart)
 
 			if err != nil {
@@ -602,7 +599,7 @@ func runTasksParallel(ctx context.Context, tasks []executionTask) []taskResult {
 	// Cleanup sandboxes
 	if !runKeepAlive {
 		for _, sb := range sandboxes {
-			_ = sb.Kill(ctx)
+			_ = killSandbox(ctx, sb)
 		}
 	} else if len(sandboxes) > 0 {
 		ids := make([]string, len(sandboxes))
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 
 	"github.com/TencentCloudAgentRuntime/ags-go-sdk/connection"
 	"github.com/TencentCloudAgentRuntime/ags-go-sdk/constant"
@@ -12,8 +14,11 @@ import (
 	"github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/command"
 	"github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/filesystem"
 
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/localsandbox"
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/token"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/tunnelstore"
 )
 
 // resolveUser returns the effective sandbox user.
@@ -28,6 +33,62 @@ func resolveUser(flagValue string) string {
 	return "user"
 }
 
+// createSandbox creates a temporary sandbox of toolName for a single command.
+// The local backend has no cloud API for the SDK to call, so its instances
+// are created through the control plane client instead.
+func createSandbox(ctx context.Context, toolName string) (*code.Sandbox, error) {
+	if config.GetBackend() != "local" {
+		opts, err := getCreateOptions()
+		if err != nil {
+			return nil, err
+		}
+		return code.Create(ctx, toolName, opts...)
+	}
+	apiClient, err := client.NewControlPlaneClient("local")
+	if err != nil {
+		return nil, err
+	}
+	instance, err := apiClient.CreateInstance(ctx, &client.CreateInstanceOptions{ToolName: toolName, Timeout: 300})
+	if err != nil {
+		return nil, err
+	}
+	return ConnectWithToken(ctx, instance.ID, instance.AccessToken)
+}
+
+// killSandbox deletes a sandbox created by createSandbox.
+func killSandbox(ctx context.Context, sandbox *code.Sandbox) error {
+	if config.GetBackend() != "local" {
+		return sandbox.Kill(ctx)
+	}
+	apiClient, err := client.NewControlPlaneClient("local")
+	if err != nil {
+		return err
+	}
+	return deleteInstance(ctx, apiClient, sandbox.SandboxId)
+}
+
+// deleteInstance deletes an instance and drops its cached access token. The
+// token is also dropped when the instance no longer exists, so every command
+// that deletes instances keeps the token cache in step.
+func deleteInstance(ctx context.Context, apiClient client.ControlPlaneClient, instanceID string) error {
+	err := apiClient.DeleteInstance(ctx, instanceID)
+	if err == nil || errors.Is(err, client.ErrNotFound) {
+		if tokenCache, cacheErr := newTokenCache(); cacheErr == nil {
+			_ = tokenCache.Delete(instanceID)
+		}
+	}
+	return err
+}
+
+// dataPlaneDialTLS returns the TLS dial function for connections the CLI
+// makes to the data plane itself (port forwarding), or nil for the default.
+func dataPlaneDialTLS() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if config.GetBackend() == "local" {
+		return localsandbox.DialTLSContext
+	}
+	return nil
+}
+
 // ConnectWithToken connects to a sandbox instance using a cached access token.
 // This function bypasses the control plane API and directly constructs data plane clients.
 //
@@ -98,6 +159,34 @@ func ConnectWithToken(ctx context.Context, instanceID string, accessToken string
 	return sandbox, nil
 }
 
+// tokenScope returns the token cache scope of the active configuration. The
+// data plane domain is the one ConnectWithToken connects to, so a cached
+// token is only used against the endpoint it was issued for.
+func tokenScope() token.Scope {
+	return token.Scope{
+		Profile: config.GetProfile(),
+		Backend: config.GetBackend(),
+		Region:  config.GetRegion(),
+		Domain:  config.Get().DataPlaneRegionDomain(),
+	}
+}
+
+// newTokenCache opens the token cache scoped to the active profile, backend,
+// region and data plane domain, encrypting tokens when tokens.encrypt is set.
+func newTokenCache() (*token.Cache, error) {
+	c, err := token.NewScopedCache(tokenScope())
+	if err != nil {
+		return nil, err
+	}
+	c.SetEncryption(config.GetTokensConfig().Encrypt, config.TokenCacheKey)
+	return c, nil
+}
+
+// newTunnelStore opens the tunnel store scoped to the active profile and region.
+func newTunnelStore() (*tunnelstore.Store, error) {
+	return tunnelstore.NewScopedStore(config.StoreScope())
+}
+
 // GetCachedTokenOrAcquire gets the access token from cache, or acquires a new one if not cached.
 // For E2B backend, the token must exist in cache (acquired during instance creation).
 // For Cloud backend, if not cached, it will call the control plane API to acquire a new token.
@@ -111,7 +200,7 @@ func ConnectWithToken(ctx context.Context, instanceID string, accessToken string
 //   - error: Any error encountered
 func GetCachedTokenOrAcquire(ctx context.Context, instanceID string) (string, error) {
 	// Try to get from cache first
-	tokenCache, err := token.NewCache()
+	tokenCache, err := newTokenCache()
 	if err != nil {
 		return "", fmt.Errorf("failed to create token cache: %w", err)
 	}
@@ -134,6 +223,26 @@ func GetCachedTokenOrAcquire(ctx context.Context, instanceID string) (string, er
 	return accessToken, nil
 }
 
+// refreshInstanceToken replaces an access token the data plane rejected,
+// unless another command has cached a different token meanwhile. The new
+// token is cached over the rejected one; when none can be acquired the
+// cached token is kept, since e2b tokens cannot be acquired again.
+func refreshInstanceToken(ctx context.Context, instanceID, rejected string) (string, error) {
+	tokenCache, err := newTokenCache()
+	if err != nil {
+		return "", fmt.Errorf("failed to create token cache: %w", err)
+	}
+	if cached, found := tokenCache.Get(instanceID); found && cached != rejected {
+		return cached, nil
+	}
+	accessToken, err := acquireInstanceToken(ctx, instanceID)
+	if err != nil {
+		return "", err
+	}
+	_ = tokenCache.Set(instanceID, accessToken)
+	return accessToken, nil
+}
+
 // getCachedTokenOnly gets the access token from cache only, without calling the control plane API.
 // This is used when --skip-status-check is enabled to ensure pure data plane operation.
 //
@@ -144,7 +253,7 @@ func GetCachedTokenOrAcquire(ctx context.Context, instanceID string) (string, er
 //   - string: The cached access token
 //   - error: Error if token is not found in cache
 func getCachedTokenOnly(instanceID string) (string, error) {
-	tokenCache, err := token.NewCache()
+	tokenCache, err := newTokenCache()
 	if err != nil {
 		return "", fmt.Errorf("failed to create token cache: %w", err)
 	}
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/token"
+)
+
+var (
+	// token prune flags
+	tokenPruneTTL     time.Duration
+	tokenPruneOffline bool
+	tokenPruneDryRun  bool
+)
+
+// tokenStoppedStatuses are instance statuses whose access tokens can no
+// longer be used.
+var tokenStoppedStatuses = []string{"STOPPED", "FAILED", "STARTING_FAILED"}
+
+// tokenPrune is a cached token selected for removal
+type tokenPrune struct {
+	InstanceID string    `json:"instance_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Reason     string    `json:"reason"`
+}
+
+func init() {
+	addTokenCommand(rootCmd)
+}
+
+// addTokenCommand adds the token command to a parent command
+func addTokenCommand(parent *cobra.Command) {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage cached instance access tokens",
+		Long: `Manage the access tokens cached in ~/.ags/tokens.json.
+
+Access tokens authenticate data plane requests (run, exec, file, proxy) to
+an instance. They are cached when an instance is created or a token is
+acquired, separately for each profile and region, and removed when the
+instance is deleted through the CLI. Tokens of instances that expired or
+were deleted elsewhere stay behind until they are pruned.
+
+Set tokens.encrypt = true to encrypt cached tokens with a key kept in the
+OS keyring; existing tokens are encrypted on the next write.`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List cached access tokens",
+		Long: `List the instances with a cached access token in the active profile and
+region. Token values are never printed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tokenCache, err := newTokenCache()
+			if err != nil {
+				return err
+			}
+			entries, err := tokenCache.Entries()
+			if err != nil {
+				return fmt.Errorf("failed to read token cache: %w", err)
+			}
+
+			f := output.NewFormatter()
+			if len(entries) == 0 && !f.IsJSON() {
+				output.PrintInfo("No cached tokens")
+				return nil
+			}
+			now := time.Now()
+			headers := []string{"INSTANCE ID", "CREATED", "AGE", "ENCRYPTED"}
+			rows := make([][]string, len(entries))
+			for i, e := range entries {
+				rows[i] = []string{
+					e.InstanceID,
+					e.CreatedAt.Local().Format("01-02 15:04"),
+					now.Sub(e.CreatedAt).Truncate(time.Second).String(),
+					fmt.Sprint(e.Encrypted),
+				}
+			}
+			return f.PrintList(headers, rows, entries, nil)
+		},
+	}
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove tokens of deleted instances and old tokens",
+		Long: `Remove cached access tokens that can no longer be used: tokens of
+instances the control plane no longer reports, or reports as stopped or
+failed, and tokens older than --ttl (default: tokens.ttl, 168h).
+
+With --offline the control plane is not queried and only the age is
+checked. --dry-run lists the tokens that would be removed.
+
+Note that the e2b backend only issues a token when an instance is created;
+pruning the token of a live e2b instance makes its data plane unreachable
+from the CLI. Use --ttl 0 to prune by instance state only.
+
+Examples:
+  ags token prune
+  ags token prune --dry-run
+  ags token prune --ttl 24h
+  ags token prune --offline --ttl 72h`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			ttl := config.GetTokensConfig().TTL
+			if cmd.Flags().Changed("ttl") {
+				ttl = tokenPruneTTL
+			}
+			if ttl < 0 {
+				return fmt.Errorf("--ttl must not be negative")
+			}
+			if tokenPruneOffline && ttl == 0 {
+				return fmt.Errorf("--offline needs a --ttl greater than 0")
+			}
+
+			tokenCache, err := newTokenCache()
+			if err != nil {
+				return err
+			}
+			entries, err := tokenCache.Entries()
+			if err != nil {
+				return fmt.Errorf("failed to read token cache: %w", err)
+			}
+
+			var statuses map[string]string
+			if !tokenPruneOffline && len(entries) > 0 {
+				if err := config.Validate(); err != nil {
+					return err
+				}
+				apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+				if err != nil {
+					return fmt.Errorf("failed to create API client: %w", err)
+				}
+				if statuses, err = instanceStatuses(ctx, apiClient, entries); err != nil {
+					return fmt.Errorf("failed to list instances (use --offline to prune by age only): %w", err)
+				}
+			}
+
+			now := time.Now()
+			var selected []tokenPrune
+			for _, e := range entries {
+				if reason := tokenPruneReason(e, statuses, ttl, now); reason != "" {
+					selected = append(selected, tokenPrune{InstanceID: e.InstanceID, CreatedAt: e.CreatedAt, Reason: reason})
+				}
+			}
+
+			f := output.NewFormatter()
+			if tokenPruneDryRun {
+				return printTokenPrune(f, selected, "dry-run")
+			}
+			if len(selected) > 0 {
+				_, err := tokenCache.Prune(func(e token.Entry) bool {
+					return slices.ContainsFunc(selected, func(p tokenPrune) bool { return p.InstanceID == e.InstanceID })
+				})
+				if err != nil {
+					return fmt.Errorf("failed to prune token cache: %w", err)
+				}
+			}
+			return printTokenPrune(f, selected, "success")
+		},
+	}
+	pruneCmd.Flags().DurationVar(&tokenPruneTTL, "ttl", 0, "Remove tokens older than this (default: tokens.ttl; 0 disables the age check)")
+	pruneCmd.Flags().BoolVar(&tokenPruneOffline, "offline", false, "Do not query the control plane; prune by age only")
+	pruneCmd.Flags().BoolVar(&tokenPruneDryRun, "dry-run", false, "List the tokens that would be removed")
+
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached access tokens",
+		Long: `Remove every cached access token of the active profile and region.
+
+Tokens cached by older versions are kept, as they may belong to another
+backend. The cloud backend acquires tokens again when they are needed; tokens of e2b
+instances cannot be recovered.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tokenCache, err := newTokenCache()
+			if err != nil {
+				return err
+			}
+			removed, err := tokenCache.Clear()
+			if err != nil {
+				return fmt.Errorf("failed to clear token cache: %w", err)
+			}
+			f := output.NewFormatter()
+			if f.IsJSON() {
+				return f.PrintJSON(map[string]any{
+					"status":    "success",
+					"removed":   len(removed),
+					"instances": removed,
+				})
+			}
+			output.PrintSuccess(fmt.Sprintf("Removed %d cached token(s)", len(removed)))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(listCmd, pruneCmd, clearCmd)
+	parent.AddCommand(cmd)
+}
+
+// instanceStatuses returns the status of the cached instances that the
+// control plane still reports, keyed by instance ID. Instances missing from
+// the list are looked up one by one, since some backends ignore InstanceIDs
+// and list running instances only (e.g. e2b omits paused sandboxes); only
+// those the control plane reports as not found are left out.
+func instanceStatuses(ctx context.Context, apiClient client.ControlPlaneClient, entries []token.Entry) (map[string]string, error) {
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.Unclaimed {
+			ids = append(ids, e.InstanceID)
+		}
+	}
+	statuses := make(map[string]string, len(ids))
+	for batch := range slices.Chunk(ids, instanceListMaxPageSize) {
+		result, err := apiClient.ListInstances(ctx, &client.ListInstancesOptions{InstanceIDs: batch})
+		if err != nil {
+			return nil, err
+		}
+		// Backends that ignore InstanceIDs return every instance; only
+		// the cached ones matter
+		for _, inst := range result.Instances {
+			if slices.Contains(batch, inst.ID) {
+				statuses[inst.ID] = inst.Status
+			}
+		}
+	}
+	for _, id := range ids {
+		if _, ok := statuses[id]; ok {
+			continue
+		}
+		inst, err := apiClient.GetInstance(ctx, id)
+		if errors.Is(err, client.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		statuses[id] = inst.Status
+	}
+	return statuses, nil
+}
+
+// tokenPruneReason returns why a cached token should be removed, or "" to
+// keep it. statuses is nil when the instances were not checked. Unclaimed
+// tokens of older versions may belong to another backend, whose instances
+// this one cannot see, so they are removed by age only.
+func tokenPruneReason(e token.Entry, statuses map[string]string, ttl time.Duration, now time.Time) string {
+	if ttl > 0 && now.Sub(e.CreatedAt) > ttl {
+		return fmt.Sprintf("older than %s", ttl)
+	}
+	if statuses == nil || e.Unclaimed {
+		return ""
+	}
+	status, ok := statuses[e.InstanceID]
+	if !ok {
+		return "instance not found"
+	}
+	if slices.Contains(tokenStoppedStatuses, strings.ToUpper(status)) {
+		return "instance " + strings.ToLower(status)
+	}
+	return ""
+}
+
+// printTokenPrune reports the tokens removed, or to be removed in a dry run
+func printTokenPrune(f *output.Formatter, pruned []tokenPrune, status string) error {
+	if f.IsJSON() {
+		if pruned == nil {
+			pruned = []tokenPrune{}
+		}
+		return f.PrintJSON(map[string]any{
+			"status":  status,
+			"removed": len(pruned),
+			"tokens":  pruned,
+		})
+	}
+	if len(pruned) == 0 {
+		output.PrintInfo("No cached tokens to prune")
+		return nil
+	}
+	headers := []string{"INSTANCE ID", "CREATED", "REASON"}
+	rows := make([][]string, len(pruned))
+	for i, p := range pruned {
+		rows[i] = []string{p.InstanceID, p.CreatedAt.Local().Format("01-02 15:04"), p.Reason}
+	}
+	if err := f.PrintTable(headers, rows, nil); err != nil {
+		return err
+	}
+	if status == "dry-run" {
+		output.PrintInfo(fmt.Sprintf("%d token(s) would be removed", len(pruned)))
+		return nil
+	}
+	output.PrintSuccess(fmt.Sprintf("Removed %d cached token(s)", len(pruned)))
+	return nil
+}
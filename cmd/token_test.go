@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/token"
+)
+
+// runningOnlyLister lists running instances only and ignores InstanceIDs,
+// like the e2b backend; GetInstance also finds paused ones.
+type runningOnlyLister struct {
+	client.ControlPlaneClient
+	instances map[string]string // ID -> status
+	gets      []string
+}
+
+func (l *runningOnlyLister) ListInstances(ctx context.Context, opts *client.ListInstancesOptions) (*client.ListInstancesResult, error) {
+	var result client.ListInstancesResult
+	for id, status := range l.instances {
+		if status == "running" {
+			result.Instances = append(result.Instances, client.Instance{ID: id, Status: status})
+		}
+	}
+	return &result, nil
+}
+
+func (l *runningOnlyLister) GetInstance(ctx context.Context, id string) (*client.Instance, error) {
+	l.gets = append(l.gets, id)
+	status, ok := l.instances[id]
+	if !ok {
+		return nil, client.ErrNotFound
+	}
+	return &client.Instance{ID: id, Status: status}, nil
+}
+
+func TestTokenPruneReason(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	statuses := map[string]string{"sb-running": "RUNNING", "sb-stopped": "STOPPED", "sb-e2b": "running"}
+
+	tests := []struct {
+		name      string
+		id        string
+		age       time.Duration
+		statuses  map[string]string
+		ttl       time.Duration
+		unclaimed bool
+		want      string
+	}{
+		{name: "live instance", id: "sb-running", age: time.Hour, statuses: statuses, ttl: 24 * time.Hour},
+		{name: "lowercase e2b status", id: "sb-e2b", age: time.Hour, statuses: statuses, ttl: 24 * time.Hour},
+		{name: "deleted instance", id: "sb-gone", age: time.Hour, statuses: statuses, ttl: 24 * time.Hour, want: "instance not found"},
+		{name: "stopped instance", id: "sb-stopped", age: time.Hour, statuses: statuses, want: "instance stopped"},
+		{name: "older than ttl", id: "sb-running", age: 48 * time.Hour, statuses: statuses, ttl: 24 * time.Hour, want: "older than 24h0m0s"},
+		{name: "offline keeps young tokens", id: "sb-gone", age: time.Hour, ttl: 24 * time.Hour},
+		{name: "ttl disabled", id: "sb-running", age: 1000 * time.Hour, statuses: statuses},
+		{name: "unclaimed kept when not found", id: "sb-gone", age: time.Hour, statuses: statuses, ttl: 24 * time.Hour, unclaimed: true},
+		{name: "unclaimed older than ttl", id: "sb-gone", age: 48 * time.Hour, statuses: statuses, ttl: 24 * time.Hour, unclaimed: true, want: "older than 24h0m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := token.Entry{InstanceID: tt.id, CreatedAt: now.Add(-tt.age), Unclaimed: tt.unclaimed}
+			if got := tokenPruneReason(e, tt.statuses, tt.ttl, now); got != tt.want {
+				t.Errorf("tokenPruneReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstanceStatusesPaused(t *testing.T) {
+	lister := &runningOnlyLister{instances: map[string]string{
+		"sb-running": "running",
+		"sb-paused":  "paused",
+		"sb-other":   "running", // not cached
+	}}
+	entries := []token.Entry{{InstanceID: "sb-running"}, {InstanceID: "sb-paused"}, {InstanceID: "sb-gone"}}
+
+	statuses, err := instanceStatuses(context.Background(), lister, entries)
+	if err != nil {
+		t.Fatalf("instanceStatuses() error = %v", err)
+	}
+	if len(statuses) != 2 || statuses["sb-running"] != "running" || statuses["sb-paused"] != "paused" {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if len(lister.gets) != 2 {
+		t.Errorf("GetInstance called for %v, want the two unlisted instances", lister.gets)
+	}
+
+	now := time.Now()
+	for _, e := range entries {
+		reason := tokenPruneReason(e, statuses, 0, now)
+		if want := map[string]string{"sb-gone": "instance not found"}[e.InstanceID]; reason != want {
+			t.Errorf("tokenPruneReason(%s) = %q, want %q", e.InstanceID, reason, want)
+		}
+	}
+}
+
+func TestTokenClearKeepsUnclaimed(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("AGS_PROFILE", "")
+	configPath := filepath.Join(home, "config.toml")
+	if err := os.WriteFile(configPath, []byte("backend = \"cloud\"\nregion = \"ap-guangzhou\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A token cached by an older version, before tokens were kept per backend
+	cacheDir := filepath.Join(home, token.CacheDir)
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	v1 := `{"version": 1, "tokens": {"e2b-1": {"access_token": "e2b-token", "created_at": "2026-01-02T03:04:05Z"}}}`
+	if err := os.WriteFile(filepath.Join(cacheDir, token.CacheFile), []byte(v1), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cfgFile, profileName = "", ""
+		config.AcceptProfile()
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+	})
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+
+	rootCmd.SetArgs([]string{"--config", configPath, "token", "clear"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("token clear: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, token.CacheFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "e2b-1") {
+		t.Errorf("token clear removed an unclaimed token: %s", data)
+	}
+}
@@ -24,6 +24,15 @@ var (
 	toolCreateMounts            []string
 	toolCreateVPCSubnets        []string
 	toolCreateVPCSecurityGroups []string
+	toolCreateImage             string
+	toolCreateRegistryType      string
+	toolCreateCommand           []string
+	toolCreateArgs              []string
+	toolCreateEnv               []string
+	toolCreatePorts             []string
+	toolCreateCPU               string
+	toolCreateMemory            string
+	toolCreateProbe             string
 
 	// tool update flags
 	toolUpdateDescription string
@@ -66,6 +75,10 @@ Filter options (ignored when --id is specified):
 
 Note: --created-since and --created-since-time cannot be used together.
 
+On the E2B backend the templates available to the API key are listed: the
+template ID is the tool ID and the first alias the name. Only --id,
+--offset and --limit are supported there.
+
 Examples:
   ags tool list
   ags tool list --id tool-xxx --id tool-yyy
@@ -156,7 +169,7 @@ Examples:
 					networkMode = "-"
 				}
 				createdAt := formatShortTime(t.CreatedAt)
-				rows[i] = []string{t.ID, t.Name, t.Type, networkMode, mountsStr, output.TruncateString(t.Description, 40), tagsStr, createdAt}
+				rows[i] = []string{t.ID, t.Name, valueOrDefault(t.Type, "-"), networkMode, mountsStr, output.TruncateString(t.Description, 40), tagsStr, createdAt}
 			}
 		}
 
@@ -170,12 +183,12 @@ Examples:
 			}
 		}
 
-		if toolListNoHeader {
+		if toolListNoHeader && !f.IsJSON() {
 			if err := f.PrintTableNoHeader(rows); err != nil {
 				return err
 			}
 		} else {
-			if err := f.PrintTable(headers, rows, pagination); err != nil {
+			if err := f.PrintList(headers, rows, result.Tools, pagination); err != nil {
 				return err
 			}
 		}
@@ -256,6 +269,9 @@ var toolGetCmd = &cobra.Command{
 			if len(tool.StorageMounts) > 0 {
 				data["storageMounts"] = tool.StorageMounts
 			}
+			if tool.CustomConfig != nil {
+				data["customConfig"] = tool.CustomConfig
+			}
 			if timing != nil {
 				data["timing"] = timing
 			}
@@ -266,7 +282,7 @@ var toolGetCmd = &cobra.Command{
 		result := []output.KeyValue{
 			{Key: "ID", Value: tool.ID},
 			{Key: "Name", Value: tool.Name},
-			{Key: "Type", Value: tool.Type},
+			{Key: "Type", Value: valueOrDefault(tool.Type, "-")},
 			{Key: "NetworkMode", Value: networkMode},
 		}
 
@@ -295,6 +311,11 @@ var toolGetCmd = &cobra.Command{
 			result = append(result, output.KeyValue{Key: "StorageMounts", Value: mountsStr})
 		}
 
+		// Add container configuration for custom tools
+		if tool.CustomConfig != nil {
+			result = append(result, customConfigKeyValues(tool.CustomConfig)...)
+		}
+
 		if err := f.PrintKeyValue(result); err != nil {
 			return err
 		}
@@ -324,12 +345,108 @@ func formatStorageMountsDetail(mounts []client.StorageMount) string {
 				lines = append(lines, fmt.Sprintf("      Endpoint:   %s", m.StorageSource.Cos.Endpoint))
 			}
 		}
+		if m.StorageSource != nil && m.StorageSource.Cfs != nil {
+			lines = append(lines, "      Type:       cfs")
+			lines = append(lines, fmt.Sprintf("      FileSystem: %s", m.StorageSource.Cfs.FileSystemID))
+			lines = append(lines, fmt.Sprintf("      Target:     %s", m.StorageSource.Cfs.MountTarget))
+			lines = append(lines, fmt.Sprintf("      Path:       %s", m.StorageSource.Cfs.Path))
+		}
 		lines = append(lines, fmt.Sprintf("      MountPath:  %s", m.MountPath))
 		lines = append(lines, fmt.Sprintf("      ReadOnly:   %t", m.ReadOnly))
 	}
 	return strings.Join(lines, "\n")
 }
 
+// buildCustomConfig builds the custom container configuration from the
+// tool create flags, or returns nil when none of them is set
+func buildCustomConfig(cmd *cobra.Command) (*client.CustomConfig, error) {
+	flags := cmd.Flags()
+	set := false
+	for _, name := range []string{"image", "image-registry-type", "command", "arg", "env", "port", "cpu", "memory", "probe"} {
+		set = set || flags.Changed(name)
+	}
+	if !set {
+		return nil, nil
+	}
+	if toolCreateImage == "" {
+		return nil, fmt.Errorf("--image is required when container flags are specified")
+	}
+
+	cfg := &client.CustomConfig{
+		Image:        toolCreateImage,
+		RegistryType: toolCreateRegistryType,
+		Command:      toolCreateCommand,
+		Args:         toolCreateArgs,
+	}
+	for _, s := range toolCreateEnv {
+		env, err := client.ParseEnvVar(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --env: %w", err)
+		}
+		cfg.Env = append(cfg.Env, *env)
+	}
+	for _, s := range toolCreatePorts {
+		port, err := client.ParsePort(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --port: %w", err)
+		}
+		cfg.Ports = append(cfg.Ports, *port)
+	}
+	if toolCreateCPU != "" || toolCreateMemory != "" {
+		cfg.Resources = &client.ResourceConfig{CPU: toolCreateCPU, Memory: toolCreateMemory}
+	}
+	if toolCreateProbe != "" {
+		probe, err := client.ParseProbe(toolCreateProbe)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --probe: %w", err)
+		}
+		cfg.Probe = probe
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid container configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// customConfigKeyValues formats the container configuration of a custom tool
+// for detailed display
+func customConfigKeyValues(c *client.CustomConfig) []output.KeyValue {
+	join := func(values []string) string {
+		if len(values) == 0 {
+			return "-"
+		}
+		return strings.Join(values, " ")
+	}
+
+	var env []string
+	for _, e := range c.Env {
+		env = append(env, fmt.Sprintf("%s=%s", e.Name, e.Value))
+	}
+	var ports []string
+	for _, p := range c.Ports {
+		ports = append(ports, client.FormatPort(p))
+	}
+	resources := "-"
+	if c.Resources != nil {
+		resources = fmt.Sprintf("cpu=%s, memory=%s", valueOrDefault(c.Resources.CPU, "-"), valueOrDefault(c.Resources.Memory, "-"))
+	}
+	probe := "-"
+	if c.Probe != nil {
+		probe = client.FormatProbe(c.Probe)
+	}
+
+	return []output.KeyValue{
+		{Key: "Image", Value: c.Image},
+		{Key: "RegistryType", Value: valueOrDefault(c.RegistryType, "-")},
+		{Key: "Command", Value: join(c.Command)},
+		{Key: "Args", Value: join(c.Args)},
+		{Key: "Env", Value: valueOrDefault(strings.Join(env, ", "), "-")},
+		{Key: "Ports", Value: valueOrDefault(strings.Join(ports, ", "), "-")},
+		{Key: "Resources", Value: resources},
+		{Key: "Probe", Value: probe},
+	}
+}
+
 // formatShortTime formats ISO8601 time to short format (MM-DD HH:MM)
 func formatShortTime(isoTime string) string {
 	if isoTime == "" {
@@ -377,6 +494,19 @@ Network modes:
 
 Storage mount format (--mount):
   type=cos,name=<name>,bucket=<bucket>,src=<source-path>,dst=<target-path>[,readonly][,endpoint=<endpoint>]
+  type=cfs,name=<name>,fs=<file-system-id>,target=<mount-target>,dst=<target-path>[,src=<source-path>][,readonly]
+
+COS mounts require --role-arn. The CFS mount target must be reachable from
+the tool's network, usually a VPC tool in the file system's VPC.
+
+Custom tools (--type custom) run your own container image, set with --image.
+Images in a private TCR registry (--image-registry-type personal or
+enterprise) are pulled with the tool's --role-arn. The remaining container
+flags (--command, --arg, --env, --port, --cpu, --memory, --probe) are
+optional and only valid for custom tools.
+
+Readiness probe format (--probe):
+  path=<path>,port=<port>[,scheme=HTTP|HTTPS][,initial-delay=<s>][,period=<s>][,timeout=<s>][,success=<n>][,failure=<n>]
 
 Examples:
   ags tool create -n my-tool -t code-interpreter
@@ -390,7 +520,18 @@ Examples:
     --vpc-sg sg-yyy1
   ags tool create -n my-tool -t code-interpreter \
     --role-arn "qcs::cam::uin/100000:roleName/AGS_COS_Role" \
-    --mount "type=cos,name=data,bucket=my-bucket-1250000000,src=/data,dst=/mnt/data"`,
+    --mount "type=cos,name=data,bucket=my-bucket-1250000000,src=/data,dst=/mnt/data"
+  ags tool create -n my-dataset-tool -t code-interpreter \
+    --network VPC --vpc-subnet subnet-xxx1 --vpc-sg sg-yyy1 \
+    --mount "type=cfs,name=datasets,fs=cfs-a1b2c3d4,target=10.0.1.12,src=/datasets,dst=/mnt/datasets,readonly"
+  ags tool create -n my-runtime -t custom \
+    --image ccr.ccs.tencentyun.com/my-ns/runtime:v1 \
+    --image-registry-type personal \
+    --role-arn "qcs::cam::uin/100000:roleName/AGS_TCR_Role" \
+    --command /app/server --arg --listen --arg :8080 \
+    --env LOG_LEVEL=info --port http:8080 \
+    --cpu 2 --memory 4Gi \
+    --probe "path=/healthz,port=8080,initial-delay=5"`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
 			start := time.Now()
@@ -454,8 +595,20 @@ Examples:
 			}
 
 			// Validate: RoleArn is required when StorageMounts is set with COS
-			if len(storageMounts) > 0 && toolCreateRoleArn == "" {
-				return fmt.Errorf("--role-arn is required when --mount is specified")
+			if client.HasCosMount(storageMounts) && toolCreateRoleArn == "" {
+				return fmt.Errorf("--role-arn is required when a cos --mount is specified")
+			}
+
+			// Build custom container config
+			customConfig, err := buildCustomConfig(cmd)
+			if err != nil {
+				return err
+			}
+			if customConfig != nil && toolCreateType != "custom" {
+				return fmt.Errorf("--image and other container flags can only be used with --type custom")
+			}
+			if customConfig != nil && customConfig.RegistryType != "" && toolCreateRoleArn == "" {
+				return fmt.Errorf("--role-arn is required when --image-registry-type is specified")
 			}
 
 			// Build VPC config if needed
@@ -482,6 +635,7 @@ Examples:
 				Tags:           tags,
 				RoleArn:        toolCreateRoleArn,
 				StorageMounts:  storageMounts,
+				CustomConfig:   customConfig,
 			})
 			if err != nil {
 				return fmt.Errorf("failed to create tool: %w", err)
@@ -514,6 +668,9 @@ Examples:
 				if len(tool.StorageMounts) > 0 {
 					data["storageMounts"] = tool.StorageMounts
 				}
+				if tool.CustomConfig != nil {
+					data["customConfig"] = tool.CustomConfig
+				}
 				if timing != nil {
 					data["timing"] = timing
 				}
@@ -541,6 +698,9 @@ Examples:
 			if len(tool.StorageMounts) > 0 {
 				result = append(result, output.KeyValue{Key: "StorageMounts", Value: client.FormatStorageMountSummary(tool.StorageMounts)})
 			}
+			if tool.CustomConfig != nil {
+				result = append(result, output.KeyValue{Key: "Image", Value: tool.CustomConfig.Image})
+			}
 
 			if err := f.PrintKeyValue(result); err != nil {
 				return err
@@ -563,6 +723,15 @@ Examples:
 	createCmd.Flags().StringArrayVar(&toolCreateTags, "tag", nil, "Tags in key=value format (can be specified multiple times)")
 	createCmd.Flags().StringVar(&toolCreateRoleArn, "role-arn", "", "Role ARN for COS access (required when --mount is specified)")
 	createCmd.Flags().StringArrayVar(&toolCreateMounts, "mount", nil, "Storage mount config (can be specified multiple times)\n"+client.FormatStorageMountHelp())
+	createCmd.Flags().StringVar(&toolCreateImage, "image", "", "Container image (custom tools only)")
+	createCmd.Flags().StringVar(&toolCreateRegistryType, "image-registry-type", "", "Image registry type: personal, enterprise (private TCR images are pulled with --role-arn)")
+	createCmd.Flags().StringArrayVar(&toolCreateCommand, "command", nil, "Container entrypoint, one element per flag (can be specified multiple times)")
+	createCmd.Flags().StringArrayVar(&toolCreateArgs, "arg", nil, "Container argument (can be specified multiple times)")
+	createCmd.Flags().StringArrayVar(&toolCreateEnv, "env", nil, "Container environment variable in NAME=value format (can be specified multiple times)")
+	createCmd.Flags().StringArrayVar(&toolCreatePorts, "port", nil, "Container port in [name:]port[/protocol] format (can be specified multiple times)")
+	createCmd.Flags().StringVar(&toolCreateCPU, "cpu", "", "CPU per instance (e.g., 1, 2)")
+	createCmd.Flags().StringVar(&toolCreateMemory, "memory", "", "Memory per instance (e.g., 2Gi, 4Gi)")
+	createCmd.Flags().StringVar(&toolCreateProbe, "probe", "", "HTTP readiness probe\n"+client.FormatProbeHelp())
 	createCmd.Flags().BoolVar(&toolTime, "time", false, "Print elapsed time")
 	cmd.AddCommand(createCmd)
 
@@ -721,6 +890,72 @@ Examples:
 	updateCmd.Flags().BoolVar(&toolTime, "time", false, "Print elapsed time")
 	cmd.AddCommand(updateCmd)
 
+	// tool apply
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Create or update a tool from a spec file",
+		Long:  toolApplyCmd.Long,
+		Args:  cobra.NoArgs,
+		RunE:  toolApplyCmd.RunE,
+	}
+	applyCmd.Flags().StringVarP(&toolApplyFile, "file", "f", "", "Tool spec file, YAML or JSON (required)")
+	applyCmd.Flags().BoolVar(&toolApplyDryRun, "dry-run", false, "Show the diff without creating or updating the tool")
+	applyCmd.Flags().BoolVar(&toolTime, "time", false, "Print elapsed time")
+	cmd.AddCommand(applyCmd)
+
+	// tool export
+	exportCmd := &cobra.Command{
+		Use:   "export <tool-id>",
+		Short: "Export a tool as a spec file",
+		Long:  toolExportCmd.Long,
+		Args:  cobra.ExactArgs(1),
+		RunE:  toolExportCmd.RunE,
+	}
+	exportCmd.Flags().StringVarP(&toolExportFile, "file", "f", "", "Write the spec to a file instead of stdout (.json for JSON, YAML otherwise)")
+	exportCmd.Flags().BoolVar(&toolTime, "time", false, "Print elapsed time")
+	cmd.AddCommand(exportCmd)
+
+	// tool clone
+	cloneCmd := &cobra.Command{
+		Use:   "clone <tool-id>",
+		Short: "Copy a tool, optionally into another region",
+		Long:  toolCloneCmd.Long,
+		Args:  cobra.ExactArgs(1),
+		RunE:  toolCloneCmd.RunE,
+	}
+	cloneCmd.Flags().StringVarP(&toolCloneName, "name", "n", "", "Name of the new tool (defaults to the source name; required within the same region)")
+	cloneCmd.Flags().StringVar(&toolCloneRegion, "region", "", "Region to create the new tool in (defaults to the configured region)")
+	cloneCmd.Flags().StringVarP(&toolCloneDescription, "description", "d", "", "Override the tool description")
+	cloneCmd.Flags().StringVar(&toolCloneTimeout, "timeout", "", "Override the default timeout (e.g., 5m, 300s, 1h)")
+	cloneCmd.Flags().StringVar(&toolCloneNetworkMode, "network", "", "Override the network mode: PUBLIC, VPC, SANDBOX, INTERNAL_SERVICE")
+	cloneCmd.Flags().StringArrayVar(&toolCloneVPCSubnets, "vpc-subnet", nil, "VPC subnet ID in the target region (can be specified multiple times)")
+	cloneCmd.Flags().StringArrayVar(&toolCloneVPCSecurityGroups, "vpc-sg", nil, "Security group ID in the target region (can be specified multiple times)")
+	cloneCmd.Flags().StringArrayVar(&toolCloneTags, "tag", nil, "Tags in key=value format, merged into the copied tags (can be specified multiple times)")
+	cloneCmd.Flags().StringVar(&toolCloneRoleArn, "role-arn", "", "Override the role ARN for COS access")
+	cloneCmd.Flags().BoolVar(&toolCloneDryRun, "dry-run", false, "Print the spec of the new tool without creating it")
+	cloneCmd.Flags().BoolVar(&toolTime, "time", false, "Print elapsed time")
+	cmd.AddCommand(cloneCmd)
+
+	// tool test
+	testCmd := &cobra.Command{
+		Use:   "test <tool-id|tool-name>",
+		Short: "Smoke-test a tool in a temporary instance",
+		Long:  toolTestCmd.Long,
+		Args:  cobra.ExactArgs(1),
+		RunE:  toolTestCmd.RunE,
+	}
+	testCmd.Flags().StringArrayVar(&toolTestCode, "code", nil, "Code snippet that must run without error (can be specified multiple times)")
+	testCmd.Flags().StringVarP(&toolTestLanguage, "language", "l", "python", "Language of --code snippets")
+	testCmd.Flags().StringArrayVar(&toolTestCommands, "cmd", nil, "Shell command that must exit with code 0 (can be specified multiple times)")
+	testCmd.Flags().StringArrayVar(&toolTestFiles, "expect-file", nil, "File or directory that must exist (can be specified multiple times)")
+	testCmd.Flags().StringArrayVar(&toolTestHTTP, "http", nil, "HTTP endpoint as <port>[/path] that must return a status below 400 (can be specified multiple times)")
+	testCmd.Flags().StringVar(&toolTestUser, "user", "", "User to run checks as (default: \"user\")")
+	testCmd.Flags().DurationVar(&toolTestWaitTimeout, "wait-timeout", 3*time.Minute, "Maximum time to wait for the instance to start")
+	testCmd.Flags().DurationVar(&toolTestCheckTimeout, "check-timeout", time.Minute, "Timeout of each check")
+	testCmd.Flags().IntVar(&toolTestInstanceTimeout, "instance-timeout", 600, "Instance timeout in seconds, a safety net if deletion fails")
+	testCmd.Flags().BoolVar(&toolTime, "time", false, "Print elapsed time")
+	cmd.AddCommand(testCmd)
+
 	// tool delete
 	deleteCmd := &cobra.Command{
 		Use:     "delete <tool-id> [tool-id...]",
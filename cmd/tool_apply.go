@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/toolspec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// tool apply flags
+	toolApplyFile   string
+	toolApplyDryRun bool
+)
+
+// toolApplyCmd represents the tool apply command
+var toolApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update a tool from a spec file",
+	Long: `Create or update a sandbox tool from a YAML or JSON spec file.
+
+The tool is looked up by name. If it does not exist it is created; otherwise
+the spec is compared field by field with the existing tool and the
+differences are printed. Description, network mode and tags are updated in
+place. Any other difference (type, default timeout, VPC, role ARN, storage
+mounts, custom container settings, or switching to or from VPC mode) cannot
+be changed after creation and is reported as an error; delete and recreate
+the tool to change them.
+
+Spec format:
+  name: my-tool
+  type: code-interpreter
+  description: Data analysis sandbox
+  defaultTimeout: 10m
+  networkMode: VPC              # PUBLIC (default), VPC, SANDBOX, INTERNAL_SERVICE
+  vpc:
+    subnetIds: [subnet-xxx]
+    securityGroupIds: [sg-yyy]
+  tags:
+    team: ai
+  roleArn: qcs::cam::uin/100000:roleName/AGS_COS_Role
+  storageMounts:
+    - name: data
+      mountPath: /mnt/data
+      readOnly: true
+      cos:
+        bucket: my-bucket-1250000000
+        path: /data
+  custom:                       # type: custom only
+    image: ccr.ccs.tencentyun.com/my-ns/runtime:v1
+    command: [/app/server]
+    env: {LOG_LEVEL: info}
+    ports: [{name: http, port: 8080}]
+    cpu: "2"
+    memory: 4Gi
+    probe: {path: /healthz, port: 8080}
+
+Examples:
+  ags tool apply -f tool.yaml
+  ags tool apply -f tool.yaml --dry-run
+  ags tool apply -f tool.json -o json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		start := time.Now()
+
+		if toolApplyFile == "" {
+			return fmt.Errorf("spec file is required (-f/--file)")
+		}
+		spec, err := toolspec.Load(toolApplyFile)
+		if err != nil {
+			return err
+		}
+
+		apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		plan, err := planToolApply(ctx, apiClient, spec)
+		if err != nil {
+			return err
+		}
+		action, changes := plan.action, plan.changes
+
+		f := output.NewFormatter()
+		if !f.IsJSON() {
+			if plan.existing != nil {
+				output.PrintInfo(fmt.Sprintf("Tool %s (%s):", spec.Name, plan.existing.ID))
+			} else {
+				output.PrintInfo(fmt.Sprintf("Tool %s does not exist and will be created:", spec.Name))
+			}
+			if len(changes) > 0 {
+				if err := printToolChanges(f, changes); err != nil {
+					return err
+				}
+			}
+		}
+
+		toolID, err := plan.apply(ctx, apiClient, spec, toolApplyDryRun)
+		if err != nil {
+			return err
+		}
+
+		var timing *output.Timing
+		if toolTime {
+			timing = output.NewTiming(time.Since(start))
+		}
+
+		if f.IsJSON() {
+			data := map[string]any{
+				"status":  "success",
+				"action":  action,
+				"dryRun":  toolApplyDryRun,
+				"id":      toolID,
+				"name":    spec.Name,
+				"changes": changes,
+			}
+			if timing != nil {
+				data["timing"] = timing
+			}
+			return f.PrintJSON(data)
+		}
+
+		switch {
+		case action == "unchanged":
+			output.PrintSuccess(fmt.Sprintf("Tool %s is up to date", spec.Name))
+		case toolApplyDryRun:
+			output.PrintInfo("Dry run: no changes applied")
+		case action == "create":
+			output.PrintSuccess(fmt.Sprintf("Tool created: %s", toolID))
+		default:
+			output.PrintSuccess(fmt.Sprintf("Tool updated: %s", toolID))
+		}
+
+		if toolTime {
+			f.PrintTiming(timing)
+		}
+		return nil
+	},
+}
+
+// toolApplyPlan is what tool apply will do for a spec.
+type toolApplyPlan struct {
+	existing *client.Tool // nil when the tool will be created
+	action   string       // "create", "update" or "unchanged"
+	changes  []toolspec.Change
+}
+
+// planToolApply looks the tool up by name and diffs the spec against it.
+func planToolApply(ctx context.Context, apiClient client.ControlPlaneClient, spec *toolspec.Spec) (*toolApplyPlan, error) {
+	existing, err := findToolByName(ctx, apiClient, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		// Diff against an empty tool to list every field that will be set
+		changes := spec.Diff(&client.Tool{})
+		for i := range changes {
+			changes[i].Immutable = false
+		}
+		return &toolApplyPlan{action: "create", changes: changes}, nil
+	}
+	plan := &toolApplyPlan{existing: existing, action: "update", changes: spec.Diff(existing)}
+	if len(plan.changes) == 0 {
+		plan.action = "unchanged"
+	}
+	return plan, nil
+}
+
+// apply creates or updates the tool unless dryRun is set, and returns its
+// ID. Changes to immutable fields are rejected in either case.
+func (p *toolApplyPlan) apply(ctx context.Context, apiClient client.ControlPlaneClient, spec *toolspec.Spec, dryRun bool) (string, error) {
+	if p.existing == nil {
+		if dryRun {
+			return "", nil
+		}
+		tool, err := apiClient.CreateTool(ctx, spec.CreateOptions())
+		if err != nil {
+			return "", fmt.Errorf("failed to create tool: %w", err)
+		}
+		return tool.ID, nil
+	}
+
+	if immutable := toolspec.ImmutableFields(p.changes); len(immutable) > 0 {
+		return "", &client.Error{
+			Kind: client.KindConflict,
+			Message: fmt.Sprintf("tool %s: immutable fields differ: %s; delete and recreate the tool to change them",
+				spec.Name, strings.Join(immutable, ", ")),
+		}
+	}
+	if p.action == "update" && !dryRun {
+		if err := apiClient.UpdateTool(ctx, spec.UpdateOptions(p.existing.ID, p.changes)); err != nil {
+			return "", fmt.Errorf("failed to update tool: %w", err)
+		}
+	}
+	return p.existing.ID, nil
+}
+
+// findToolByName pages through all tools looking for an exact name match
+// and returns its full details, or nil if there is none.
+func findToolByName(ctx context.Context, apiClient client.ControlPlaneClient, name string) (*client.Tool, error) {
+	const pageSize = 100
+	for offset := 0; ; offset += pageSize {
+		result, err := apiClient.ListTools(ctx, &client.ListToolsOptions{Offset: offset, Limit: pageSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		for _, t := range result.Tools {
+			if t.Name == name {
+				tool, err := apiClient.GetTool(ctx, t.ID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get tool %s: %w", t.ID, err)
+				}
+				return tool, nil
+			}
+		}
+		if len(result.Tools) < pageSize || offset+len(result.Tools) >= result.TotalCount {
+			return nil, nil
+		}
+	}
+}
+
+// printToolChanges prints a field-level diff table.
+func printToolChanges(f *output.Formatter, changes []toolspec.Change) error {
+	headers := []string{"FIELD", "CURRENT", "DESIRED", "NOTE"}
+	rows := make([][]string, len(changes))
+	for i, c := range changes {
+		note := ""
+		if c.Immutable {
+			note = "immutable"
+		}
+		rows[i] = []string{c.Field, valueOrDefault(c.Current, "-"), valueOrDefault(c.Desired, "-"), note}
+	}
+	return f.PrintTable(headers, rows, nil)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/toolspec"
+)
+
+// toolStore serves tools from a fixed slice and records writes.
+type toolStore struct {
+	client.ControlPlaneClient
+	tools   []client.Tool
+	created []*client.CreateToolOptions
+	updated []*client.UpdateToolOptions
+}
+
+func (s *toolStore) ListTools(ctx context.Context, opts *client.ListToolsOptions) (*client.ListToolsResult, error) {
+	return &client.ListToolsResult{Tools: s.tools, TotalCount: len(s.tools)}, nil
+}
+
+func (s *toolStore) GetTool(ctx context.Context, id string) (*client.Tool, error) {
+	for i := range s.tools {
+		if s.tools[i].ID == id {
+			return &s.tools[i], nil
+		}
+	}
+	return nil, client.ErrNotFound
+}
+
+func (s *toolStore) CreateTool(ctx context.Context, opts *client.CreateToolOptions) (*client.Tool, error) {
+	s.created = append(s.created, opts)
+	return &client.Tool{ID: "sdt-new", Name: opts.Name}, nil
+}
+
+func (s *toolStore) UpdateTool(ctx context.Context, opts *client.UpdateToolOptions) error {
+	s.updated = append(s.updated, opts)
+	return nil
+}
+
+func TestToolApply(t *testing.T) {
+	existing := client.Tool{
+		ID:          "sdt-1",
+		Name:        "my-tool",
+		Type:        "code-interpreter",
+		Description: "old",
+		NetworkMode: "PUBLIC",
+		Tags:        map[string]string{"team": "ai"},
+	}
+
+	tests := []struct {
+		name     string
+		tools    []client.Tool
+		spec     toolspec.Spec
+		dryRun   bool
+		action   string
+		id       string
+		creates  int
+		updates  int
+		conflict bool
+	}{
+		{
+			name:    "create",
+			spec:    toolspec.Spec{Name: "my-tool", Type: "code-interpreter", NetworkMode: "PUBLIC"},
+			action:  "create",
+			id:      "sdt-new",
+			creates: 1,
+		},
+		{
+			name:   "create dry run",
+			spec:   toolspec.Spec{Name: "my-tool", Type: "code-interpreter", NetworkMode: "PUBLIC"},
+			dryRun: true,
+			action: "create",
+		},
+		{
+			name:    "update",
+			tools:   []client.Tool{existing},
+			spec:    toolspec.Spec{Name: "my-tool", Type: "code-interpreter", Description: "new", NetworkMode: "PUBLIC", Tags: map[string]string{"team": "ai"}},
+			action:  "update",
+			id:      "sdt-1",
+			updates: 1,
+		},
+		{
+			name:   "update dry run",
+			tools:  []client.Tool{existing},
+			spec:   toolspec.Spec{Name: "my-tool", Type: "code-interpreter", Description: "new", NetworkMode: "PUBLIC", Tags: map[string]string{"team": "ai"}},
+			dryRun: true,
+			action: "update",
+			id:     "sdt-1",
+		},
+		{
+			name:   "unchanged",
+			tools:  []client.Tool{existing},
+			spec:   toolspec.Spec{Name: "my-tool", Type: "code-interpreter", Description: "old", NetworkMode: "PUBLIC", Tags: map[string]string{"team": "ai"}},
+			action: "unchanged",
+			id:     "sdt-1",
+		},
+		{
+			name:     "immutable",
+			tools:    []client.Tool{existing},
+			spec:     toolspec.Spec{Name: "my-tool", Type: "browser", Description: "old", NetworkMode: "PUBLIC", Tags: map[string]string{"team": "ai"}},
+			dryRun:   true,
+			action:   "update",
+			conflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &toolStore{tools: tt.tools}
+			ctx := context.Background()
+
+			plan, err := planToolApply(ctx, store, &tt.spec)
+			if err != nil {
+				t.Fatalf("planToolApply() error = %v", err)
+			}
+			if plan.action != tt.action {
+				t.Errorf("action = %q, want %q", plan.action, tt.action)
+			}
+			if tt.action == "unchanged" && len(plan.changes) != 0 {
+				t.Errorf("changes = %+v, want none", plan.changes)
+			}
+
+			id, err := plan.apply(ctx, store, &tt.spec, tt.dryRun)
+			if tt.conflict {
+				if !errors.Is(err, client.ErrConflict) {
+					t.Errorf("apply() error = %v, want conflict", err)
+				}
+			} else if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			if id != tt.id {
+				t.Errorf("id = %q, want %q", id, tt.id)
+			}
+			if len(store.created) != tt.creates || len(store.updated) != tt.updates {
+				t.Errorf("created %d, updated %d; want %d, %d", len(store.created), len(store.updated), tt.creates, tt.updates)
+			}
+			if tt.updates > 0 {
+				if opts := store.updated[0]; opts.ToolID != "sdt-1" || opts.Description == nil || *opts.Description != "new" || opts.Tags != nil {
+					t.Errorf("UpdateTool() options = %+v, want only the description", opts)
+				}
+			}
+		})
+	}
+}
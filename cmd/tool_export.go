@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/toolspec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// tool export flags
+	toolExportFile string
+
+	// tool clone flags
+	toolCloneName              string
+	toolCloneRegion            string
+	toolCloneDescription       string
+	toolCloneTimeout           string
+	toolCloneNetworkMode       string
+	toolCloneTags              []string
+	toolCloneRoleArn           string
+	toolCloneVPCSubnets        []string
+	toolCloneVPCSecurityGroups []string
+	toolCloneDryRun            bool
+)
+
+// toolExportCmd represents the tool export command
+var toolExportCmd = &cobra.Command{
+	Use:   "export <tool-id>",
+	Short: "Export a tool as a spec file",
+	Long: `Export an existing tool in the spec format used by 'ags tool apply'.
+
+The spec includes the type, description, default timeout, network mode, VPC
+configuration, tags, role ARN and storage mounts, so the tool can be
+recreated in another region or account, or kept in version control.
+
+The spec is printed as YAML on stdout, or as JSON with -o json. With --file
+it is written to a file instead; a .json extension selects JSON.
+
+Examples:
+  ags tool export sdt-xxx > tool.yaml
+  ags tool export sdt-xxx --file tool.json
+  ags tool export sdt-xxx -o json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		start := time.Now()
+		toolID := args[0]
+
+		apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		tool, err := apiClient.GetTool(ctx, toolID)
+		if err != nil {
+			return fmt.Errorf("failed to get tool: %w", err)
+		}
+		spec := toolspec.FromTool(tool)
+
+		f := output.NewFormatter()
+		var timing *output.Timing
+		if toolTime {
+			timing = output.NewTiming(time.Since(start))
+		}
+
+		if toolExportFile == "" {
+			if f.IsJSON() {
+				return f.PrintJSON(spec)
+			}
+			data, err := spec.Marshal()
+			if err != nil {
+				return fmt.Errorf("failed to encode tool spec: %w", err)
+			}
+			if _, err := os.Stdout.Write(data); err != nil {
+				return err
+			}
+			if toolTime {
+				f.PrintTiming(timing)
+			}
+			return nil
+		}
+
+		if err := writeToolSpec(toolExportFile, spec); err != nil {
+			return err
+		}
+
+		if f.IsJSON() {
+			data := map[string]any{
+				"status": "success",
+				"id":     tool.ID,
+				"name":   tool.Name,
+				"file":   toolExportFile,
+			}
+			if timing != nil {
+				data["timing"] = timing
+			}
+			return f.PrintJSON(data)
+		}
+
+		output.PrintSuccess(fmt.Sprintf("Tool %s exported to %s", tool.ID, toolExportFile))
+		if toolTime {
+			f.PrintTiming(timing)
+		}
+		return nil
+	},
+}
+
+// writeToolSpec writes spec to path as JSON when the extension is .json and
+// as YAML otherwise.
+func writeToolSpec(path string, spec *toolspec.Spec) error {
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err = json.MarshalIndent(spec, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = spec.Marshal()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode tool spec: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write tool spec: %w", err)
+	}
+	return nil
+}
+
+// toolCloneCmd represents the tool clone command
+var toolCloneCmd = &cobra.Command{
+	Use:   "clone <tool-id>",
+	Short: "Copy a tool, optionally into another region",
+	Long: `Create a copy of an existing tool, optionally in another region.
+
+The source tool is read in the configured region and exported as a spec
+(see 'ags tool export'); the overrides below are applied and the new tool is
+created in --region. Tags given with --tag are merged into the copied tags.
+
+--name is required when cloning within the same region. VPC subnets and
+security groups belong to a region, so cloning a VPC tool into another
+region requires --vpc-subnet and --vpc-sg (or --network with another mode).
+CFS file systems are regional too, so tools with CFS mounts can only be
+cloned within their region.
+The command fails if a tool with the new name already exists in the target
+region; use 'ags tool apply' to update it instead.
+
+Examples:
+  # Promote a tool from staging to production
+  ags tool clone sdt-xxx --region ap-shanghai
+
+  # Copy within the region under a new name
+  ags tool clone sdt-xxx --name my-tool-v2 --tag version=2
+
+  # Clone a VPC tool into another region's network
+  ags tool clone sdt-xxx --region ap-beijing --vpc-subnet subnet-bj1 --vpc-sg sg-bj1
+
+  # Preview the spec that would be created
+  ags tool clone sdt-xxx --region ap-shanghai --dry-run`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		start := time.Now()
+		toolID := args[0]
+
+		sourceRegion := config.GetRegion()
+		targetRegion := toolCloneRegion
+		if targetRegion == "" {
+			targetRegion = sourceRegion
+		}
+		crossRegion := targetRegion != sourceRegion
+		if !crossRegion && toolCloneName == "" {
+			return fmt.Errorf("--name is required when cloning within the same region")
+		}
+
+		sourceClient, err := client.NewControlPlaneClient(config.GetBackend())
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+		tool, err := sourceClient.GetTool(ctx, toolID)
+		if err != nil {
+			return fmt.Errorf("failed to get tool: %w", err)
+		}
+
+		spec, err := cloneSpec(cmd, tool, toolID, targetRegion, crossRegion)
+		if err != nil {
+			return err
+		}
+
+		f := output.NewFormatter()
+		if crossRegion && !f.IsJSON() {
+			for _, m := range spec.StorageMounts {
+				if m.Cos != nil && m.Cos.Endpoint == "" {
+					output.PrintWarning(fmt.Sprintf("Storage mount %s has no COS endpoint and will use the default endpoint of %s", m.Name, targetRegion))
+				}
+			}
+		}
+
+		targetClient := sourceClient
+		if crossRegion {
+			targetClient, err = client.NewControlPlaneClientInRegion(config.GetBackend(), targetRegion)
+			if err != nil {
+				return fmt.Errorf("failed to create API client for %s: %w", targetRegion, err)
+			}
+		}
+
+		existing, err := findToolByName(ctx, targetClient, spec.Name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return &client.Error{
+				Kind: client.KindConflict,
+				Message: fmt.Sprintf("tool %s already exists in %s (%s); use 'ags tool apply' to update it",
+					spec.Name, targetRegion, existing.ID),
+			}
+		}
+
+		if toolCloneDryRun {
+			if f.IsJSON() {
+				return f.PrintJSON(map[string]any{
+					"status": "success",
+					"dryRun": true,
+					"source": toolID,
+					"region": targetRegion,
+					"spec":   spec,
+				})
+			}
+			output.PrintInfo(fmt.Sprintf("Dry run: would create tool %s in %s from %s:", spec.Name, targetRegion, toolID))
+			data, err := spec.Marshal()
+			if err != nil {
+				return fmt.Errorf("failed to encode tool spec: %w", err)
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+
+		created, err := targetClient.CreateTool(ctx, spec.CreateOptions())
+		if err != nil {
+			return fmt.Errorf("failed to create tool: %w", err)
+		}
+
+		var timing *output.Timing
+		if toolTime {
+			timing = output.NewTiming(time.Since(start))
+		}
+
+		if f.IsJSON() {
+			data := map[string]any{
+				"status":  "success",
+				"message": fmt.Sprintf("Tool cloned: %s", created.ID),
+				"id":      created.ID,
+				"name":    created.Name,
+				"region":  targetRegion,
+				"source":  toolID,
+			}
+			if timing != nil {
+				data["timing"] = timing
+			}
+			return f.PrintJSON(data)
+		}
+
+		output.PrintSuccess(fmt.Sprintf("Tool cloned: %s", created.ID))
+		if err := f.PrintKeyValue([]output.KeyValue{
+			{Key: "ID", Value: created.ID},
+			{Key: "Name", Value: created.Name},
+			{Key: "Region", Value: targetRegion},
+			{Key: "Source", Value: fmt.Sprintf("%s (%s)", toolID, sourceRegion)},
+		}); err != nil {
+			return err
+		}
+		if toolTime {
+			f.PrintTiming(timing)
+		}
+		return nil
+	},
+}
+
+// cloneSpec builds the spec of the copy of tool to create in targetRegion:
+// the exported spec with the clone flags applied, checked for settings that
+// do not carry over to another region.
+func cloneSpec(cmd *cobra.Command, tool *client.Tool, toolID, targetRegion string, crossRegion bool) (*toolspec.Spec, error) {
+	spec := toolspec.FromTool(tool)
+	if err := applyCloneOverrides(cmd, spec); err != nil {
+		return nil, err
+	}
+	if crossRegion && spec.NetworkMode == "VPC" && !cmd.Flags().Changed("vpc-subnet") {
+		return nil, fmt.Errorf("tool %s uses VPC networking; VPC subnets and security groups are region-specific, pass --vpc-subnet and --vpc-sg for %s", toolID, targetRegion)
+	}
+	if crossRegion {
+		for _, m := range spec.StorageMounts {
+			if m.Cfs != nil {
+				return nil, fmt.Errorf("storage mount %s uses CFS file system %s, which cannot be mounted from %s; recreate the tool with 'ags tool apply' and a file system in that region", m.Name, m.Cfs.FileSystemID, targetRegion)
+			}
+		}
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid clone of tool %s: %w", toolID, err)
+	}
+	return spec, nil
+}
+
+// applyCloneOverrides applies the clone flags that were set to spec.
+func applyCloneOverrides(cmd *cobra.Command, spec *toolspec.Spec) error {
+	flags := cmd.Flags()
+	if toolCloneName != "" {
+		spec.Name = toolCloneName
+	}
+	if flags.Changed("description") {
+		spec.Description = toolCloneDescription
+	}
+	if flags.Changed("timeout") {
+		spec.DefaultTimeout = toolCloneTimeout
+	}
+	if flags.Changed("role-arn") {
+		spec.RoleArn = toolCloneRoleArn
+	}
+	if flags.Changed("network") {
+		spec.NetworkMode = strings.ToUpper(toolCloneNetworkMode)
+		if spec.NetworkMode != "VPC" {
+			spec.VPC = nil
+		}
+	}
+	if flags.Changed("vpc-subnet") || flags.Changed("vpc-sg") {
+		if spec.VPC == nil {
+			spec.VPC = &toolspec.VPC{}
+		}
+		if flags.Changed("vpc-subnet") {
+			spec.VPC.SubnetIDs = toolCloneVPCSubnets
+		}
+		if flags.Changed("vpc-sg") {
+			spec.VPC.SecurityGroupIDs = toolCloneVPCSecurityGroups
+		}
+	}
+	for _, tag := range toolCloneTags {
+		key, value, ok := strings.Cut(tag, "=")
+		if !ok {
+			return fmt.Errorf("invalid tag format: %s (expected key=value)", tag)
+		}
+		if spec.Tags == nil {
+			spec.Tags = make(map[string]string)
+		}
+		spec.Tags[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/toolspec"
+	"github.com/spf13/cobra"
+)
+
+// parseCloneFlags returns a fresh tool clone command with args parsed, so
+// that every case starts from the flag defaults.
+func parseCloneFlags(t *testing.T, args []string) *cobra.Command {
+	t.Helper()
+	root := &cobra.Command{Use: "ags"}
+	addToolCommand(root)
+	cloneCmd, _, err := root.Find([]string{"tool", "clone"})
+	if err != nil {
+		t.Fatalf("clone command not found: %v", err)
+	}
+	if err := cloneCmd.ParseFlags(args); err != nil {
+		t.Fatalf("ParseFlags(%v) error = %v", args, err)
+	}
+	return cloneCmd
+}
+
+func TestCloneSpec(t *testing.T) {
+	custom := &client.Tool{
+		ID:          "sdt-custom",
+		Name:        "my-app",
+		Type:        "custom",
+		Description: "app runtime",
+		NetworkMode: "PUBLIC",
+		Tags:        map[string]string{"team": "ai", "env": "staging"},
+		CustomConfig: &client.CustomConfig{
+			Image:     "ccr.ccs.tencentyun.com/ns/app:v1",
+			Command:   []string{"/app/server"},
+			Env:       []client.EnvVar{{Name: "LOG_LEVEL", Value: "info"}},
+			Ports:     []client.PortConfig{{Name: "http", Port: 8080}},
+			Resources: &client.ResourceConfig{CPU: "2", Memory: "4Gi"},
+		},
+	}
+	vpc := &client.Tool{
+		ID:          "sdt-vpc",
+		Name:        "my-vpc-tool",
+		Type:        "code-interpreter",
+		NetworkMode: "VPC",
+		VPCConfig:   &client.VPCConfig{SubnetIds: []string{"subnet-gz"}, SecurityGroupIds: []string{"sg-gz"}},
+	}
+	cfs := &client.Tool{
+		ID:          "sdt-cfs",
+		Name:        "my-cfs-tool",
+		Type:        "code-interpreter",
+		NetworkMode: "PUBLIC",
+		StorageMounts: []client.StorageMount{{
+			Name:          "shared",
+			MountPath:     "/mnt/shared",
+			StorageSource: &client.StorageSource{Cfs: &client.CfsStorageSource{FileSystemID: "cfs-1", MountTarget: "10.0.0.2", Path: "/"}},
+		}},
+	}
+
+	tests := []struct {
+		name        string
+		tool        *client.Tool
+		args        []string
+		crossRegion bool
+		check       func(t *testing.T, spec *toolspec.Spec)
+		wantErr     string
+	}{
+		{
+			name: "rename keeps the custom config",
+			tool: custom,
+			args: []string{"--name", "my-app-v2"},
+			check: func(t *testing.T, spec *toolspec.Spec) {
+				if spec.Name != "my-app-v2" || spec.Description != "app runtime" {
+					t.Errorf("name, description = %q, %q", spec.Name, spec.Description)
+				}
+				want := &toolspec.Custom{
+					Image:   "ccr.ccs.tencentyun.com/ns/app:v1",
+					Command: []string{"/app/server"},
+					Env:     map[string]string{"LOG_LEVEL": "info"},
+					Ports:   []toolspec.Port{{Name: "http", Port: 8080}},
+					CPU:     "2",
+					Memory:  "4Gi",
+				}
+				if !reflect.DeepEqual(spec.Custom, want) {
+					t.Errorf("custom = %+v, want %+v", spec.Custom, want)
+				}
+			},
+		},
+		{
+			name:        "other region keeps the name and merges tags",
+			tool:        custom,
+			args:        []string{"--tag", "env=prod", "--tag", "owner=ops", "--description", "promoted"},
+			crossRegion: true,
+			check: func(t *testing.T, spec *toolspec.Spec) {
+				if spec.Name != "my-app" || spec.Description != "promoted" {
+					t.Errorf("name, description = %q, %q", spec.Name, spec.Description)
+				}
+				want := map[string]string{"team": "ai", "env": "prod", "owner": "ops"}
+				if !reflect.DeepEqual(spec.Tags, want) {
+					t.Errorf("tags = %v, want %v", spec.Tags, want)
+				}
+				if spec.Custom == nil || spec.Custom.Image != "ccr.ccs.tencentyun.com/ns/app:v1" {
+					t.Errorf("custom = %+v, want the source container", spec.Custom)
+				}
+			},
+		},
+		{
+			name:    "invalid tag",
+			tool:    custom,
+			args:    []string{"--name", "copy", "--tag", "env"},
+			wantErr: "invalid tag format",
+		},
+		{
+			name:        "VPC into another region needs its network",
+			tool:        vpc,
+			crossRegion: true,
+			wantErr:     "pass --vpc-subnet and --vpc-sg for ap-beijing",
+		},
+		{
+			name:        "VPC into another region",
+			tool:        vpc,
+			args:        []string{"--vpc-subnet", "subnet-bj", "--vpc-sg", "sg-bj"},
+			crossRegion: true,
+			check: func(t *testing.T, spec *toolspec.Spec) {
+				want := &toolspec.VPC{SubnetIDs: []string{"subnet-bj"}, SecurityGroupIDs: []string{"sg-bj"}}
+				if !reflect.DeepEqual(spec.VPC, want) {
+					t.Errorf("vpc = %+v, want %+v", spec.VPC, want)
+				}
+			},
+		},
+		{
+			name:        "switching off VPC drops the network",
+			tool:        vpc,
+			args:        []string{"--network", "public"},
+			crossRegion: true,
+			check: func(t *testing.T, spec *toolspec.Spec) {
+				if spec.NetworkMode != "PUBLIC" || spec.VPC != nil {
+					t.Errorf("network = %s, vpc = %+v", spec.NetworkMode, spec.VPC)
+				}
+			},
+		},
+		{
+			name: "CFS within the region",
+			tool: cfs,
+			args: []string{"--name", "copy"},
+			check: func(t *testing.T, spec *toolspec.Spec) {
+				if len(spec.StorageMounts) != 1 || spec.StorageMounts[0].Cfs == nil {
+					t.Errorf("storage mounts = %+v", spec.StorageMounts)
+				}
+			},
+		},
+		{
+			name:        "CFS into another region",
+			tool:        cfs,
+			crossRegion: true,
+			wantErr:     "cannot be mounted from ap-beijing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := parseCloneFlags(t, tt.args)
+			spec, err := cloneSpec(cmd, tt.tool, tt.tool.ID, "ap-beijing", tt.crossRegion)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("cloneSpec() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cloneSpec() error = %v", err)
+			}
+			tt.check(t, spec)
+		})
+	}
+
+	// The source tags are copied, not shared
+	if custom.Tags["env"] != "staging" {
+		t.Errorf("source tags modified: %v", custom.Tags)
+	}
+}
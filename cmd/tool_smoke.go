@@ -0,0 +1,461 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/sandbox/code"
+	toolcode "github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/code"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/command"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/filesystem"
+	"github.com/spf13/cobra"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/proxy"
+)
+
+var (
+	// tool test flags
+	toolTestCode            []string
+	toolTestLanguage        string
+	toolTestCommands        []string
+	toolTestFiles           []string
+	toolTestHTTP            []string
+	toolTestUser            string
+	toolTestWaitTimeout     time.Duration
+	toolTestCheckTimeout    time.Duration
+	toolTestInstanceTimeout int
+)
+
+// toolTestDeleteTimeout bounds the final instance deletion, which runs even
+// after the command was interrupted.
+const toolTestDeleteTimeout = 30 * time.Second
+
+// toolCheck is the outcome of one smoke test check.
+type toolCheck struct {
+	Name     string `json:"name"`
+	Target   string `json:"target"`
+	Passed   bool   `json:"passed"`
+	Detail   string `json:"detail,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// httpCheck is a parsed --http value.
+type httpCheck struct {
+	port int
+	path string
+}
+
+// toolTestCmd represents the tool test command
+var toolTestCmd = &cobra.Command{
+	Use:   "test <tool-id|tool-name>",
+	Short: "Smoke-test a tool in a temporary instance",
+	Long: `Smoke-test a tool end-to-end in a temporary instance.
+
+The command creates an instance of the tool, waits until it is running and
+runs the checks below in order. Every check runs even if an earlier one
+failed. A pass/fail report is printed and the instance is always deleted,
+including when the command is interrupted.
+
+Checks:
+  - Mounts (automatic): every storage mount declared by the tool must be
+    visible as a directory at its mount path
+  - --expect-file <path>: the file or directory must exist
+  - --cmd <command>: the shell command must exit with code 0
+  - --code <snippet>: the code (--language, default python) must run
+    without raising an error
+  - --http <port>[/path]: an HTTP GET through the sandbox gateway (as used
+    by 'ags proxy') must return a status below 400; connection errors and
+    gateway errors are retried until --check-timeout
+
+If no check is given and the tool has no storage mounts, 'true' is run as a
+shell command to verify the data plane. The command exits with code 1 when
+any check fails, so it can gate CI pipelines.
+
+Examples:
+  ags tool test sdt-xxx
+  ags tool test my-tool --code 'import pandas; print(pandas.__version__)'
+  ags tool test my-tool --cmd 'python3 --version' --expect-file /opt/app/config.yaml
+  ags tool test my-runtime --http 8080/healthz --check-timeout 2m
+  ags tool test my-tool --cmd 'ls /mnt/data' -o json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		start := time.Now()
+
+		if err := config.Validate(); err != nil {
+			return err
+		}
+
+		var httpChecks []httpCheck
+		for _, s := range toolTestHTTP {
+			c, err := parseHTTPCheck(s)
+			if err != nil {
+				return fmt.Errorf("invalid --http: %w", err)
+			}
+			httpChecks = append(httpChecks, c)
+		}
+
+		// Interrupting the command cancels the checks; the instance is still deleted below
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		f := output.NewFormatter()
+		progress := func(msg string) {
+			if !f.IsJSON() {
+				output.PrintInfo(msg)
+			}
+		}
+
+		tool, createOpts, err := resolveToolForTest(ctx, apiClient, args[0])
+		if err != nil {
+			return err
+		}
+		createOpts.Timeout = toolTestInstanceTimeout
+		if createOpts.ToolID == "" && !f.IsJSON() {
+			output.PrintWarning("The backend cannot look up tools; storage mount checks are skipped")
+		}
+
+		instance, err := apiClient.CreateInstance(ctx, createOpts)
+		if err != nil {
+			return fmt.Errorf("failed to create instance: %w", err)
+		}
+		progress(fmt.Sprintf("Instance created: %s", instance.ID))
+
+		deleted := false
+		defer func() {
+			if deleted {
+				return
+			}
+			if err := deleteTestInstance(apiClient, instance.ID); err != nil {
+				output.PrintWarning(fmt.Sprintf("Failed to delete instance %s: %v", instance.ID, err))
+			}
+		}()
+
+		instance, err = waitForInstanceRunning(ctx, apiClient, instance, toolTestWaitTimeout)
+		if err != nil {
+			return fmt.Errorf("instance %s did not start: %w", instance.ID, err)
+		}
+		progress(fmt.Sprintf("Instance %s is running, running checks...", instance.ID))
+
+		var accessToken string
+		if instance.Secure || len(httpChecks) > 0 {
+			if err := cacheInstanceToken(ctx, apiClient, instance); err != nil {
+				return fmt.Errorf("failed to get access token: %w", err)
+			}
+			if accessToken, err = GetCachedTokenOrAcquire(ctx, instance.ID); err != nil {
+				return fmt.Errorf("failed to get access token: %w", err)
+			}
+		}
+		sandbox, err := ConnectWithToken(ctx, instance.ID, accessToken)
+		if err != nil {
+			return fmt.Errorf("failed to connect to instance: %w", err)
+		}
+
+		checks := runToolChecks(ctx, sandbox, tool, instance.ID, accessToken, httpChecks)
+
+		if err := deleteTestInstance(apiClient, instance.ID); err != nil {
+			output.PrintWarning(fmt.Sprintf("Failed to delete instance %s: %v", instance.ID, err))
+		}
+		deleted = true
+
+		failed := 0
+		for _, c := range checks {
+			if !c.Passed {
+				failed++
+			}
+		}
+
+		var timing *output.Timing
+		if toolTime {
+			timing = output.NewTiming(time.Since(start))
+		}
+
+		var result error
+		if failed > 0 {
+			result = fmt.Errorf("%d of %d check(s) failed", failed, len(checks))
+		}
+
+		if f.IsJSON() {
+			status := "passed"
+			if failed > 0 {
+				status = "failed"
+			}
+			data := map[string]any{
+				"status":     status,
+				"tool":       valueOrDefault(tool.ID, tool.Name),
+				"instanceId": instance.ID,
+				"passed":     len(checks) - failed,
+				"failed":     failed,
+				"checks":     checks,
+			}
+			if timing != nil {
+				data["timing"] = timing
+			}
+			if err := f.PrintJSON(data); err != nil {
+				return err
+			}
+			if result != nil {
+				return reportedError(result)
+			}
+			return nil
+		}
+
+		headers := []string{"CHECK", "TARGET", "RESULT", "DURATION", "DETAIL"}
+		rows := make([][]string, len(checks))
+		for i, c := range checks {
+			res := "PASS"
+			if !c.Passed {
+				res = "FAIL"
+			}
+			rows[i] = []string{c.Name, c.Target, res, c.Duration, valueOrDefault(c.Detail, "-")}
+		}
+		if err := f.PrintTable(headers, rows, nil); err != nil {
+			return err
+		}
+		if toolTime {
+			f.PrintTiming(timing)
+		}
+		if result != nil {
+			return result
+		}
+		output.PrintSuccess(fmt.Sprintf("All %d check(s) passed", len(checks)))
+		return nil
+	},
+}
+
+// resolveToolForTest looks up the tool by ID, then by name. Backends that
+// cannot look up tools fall back to creating the instance by name, without
+// mount checks.
+func resolveToolForTest(ctx context.Context, apiClient client.ControlPlaneClient, ref string) (*client.Tool, *client.CreateInstanceOptions, error) {
+	tool, err := apiClient.GetTool(ctx, ref)
+	if err == nil {
+		return tool, &client.CreateInstanceOptions{ToolID: tool.ID}, nil
+	}
+	switch {
+	case errors.Is(err, client.ErrUnsupported):
+		return &client.Tool{Name: ref}, &client.CreateInstanceOptions{ToolName: ref}, nil
+	case !errors.Is(err, client.ErrNotFound):
+		return nil, nil, fmt.Errorf("failed to get tool: %w", err)
+	}
+
+	tool, err = findToolByName(ctx, apiClient, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tool == nil {
+		return nil, nil, &client.Error{Kind: client.KindNotFound, Message: fmt.Sprintf("tool not found: %s", ref)}
+	}
+	return tool, &client.CreateInstanceOptions{ToolID: tool.ID}, nil
+}
+
+// waitForInstanceRunning polls the instance until it is running, fails or
+// the timeout expires.
+func waitForInstanceRunning(ctx context.Context, apiClient client.ControlPlaneClient, instance *client.Instance, timeout time.Duration) (*client.Instance, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		switch strings.ToUpper(instance.Status) {
+		case "RUNNING":
+			return instance, nil
+		case "FAILED", "ERROR", "STARTING_FAILED", "STOPPING", "STOPPED":
+			return instance, fmt.Errorf("instance status is %s", instance.Status)
+		}
+		if time.Now().After(deadline) {
+			return instance, &client.Error{Kind: client.KindTimeout, Message: fmt.Sprintf("instance is still %s after %s", instance.Status, timeout)}
+		}
+
+		select {
+		case <-ctx.Done():
+			return instance, ctx.Err()
+		case <-time.After(time.Second):
+		}
+
+		current, err := apiClient.GetInstance(ctx, instance.ID)
+		if err != nil {
+			return instance, err
+		}
+		instance = current
+	}
+}
+
+// deleteTestInstance deletes the test instance with a fresh context so that
+// it also runs after an interrupt, and drops its cached token.
+func deleteTestInstance(apiClient client.ControlPlaneClient, instanceID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), toolTestDeleteTimeout)
+	defer cancel()
+	if err := deleteInstance(ctx, apiClient, instanceID); err != nil && !errors.Is(err, client.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// runToolChecks runs every configured check against the instance.
+func runToolChecks(ctx context.Context, sandbox *code.Sandbox, tool *client.Tool, instanceID, accessToken string, httpChecks []httpCheck) []toolCheck {
+	user := resolveUser(toolTestUser)
+	var checks []toolCheck
+	run := func(name, target string, fn func(ctx context.Context) (string, error)) {
+		checkCtx, cancel := context.WithTimeout(ctx, toolTestCheckTimeout)
+		defer cancel()
+		start := time.Now()
+		detail, err := fn(checkCtx)
+		c := toolCheck{Name: name, Target: target, Passed: err == nil, Detail: detail, Duration: formatCheckDuration(time.Since(start))}
+		if err != nil {
+			c.Detail = err.Error()
+		}
+		checks = append(checks, c)
+	}
+
+	for _, m := range tool.StorageMounts {
+		run("mount", fmt.Sprintf("%s (%s)", m.MountPath, m.Name), func(ctx context.Context) (string, error) {
+			info, err := sandbox.Files.GetInfo(ctx, m.MountPath, &filesystem.GetInfoConfig{User: user})
+			if err != nil {
+				return "", err
+			}
+			if info.Type == nil || *info.Type != filesystem.Dir {
+				return "", fmt.Errorf("not a directory")
+			}
+			return info.Permissions, nil
+		})
+	}
+
+	for _, path := range toolTestFiles {
+		run("file", path, func(ctx context.Context) (string, error) {
+			info, err := sandbox.Files.GetInfo(ctx, path, &filesystem.GetInfoConfig{User: user})
+			if err != nil {
+				return "", err
+			}
+			if info.Type != nil && *info.Type == filesystem.Dir {
+				return "directory", nil
+			}
+			return fmt.Sprintf("%d bytes", info.Size), nil
+		})
+	}
+
+	commands := toolTestCommands
+	if len(commands) == 0 && len(toolTestFiles) == 0 && len(toolTestCode) == 0 && len(httpChecks) == 0 && len(tool.StorageMounts) == 0 {
+		commands = []string{"true"}
+	}
+	for _, cmdStr := range commands {
+		run("cmd", cmdStr, func(ctx context.Context) (string, error) {
+			result, err := sandbox.Commands.Run(ctx, cmdStr, &command.ProcessConfig{User: user}, nil)
+			if err != nil {
+				return "", err
+			}
+			if result.ExitCode != 0 {
+				return "", fmt.Errorf("exit code %d: %s", result.ExitCode, lastLine(string(result.Stderr)))
+			}
+			return lastLine(string(result.Stdout)), nil
+		})
+	}
+
+	for _, snippet := range toolTestCode {
+		run("code", snippet, func(ctx context.Context) (string, error) {
+			result, err := sandbox.Code.RunCode(ctx, snippet, &toolcode.RunCodeConfig{Language: toolTestLanguage}, nil)
+			if err != nil {
+				return "", err
+			}
+			if result.Error != nil {
+				return "", fmt.Errorf("%s: %s", result.Error.Name, result.Error.Value)
+			}
+			return lastLine(strings.Join(result.Logs.Stdout, "")), nil
+		})
+	}
+
+	for _, h := range httpChecks {
+		run("http", fmt.Sprintf("%d%s", h.port, h.path), func(ctx context.Context) (string, error) {
+			return probeHTTP(ctx, instanceID, accessToken, h)
+		})
+	}
+
+	return checks
+}
+
+// probeHTTP sends GET requests to a sandbox port through a local proxy until
+// the service answers with a status below 400, or the context expires.
+// Connection and gateway errors are retried since the service may still be
+// starting.
+func probeHTTP(ctx context.Context, instanceID, accessToken string, h httpCheck) (string, error) {
+	p, err := proxy.New(proxy.Options{
+		InstanceID:     instanceID,
+		Domain:         config.Get().DataPlaneRegionDomain(),
+		RemotePort:     h.port,
+		Token:          accessToken,
+		Logger:         log.New(io.Discard, "", 0),
+		DialTLSContext: dataPlaneDialTLS(),
+	})
+	if err != nil {
+		return "", err
+	}
+	addr, err := p.Start()
+	if err != nil {
+		return "", err
+	}
+	defer p.Stop()
+
+	httpClient := &http.Client{}
+	url := "http://" + addr + h.path
+	var lastErr error
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := httpClient.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+			switch {
+			case resp.StatusCode < 400:
+				return resp.Status, nil
+			case resp.StatusCode < 500:
+				return "", fmt.Errorf("HTTP %s", resp.Status)
+			}
+			lastErr = fmt.Errorf("HTTP %s", resp.Status)
+		} else {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", lastErr
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// parseHTTPCheck parses an --http value in the format <port>[/path].
+func parseHTTPCheck(s string) (httpCheck, error) {
+	portStr, path, _ := strings.Cut(s, "/")
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return httpCheck{}, fmt.Errorf("invalid port in %q (expected <port>[/path])", s)
+	}
+	return httpCheck{port: port, path: "/" + path}, nil
+}
+
+// lastLine returns the last non-empty line of s, used as a short check detail.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// formatCheckDuration rounds d for the report.
+func formatCheckDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
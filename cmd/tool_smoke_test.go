@@ -0,0 +1,54 @@
+package cmd
+
+import "testing"
+
+func TestParseHTTPCheck(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    httpCheck
+		wantErr bool
+	}{
+		{value: "8080", want: httpCheck{port: 8080, path: "/"}},
+		{value: "8080/", want: httpCheck{port: 8080, path: "/"}},
+		{value: "8080/healthz", want: httpCheck{port: 8080, path: "/healthz"}},
+		{value: "3000/api/v1/status?full=1", want: httpCheck{port: 3000, path: "/api/v1/status?full=1"}},
+		{value: "", wantErr: true},
+		{value: "/healthz", wantErr: true},
+		{value: "http/healthz", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "70000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseHTTPCheck(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseHTTPCheck(%q) expected error", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHTTPCheck(%q) unexpected error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseHTTPCheck(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastLine(t *testing.T) {
+	tests := map[string]string{
+		"":                      "",
+		"ok\n":                  "ok",
+		"Python 3.11.4":         "Python 3.11.4",
+		"line1\nline2\n\n":      "line2",
+		"Traceback\n  boom  \n": "boom",
+	}
+	for in, want := range tests {
+		if got := lastLine(in); got != want {
+			t.Errorf("lastLine(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,558 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/sandbox/code"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/command"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/tool/filesystem"
+	"github.com/spf13/cobra"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/manifest"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/output"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/tunnelstore"
+)
+
+var (
+	// up/down command flags
+	upFile       string
+	upTime       bool
+	downFile     string
+	downKeepInst bool
+)
+
+// forwardReadyTimeout bounds how long 'ags up' waits for a port forward to listen.
+const forwardReadyTimeout = 30 * time.Second
+
+func init() {
+	addUpCommand(rootCmd)
+}
+
+// addUpCommand adds the up and down commands to a parent command.
+func addUpCommand(parent *cobra.Command) {
+	upCmd := &cobra.Command{
+		Use:   "up",
+		Short: "Create and provision a sandbox from a manifest",
+		Long: `Create a sandbox environment described by a manifest file (default: sandbox.yaml).
+
+'ags up' creates the instance, uploads files, runs setup commands, starts
+background processes and spawns local port forwards. The resulting state is
+recorded in ~/.ags/envs/<profile>@<region>/<name>.json so that 'ags down' can
+tear everything down with the same backend, profile and region.
+
+If provisioning fails after the instance was created, the instance is kept for
+inspection; run 'ags down' to clean it up.
+
+Manifest example:
+  name: my-app
+  tool: code-interpreter-v1      # or toolId: sdt-xxxx
+  timeout: 3600
+  authMode: TOKEN
+  workdir: /home/user/app
+  env:
+    APP_ENV: dev
+  mounts:
+    - name: data
+      mountPath: /data
+      subPath: user-123
+      readOnly: true
+  uploads:
+    - src: ./src                 # relative to the manifest directory
+      dst: /home/user/app
+  setup:
+    - pip install -r requirements.txt
+  processes:
+    - name: web
+      command: python -m http.server 8080
+  forwards:
+    - "3000:8080"                # [local_port:]<remote_port>
+
+Examples:
+  ags up
+  ags up -f envs/dev.yaml`,
+		Args: cobra.NoArgs,
+		RunE: runUp,
+	}
+	upCmd.Flags().StringVarP(&upFile, "file", "f", manifest.DefaultFile, "Manifest file")
+	upCmd.Flags().BoolVar(&upTime, "time", false, "Print elapsed time to stderr")
+
+	downCmd := &cobra.Command{
+		Use:   "down [name]",
+		Short: "Tear down a sandbox environment created by 'ags up'",
+		Long: `Tear down a sandbox environment created by 'ags up'.
+
+Stops the local port forwards, deletes the instance and removes the recorded
+state. The environment is selected by name, or by the manifest given with -f
+(default: sandbox.yaml).
+
+Examples:
+  ags down
+  ags down -f envs/dev.yaml
+  ags down my-app
+  ags down my-app --keep-instance`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runDown,
+	}
+	downCmd.Flags().StringVarP(&downFile, "file", "f", manifest.DefaultFile, "Manifest file")
+	downCmd.Flags().BoolVar(&downKeepInst, "keep-instance", false, "Only stop local port forwards, keep the instance running")
+
+	parent.AddCommand(upCmd, downCmd)
+}
+
+func runUp(_ *cobra.Command, _ []string) error {
+	ctx := context.Background()
+	start := time.Now()
+
+	m, err := manifest.Load(upFile)
+	if err != nil {
+		return err
+	}
+
+	type forwardSpec struct{ local, remote int }
+	var forwards []forwardSpec
+	for _, spec := range m.Forwards {
+		local, remote, err := parsePortSpec(spec)
+		if err != nil {
+			return fmt.Errorf("invalid forward %q: %w", spec, err)
+		}
+		forwards = append(forwards, forwardSpec{local, remote})
+	}
+
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	store, err := manifest.NewScopedStateStore(config.StoreScope())
+	if err != nil {
+		return err
+	}
+	if state, ok, err := store.Get(m.Name); err != nil {
+		return err
+	} else if ok {
+		return fmt.Errorf("environment %q is already up (instance %s); run 'ags down' first", m.Name, state.InstanceID)
+	}
+
+	apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+	if err != nil {
+		return fmt.Errorf("failed to create API client: %w", err)
+	}
+
+	f := output.NewFormatter()
+	progress := func(msg string) {
+		if !f.IsJSON() {
+			output.PrintInfo(msg)
+		}
+	}
+
+	instance, err := apiClient.CreateInstance(ctx, m.CreateOptions())
+	if err != nil {
+		return fmt.Errorf("failed to create instance: %w", err)
+	}
+	if err := cacheInstanceToken(ctx, apiClient, instance); err != nil {
+		output.PrintWarning(fmt.Sprintf("Failed to cache access token: %v", err))
+	}
+	progress(fmt.Sprintf("Instance created: %s", instance.ID))
+
+	state := &manifest.State{
+		Name:         m.Name,
+		ManifestPath: m.Path,
+		InstanceID:   instance.ID,
+		Backend:      config.GetBackend(),
+		Profile:      config.GetProfile(),
+		Region:       config.GetRegion(),
+		CreatedAt:    time.Now(),
+	}
+	if err := store.Save(state); err != nil {
+		return fmt.Errorf("failed to save environment state: %w", err)
+	}
+
+	// From here on the instance exists; failures leave it for 'ags down'.
+	fail := func(err error) error {
+		return fmt.Errorf("%w (instance %s kept; run 'ags down %s' to clean up)", err, instance.ID, m.Name)
+	}
+
+	if len(m.Uploads) > 0 || len(m.Setup) > 0 || len(m.Processes) > 0 {
+		sandbox, err := ConnectSandboxWithCache(ctx, instance.ID)
+		if err != nil {
+			return fail(fmt.Errorf("failed to connect to instance: %w", err))
+		}
+
+		for _, upload := range m.Uploads {
+			n, err := uploadPath(ctx, sandbox, m.SourcePath(upload.Src), upload.Dst, resolveUser(m.User))
+			if err != nil {
+				return fail(err)
+			}
+			progress(fmt.Sprintf("Uploaded %s -> %s (%d file(s))", upload.Src, upload.Dst, n))
+		}
+
+		for _, cmdStr := range m.Setup {
+			progress(fmt.Sprintf("Running: %s", cmdStr))
+			if err := runSetupCommand(ctx, sandbox, m, cmdStr, !f.IsJSON()); err != nil {
+				return fail(err)
+			}
+		}
+
+		for _, p := range m.Processes {
+			pid, err := startBackgroundProcess(ctx, sandbox, m, p)
+			if err != nil {
+				return fail(fmt.Errorf("failed to start process %q: %w", p.Name, err))
+			}
+			state.Processes = append(state.Processes, manifest.ProcessState{Name: p.Name, PID: pid})
+			progress(fmt.Sprintf("Started process %s (PID %d)", p.Name, pid))
+		}
+		if err := store.Save(state); err != nil {
+			return fail(fmt.Errorf("failed to save environment state: %w", err))
+		}
+	}
+
+	for _, fw := range forwards {
+		fwState, err := startForward(ctx, store.Dir(), m.Name, instance.ID, fw.local, fw.remote)
+		if err != nil {
+			return fail(err)
+		}
+		state.Forwards = append(state.Forwards, *fwState)
+		if err := store.Save(state); err != nil {
+			return fail(fmt.Errorf("failed to save environment state: %w", err))
+		}
+		progress(fmt.Sprintf("Forwarding 127.0.0.1:%d -> %d", fw.local, fw.remote))
+	}
+
+	var timing *output.Timing
+	if upTime {
+		timing = output.NewTiming(time.Since(start))
+	}
+
+	if f.IsJSON() {
+		data := map[string]any{
+			"status":     "success",
+			"message":    fmt.Sprintf("Environment %s is up", m.Name),
+			"name":       m.Name,
+			"instanceId": instance.ID,
+			"processes":  state.Processes,
+			"forwards":   state.Forwards,
+		}
+		if timing != nil {
+			data["timing"] = timing
+		}
+		return f.PrintJSON(data)
+	}
+
+	output.PrintSuccess(fmt.Sprintf("Environment %s is up", m.Name))
+	result := []output.KeyValue{
+		{Key: "Name", Value: m.Name},
+		{Key: "Instance", Value: instance.ID},
+		{Key: "Tool", Value: valueOrDefault(instance.ToolName, instance.ToolID)},
+		{Key: "Processes", Value: formatProcessList(state.Processes)},
+	}
+	for _, fw := range state.Forwards {
+		result = append(result, output.KeyValue{
+			Key:   fmt.Sprintf("Forward %d", fw.RemotePort),
+			Value: fmt.Sprintf("http://127.0.0.1:%d", fw.LocalPort),
+		})
+	}
+	if err := f.PrintKeyValue(result); err != nil {
+		return err
+	}
+	if upTime {
+		f.PrintTiming(timing)
+	}
+	return nil
+}
+
+func runDown(_ *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	var name string
+	if len(args) == 1 {
+		name = args[0]
+	} else {
+		m, err := manifest.Load(downFile)
+		if err != nil {
+			return err
+		}
+		name = m.Name
+	}
+
+	store, err := manifest.NewScopedStateStore(config.StoreScope())
+	if err != nil {
+		return err
+	}
+	state, ok, err := store.Get(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("environment %q is not up in profile %s, region %s", name, config.GetProfile(), config.GetRegion())
+	}
+	if err := checkStateScope(state); err != nil {
+		return err
+	}
+
+	f := output.NewFormatter()
+
+	var remaining []manifest.ForwardState
+	for _, fw := range state.Forwards {
+		if !tunnelstore.StopProcess(fw.PID, fw.ExePath) {
+			output.PrintWarning(fmt.Sprintf("Port forward %d -> %d (PID %d) could not be terminated", fw.LocalPort, fw.RemotePort, fw.PID))
+			remaining = append(remaining, fw)
+		}
+	}
+	state.Forwards = remaining
+
+	if downKeepInst {
+		if err := store.Save(state); err != nil {
+			return err
+		}
+		if f.IsJSON() {
+			return f.PrintJSON(map[string]any{
+				"status":     "success",
+				"message":    fmt.Sprintf("Port forwards of %s stopped", name),
+				"name":       name,
+				"instanceId": state.InstanceID,
+			})
+		}
+		output.PrintSuccess(fmt.Sprintf("Port forwards of %s stopped; instance %s kept", name, state.InstanceID))
+		return nil
+	}
+
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	apiClient, err := client.NewControlPlaneClient(config.GetBackend())
+	if err != nil {
+		return fmt.Errorf("failed to create API client: %w", err)
+	}
+	// An instance that already expired or was deleted elsewhere is fine here
+	if err := deleteInstance(ctx, apiClient, state.InstanceID); err != nil && !errors.Is(err, client.ErrNotFound) {
+		_ = store.Save(state)
+		return fmt.Errorf("failed to delete instance %s: %w", state.InstanceID, err)
+	}
+
+	if len(remaining) > 0 {
+		// Keep the state so the surviving forwards remain discoverable.
+		if err := store.Save(state); err != nil {
+			return err
+		}
+		return fmt.Errorf("instance %s deleted but %d port forward(s) could not be stopped", state.InstanceID, len(remaining))
+	}
+	if err := store.Remove(name); err != nil {
+		return err
+	}
+
+	if f.IsJSON() {
+		return f.PrintJSON(map[string]any{
+			"status":     "success",
+			"message":    fmt.Sprintf("Environment %s is down", name),
+			"name":       name,
+			"instanceId": state.InstanceID,
+		})
+	}
+	output.PrintSuccess(fmt.Sprintf("Environment %s is down (instance %s deleted)", name, state.InstanceID))
+	return nil
+}
+
+// checkStateScope refuses to tear down an environment with another backend,
+// profile or region than it was brought up with, which would delete in the
+// wrong account or orphan the instance. State written by older versions
+// records only the backend.
+func checkStateScope(state *manifest.State) error {
+	checks := []struct{ name, recorded, active, flag string }{
+		{"backend", state.Backend, config.GetBackend(), "--backend"},
+		{"profile", state.Profile, config.GetProfile(), "--profile"},
+		{"region", state.Region, config.GetRegion(), "--region"},
+	}
+	for _, c := range checks {
+		if c.recorded != "" && c.recorded != c.active {
+			return fmt.Errorf("environment %q was brought up with %s %s, not %s; run 'ags %s %s down %s'",
+				state.Name, c.name, c.recorded, c.active, c.flag, c.recorded, state.Name)
+		}
+	}
+	return nil
+}
+
+// uploadPath copies a local file or directory tree to dst in the sandbox and
+// returns the number of files written.
+func uploadPath(ctx context.Context, sandbox *code.Sandbox, src, dst, user string) (int, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to access upload source: %w", err)
+	}
+
+	writeFile := func(local, remote string) error {
+		file, err := os.Open(local)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", local, err)
+		}
+		defer file.Close()
+		if _, err := sandbox.Files.Write(ctx, remote, file, &filesystem.WriteConfig{User: user}); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", local, err)
+		}
+		return nil
+	}
+
+	if !info.IsDir() {
+		return 1, writeFile(src, dst)
+	}
+
+	var count int
+	err = filepath.WalkDir(src, func(local string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, local)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(local, path.Join(dst, filepath.ToSlash(rel))); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// processConfig builds the sandbox process config for a manifest command.
+// Per-command env and cwd override the manifest-wide values.
+func processConfig(m *manifest.Manifest, cwd string, env map[string]string) *command.ProcessConfig {
+	envs := make(map[string]string, len(m.Env)+len(env))
+	for k, v := range m.Env {
+		envs[k] = v
+	}
+	for k, v := range env {
+		envs[k] = v
+	}
+
+	cfg := &command.ProcessConfig{
+		User: resolveUser(m.User),
+		Envs: envs,
+	}
+	if cwd == "" {
+		cwd = m.Workdir
+	}
+	if cwd != "" {
+		cfg.Cwd = &cwd
+	}
+	return cfg
+}
+
+// runSetupCommand runs a setup command to completion, streaming its output to
+// stderr when stream is set, and fails on a non-zero exit code.
+func runSetupCommand(ctx context.Context, sandbox *code.Sandbox, m *manifest.Manifest, cmdStr string, stream bool) error {
+	var callbacks *command.OnOutputConfig
+	if stream {
+		callbacks = &command.OnOutputConfig{
+			OnStdout: func(data []byte) { _, _ = os.Stderr.Write(data) },
+			OnStderr: func(data []byte) { _, _ = os.Stderr.Write(data) },
+		}
+	}
+
+	result, err := sandbox.Commands.Run(ctx, cmdStr, processConfig(m, "", nil), callbacks)
+	if err != nil {
+		return fmt.Errorf("setup command %q failed: %w", cmdStr, err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("setup command %q failed with exit code %d", cmdStr, result.ExitCode)
+	}
+	return nil
+}
+
+// startBackgroundProcess starts a long-running process in the sandbox and
+// detaches from it, returning its PID.
+func startBackgroundProcess(ctx context.Context, sandbox *code.Sandbox, m *manifest.Manifest, p manifest.Process) (uint32, error) {
+	handle, err := sandbox.Commands.Start(ctx, p.Command, processConfig(m, p.Cwd, p.Env), nil)
+	if err != nil {
+		return 0, err
+	}
+	pid := handle.Pid
+	if err := handle.Disconnect(ctx); err != nil {
+		return 0, err
+	}
+	return pid, nil
+}
+
+// startForward spawns a background 'ags proxy' process for one port and waits
+// until it accepts connections. Its output is written to a log file next to
+// the environment state.
+func startForward(ctx context.Context, logDir, name, instanceID string, localPort, remotePort int) (*manifest.ForwardState, error) {
+	cmd, selfPath, err := newSelfCommand("proxy", instanceID, fmt.Sprintf("%d:%d", localPort, remotePort))
+	if err != nil {
+		return nil, err
+	}
+
+	logPath := filepath.Join(logDir, fmt.Sprintf("%s-forward-%d.log", name, localPort))
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create forward log: %w", err)
+	}
+	defer logFile.Close()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start port forward: %w", err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort))
+	deadline := time.Now().Add(forwardReadyTimeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			break
+		}
+		select {
+		case <-exited:
+			return nil, fmt.Errorf("port forward %d -> %d exited, see %s", localPort, remotePort, logPath)
+		case <-ctx.Done():
+			_ = cmd.Process.Kill()
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			_ = cmd.Process.Kill()
+			return nil, fmt.Errorf("port forward %d -> %d did not become ready within %s", localPort, remotePort, forwardReadyTimeout)
+		}
+	}
+
+	return &manifest.ForwardState{
+		LocalPort:  localPort,
+		RemotePort: remotePort,
+		PID:        cmd.Process.Pid,
+		ExePath:    selfPath,
+		LogPath:    logPath,
+	}, nil
+}
+
+// formatProcessList renders background processes as "name(pid)" pairs.
+func formatProcessList(processes []manifest.ProcessState) string {
+	if len(processes) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(processes))
+	for i, p := range processes {
+		parts[i] = fmt.Sprintf("%s(%d)", p.Name, p.PID)
+	}
+	return strings.Join(parts, ", ")
+}
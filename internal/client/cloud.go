@@ -19,23 +19,29 @@ type CloudControlPlane struct {
 
 // NewCloudControlPlane creates a new Cloud control plane client
 func NewCloudControlPlane() (*CloudControlPlane, error) {
-	cfg := config.Get()
-	cloudCfg := config.GetCloudConfig()
+	return newCloudControlPlane(config.Get())
+}
+
+func newCloudControlPlane(cfg *config.Config) (*CloudControlPlane, error) {
+	credential, _, err := config.CloudCredential()
+	if err != nil {
+		return nil, err
+	}
 
 	// Create tool client (tencentcloud-sdk-go)
-	toolClient, err := NewCloudToolClient(cfg, &cloudCfg)
+	toolClient, err := NewCloudToolClient(cfg, credential)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create instance client (tencentcloud-sdk-go)
-	instanceClient, err := NewCloudInstanceClient(cfg, &cloudCfg)
+	instanceClient, err := NewCloudInstanceClient(cfg, credential)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create API key client (tencentcloud-sdk-go)
-	apikeyClient, err := NewCloudAPIKeyClient(cfg, &cloudCfg)
+	apikeyClient, err := NewCloudAPIKeyClient(cfg, credential)
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +102,21 @@ func (c *CloudControlPlane) DeleteInstance(ctx context.Context, id string) error
 	return c.instance.DeleteInstance(ctx, id)
 }
 
+// PauseInstance pauses a sandbox instance
+func (c *CloudControlPlane) PauseInstance(ctx context.Context, id string) error {
+	return c.instance.PauseInstance(ctx, id)
+}
+
+// ResumeInstance resumes a paused sandbox instance
+func (c *CloudControlPlane) ResumeInstance(ctx context.Context, id string, timeout int) (*Instance, error) {
+	return c.instance.ResumeInstance(ctx, id, timeout)
+}
+
+// GetInstanceMetrics returns the latest resource usage sample of an instance
+func (c *CloudControlPlane) GetInstanceMetrics(ctx context.Context, id string) (*InstanceMetrics, error) {
+	return c.instance.GetInstanceMetrics(ctx, id)
+}
+
 // AcquireToken acquires an access token for data plane operations
 func (c *CloudControlPlane) AcquireToken(ctx context.Context, instanceID string) (string, error) {
 	return c.instance.AcquireToken(ctx, instanceID)
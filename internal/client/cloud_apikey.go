@@ -13,12 +13,11 @@ import (
 // CloudAPIKeyClient handles API Key operations using tencentcloud-sdk-go
 type CloudAPIKeyClient struct {
 	client *ags.Client
+	retry  RetryPolicy
 }
 
 // NewCloudAPIKeyClient creates a new Cloud API Key client
-func NewCloudAPIKeyClient(cfg *config.Config, cloudCfg *config.CloudConfig) (*CloudAPIKeyClient, error) {
-	credential := common.NewCredential(cloudCfg.SecretID, cloudCfg.SecretKey)
-
+func NewCloudAPIKeyClient(cfg *config.Config, credential common.CredentialIface) (*CloudAPIKeyClient, error) {
 	cpf := profile.NewClientProfile()
 	cpf.HttpProfile.Endpoint = cfg.ControlPlaneEndpoint()
 
@@ -29,6 +28,7 @@ func NewCloudAPIKeyClient(cfg *config.Config, cloudCfg *config.CloudConfig) (*Cl
 
 	return &CloudAPIKeyClient{
 		client: client,
+		retry:  defaultRetryPolicy(),
 	}, nil
 }
 
@@ -37,9 +37,14 @@ func (c *CloudAPIKeyClient) CreateAPIKey(ctx context.Context, name string) (*Cre
 	request := ags.NewCreateAPIKeyRequest()
 	request.Name = &name
 
-	response, err := c.client.CreateAPIKeyWithContext(ctx, request)
+	// Not safe to repeat: a retried request could create a second key
+	var response *ags.CreateAPIKeyResponse
+	err := c.retry.cloudCall(ctx, "CreateAPIKey", false, func() (err error) {
+		response, err = c.client.CreateAPIKeyWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create API key: %w", err)
+		return nil, wrapCloudError("failed to create API key", err)
 	}
 
 	return &CreateAPIKeyResult{
@@ -53,9 +58,13 @@ func (c *CloudAPIKeyClient) CreateAPIKey(ctx context.Context, name string) (*Cre
 func (c *CloudAPIKeyClient) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
 	request := ags.NewDescribeAPIKeyListRequest()
 
-	response, err := c.client.DescribeAPIKeyListWithContext(ctx, request)
+	var response *ags.DescribeAPIKeyListResponse
+	err := c.retry.cloudCall(ctx, "DescribeAPIKeyList", true, func() (err error) {
+		response, err = c.client.DescribeAPIKeyListWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list API keys: %w", err)
+		return nil, wrapCloudError("failed to list API keys", err)
 	}
 
 	keys := make([]APIKey, 0, len(response.Response.APIKeySet))
@@ -77,9 +86,12 @@ func (c *CloudAPIKeyClient) DeleteAPIKey(ctx context.Context, keyID string) erro
 	request := ags.NewDeleteAPIKeyRequest()
 	request.KeyId = &keyID
 
-	_, err := c.client.DeleteAPIKeyWithContext(ctx, request)
+	err := c.retry.cloudCall(ctx, "DeleteAPIKey", true, func() error {
+		_, err := c.client.DeleteAPIKeyWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete API key: %w", err)
+		return wrapCloudError("failed to delete API key", err)
 	}
 
 	return nil
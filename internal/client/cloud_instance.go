@@ -18,11 +18,11 @@ type CloudInstanceClient struct {
 	client          *ags.Client
 	region          string
 	dataPlaneDomain string
+	retry           RetryPolicy
 }
 
 // NewCloudInstanceClient creates a new Cloud Instance client
-func NewCloudInstanceClient(cfg *config.Config, cloudCfg *config.CloudConfig) (*CloudInstanceClient, error) {
-	credential := common.NewCredential(cloudCfg.SecretID, cloudCfg.SecretKey)
+func NewCloudInstanceClient(cfg *config.Config, credential common.CredentialIface) (*CloudInstanceClient, error) {
 	cpf := profile.NewClientProfile()
 	cpf.HttpProfile.Endpoint = cfg.ControlPlaneEndpoint()
 
@@ -35,6 +35,7 @@ func NewCloudInstanceClient(cfg *config.Config, cloudCfg *config.CloudConfig) (*
 		client:          client,
 		region:          cfg.Region,
 		dataPlaneDomain: cfg.DataPlaneDomain(),
+		retry:           defaultRetryPolicy(),
 	}, nil
 }
 
@@ -74,9 +75,17 @@ func (c *CloudInstanceClient) CreateInstance(ctx context.Context, opts *CreateIn
 		request.AuthMode = &authMode
 	}
 
-	response, err := c.client.StartSandboxInstanceWithContext(ctx, request)
+	// The client token makes retries return the instance started by the first attempt
+	clientToken := newClientToken()
+	request.ClientToken = &clientToken
+
+	var response *ags.StartSandboxInstanceResponse
+	err := c.retry.cloudCall(ctx, "StartSandboxInstance", true, func() (err error) {
+		response, err = c.client.StartSandboxInstanceWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create instance: %w", err)
+		return nil, wrapCloudError("failed to create instance", err)
 	}
 
 	inst := response.Response.Instance
@@ -214,9 +223,13 @@ func (c *CloudInstanceClient) ListInstances(ctx context.Context, opts *ListInsta
 		}
 	}
 
-	response, err := c.client.DescribeSandboxInstanceListWithContext(ctx, request)
+	var response *ags.DescribeSandboxInstanceListResponse
+	err := c.retry.cloudCall(ctx, "DescribeSandboxInstanceList", true, func() (err error) {
+		response, err = c.client.DescribeSandboxInstanceListWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list instances: %w", err)
+		return nil, wrapCloudError("failed to list instances", err)
 	}
 
 	result := &ListInstancesResult{
@@ -268,11 +281,11 @@ func (c *CloudInstanceClient) GetInstance(ctx context.Context, id string) (*Inst
 		InstanceIDs: []string{id},
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get instance: %w", err)
+		return nil, wrapCloudError("failed to get instance", err)
 	}
 
 	if len(result.Instances) == 0 {
-		return nil, fmt.Errorf("instance not found: %s", id)
+		return nil, newError(KindNotFound, "instance not found: %s", id)
 	}
 
 	inst := result.Instances[0]
@@ -284,21 +297,44 @@ func (c *CloudInstanceClient) DeleteInstance(ctx context.Context, id string) err
 	request := ags.NewStopSandboxInstanceRequest()
 	request.InstanceId = &id
 
-	_, err := c.client.StopSandboxInstanceWithContext(ctx, request)
+	err := c.retry.cloudCall(ctx, "StopSandboxInstance", true, func() error {
+		_, err := c.client.StopSandboxInstanceWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete instance: %w", err)
+		return wrapCloudError("failed to delete instance", err)
 	}
 	return nil
 }
 
+// PauseInstance is not supported by the cloud API
+func (c *CloudInstanceClient) PauseInstance(ctx context.Context, id string) error {
+	return unsupportedError("instance pause is not supported by cloud backend, please use e2b backend")
+}
+
+// ResumeInstance is not supported by the cloud API
+func (c *CloudInstanceClient) ResumeInstance(ctx context.Context, id string, timeout int) (*Instance, error) {
+	return nil, unsupportedError("instance resume is not supported by cloud backend, please use e2b backend")
+}
+
+// GetInstanceMetrics is not available from the cloud API; callers fall back
+// to sampling inside the sandbox.
+func (c *CloudInstanceClient) GetInstanceMetrics(ctx context.Context, id string) (*InstanceMetrics, error) {
+	return nil, fmt.Errorf("cloud backend: %w", ErrMetricsUnavailable)
+}
+
 // AcquireToken acquires an access token for data plane operations.
 // The token is used to authenticate with the E2B data plane gateway.
 func (c *CloudInstanceClient) AcquireToken(ctx context.Context, instanceID string) (string, error) {
-	tokenResp, err := c.client.AcquireSandboxInstanceTokenWithContext(ctx, &ags.AcquireSandboxInstanceTokenRequest{
-		InstanceId: &instanceID,
+	var tokenResp *ags.AcquireSandboxInstanceTokenResponse
+	err := c.retry.cloudCall(ctx, "AcquireSandboxInstanceToken", true, func() (err error) {
+		tokenResp, err = c.client.AcquireSandboxInstanceTokenWithContext(ctx, &ags.AcquireSandboxInstanceTokenRequest{
+			InstanceId: &instanceID,
+		})
+		return err
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to acquire token: %w", err)
+		return "", wrapCloudError("failed to acquire token", err)
 	}
 	if tokenResp.Response == nil || tokenResp.Response.Token == nil {
 		return "", fmt.Errorf("no token returned from API")
@@ -14,12 +14,11 @@ import (
 type CloudToolClient struct {
 	client *ags.Client
 	region string
+	retry  RetryPolicy
 }
 
 // NewCloudToolClient creates a new Cloud Tool client
-func NewCloudToolClient(cfg *config.Config, cloudCfg *config.CloudConfig) (*CloudToolClient, error) {
-	credential := common.NewCredential(cloudCfg.SecretID, cloudCfg.SecretKey)
-
+func NewCloudToolClient(cfg *config.Config, credential common.CredentialIface) (*CloudToolClient, error) {
 	cpf := profile.NewClientProfile()
 	cpf.HttpProfile.Endpoint = cfg.ControlPlaneEndpoint()
 
@@ -31,6 +30,7 @@ func NewCloudToolClient(cfg *config.Config, cloudCfg *config.CloudConfig) (*Clou
 	return &CloudToolClient{
 		client: client,
 		region: cfg.Region,
+		retry:  defaultRetryPolicy(),
 	}, nil
 }
 
@@ -115,9 +115,13 @@ func (c *CloudToolClient) ListTools(ctx context.Context, opts *ListToolsOptions)
 		}
 	}
 
-	response, err := c.client.DescribeSandboxToolListWithContext(ctx, request)
+	var response *ags.DescribeSandboxToolListResponse
+	err := c.retry.cloudCall(ctx, "DescribeSandboxToolList", true, func() (err error) {
+		response, err = c.client.DescribeSandboxToolListWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tools: %w", err)
+		return nil, wrapCloudError("failed to list tools", err)
 	}
 
 	tools := make([]Tool, 0, len(response.Response.SandboxToolSet))
@@ -147,16 +151,18 @@ func (c *CloudToolClient) ListTools(ctx context.Context, opts *ListToolsOptions)
 		}
 
 		tools = append(tools, Tool{
-			ID:            derefString(t.ToolId),
-			Name:          derefString(t.ToolName),
-			Description:   derefString(t.Description),
-			Type:          derefString(t.ToolType),
-			NetworkMode:   networkMode,
-			VPCConfig:     vpcConfig,
-			Tags:          tags,
-			RoleArn:       derefString(t.RoleArn),
-			StorageMounts: storageMounts,
-			CreatedAt:     derefString(t.CreateTime),
+			ID:             derefString(t.ToolId),
+			Name:           derefString(t.ToolName),
+			Description:    derefString(t.Description),
+			Type:           derefString(t.ToolType),
+			DefaultTimeout: derefString(t.DefaultTimeout),
+			NetworkMode:    networkMode,
+			VPCConfig:      vpcConfig,
+			Tags:           tags,
+			RoleArn:        derefString(t.RoleArn),
+			StorageMounts:  storageMounts,
+			CustomConfig:   parseAPICustomConfiguration(t.CustomConfiguration),
+			CreatedAt:      derefString(t.CreateTime),
 		})
 	}
 
@@ -183,7 +189,7 @@ func (c *CloudToolClient) GetTool(ctx context.Context, id string) (*Tool, error)
 		return nil, err
 	}
 	if len(result.Tools) == 0 {
-		return nil, fmt.Errorf("tool not found: %s", id)
+		return nil, newError(KindNotFound, "tool not found: %s", id)
 	}
 	return &result.Tools[0], nil
 }
@@ -237,20 +243,35 @@ func (c *CloudToolClient) CreateTool(ctx context.Context, opts *CreateToolOption
 		request.StorageMounts = toAPIStorageMounts(opts.StorageMounts)
 	}
 
-	response, err := c.client.CreateSandboxToolWithContext(ctx, request)
+	// Set container configuration for custom tools
+	if opts.CustomConfig != nil {
+		request.CustomConfiguration = toAPICustomConfiguration(opts.CustomConfig)
+	}
+
+	// The client token makes retries return the tool created by the first attempt
+	clientToken := newClientToken()
+	request.ClientToken = &clientToken
+
+	var response *ags.CreateSandboxToolResponse
+	err := c.retry.cloudCall(ctx, "CreateSandboxTool", true, func() (err error) {
+		response, err = c.client.CreateSandboxToolWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create tool: %w", err)
+		return nil, wrapCloudError("failed to create tool", err)
 	}
 
 	return &Tool{
-		ID:            derefString(response.Response.ToolId),
-		Name:          opts.Name,
-		Description:   opts.Description,
-		Type:          opts.Type,
-		NetworkMode:   networkMode,
-		VPCConfig:     opts.VPCConfig,
-		RoleArn:       opts.RoleArn,
-		StorageMounts: opts.StorageMounts,
+		ID:             derefString(response.Response.ToolId),
+		Name:           opts.Name,
+		Description:    opts.Description,
+		Type:           opts.Type,
+		DefaultTimeout: opts.DefaultTimeout,
+		NetworkMode:    networkMode,
+		VPCConfig:      opts.VPCConfig,
+		RoleArn:        opts.RoleArn,
+		StorageMounts:  opts.StorageMounts,
+		CustomConfig:   opts.CustomConfig,
 	}, nil
 }
 
@@ -279,6 +300,15 @@ func toAPIStorageMounts(mounts []StorageMount) []*ags.StorageMount {
 				apiMount.StorageSource.Cos.Endpoint = &m.StorageSource.Cos.Endpoint
 			}
 		}
+		if m.StorageSource != nil && m.StorageSource.Cfs != nil {
+			apiMount.StorageSource = &ags.StorageSource{
+				Cfs: &ags.CfsStorageSource{
+					FileSystemId: &m.StorageSource.Cfs.FileSystemID,
+					MountTarget:  &m.StorageSource.Cfs.MountTarget,
+					Path:         &m.StorageSource.Cfs.Path,
+				},
+			}
+		}
 
 		result[i] = apiMount
 	}
@@ -363,6 +393,15 @@ func parseAPIStorageMounts(apiMounts []*ags.StorageMount) []StorageMount {
 				},
 			}
 		}
+		if m.StorageSource != nil && m.StorageSource.Cfs != nil {
+			mount.StorageSource = &StorageSource{
+				Cfs: &CfsStorageSource{
+					FileSystemID: derefString(m.StorageSource.Cfs.FileSystemId),
+					MountTarget:  derefString(m.StorageSource.Cfs.MountTarget),
+					Path:         derefString(m.StorageSource.Cfs.Path),
+				},
+			}
+		}
 
 		result[i] = mount
 	}
@@ -370,6 +409,152 @@ func parseAPIStorageMounts(apiMounts []*ags.StorageMount) []StorageMount {
 	return result
 }
 
+// toAPICustomConfiguration converts client CustomConfig to API format
+func toAPICustomConfiguration(c *CustomConfig) *ags.CustomConfiguration {
+	result := &ags.CustomConfiguration{
+		Image:   strPtr(c.Image),
+		Command: toStringPtrs(c.Command),
+		Args:    toStringPtrs(c.Args),
+	}
+	if c.RegistryType != "" {
+		result.ImageRegistryType = strPtr(c.RegistryType)
+	}
+
+	for _, e := range c.Env {
+		result.Env = append(result.Env, &ags.EnvVar{Name: strPtr(e.Name), Value: strPtr(e.Value)})
+	}
+
+	for _, p := range c.Ports {
+		port := &ags.PortConfiguration{Port: int64Ptr(p.Port)}
+		if p.Name != "" {
+			port.Name = strPtr(p.Name)
+		}
+		if p.Protocol != "" {
+			port.Protocol = strPtr(p.Protocol)
+		}
+		result.Ports = append(result.Ports, port)
+	}
+
+	if c.Resources != nil {
+		result.Resources = &ags.ResourceConfiguration{}
+		if c.Resources.CPU != "" {
+			result.Resources.CPU = strPtr(c.Resources.CPU)
+		}
+		if c.Resources.Memory != "" {
+			result.Resources.Memory = strPtr(c.Resources.Memory)
+		}
+	}
+
+	if c.Probe != nil {
+		action := &ags.HttpGetAction{
+			Path: strPtr(c.Probe.Path),
+			Port: int64Ptr(c.Probe.Port),
+		}
+		if c.Probe.Scheme != "" {
+			action.Scheme = strPtr(c.Probe.Scheme)
+		}
+		// Zero values are left unset so the platform defaults apply
+		optional := func(v int) *int64 {
+			if v == 0 {
+				return nil
+			}
+			return int64Ptr(v)
+		}
+		result.Probe = &ags.ProbeConfiguration{
+			HttpGet:             action,
+			InitialDelaySeconds: optional(c.Probe.InitialDelaySeconds),
+			PeriodSeconds:       optional(c.Probe.PeriodSeconds),
+			TimeoutSeconds:      optional(c.Probe.TimeoutSeconds),
+			SuccessThreshold:    optional(c.Probe.SuccessThreshold),
+			FailureThreshold:    optional(c.Probe.FailureThreshold),
+		}
+	}
+
+	return result
+}
+
+// parseAPICustomConfiguration converts API CustomConfiguration to client format
+func parseAPICustomConfiguration(apiCfg *ags.CustomConfiguration) *CustomConfig {
+	if apiCfg == nil {
+		return nil
+	}
+
+	c := &CustomConfig{
+		Image:        derefString(apiCfg.Image),
+		RegistryType: derefString(apiCfg.ImageRegistryType),
+		Command:      fromStringPtrs(apiCfg.Command),
+		Args:         fromStringPtrs(apiCfg.Args),
+	}
+
+	for _, e := range apiCfg.Env {
+		if e != nil {
+			c.Env = append(c.Env, EnvVar{Name: derefString(e.Name), Value: derefString(e.Value)})
+		}
+	}
+
+	for _, p := range apiCfg.Ports {
+		if p != nil {
+			c.Ports = append(c.Ports, PortConfig{
+				Name:     derefString(p.Name),
+				Port:     derefInt(p.Port),
+				Protocol: derefString(p.Protocol),
+			})
+		}
+	}
+
+	if apiCfg.Resources != nil {
+		c.Resources = &ResourceConfig{
+			CPU:    derefString(apiCfg.Resources.CPU),
+			Memory: derefString(apiCfg.Resources.Memory),
+		}
+	}
+
+	if apiCfg.Probe != nil && apiCfg.Probe.HttpGet != nil {
+		c.Probe = &ProbeConfig{
+			Path:                derefString(apiCfg.Probe.HttpGet.Path),
+			Port:                derefInt(apiCfg.Probe.HttpGet.Port),
+			Scheme:              derefString(apiCfg.Probe.HttpGet.Scheme),
+			InitialDelaySeconds: derefInt(apiCfg.Probe.InitialDelaySeconds),
+			PeriodSeconds:       derefInt(apiCfg.Probe.PeriodSeconds),
+			TimeoutSeconds:      derefInt(apiCfg.Probe.TimeoutSeconds),
+			SuccessThreshold:    derefInt(apiCfg.Probe.SuccessThreshold),
+			FailureThreshold:    derefInt(apiCfg.Probe.FailureThreshold),
+		}
+	}
+
+	return c
+}
+
+// toStringPtrs converts a string slice to the pointer slice used by the API
+func toStringPtrs(values []string) []*string {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]*string, len(values))
+	for i := range values {
+		result[i] = &values[i]
+	}
+	return result
+}
+
+// fromStringPtrs converts an API pointer slice to a string slice
+func fromStringPtrs(values []*string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = derefString(v)
+	}
+	return result
+}
+
+// int64Ptr returns a pointer to v as int64
+func int64Ptr(v int) *int64 {
+	n := int64(v)
+	return &n
+}
+
 // UpdateTool updates a sandbox tool
 func (c *CloudToolClient) UpdateTool(ctx context.Context, opts *UpdateToolOptions) error {
 	request := ags.NewUpdateSandboxToolRequest()
@@ -398,9 +583,12 @@ func (c *CloudToolClient) UpdateTool(ctx context.Context, opts *UpdateToolOption
 		request.Tags = tags
 	}
 
-	_, err := c.client.UpdateSandboxToolWithContext(ctx, request)
+	err := c.retry.cloudCall(ctx, "UpdateSandboxTool", true, func() error {
+		_, err := c.client.UpdateSandboxToolWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update tool: %w", err)
+		return wrapCloudError("failed to update tool", err)
 	}
 
 	return nil
@@ -411,9 +599,12 @@ func (c *CloudToolClient) DeleteTool(ctx context.Context, id string) error {
 	request := ags.NewDeleteSandboxToolRequest()
 	request.ToolId = &id
 
-	_, err := c.client.DeleteSandboxToolWithContext(ctx, request)
+	err := c.retry.cloudCall(ctx, "DeleteSandboxTool", true, func() error {
+		_, err := c.client.DeleteSandboxToolWithContext(ctx, request)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete tool: %w", err)
+		return wrapCloudError("failed to delete tool", err)
 	}
 
 	return nil
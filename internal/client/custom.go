@@ -0,0 +1,190 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidRegistryTypes lists the image registry types of a custom tool
+var ValidRegistryTypes = []string{"personal", "enterprise"}
+
+// Validate checks the custom container configuration
+func (c *CustomConfig) Validate() error {
+	if c.Image == "" {
+		return fmt.Errorf("image is required")
+	}
+	if c.RegistryType != "" && !slices.Contains(ValidRegistryTypes, c.RegistryType) {
+		return fmt.Errorf("invalid registry type: %s (must be personal or enterprise)", c.RegistryType)
+	}
+	for _, e := range c.Env {
+		if e.Name == "" {
+			return fmt.Errorf("env name is required")
+		}
+	}
+	ports := make(map[int]bool)
+	for _, p := range c.Ports {
+		if p.Port < 1 || p.Port > 65535 {
+			return fmt.Errorf("invalid port: %d", p.Port)
+		}
+		if ports[p.Port] {
+			return fmt.Errorf("duplicate port: %d", p.Port)
+		}
+		ports[p.Port] = true
+		switch p.Protocol {
+		case "", "TCP", "UDP":
+		default:
+			return fmt.Errorf("invalid protocol for port %d: %s (must be TCP or UDP)", p.Port, p.Protocol)
+		}
+	}
+	if c.Probe != nil {
+		if !strings.HasPrefix(c.Probe.Path, "/") {
+			return fmt.Errorf("probe path must be absolute path (start with /)")
+		}
+		if c.Probe.Port < 1 || c.Probe.Port > 65535 {
+			return fmt.Errorf("invalid probe port: %d", c.Probe.Port)
+		}
+		switch c.Probe.Scheme {
+		case "", "HTTP", "HTTPS":
+		default:
+			return fmt.Errorf("invalid probe scheme: %s (must be HTTP or HTTPS)", c.Probe.Scheme)
+		}
+	}
+	return nil
+}
+
+// ParseEnvVar parses --env parameter string into EnvVar
+// Format: "<name>=<value>"
+func ParseEnvVar(s string) (*EnvVar, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("invalid env format: %s (expected NAME=value)", s)
+	}
+	return &EnvVar{Name: name, Value: value}, nil
+}
+
+// ParsePort parses --port parameter string into PortConfig
+// Format: "[<name>:]<port>[/<protocol>]", e.g. "8080", "http:8080/TCP"
+func ParsePort(s string) (*PortConfig, error) {
+	p := &PortConfig{}
+	rest := s
+	if name, port, ok := strings.Cut(rest, ":"); ok {
+		p.Name = name
+		rest = port
+	}
+	if port, protocol, ok := strings.Cut(rest, "/"); ok {
+		p.Protocol = strings.ToUpper(protocol)
+		rest = port
+	}
+	port, err := strconv.Atoi(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port format: %s (expected [name:]port[/protocol])", s)
+	}
+	p.Port = port
+	return p, nil
+}
+
+// ParseProbe parses --probe parameter string into ProbeConfig
+// Format: "path=<path>,port=<port>[,scheme=HTTP|HTTPS][,initial-delay=<s>][,period=<s>][,timeout=<s>][,success=<n>][,failure=<n>]"
+func ParseProbe(s string) (*ProbeConfig, error) {
+	params := parseKeyValuePairs(s)
+
+	probe := &ProbeConfig{
+		Path:   params["path"],
+		Scheme: strings.ToUpper(params["scheme"]),
+	}
+	if probe.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if params["port"] == "" {
+		return nil, fmt.Errorf("port is required")
+	}
+
+	ints := []struct {
+		key string
+		dst *int
+	}{
+		{"port", &probe.Port},
+		{"initial-delay", &probe.InitialDelaySeconds},
+		{"period", &probe.PeriodSeconds},
+		{"timeout", &probe.TimeoutSeconds},
+		{"success", &probe.SuccessThreshold},
+		{"failure", &probe.FailureThreshold},
+	}
+	for _, f := range ints {
+		v, ok := params[f.key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", f.key)
+		}
+		*f.dst = n
+	}
+
+	return probe, nil
+}
+
+// FormatPort renders a port as "[name:]port/protocol"
+func FormatPort(p PortConfig) string {
+	protocol := p.Protocol
+	if protocol == "" {
+		protocol = "TCP"
+	}
+	s := fmt.Sprintf("%d/%s", p.Port, protocol)
+	if p.Name != "" {
+		s = p.Name + ":" + s
+	}
+	return s
+}
+
+// FormatProbe renders a readiness probe as a URL with its non-default settings
+func FormatProbe(p *ProbeConfig) string {
+	scheme := p.Scheme
+	if scheme == "" {
+		scheme = "HTTP"
+	}
+	s := fmt.Sprintf("%s://:%d%s", strings.ToLower(scheme), p.Port, p.Path)
+	var opts []string
+	for _, o := range []struct {
+		name  string
+		value int
+	}{
+		{"initial-delay", p.InitialDelaySeconds},
+		{"period", p.PeriodSeconds},
+		{"timeout", p.TimeoutSeconds},
+		{"success", p.SuccessThreshold},
+		{"failure", p.FailureThreshold},
+	} {
+		if o.value > 0 {
+			opts = append(opts, fmt.Sprintf("%s=%d", o.name, o.value))
+		}
+	}
+	if len(opts) > 0 {
+		s += " (" + strings.Join(opts, ", ") + ")"
+	}
+	return s
+}
+
+// FormatProbeHelp returns help text for --probe parameter
+func FormatProbeHelp() string {
+	return `HTTP readiness probe in key=value format.
+
+Format:
+  path=<path>,port=<port>[,scheme=HTTP|HTTPS][,initial-delay=<s>][,period=<s>][,timeout=<s>][,success=<n>][,failure=<n>]
+
+Parameters:
+  path           HTTP path to probe, must start with / (required)
+  port           Container port to probe (required)
+  scheme         HTTP (default) or HTTPS
+  initial-delay  Seconds to wait before the first probe
+  period         Seconds between probes
+  timeout        Seconds before a probe times out
+  success        Consecutive successes required to become ready
+  failure        Consecutive failures before the instance is marked failed
+
+Example:
+  --probe "path=/healthz,port=8080,initial-delay=5,period=10"`
+}
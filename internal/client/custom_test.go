@@ -0,0 +1,76 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    PortConfig
+		wantErr bool
+	}{
+		{in: "8080", want: PortConfig{Port: 8080}},
+		{in: "http:8080", want: PortConfig{Name: "http", Port: 8080}},
+		{in: "dns:53/udp", want: PortConfig{Name: "dns", Port: 53, Protocol: "UDP"}},
+		{in: "http", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePort(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParsePort() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil || *got != tt.want {
+				t.Errorf("ParsePort() = %+v, %v, want %+v", got, err, tt.want)
+			}
+			if s := FormatPort(*got); tt.want.Protocol == "" && s != tt.in+"/TCP" {
+				t.Errorf("FormatPort() = %q", s)
+			}
+		})
+	}
+}
+
+func TestParseProbe(t *testing.T) {
+	got, err := ParseProbe("path=/healthz,port=8080,scheme=https,initial-delay=5,failure=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ProbeConfig{Path: "/healthz", Port: 8080, Scheme: "HTTPS", InitialDelaySeconds: 5, FailureThreshold: 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseProbe() = %+v, want %+v", got, want)
+	}
+	if s := FormatProbe(got); s != "https://:8080/healthz (initial-delay=5, failure=3)" {
+		t.Errorf("FormatProbe() = %q", s)
+	}
+
+	for _, in := range []string{"port=8080", "path=/", "path=/,port=x", "path=/,port=80,period=-1"} {
+		if _, err := ParseProbe(in); err == nil {
+			t.Errorf("ParseProbe(%q) expected error", in)
+		}
+	}
+}
+
+func TestCustomConfigValidate(t *testing.T) {
+	valid := CustomConfig{Image: "nginx", Ports: []PortConfig{{Port: 80}}, Probe: &ProbeConfig{Path: "/", Port: 80}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error: %v", err)
+	}
+
+	for name, c := range map[string]CustomConfig{
+		"no image":       {},
+		"bad registry":   {Image: "nginx", RegistryType: "docker"},
+		"duplicate port": {Image: "nginx", Ports: []PortConfig{{Port: 80}, {Port: 80}}},
+		"bad protocol":   {Image: "nginx", Ports: []PortConfig{{Port: 80, Protocol: "SCTP"}}},
+		"relative probe": {Image: "nginx", Probe: &ProbeConfig{Path: "healthz", Port: 80}},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: Validate() expected error", name)
+		}
+	}
+}
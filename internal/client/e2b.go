@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
@@ -20,6 +23,7 @@ type E2BControlPlane struct {
 	apiKey     string
 	domain     string
 	region     string
+	retry      RetryPolicy
 }
 
 // NewE2BControlPlane creates a new E2B control plane client
@@ -32,6 +36,7 @@ func NewE2BControlPlane() (*E2BControlPlane, error) {
 		apiKey:     e2bCfg.APIKey,
 		domain:     cfg.Domain,
 		region:     cfg.Region,
+		retry:      defaultRetryPolicy(),
 	}, nil
 }
 
@@ -39,52 +44,201 @@ func (c *E2BControlPlane) getAPIEndpoint() string {
 	return fmt.Sprintf("https://api.%s.%s", c.region, c.domain)
 }
 
+// doRequest sends an API request, retrying rate-limited and transiently
+// failed attempts under the client's retry policy. When the attempts run out
+// on an error status, the last response is returned for the caller to report.
 func (c *E2BControlPlane) doRequest(ctx context.Context, method, url string, body any) (*http.Response, error) {
-	var bodyReader io.Reader
+	var data []byte
 	if body != nil {
-		data, err := json.Marshal(body)
+		var err error
+		data, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(data)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	var resp *http.Response
+	err := c.retry.do(ctx, method+" "+strings.TrimPrefix(url, c.getAPIEndpoint()), func() (retryDecision, error) {
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			resp = nil
+		}
 
-	req.Header.Set("X-API-Key", c.apiKey)
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		var bodyReader io.Reader
+		if data != nil {
+			bodyReader = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+		if err != nil {
+			return retryDecision{}, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("X-API-Key", c.apiKey)
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
-	return c.httpClient.Do(req)
+		r, err := c.httpClient.Do(req)
+		if err != nil {
+			return transportRetryDecision(method, err), wrapTransportError("E2B API request failed", err)
+		}
+		resp = r
+		if decision := httpRetryDecision(method, r); decision.retry {
+			return decision, errRetryableStatus
+		}
+		return retryDecision{}, nil
+	})
+	if err != nil && err != errRetryableStatus {
+		return nil, err
+	}
+	return resp, nil
 }
 
-// ========== Tool Operations (not supported by E2B) ==========
+// ========== Tool Operations ==========
+//
+// E2B templates are exposed as read-only tools: the template ID is the tool
+// ID and the first alias, when present, is the tool name. Both can be passed
+// to CreateInstance.
 
 // CreateTool is not supported by E2B backend
 func (c *E2BControlPlane) CreateTool(ctx context.Context, opts *CreateToolOptions) (*Tool, error) {
-	return nil, fmt.Errorf("tool operations are not supported by E2B backend, please use cloud backend")
+	return nil, unsupportedError("creating tools is not supported by E2B backend, please use cloud backend")
 }
 
 // UpdateTool is not supported by E2B backend
 func (c *E2BControlPlane) UpdateTool(ctx context.Context, opts *UpdateToolOptions) error {
-	return fmt.Errorf("tool operations are not supported by E2B backend, please use cloud backend")
+	return unsupportedError("updating tools is not supported by E2B backend, please use cloud backend")
 }
 
 // DeleteTool is not supported by E2B backend
 func (c *E2BControlPlane) DeleteTool(ctx context.Context, id string) error {
-	return fmt.Errorf("tool operations are not supported by E2B backend, please use cloud backend")
+	return unsupportedError("deleting tools is not supported by E2B backend, please use cloud backend")
+}
+
+// e2bTemplate is a template as returned by GET /templates
+type e2bTemplate struct {
+	TemplateID  string   `json:"templateID"`
+	Aliases     []string `json:"aliases"`
+	Public      bool     `json:"public"`
+	CPUCount    int      `json:"cpuCount"`
+	MemoryMB    int      `json:"memoryMB"`
+	DiskSizeMB  int      `json:"diskSizeMB"`
+	BuildStatus string   `json:"buildStatus"`
+	CreatedAt   string   `json:"createdAt"`
 }
 
-// ListTools is not supported by E2B backend
+// toTool maps a template onto the backend-agnostic Tool type. Resources
+// and visibility have no Tool field, so they are summarized in the
+// description.
+func (t *e2bTemplate) toTool() Tool {
+	name := t.TemplateID
+	if len(t.Aliases) > 0 {
+		name = t.Aliases[0]
+	}
+
+	var details []string
+	if t.CPUCount > 0 {
+		details = append(details, fmt.Sprintf("%d vCPU", t.CPUCount))
+	}
+	if t.MemoryMB > 0 {
+		details = append(details, fmt.Sprintf("%d MiB memory", t.MemoryMB))
+	}
+	if t.DiskSizeMB > 0 {
+		details = append(details, fmt.Sprintf("%d MiB disk", t.DiskSizeMB))
+	}
+	if t.Public {
+		details = append(details, "public")
+	}
+	if t.BuildStatus != "" && t.BuildStatus != "ready" {
+		details = append(details, "build "+t.BuildStatus)
+	}
+
+	return Tool{
+		ID:          t.TemplateID,
+		Name:        name,
+		Description: strings.Join(details, ", "),
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// matches reports whether ref is the template ID or one of its aliases
+func (t *e2bTemplate) matches(ref string) bool {
+	return t.TemplateID == ref || slices.Contains(t.Aliases, ref)
+}
+
+// listTemplates returns the templates visible to the API key
+func (c *E2BControlPlane) listTemplates(ctx context.Context) ([]e2bTemplate, error) {
+	url := c.getAPIEndpoint() + "/templates"
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError("failed to list templates", resp)
+	}
+
+	var templates []e2bTemplate
+	if err := json.NewDecoder(resp.Body).Decode(&templates); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return templates, nil
+}
+
+// ListTools lists the templates available to the API key via GET /templates.
+// The templates API has no server-side filtering or pagination, so ToolIDs,
+// Offset and Limit are applied locally; other filters are not supported.
 func (c *E2BControlPlane) ListTools(ctx context.Context, opts *ListToolsOptions) (*ListToolsResult, error) {
-	return nil, fmt.Errorf("tool operations are not supported by E2B backend, please use cloud backend")
+	if opts == nil {
+		opts = &ListToolsOptions{}
+	}
+	if opts.Status != "" || opts.ToolType != "" || opts.CreatedSince != "" || opts.CreatedSinceTime != "" || len(opts.Tags) > 0 {
+		return nil, unsupportedError("filtering tools by status, type, creation time or tags is not supported by E2B backend")
+	}
+
+	templates, err := c.listTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tools []Tool
+	for _, t := range templates {
+		if len(opts.ToolIDs) > 0 && !slices.ContainsFunc(opts.ToolIDs, t.matches) {
+			continue
+		}
+		tools = append(tools, t.toTool())
+	}
+	sort.SliceStable(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+
+	total := len(tools)
+	if len(opts.ToolIDs) == 0 {
+		limit := opts.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		start := min(max(opts.Offset, 0), total)
+		tools = tools[start:min(start+limit, total)]
+	}
+
+	return &ListToolsResult{
+		Tools:      tools,
+		TotalCount: total,
+	}, nil
 }
 
-// GetTool is not supported by E2B backend
+// GetTool returns the template with the given ID or alias
 func (c *E2BControlPlane) GetTool(ctx context.Context, id string) (*Tool, error) {
-	return nil, fmt.Errorf("tool operations are not supported by E2B backend, please use cloud backend")
+	templates, err := c.listTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if t.matches(id) {
+			tool := t.toTool()
+			return &tool, nil
+		}
+	}
+	return nil, newError(KindNotFound, "tool not found: %s", id)
 }
 
 // ========== Instance Operations ==========
@@ -139,8 +293,7 @@ func (c *E2BControlPlane) CreateInstance(ctx context.Context, opts *CreateInstan
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to create instance: %s - %s", resp.Status, string(body))
+		return nil, newHTTPError("failed to create instance", resp)
 	}
 
 	var result struct {
@@ -186,8 +339,7 @@ func (c *E2BControlPlane) ListInstances(ctx context.Context, opts *ListInstances
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to list instances: %s - %s", resp.Status, string(body))
+		return nil, newHTTPError("failed to list instances", resp)
 	}
 
 	var sandboxes []struct {
@@ -195,6 +347,8 @@ func (c *E2BControlPlane) ListInstances(ctx context.Context, opts *ListInstances
 		TemplateID string `json:"templateID"`
 		Alias      string `json:"alias"`
 		StartedAt  string `json:"startedAt"`
+		EndAt      string `json:"endAt"`
+		State      string `json:"state"`
 		Secure     *bool  `json:"secure,omitempty"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&sandboxes); err != nil {
@@ -211,12 +365,17 @@ func (c *E2BControlPlane) ListInstances(ctx context.Context, opts *ListInstances
 		if s.Secure != nil {
 			secure = *s.Secure
 		}
+		status := s.State
+		if status == "" {
+			status = "running"
+		}
 		instances[i] = Instance{
 			ID:        s.SandboxID,
 			ToolID:    s.TemplateID,
 			ToolName:  s.TemplateID,
-			Status:    "running",
+			Status:    status,
 			CreatedAt: s.StartedAt,
+			ExpiresAt: s.EndAt,
 			Secure:    secure,
 		}
 	}
@@ -240,8 +399,7 @@ func (c *E2BControlPlane) GetInstance(ctx context.Context, id string) (*Instance
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get instance: %s - %s", resp.Status, string(body))
+		return nil, newHTTPError("failed to get instance", resp)
 	}
 
 	var result struct {
@@ -249,6 +407,7 @@ func (c *E2BControlPlane) GetInstance(ctx context.Context, id string) (*Instance
 		TemplateID      string `json:"templateID"`
 		Alias           string `json:"alias"`
 		StartedAt       string `json:"startedAt"`
+		EndAt           string `json:"endAt"`
 		State           string `json:"state"`
 		EnvdAccessToken string `json:"envdAccessToken"`
 		Secure          *bool  `json:"secure,omitempty"`
@@ -263,6 +422,7 @@ func (c *E2BControlPlane) GetInstance(ctx context.Context, id string) (*Instance
 		ToolName:    result.TemplateID,
 		Status:      result.State,
 		CreatedAt:   result.StartedAt,
+		ExpiresAt:   result.EndAt,
 		AccessToken: result.EnvdAccessToken,
 		Domain:      fmt.Sprintf("%s.%s", c.region, c.domain),
 		Secure:      e2bSecure(result.Secure, result.EnvdAccessToken),
@@ -280,13 +440,135 @@ func (c *E2BControlPlane) DeleteInstance(ctx context.Context, id string) error {
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete instance: %s - %s", resp.Status, string(body))
+		return newHTTPError("failed to delete instance", resp)
 	}
 
 	return nil
 }
 
+// PauseInstance pauses a running sandbox via POST /sandboxes/{id}/pause.
+// The sandbox filesystem and memory are snapshotted and can be restored with ResumeInstance.
+func (c *E2BControlPlane) PauseInstance(ctx context.Context, id string) error {
+	url := c.getAPIEndpoint() + "/sandboxes/" + id + "/pause"
+
+	resp, err := c.doRequest(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return newError(KindNotFound, "instance not found: %s", id)
+	case http.StatusConflict:
+		return newError(KindConflict, "instance %s is already paused", id)
+	default:
+		return newHTTPError("failed to pause instance", resp)
+	}
+}
+
+// ResumeInstance resumes a paused sandbox via POST /sandboxes/{id}/resume.
+// The returned Instance may carry a new AccessToken which should replace any cached one.
+func (c *E2BControlPlane) ResumeInstance(ctx context.Context, id string, timeout int) (*Instance, error) {
+	url := c.getAPIEndpoint() + "/sandboxes/" + id + "/resume"
+
+	reqBody := map[string]any{}
+	if timeout > 0 {
+		reqBody["timeout"] = timeout
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound:
+		return nil, newError(KindNotFound, "instance not found: %s", id)
+	case http.StatusConflict:
+		return nil, newError(KindConflict, "instance %s is already running", id)
+	default:
+		return nil, newHTTPError("failed to resume instance", resp)
+	}
+
+	var result struct {
+		SandboxID       string `json:"sandboxID"`
+		TemplateID      string `json:"templateID"`
+		EnvdAccessToken string `json:"envdAccessToken"`
+		Secure          *bool  `json:"secure,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.SandboxID == "" {
+		result.SandboxID = id
+	}
+
+	return &Instance{
+		ID:          result.SandboxID,
+		ToolID:      result.TemplateID,
+		ToolName:    result.TemplateID,
+		Status:      "running",
+		AccessToken: result.EnvdAccessToken,
+		Domain:      fmt.Sprintf("%s.%s", c.region, c.domain),
+		Secure:      e2bSecure(result.Secure, result.EnvdAccessToken),
+	}, nil
+}
+
+// GetInstanceMetrics returns the most recent sample from GET /sandboxes/{id}/metrics
+func (c *E2BControlPlane) GetInstanceMetrics(ctx context.Context, id string) (*InstanceMetrics, error) {
+	url := c.getAPIEndpoint() + "/sandboxes/" + id + "/metrics"
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, newError(KindNotFound, "instance not found: %s", id)
+	case http.StatusNotImplemented:
+		return nil, fmt.Errorf("e2b backend: %w", ErrMetricsUnavailable)
+	default:
+		return nil, newHTTPError("failed to get instance metrics", resp)
+	}
+
+	var samples []struct {
+		Timestamp  string  `json:"timestamp"`
+		CPUCount   int     `json:"cpuCount"`
+		CPUUsedPct float64 `json:"cpuUsedPct"`
+		MemUsed    int64   `json:"memUsed"`
+		MemTotal   int64   `json:"memTotal"`
+		DiskUsed   int64   `json:"diskUsed"`
+		DiskTotal  int64   `json:"diskTotal"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&samples); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(samples) == 0 {
+		// Freshly started sandboxes may not have reported a sample yet
+		return nil, fmt.Errorf("no samples for instance %s: %w", id, ErrMetricsUnavailable)
+	}
+
+	latest := samples[len(samples)-1]
+	return &InstanceMetrics{
+		Timestamp:  latest.Timestamp,
+		CPUCount:   latest.CPUCount,
+		CPUUsedPct: latest.CPUUsedPct,
+		MemUsed:    latest.MemUsed,
+		MemTotal:   latest.MemTotal,
+		DiskUsed:   latest.DiskUsed,
+		DiskTotal:  latest.DiskTotal,
+		Source:     "api",
+	}, nil
+}
+
 // AcquireToken acquires an access token by calling GET /sandboxes/{id}.
 // The envdAccessToken field is included in the instance detail response.
 func (c *E2BControlPlane) AcquireToken(ctx context.Context, instanceID string) (string, error) {
@@ -304,15 +586,15 @@ func (c *E2BControlPlane) AcquireToken(ctx context.Context, instanceID string) (
 
 // CreateAPIKey is not supported by E2B backend
 func (c *E2BControlPlane) CreateAPIKey(ctx context.Context, name string) (*CreateAPIKeyResult, error) {
-	return nil, fmt.Errorf("API key management is not supported by E2B backend")
+	return nil, unsupportedError("API key management is not supported by E2B backend")
 }
 
 // ListAPIKeys is not supported by E2B backend
 func (c *E2BControlPlane) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
-	return nil, fmt.Errorf("API key management is not supported by E2B backend")
+	return nil, unsupportedError("API key management is not supported by E2B backend")
 }
 
 // DeleteAPIKey is not supported by E2B backend
 func (c *E2BControlPlane) DeleteAPIKey(ctx context.Context, keyID string) error {
-	return fmt.Errorf("API key management is not supported by E2B backend")
+	return unsupportedError("API key management is not supported by E2B backend")
 }
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+const templatesJSON = `[
+  {"templateID": "tpl-ci", "aliases": ["code-interpreter-v1"], "public": true, "cpuCount": 2, "memoryMB": 512, "diskSizeMB": 1024, "buildStatus": "ready", "createdAt": "2026-01-02T03:04:05Z"},
+  {"templateID": "tpl-browser", "aliases": ["browser-v1", "browser"], "cpuCount": 4, "memoryMB": 4096, "buildStatus": "building"},
+  {"templateID": "tpl-raw", "cpuCount": 1}
+]`
+
+func newTestE2BControlPlane(t *testing.T) *E2BControlPlane {
+	t.Helper()
+	return &E2BControlPlane{
+		apiKey: "key",
+		domain: "example.com",
+		region: "ap-test",
+		httpClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.URL.String() != "https://api.ap-test.example.com/templates" || r.Header.Get("X-API-Key") != "key" {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(templatesJSON)),
+				Header:     make(http.Header),
+			}, nil
+		})},
+	}
+}
+
+func TestE2BListTools(t *testing.T) {
+	c := newTestE2BControlPlane(t)
+	ctx := context.Background()
+
+	result, err := c.ListTools(ctx, &ListToolsOptions{})
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if result.TotalCount != 3 || len(result.Tools) != 3 {
+		t.Fatalf("ListTools() = %+v", result)
+	}
+	// Sorted by name; the first alias is the name
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	if got := strings.Join(names, ","); got != "browser-v1,code-interpreter-v1,tpl-raw" {
+		t.Errorf("names = %s", got)
+	}
+	ci := result.Tools[1]
+	if ci.ID != "tpl-ci" || ci.Description != "2 vCPU, 512 MiB memory, 1024 MiB disk, public" || ci.CreatedAt != "2026-01-02T03:04:05Z" {
+		t.Errorf("code interpreter = %+v", ci)
+	}
+	if d := result.Tools[0].Description; d != "4 vCPU, 4096 MiB memory, build building" {
+		t.Errorf("browser description = %q", d)
+	}
+
+	page, err := c.ListTools(ctx, &ListToolsOptions{Offset: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 3 || len(page.Tools) != 1 || page.Tools[0].ID != "tpl-ci" {
+		t.Errorf("ListTools(offset 1, limit 1) = %+v", page)
+	}
+
+	byID, err := c.ListTools(ctx, &ListToolsOptions{ToolIDs: []string{"browser", "tpl-raw"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byID.TotalCount != 2 {
+		t.Errorf("ListTools(ids) = %+v", byID)
+	}
+
+	if _, err := c.ListTools(ctx, &ListToolsOptions{Status: "ACTIVE"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ListTools(status) error = %v, want unsupported", err)
+	}
+}
+
+func TestE2BGetTool(t *testing.T) {
+	c := newTestE2BControlPlane(t)
+	ctx := context.Background()
+
+	for _, ref := range []string{"tpl-browser", "browser"} {
+		tool, err := c.GetTool(ctx, ref)
+		if err != nil {
+			t.Fatalf("GetTool(%q) error = %v", ref, err)
+		}
+		if tool.ID != "tpl-browser" || tool.Name != "browser-v1" {
+			t.Errorf("GetTool(%q) = %+v", ref, tool)
+		}
+	}
+
+	if _, err := c.GetTool(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTool(missing) error = %v, want not found", err)
+	}
+}
+
+// newHandlerE2BControlPlane returns a client whose API requests are served
+// by handler.
+func newHandlerE2BControlPlane(handler http.HandlerFunc) *E2BControlPlane {
+	return &E2BControlPlane{
+		apiKey: "key",
+		domain: "example.com",
+		region: "ap-test",
+		httpClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			handler(rec, r)
+			return rec.Result(), nil
+		})},
+	}
+}
+
+func TestE2BPauseInstance(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		kind   ErrorKind
+	}{
+		{"paused", http.StatusNoContent, ""},
+		{"not found", http.StatusNotFound, KindNotFound},
+		{"already paused", http.StatusConflict, KindConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newHandlerE2BControlPlane(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/sandboxes/sbx-1/pause" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				}
+				w.WriteHeader(tt.status)
+			})
+			err := c.PauseInstance(context.Background(), "sbx-1")
+			if tt.kind == "" {
+				if err != nil {
+					t.Fatalf("PauseInstance() error = %v", err)
+				}
+				return
+			}
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.Kind != tt.kind {
+				t.Errorf("PauseInstance() error = %v, want kind %s", err, tt.kind)
+			}
+			if tt.kind == KindNotFound && !errors.Is(err, ErrNotFound) {
+				t.Errorf("PauseInstance() error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestE2BResumeInstance(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   ErrorKind
+	}{
+		{"resumed", http.StatusCreated, `{"sandboxID": "sbx-1", "templateID": "tpl-ci", "envdAccessToken": "new-token"}`, ""},
+		{"not found", http.StatusNotFound, "", KindNotFound},
+		{"already running", http.StatusConflict, "", KindConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newHandlerE2BControlPlane(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/sandboxes/sbx-1/resume" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				}
+				if body, _ := io.ReadAll(r.Body); string(body) != `{"timeout":600}` {
+					t.Errorf("request body = %s", body)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			})
+			inst, err := c.ResumeInstance(context.Background(), "sbx-1", 600)
+			if tt.kind != "" {
+				var apiErr *Error
+				if !errors.As(err, &apiErr) || apiErr.Kind != tt.kind {
+					t.Errorf("ResumeInstance() error = %v, want kind %s", err, tt.kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResumeInstance() error = %v", err)
+			}
+			// The rotated token replaces the cached one
+			if inst.ID != "sbx-1" || inst.AccessToken != "new-token" || !inst.Secure || inst.Status != "running" {
+				t.Errorf("ResumeInstance() = %+v", inst)
+			}
+			if inst.Domain != "ap-test.example.com" {
+				t.Errorf("Domain = %q", inst.Domain)
+			}
+		})
+	}
+}
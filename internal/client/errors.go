@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	tcerr "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+// ErrorKind classifies control plane errors independently of the backend.
+// The kind is stable and is reported in JSON errors and mapped to exit codes.
+type ErrorKind string
+
+const (
+	KindNotFound    ErrorKind = "not_found"   // instance, tool or key does not exist
+	KindAuth        ErrorKind = "auth"        // missing or invalid credentials, or permission denied
+	KindQuota       ErrorKind = "quota"       // quota, rate or resource limit exceeded
+	KindConflict    ErrorKind = "conflict"    // resource already exists or is in the wrong state
+	KindUnsupported ErrorKind = "unsupported" // operation not supported by the selected backend
+	KindNetwork     ErrorKind = "network"     // control plane unreachable
+	KindTimeout     ErrorKind = "timeout"     // request or operation timed out
+)
+
+// Error is a classified control plane error. Code carries the backend's own
+// error code (a Tencent Cloud error code or an HTTP status) when known.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is makes the kind sentinels below match any error of the same kind,
+// e.g. errors.Is(err, ErrNotFound).
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.Err == nil && t.Kind == e.Kind
+}
+
+// Sentinels for errors.Is checks by kind.
+var (
+	ErrNotFound    = &Error{Kind: KindNotFound}
+	ErrAuth        = &Error{Kind: KindAuth}
+	ErrQuota       = &Error{Kind: KindQuota}
+	ErrConflict    = &Error{Kind: KindConflict}
+	ErrUnsupported = &Error{Kind: KindUnsupported}
+	ErrNetwork     = &Error{Kind: KindNetwork}
+	ErrTimeout     = &Error{Kind: KindTimeout}
+)
+
+// KindOf returns the kind of err, or "" if it is not classified.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTimeout
+	}
+	return ""
+}
+
+// CodeOf returns the backend error code carried by err, if any.
+func CodeOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+func newError(kind ErrorKind, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// unsupportedError reports an operation the backend does not implement.
+func unsupportedError(format string, args ...any) error {
+	return newError(KindUnsupported, format, args...)
+}
+
+// wrapCloudError classifies an error returned by tencentcloud-sdk-go and
+// prefixes it with msg.
+func wrapCloudError(msg string, err error) error {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	var sdkErr *tcerr.TencentCloudSDKError
+	if !errors.As(err, &sdkErr) {
+		return wrapTransportError(msg, err)
+	}
+	return &Error{Kind: cloudErrorKind(sdkErr.Code, sdkErr.Message), Code: sdkErr.Code, Message: msg, Err: err}
+}
+
+// cloudErrorKind maps Tencent Cloud API error codes to a kind. Codes are
+// dotted ("ResourceNotFound.SandboxInstance"), so the prefix decides first.
+func cloudErrorKind(code, message string) ErrorKind {
+	prefix, _, _ := strings.Cut(code, ".")
+	switch prefix {
+	case "AuthFailure", "UnauthorizedOperation":
+		return KindAuth
+	case "ResourceNotFound":
+		return KindNotFound
+	case "LimitExceeded", "RequestLimitExceeded", "ResourceInsufficient":
+		return KindQuota
+	case "ResourceInUse":
+		return KindConflict
+	case "UnsupportedOperation", "UnsupportedRegion", "UnknownParameter":
+		return KindUnsupported
+	}
+
+	switch {
+	case code == "ClientError.CredentialError":
+		return KindAuth
+	case code == "ClientError.NetworkError":
+		if isTimeoutMessage(message) {
+			return KindTimeout
+		}
+		return KindNetwork
+	case strings.Contains(code, "NotFound") || strings.Contains(code, "NotExist"):
+		return KindNotFound
+	case strings.Contains(code, "AlreadyExists") || strings.Contains(code, "Duplicate") || strings.Contains(code, "Conflict"):
+		return KindConflict
+	case strings.Contains(code, "Timeout"):
+		return KindTimeout
+	}
+	return ""
+}
+
+func isTimeoutMessage(message string) bool {
+	return strings.Contains(message, "deadline exceeded") || strings.Contains(message, "Timeout") || strings.Contains(message, "timeout")
+}
+
+// wrapTransportError classifies errors from sending an HTTP request.
+func wrapTransportError(msg string, err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return &Error{Kind: KindTimeout, Message: msg, Err: err}
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("%s: %w", msg, err)
+	case errors.As(err, &netErr):
+		return &Error{Kind: KindNetwork, Message: msg, Err: err}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// newHTTPError builds an error from a non-success E2B API response,
+// reading the response body into the message.
+func newHTTPError(msg string, resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return &Error{
+		Kind:    httpStatusKind(resp.StatusCode),
+		Code:    strconv.Itoa(resp.StatusCode),
+		Message: fmt.Sprintf("%s: %s - %s", msg, resp.Status, string(body)),
+	}
+}
+
+// httpStatusKind maps E2B API HTTP statuses to a kind.
+func httpStatusKind(status int) ErrorKind {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return KindAuth
+	case http.StatusNotFound:
+		return KindNotFound
+	case http.StatusConflict:
+		return KindConflict
+	case http.StatusTooManyRequests, http.StatusPaymentRequired:
+		return KindQuota
+	case http.StatusNotImplemented:
+		return KindUnsupported
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return KindTimeout
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return KindNetwork
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tcerr "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+func TestWrapCloudError(t *testing.T) {
+	tests := []struct {
+		code string
+		msg  string
+		want ErrorKind
+	}{
+		{code: "ResourceNotFound.SandboxInstance", want: KindNotFound},
+		{code: "AuthFailure.SignatureFailure", want: KindAuth},
+		{code: "UnauthorizedOperation", want: KindAuth},
+		{code: "LimitExceeded.SandboxInstance", want: KindQuota},
+		{code: "RequestLimitExceeded", want: KindQuota},
+		{code: "ResourceInUse", want: KindConflict},
+		{code: "InvalidParameterValue.ToolNameAlreadyExists", want: KindConflict},
+		{code: "UnsupportedOperation", want: KindUnsupported},
+		{code: "ClientError.NetworkError", msg: "connection refused", want: KindNetwork},
+		{code: "ClientError.NetworkError", msg: "context deadline exceeded", want: KindTimeout},
+		{code: "InternalError", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			err := wrapCloudError("failed to get instance", tcerr.NewTencentCloudSDKError(tt.code, tt.msg, "req-1"))
+			if got := KindOf(err); got != tt.want {
+				t.Errorf("KindOf() = %q, want %q", got, tt.want)
+			}
+			if got := CodeOf(err); got != tt.code {
+				t.Errorf("CodeOf() = %q, want %q", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestWrapCloudErrorKeepsKind(t *testing.T) {
+	inner := wrapCloudError("failed to list instances", tcerr.NewTencentCloudSDKError("AuthFailure", "bad key", ""))
+	err := wrapCloudError("failed to get instance", inner)
+	if !errors.Is(err, ErrAuth) {
+		t.Errorf("errors.Is(err, ErrAuth) = false for %v", err)
+	}
+	if got, want := err.Error(), "failed to get instance: failed to list instances: [TencentCloudSDKError] Code=AuthFailure, Message=bad key"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestWrapTransportError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	if got := KindOf(wrapTransportError("request failed", refused)); got != KindNetwork {
+		t.Errorf("dial error kind = %q, want network", got)
+	}
+	if got := KindOf(wrapTransportError("request failed", context.DeadlineExceeded)); got != KindTimeout {
+		t.Errorf("deadline kind = %q, want timeout", got)
+	}
+	if got := KindOf(wrapTransportError("request failed", context.Canceled)); got != "" {
+		t.Errorf("canceled kind = %q, want unclassified", got)
+	}
+}
+
+func TestNewHTTPError(t *testing.T) {
+	tests := []struct {
+		status int
+		want   ErrorKind
+	}{
+		{status: http.StatusUnauthorized, want: KindAuth},
+		{status: http.StatusForbidden, want: KindAuth},
+		{status: http.StatusNotFound, want: KindNotFound},
+		{status: http.StatusConflict, want: KindConflict},
+		{status: http.StatusTooManyRequests, want: KindQuota},
+		{status: http.StatusNotImplemented, want: KindUnsupported},
+		{status: http.StatusGatewayTimeout, want: KindTimeout},
+		{status: http.StatusInternalServerError, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(tt.status)
+			_, _ = rec.WriteString("details")
+			resp := rec.Result()
+			resp.Status = fmt.Sprintf("%d %s", tt.status, http.StatusText(tt.status))
+
+			err := newHTTPError("failed to get instance", resp)
+			if got := KindOf(err); got != tt.want {
+				t.Errorf("KindOf() = %q, want %q", got, tt.want)
+			}
+			if got, want := err.Error(), "failed to get instance: "+resp.Status+" - details"; got != want {
+				t.Errorf("Error() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestErrMetricsUnavailable(t *testing.T) {
+	err := fmt.Errorf("cloud backend: %w", ErrMetricsUnavailable)
+	if !errors.Is(err, ErrMetricsUnavailable) {
+		t.Error("errors.Is(err, ErrMetricsUnavailable) = false")
+	}
+	if !errors.Is(err, ErrUnsupported) {
+		t.Error("errors.Is(err, ErrUnsupported) = false")
+	}
+	if errors.Is(unsupportedError("tool operations are not supported"), ErrMetricsUnavailable) {
+		t.Error("unrelated unsupported error matched ErrMetricsUnavailable")
+	}
+}
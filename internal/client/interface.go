@@ -1,13 +1,18 @@
 package client
 
-import "context"
+import (
+	"context"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+)
 
 // ControlPlaneClient defines the interface for control plane operations.
 // Control plane handles instance lifecycle management, tool management, and API key management.
 //
-// There are two backend implementations:
-//   - Cloud backend: Uses Tencent Cloud API with AKSK credentials (tencentcloud-sdk-go)
+// There are three backend implementations:
+//   - Cloud backend: Uses Tencent Cloud API with credentials from config.CloudCredential (tencentcloud-sdk-go)
 //   - E2B backend: Uses E2B protocol with API Key (REST API)
+//   - Local backend: Runs sandboxes on the local host, without credentials
 //
 // Data plane operations (code execution, file operations, etc.) are handled separately
 // via ags-go-sdk, which uses E2B protocol with Access Token.
@@ -25,6 +30,16 @@ type ControlPlaneClient interface {
 	GetInstance(ctx context.Context, id string) (*Instance, error)
 	DeleteInstance(ctx context.Context, id string) error
 
+	// PauseInstance snapshots a running instance and releases its compute;
+	// ResumeInstance restores it with a fresh timeout in seconds (0 = backend default).
+	// E2B backend only, cloud backend returns not supported error.
+	PauseInstance(ctx context.Context, id string) error
+	ResumeInstance(ctx context.Context, id string, timeout int) (*Instance, error)
+
+	// GetInstanceMetrics returns the latest resource usage sample of an instance.
+	// Returns ErrMetricsUnavailable when the backend has no metrics API (cloud backend).
+	GetInstanceMetrics(ctx context.Context, id string) (*InstanceMetrics, error)
+
 	// AcquireToken acquires an access token for data plane operations.
 	// For cloud backend, this calls AcquireSandboxInstanceToken API.
 	// For E2B backend, this calls GET /sandboxes/{id} to retrieve the envdAccessToken.
@@ -37,14 +52,43 @@ type ControlPlaneClient interface {
 }
 
 // NewControlPlaneClient creates a new control plane client based on the backend type.
-// Supported backends: "e2b", "cloud"
+// Supported backends: "e2b", "cloud", "local"
 func NewControlPlaneClient(backend string) (ControlPlaneClient, error) {
+	if err := config.ProfileError(); err != nil {
+		return nil, err
+	}
 	switch backend {
 	case "e2b":
 		return NewE2BControlPlane()
 	case "cloud":
 		return NewCloudControlPlane()
+	case "local":
+		return NewLocalControlPlane()
 	default:
 		return NewE2BControlPlane()
 	}
 }
+
+// NewControlPlaneClientInRegion creates a control plane client like
+// NewControlPlaneClient, but for region instead of the configured region.
+// It is used by commands that copy resources between regions.
+func NewControlPlaneClientInRegion(backend, region string) (ControlPlaneClient, error) {
+	if err := config.ProfileError(); err != nil {
+		return nil, err
+	}
+	cfg := *config.Get()
+	cfg.Region = region
+	switch backend {
+	case "cloud":
+		return newCloudControlPlane(&cfg)
+	case "local":
+		return nil, unsupportedError("local backend has no regions")
+	default:
+		c, err := NewE2BControlPlane()
+		if err != nil {
+			return nil, err
+		}
+		c.region = region
+		return c, nil
+	}
+}
@@ -0,0 +1,282 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/localsandbox"
+)
+
+// localTool is the only tool of the local backend.
+var localTool = Tool{
+	ID:             "code-interpreter-v1",
+	Name:           "code-interpreter-v1",
+	Description:    "Runs commands and code on this host, without isolation",
+	Type:           "code-interpreter",
+	DefaultTimeout: "5m",
+	NetworkMode:    "PUBLIC",
+}
+
+// LocalControlPlane implements ControlPlaneClient with sandboxes running on
+// the local host (see package localsandbox). It needs no credentials or
+// network access and is meant for development and testing.
+type LocalControlPlane struct {
+	store  *localsandbox.Store
+	domain string
+}
+
+// NewLocalControlPlane creates a new local control plane client
+func NewLocalControlPlane() (*LocalControlPlane, error) {
+	store, err := localsandbox.NewStore()
+	if err != nil {
+		return nil, err
+	}
+	return &LocalControlPlane{store: store, domain: config.Get().DataPlaneRegionDomain()}, nil
+}
+
+// CreateTool is not supported by local backend
+func (c *LocalControlPlane) CreateTool(ctx context.Context, opts *CreateToolOptions) (*Tool, error) {
+	return nil, unsupportedError("creating tools is not supported by local backend, please use cloud backend")
+}
+
+// UpdateTool is not supported by local backend
+func (c *LocalControlPlane) UpdateTool(ctx context.Context, opts *UpdateToolOptions) error {
+	return unsupportedError("updating tools is not supported by local backend, please use cloud backend")
+}
+
+// ListTools returns the built-in code interpreter tool
+func (c *LocalControlPlane) ListTools(ctx context.Context, opts *ListToolsOptions) (*ListToolsResult, error) {
+	tools := []Tool{localTool}
+	if opts != nil {
+		if len(opts.ToolIDs) > 0 && !slices.Contains(opts.ToolIDs, localTool.ID) {
+			tools = nil
+		}
+		if opts.ToolType != "" && opts.ToolType != localTool.Type {
+			tools = nil
+		}
+	}
+	return &ListToolsResult{Tools: tools, TotalCount: len(tools)}, nil
+}
+
+// GetTool returns the built-in tool by ID or name
+func (c *LocalControlPlane) GetTool(ctx context.Context, id string) (*Tool, error) {
+	if id != localTool.ID && id != localTool.Name {
+		return nil, newError(KindNotFound, "tool not found: %s", id)
+	}
+	tool := localTool
+	return &tool, nil
+}
+
+// DeleteTool is not supported by local backend
+func (c *LocalControlPlane) DeleteTool(ctx context.Context, id string) error {
+	return unsupportedError("deleting tools is not supported by local backend, please use cloud backend")
+}
+
+// CreateInstance creates an instance directory and starts its daemon
+func (c *LocalControlPlane) CreateInstance(ctx context.Context, opts *CreateInstanceOptions) (*Instance, error) {
+	inst, err := c.newRecord(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.store.Save(inst); err != nil {
+		_ = os.RemoveAll(inst.Dir)
+		return nil, err
+	}
+	if err := c.store.Start(inst); err != nil {
+		_ = c.store.Stop(inst)
+		return nil, err
+	}
+
+	result := c.toInstance(inst)
+	result.AccessToken = inst.Token
+	return result, nil
+}
+
+// newRecord validates opts and creates the directory and record of a new
+// instance. Every instance gets an access token, so that clients which
+// always send one (e.g. proxy) work in every auth mode; in NONE and PUBLIC
+// mode the daemon just does not check it.
+func (c *LocalControlPlane) newRecord(ctx context.Context, opts *CreateInstanceOptions) (*localsandbox.Instance, error) {
+	toolName := opts.ToolName
+	if toolName == "" {
+		toolName = opts.ToolID
+	}
+	if toolName == "" {
+		toolName = localTool.Name
+	}
+	if _, err := c.GetTool(ctx, toolName); err != nil {
+		return nil, err
+	}
+	if len(opts.MountOptions) > 0 {
+		return nil, unsupportedError("mount options are not supported by local backend")
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 300 // default 5 minutes
+	}
+
+	id := localsandbox.IDPrefix + randomHex(8)
+	dir, err := os.MkdirTemp("", "ags-"+id+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instance directory: %w", err)
+	}
+	now := time.Now()
+	return &localsandbox.Instance{
+		ID:       id,
+		ToolID:   localTool.ID,
+		ToolName: localTool.Name,
+		Dir:      dir,
+		Token:    randomHex(16),
+		// PUBLIC only opens application ports, which are not behind the
+		// daemon locally anyway, so the daemon itself stays open as well.
+		Open:      opts.AuthMode == AuthModeNone || opts.AuthMode == AuthModePublic,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(timeout) * time.Second),
+	}, nil
+}
+
+// toInstance maps an instance record onto the backend-agnostic Instance type
+func (c *LocalControlPlane) toInstance(inst *localsandbox.Instance) *Instance {
+	timeout := uint64(inst.ExpiresAt.Sub(inst.CreatedAt).Seconds())
+	return &Instance{
+		ID:             inst.ID,
+		ToolID:         inst.ToolID,
+		ToolName:       inst.ToolName,
+		Status:         "running",
+		CreatedAt:      inst.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:      inst.ExpiresAt.Format(time.RFC3339),
+		TimeoutSeconds: &timeout,
+		Domain:         c.domain,
+		Secure:         inst.Token != "" && !inst.Open,
+	}
+}
+
+// ListInstances returns the running local instances, oldest first
+func (c *LocalControlPlane) ListInstances(ctx context.Context, opts *ListInstancesOptions) (*ListInstancesResult, error) {
+	if opts == nil {
+		opts = &ListInstancesOptions{}
+	}
+	if opts.CreatedSince != "" || opts.CreatedSinceTime != "" {
+		return nil, unsupportedError("filtering instances by creation time is not supported by local backend")
+	}
+
+	records, err := c.store.List()
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]Instance, 0, len(records))
+	for _, r := range records {
+		inst := c.toInstance(r)
+		if len(opts.InstanceIDs) > 0 && !slices.Contains(opts.InstanceIDs, inst.ID) {
+			continue
+		}
+		if opts.ToolID != "" && opts.ToolID != inst.ToolID && opts.ToolID != inst.ToolName {
+			continue
+		}
+		if opts.Status != "" && !strings.EqualFold(opts.Status, inst.Status) {
+			continue
+		}
+		instances = append(instances, *inst)
+	}
+
+	total := len(instances)
+	if len(opts.InstanceIDs) == 0 {
+		limit := opts.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		start := min(max(opts.Offset, 0), total)
+		instances = instances[start:min(start+limit, total)]
+	}
+	return &ListInstancesResult{Instances: instances, TotalCount: total}, nil
+}
+
+// get returns the record of a running instance
+func (c *LocalControlPlane) get(id string) (*localsandbox.Instance, error) {
+	inst, err := c.store.Get(id)
+	if errors.Is(err, localsandbox.ErrNotFound) || (err == nil && !inst.Running()) {
+		return nil, newError(KindNotFound, "instance not found: %s", id)
+	}
+	return inst, err
+}
+
+// GetInstance returns a specific instance by ID
+func (c *LocalControlPlane) GetInstance(ctx context.Context, id string) (*Instance, error) {
+	inst, err := c.get(id)
+	if err != nil {
+		return nil, err
+	}
+	result := c.toInstance(inst)
+	result.AccessToken = inst.Token
+	return result, nil
+}
+
+// DeleteInstance stops the daemon of an instance and removes its directory
+func (c *LocalControlPlane) DeleteInstance(ctx context.Context, id string) error {
+	inst, err := c.store.Get(id)
+	if errors.Is(err, localsandbox.ErrNotFound) {
+		return newError(KindNotFound, "instance not found: %s", id)
+	}
+	if err != nil {
+		return err
+	}
+	return c.store.Stop(inst)
+}
+
+// PauseInstance is not supported by local backend
+func (c *LocalControlPlane) PauseInstance(ctx context.Context, id string) error {
+	return unsupportedError("pausing instances is not supported by local backend")
+}
+
+// ResumeInstance is not supported by local backend
+func (c *LocalControlPlane) ResumeInstance(ctx context.Context, id string, timeout int) (*Instance, error) {
+	return nil, unsupportedError("resuming instances is not supported by local backend")
+}
+
+// GetInstanceMetrics is not available from local backend; callers sample
+// the instance directly
+func (c *LocalControlPlane) GetInstanceMetrics(ctx context.Context, id string) (*InstanceMetrics, error) {
+	if _, err := c.get(id); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("local backend: %w", ErrMetricsUnavailable)
+}
+
+// AcquireToken returns the access token recorded for the instance
+func (c *LocalControlPlane) AcquireToken(ctx context.Context, instanceID string) (string, error) {
+	inst, err := c.get(instanceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire token: %w", err)
+	}
+	return inst.Token, nil
+}
+
+// CreateAPIKey is not supported by local backend
+func (c *LocalControlPlane) CreateAPIKey(ctx context.Context, name string) (*CreateAPIKeyResult, error) {
+	return nil, unsupportedError("API key management is not supported by local backend")
+}
+
+// ListAPIKeys is not supported by local backend
+func (c *LocalControlPlane) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	return nil, unsupportedError("API key management is not supported by local backend")
+}
+
+// DeleteAPIKey is not supported by local backend
+func (c *LocalControlPlane) DeleteAPIKey(ctx context.Context, keyID string) error {
+	return unsupportedError("API key management is not supported by local backend")
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/localsandbox"
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/proxy"
+)
+
+// newTestLocalControlPlane returns a local control plane keeping its
+// records where the data plane dialer looks them up.
+func newTestLocalControlPlane(t *testing.T) *LocalControlPlane {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	store, err := localsandbox.NewStoreAt(filepath.Join(home, ".ags", "local"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &LocalControlPlane{store: store, domain: "local.test"}
+}
+
+// TestLocalProxyAuthModeNone verifies that an instance created without
+// authentication can still be port-forwarded, which requires a token.
+func TestLocalProxyAuthModeNone(t *testing.T) {
+	c := newTestLocalControlPlane(t)
+	ctx := context.Background()
+
+	inst, err := c.newRecord(ctx, &CreateInstanceOptions{AuthMode: AuthModeNone})
+	if err != nil {
+		t.Fatalf("newRecord() error = %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(inst.Dir) })
+	if !inst.Open || inst.Token == "" {
+		t.Fatalf("record = %+v, want an open instance with a token", inst)
+	}
+	inst.PID = os.Getpid() // stands in for the daemon
+	if err := c.store.Save(inst); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInstance(ctx, inst.ID); err != nil || got.Secure {
+		t.Errorf("GetInstance() = %+v, %v; want an instance that is not secure", got, err)
+	}
+
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer app.Close()
+	appURL, _ := url.Parse(app.URL)
+	port, _ := strconv.Atoi(appURL.Port())
+
+	accessToken, err := c.AcquireToken(ctx, inst.ID)
+	if err != nil {
+		t.Fatalf("AcquireToken() error = %v", err)
+	}
+	p, err := proxy.New(proxy.Options{
+		InstanceID:     inst.ID,
+		Domain:         c.domain,
+		RemotePort:     port,
+		Token:          accessToken,
+		ListenAddress:  "127.0.0.1:0",
+		Logger:         log.New(io.Discard, "", 0),
+		DialTLSContext: localsandbox.DialTLSContext,
+	})
+	if err != nil {
+		t.Fatalf("proxy.New() error = %v", err)
+	}
+	addr, err := p.Start()
+	if err != nil {
+		t.Fatalf("proxy Start() error = %v", err)
+	}
+	defer p.Stop()
+
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatalf("request through proxy failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "hello" {
+		t.Errorf("body = %q, want hello", body)
+	}
+}
+
+func TestLocalListInstances(t *testing.T) {
+	c := newTestLocalControlPlane(t)
+	ctx := context.Background()
+	now := time.Now()
+	for i := range 3 {
+		inst := &localsandbox.Instance{
+			ID:        fmt.Sprintf("local-%d", i),
+			ToolID:    localTool.ID,
+			ToolName:  localTool.Name,
+			PID:       os.Getpid(),
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+			ExpiresAt: now.Add(time.Hour),
+		}
+		if err := c.store.Save(inst); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		opts  *ListInstancesOptions
+		ids   string
+		total int
+	}{
+		{"all", nil, "local-0,local-1,local-2", 3},
+		{"running", &ListInstancesOptions{Status: "RUNNING"}, "local-0,local-1,local-2", 3},
+		{"stopped", &ListInstancesOptions{Status: "STOPPED"}, "", 0},
+		{"tool", &ListInstancesOptions{ToolID: localTool.ID}, "local-0,local-1,local-2", 3},
+		{"other tool", &ListInstancesOptions{ToolID: "sdt-other"}, "", 0},
+		{"page", &ListInstancesOptions{Offset: 1, Limit: 1}, "local-1", 3},
+		{"offset past the end", &ListInstancesOptions{Offset: 5}, "", 3},
+		{"ids", &ListInstancesOptions{InstanceIDs: []string{"local-2", "local-0"}, Limit: 1}, "local-0,local-2", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := c.ListInstances(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("ListInstances() error = %v", err)
+			}
+			var ids []string
+			for _, inst := range result.Instances {
+				ids = append(ids, inst.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.ids || result.TotalCount != tt.total {
+				t.Errorf("ListInstances() = %s (total %d), want %s (total %d)", got, result.TotalCount, tt.ids, tt.total)
+			}
+		})
+	}
+
+	if _, err := c.ListInstances(ctx, &ListInstancesOptions{CreatedSince: "1h"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ListInstances(created since) error = %v, want unsupported", err)
+	}
+}
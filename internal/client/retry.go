@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/config"
+	tcerr "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+// RetryPolicy retries control plane requests that failed transiently:
+// rate limiting, server errors and network failures. Waits grow
+// exponentially from BaseDelay up to MaxDelay with jitter, and a
+// Retry-After hint from the server replaces the computed wait.
+//
+// A request is only retried when repeating it cannot apply the same change
+// twice: it was rejected before being processed (rate limiting, refused
+// connections), its method is idempotent, or it carries a client token.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Debugf, when set, is called before each retry
+	Debugf func(format string, args ...any)
+}
+
+// NewRetryPolicy builds a policy from the retry configuration. Retries are
+// logged to stderr when debug is enabled.
+func NewRetryPolicy(cfg config.RetryConfig, debug bool) RetryPolicy {
+	p := RetryPolicy{
+		MaxAttempts: cfg.MaxAttempts,
+		BaseDelay:   cfg.BaseDelay,
+		MaxDelay:    cfg.MaxDelay,
+	}
+	if debug {
+		p.Debugf = func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "Debug: "+format+"\n", args...)
+		}
+	}
+	return p
+}
+
+// defaultRetryPolicy returns the policy of the loaded configuration.
+func defaultRetryPolicy() RetryPolicy {
+	return NewRetryPolicy(config.GetRetryConfig(), config.GetDebug())
+}
+
+// errRetryableStatus marks an attempt that got a response with a retryable
+// status; the response itself is kept by the caller.
+var errRetryableStatus = errors.New("retryable response status")
+
+// retryDecision tells the retry loop whether a failed attempt may be repeated.
+type retryDecision struct {
+	retry  bool
+	after  time.Duration // server-provided wait, 0 if none
+	reason string
+}
+
+// do calls attempt until it succeeds, returns a non-retryable failure, the
+// attempts are exhausted or ctx is done, and returns the last error.
+func (p RetryPolicy) do(ctx context.Context, op string, attempt func() (retryDecision, error)) error {
+	maxAttempts := max(p.MaxAttempts, 1)
+	for n := 1; ; n++ {
+		decision, err := attempt()
+		if err == nil || !decision.retry || n >= maxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		wait := p.backoff(n)
+		if decision.after > 0 {
+			if p.MaxDelay > 0 && decision.after > p.MaxDelay {
+				p.debugf("%s: %s, not retrying: server asked to wait %s (retry.max_delay is %s)", op, decision.reason, decision.after, p.MaxDelay)
+				return err
+			}
+			wait = decision.after
+		}
+		p.debugf("%s: %s, retrying in %s (attempt %d/%d)", op, decision.reason, wait.Round(time.Millisecond), n+1, maxAttempts)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait before retry n (1-based): BaseDelay doubled for
+// each earlier retry, capped at MaxDelay, with up to half of it randomized so
+// that concurrent clients spread out.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := 1; i < n && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func (p RetryPolicy) debugf(format string, args ...any) {
+	if p.Debugf != nil {
+		p.Debugf(format, args...)
+	}
+}
+
+// isIdempotentMethod reports whether repeating an HTTP request has the same
+// effect as sending it once.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// httpRetryDecision decides whether an E2B API response is worth retrying.
+// 429 means the request was not processed, so any method may be repeated;
+// server errors only for idempotent methods.
+func httpRetryDecision(method string, resp *http.Response) retryDecision {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return retryDecision{retry: true, after: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), reason: resp.Status}
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return retryDecision{
+			retry:  isIdempotentMethod(method),
+			after:  parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			reason: resp.Status,
+		}
+	}
+	return retryDecision{}
+}
+
+// transportRetryDecision decides whether a failure to send a request is
+// worth retrying. A request that never reached the server (refused
+// connection, failed DNS lookup) is always safe to repeat; after a reset or
+// timeout the server may have acted on it, so only idempotent ones are.
+// An expired caller context is handled by the retry loop itself.
+func transportRetryDecision(method string, err error) retryDecision {
+	if errors.Is(err, context.Canceled) {
+		return retryDecision{}
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return retryDecision{retry: dnsErr.IsTemporary || dnsErr.IsTimeout, reason: "DNS lookup failed"}
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return retryDecision{retry: true, reason: "connection failed"}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return retryDecision{retry: isIdempotentMethod(method), reason: "connection error"}
+	}
+	return retryDecision{}
+}
+
+// cloudRetryDecision decides whether a Tencent Cloud API error is worth
+// retrying. Rate limiting rejects the request before it is processed; server
+// and network errors are only retried when the call is safe to repeat.
+func cloudRetryDecision(err error, safe bool) retryDecision {
+	var sdkErr *tcerr.TencentCloudSDKError
+	if !errors.As(err, &sdkErr) {
+		return retryDecision{}
+	}
+	prefix, _, _ := strings.Cut(sdkErr.Code, ".")
+	switch {
+	case prefix == "RequestLimitExceeded":
+		return retryDecision{retry: true, reason: sdkErr.Code}
+	case prefix == "InternalError", sdkErr.Code == "ClientError.NetworkError":
+		return retryDecision{retry: safe, reason: sdkErr.Code}
+	}
+	return retryDecision{}
+}
+
+// cloudCall runs a Tencent Cloud API call under the policy. safe marks calls
+// that can be repeated: queries, idempotent updates and deletes, and
+// requests carrying a client token.
+func (p RetryPolicy) cloudCall(ctx context.Context, action string, safe bool, call func() error) error {
+	return p.do(ctx, action, func() (retryDecision, error) {
+		err := call()
+		if err != nil {
+			return cloudRetryDecision(err, safe), err
+		}
+		return retryDecision{}, nil
+	})
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date. It returns 0 when the header is absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// newClientToken returns a random token that lets the cloud API recognize
+// a retried create request as the same request.
+func newClientToken() string {
+	b := make([]byte, 16)
+	_, _ = cryptorand.Read(b)
+	return hex.EncodeToString(b)
+}
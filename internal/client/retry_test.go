@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	tcerr "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		" 10 ":                          10 * time.Second,
+		"0":                             0,
+		"-5":                            0,
+		"soon":                          0,
+		"Fri, 01 May 2026 12:00:07 GMT": 7 * time.Second,
+		"Fri, 01 May 2026 11:59:00 GMT": 0,
+	}
+	for in, want := range tests {
+		if got := parseRetryAfter(in, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for n, ceiling := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		for range 20 {
+			if d := p.backoff(n); d < ceiling/2 || d > ceiling {
+				t.Fatalf("backoff(%d) = %s, want within [%s, %s]", n, d, ceiling/2, ceiling)
+			}
+		}
+	}
+	if d := (RetryPolicy{}).backoff(3); d != 0 {
+		t.Errorf("backoff without base delay = %s", d)
+	}
+}
+
+func TestCloudRetryDecision(t *testing.T) {
+	tests := []struct {
+		code string
+		safe bool
+		want bool
+	}{
+		{code: "RequestLimitExceeded", want: true},
+		{code: "RequestLimitExceeded.UinLimitExceeded", want: true},
+		{code: "InternalError", safe: true, want: true},
+		{code: "InternalError", want: false},
+		{code: "ClientError.NetworkError", safe: true, want: true},
+		{code: "ClientError.NetworkError", want: false},
+		{code: "ResourceNotFound.SandboxInstance", safe: true, want: false},
+		{code: "LimitExceeded", safe: true, want: false},
+	}
+	for _, tt := range tests {
+		got := cloudRetryDecision(tcerr.NewTencentCloudSDKError(tt.code, "msg", "req-1"), tt.safe)
+		if got.retry != tt.want {
+			t.Errorf("cloudRetryDecision(%s, safe=%v) retry = %v, want %v", tt.code, tt.safe, got.retry, tt.want)
+		}
+	}
+	if cloudRetryDecision(errors.New("boom"), true).retry {
+		t.Error("non-SDK errors should not be retried")
+	}
+}
+
+func TestTransportRetryDecision(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	if !transportRetryDecision(http.MethodPost, refused).retry {
+		t.Error("refused connection should be retried for POST")
+	}
+	if transportRetryDecision(http.MethodPost, reset).retry {
+		t.Error("reset connection should not be retried for POST")
+	}
+	if !transportRetryDecision(http.MethodGet, reset).retry {
+		t.Error("reset connection should be retried for GET")
+	}
+	if transportRetryDecision(http.MethodGet, context.Canceled).retry {
+		t.Error("canceled requests should not be retried")
+	}
+}
+
+// newRetryTestClient returns an E2B client whose transport replies with the
+// given statuses in turn, and a pointer to the number of requests sent.
+func newRetryTestClient(t *testing.T, header http.Header, statuses ...int) (*E2BControlPlane, *int) {
+	t.Helper()
+	calls := 0
+	c := &E2BControlPlane{
+		apiKey: "key",
+		domain: "example.com",
+		region: "ap-test",
+		retry:  RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+		httpClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Body != nil {
+				if body, _ := io.ReadAll(r.Body); string(body) != `{"templateID":"tpl"}` {
+					t.Errorf("attempt %d body = %q", calls+1, body)
+				}
+			}
+			status := statuses[min(calls, len(statuses)-1)]
+			calls++
+			return &http.Response{
+				StatusCode: status,
+				Status:     http.StatusText(status),
+				Body:       io.NopCloser(strings.NewReader("{}")),
+				Header:     header,
+			}, nil
+		})},
+	}
+	return c, &calls
+}
+
+func TestE2BDoRequestRetry(t *testing.T) {
+	ctx := context.Background()
+	body := map[string]string{"templateID": "tpl"}
+	url := "https://api.ap-test.example.com/sandboxes"
+
+	tests := []struct {
+		name       string
+		method     string
+		header     http.Header
+		statuses   []int
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "rate limited create is retried", method: http.MethodPost, statuses: []int{429, 201}, wantStatus: 201, wantCalls: 2},
+		{name: "server error on create is not retried", method: http.MethodPost, statuses: []int{503, 201}, wantStatus: 503, wantCalls: 1},
+		{name: "server error on list is retried", method: http.MethodGet, statuses: []int{502, 200}, wantStatus: 200, wantCalls: 2},
+		{name: "attempts are capped", method: http.MethodGet, statuses: []int{503}, wantStatus: 503, wantCalls: 3},
+		{name: "client errors are not retried", method: http.MethodGet, statuses: []int{404}, wantStatus: 404, wantCalls: 1},
+		{name: "retry-after above max delay gives up", method: http.MethodPost, header: http.Header{"Retry-After": {"60"}}, statuses: []int{429, 201}, wantStatus: 429, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, calls := newRetryTestClient(t, tt.header, tt.statuses...)
+			var reqBody any
+			if tt.method == http.MethodPost {
+				reqBody = body
+			}
+			resp, err := c.doRequest(ctx, tt.method, url, reqBody)
+			if err != nil {
+				t.Fatalf("doRequest() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus || *calls != tt.wantCalls {
+				t.Errorf("doRequest() status = %d after %d calls, want %d after %d", resp.StatusCode, *calls, tt.wantStatus, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDebugf(t *testing.T) {
+	var logs []string
+	p := RetryPolicy{MaxAttempts: 2, Debugf: func(format string, args ...any) {
+		logs = append(logs, format)
+	}}
+	attempts := 0
+	err := p.do(context.Background(), "GET /sandboxes", func() (retryDecision, error) {
+		attempts++
+		return retryDecision{retry: true, reason: "429 Too Many Requests"}, errors.New("rate limited")
+	})
+	if err == nil || attempts != 2 || len(logs) != 1 {
+		t.Errorf("do() err = %v, attempts = %d, logs = %v", err, attempts, logs)
+	}
+}
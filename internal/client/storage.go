@@ -2,17 +2,21 @@ package client
 
 import (
 	"fmt"
+	"path"
 	"strings"
 )
 
 // ParseStorageMount parses --mount parameter string into StorageMount
-// Format: "type=cos,name=<name>,bucket=<bucket>,src=<source-path>,dst=<target-path>[,readonly][,endpoint=<endpoint>]"
+// Format:
+//
+//	"type=cos,name=<name>,bucket=<bucket>,src=<source-path>,dst=<target-path>[,readonly][,endpoint=<endpoint>]"
+//	"type=cfs,name=<name>,fs=<file-system-id>,target=<mount-target>,dst=<target-path>[,src=<source-path>][,readonly]"
 func ParseStorageMount(s string) (*StorageMount, error) {
 	params := parseKeyValuePairs(s)
 
 	storageType := params["type"]
 	if storageType == "" {
-		return nil, fmt.Errorf("type is required (currently supported: cos)")
+		return nil, fmt.Errorf("type is required (supported: cos, cfs)")
 	}
 
 	mount := &StorageMount{
@@ -36,42 +40,37 @@ func ParseStorageMount(s string) (*StorageMount, error) {
 	// Parse type-specific configuration
 	switch StorageType(storageType) {
 	case StorageTypeCos:
-		cos, err := parseCosSource(params)
-		if err != nil {
-			return nil, fmt.Errorf("cos config error: %w", err)
+		mount.StorageSource.Cos = &CosStorageSource{
+			BucketName: params["bucket"],
+			BucketPath: params["src"],
+			Endpoint:   params["endpoint"],
+		}
+
+	case StorageTypeCfs:
+		// The whole file system is mounted unless src selects a directory
+		path := params["src"]
+		if path == "" {
+			path = "/"
+		}
+		mount.StorageSource.Cfs = &CfsStorageSource{
+			FileSystemID: params["fs"],
+			MountTarget:  params["target"],
+			Path:         path,
 		}
-		mount.StorageSource.Cos = cos
 
 	default:
-		return nil, fmt.Errorf("unsupported storage type: %s (currently supported: cos)", storageType)
+		return nil, fmt.Errorf("unsupported storage type: %s (supported: cos, cfs)", storageType)
 	}
 
-	return mount, nil
-}
-
-// parseCosSource parses COS configuration from parameters
-func parseCosSource(params map[string]string) (*CosStorageSource, error) {
-	cos := &CosStorageSource{
-		BucketName: params["bucket"],
-		BucketPath: params["src"],
-		Endpoint:   params["endpoint"],
+	if err := mount.StorageSource.Validate(); err != nil {
+		return nil, err
 	}
 
-	if cos.BucketName == "" {
-		return nil, fmt.Errorf("bucket is required")
-	}
-	if cos.BucketPath == "" {
-		return nil, fmt.Errorf("src is required")
-	}
-	if !strings.HasPrefix(cos.BucketPath, "/") {
-		return nil, fmt.Errorf("src must be absolute path (start with /)")
-	}
-
-	return cos, nil
+	return mount, nil
 }
 
 // ParseMountOption parses --mount-option parameter string into MountOption
-// Format: "name=<name>[,dst=<target-path>][,subpath=<sub-path>][,readonly]"
+// Format: "name=<name>[,dst=<target-path>][,subpath=<sub-path>][,readonly[=true|false]]"
 func ParseMountOption(s string) (*MountOption, error) {
 	params := parseKeyValuePairs(s)
 
@@ -81,9 +80,18 @@ func ParseMountOption(s string) (*MountOption, error) {
 		SubPath:   params["subpath"],
 	}
 
-	// Handle readonly flag
-	if params["readonly"] != "" {
-		readOnly := true
+	// Handle readonly flag; readonly=false is kept so that loosening a
+	// read-only mount can be reported instead of silently ignored
+	if v, ok := params["readonly"]; ok {
+		var readOnly bool
+		switch strings.ToLower(v) {
+		case "true":
+			readOnly = true
+		case "false":
+			readOnly = false
+		default:
+			return nil, fmt.Errorf("invalid readonly value: %s (expected true or false)", v)
+		}
 		opt.ReadOnly = &readOnly
 	}
 
@@ -99,6 +107,82 @@ func ParseMountOption(s string) (*MountOption, error) {
 	return opt, nil
 }
 
+// EffectiveMount is a tool storage mount with the instance mount options applied
+type EffectiveMount struct {
+	Name       string `json:"name"`
+	Source     string `json:"source"`
+	MountPath  string `json:"mount_path"`
+	SubPath    string `json:"sub_path,omitempty"`
+	ReadOnly   bool   `json:"read_only"`
+	Overridden bool   `json:"overridden"`
+}
+
+// MergeMountOptions validates instance mount options against the tool's
+// storage mounts and returns the effective mounts. Every option must name a
+// mount of the tool, a mount may only be overridden once, subpath must stay
+// inside the source, read-only mounts cannot be made writable, and the
+// resulting mount paths must not collide.
+func MergeMountOptions(mounts []StorageMount, opts []MountOption) ([]EffectiveMount, error) {
+	index := make(map[string]int, len(mounts))
+	names := make([]string, len(mounts))
+	for i, m := range mounts {
+		index[m.Name] = i
+		names[i] = m.Name
+	}
+
+	effective := make([]EffectiveMount, len(mounts))
+	for i, m := range mounts {
+		source := "-"
+		if m.StorageSource != nil {
+			source = m.StorageSource.Summary()
+		}
+		effective[i] = EffectiveMount{Name: m.Name, Source: source, MountPath: m.MountPath, ReadOnly: m.ReadOnly}
+	}
+
+	for _, opt := range opts {
+		i, ok := index[opt.Name]
+		if !ok {
+			if len(mounts) == 0 {
+				return nil, fmt.Errorf("unknown mount %q: the tool has no storage mounts", opt.Name)
+			}
+			return nil, fmt.Errorf("unknown mount %q (valid mounts: %s)", opt.Name, strings.Join(names, ", "))
+		}
+		e := &effective[i]
+		if e.Overridden {
+			return nil, fmt.Errorf("mount %q is specified more than once", opt.Name)
+		}
+		e.Overridden = true
+
+		if opt.MountPath != "" {
+			e.MountPath = opt.MountPath
+		}
+		if opt.SubPath != "" {
+			clean := path.Clean(opt.SubPath)
+			if path.IsAbs(opt.SubPath) || clean == ".." || strings.HasPrefix(clean, "../") {
+				return nil, fmt.Errorf("subpath of mount %q must be a relative path inside the source: %s", opt.Name, opt.SubPath)
+			}
+			e.SubPath = clean
+		}
+		if opt.ReadOnly != nil {
+			if e.ReadOnly && !*opt.ReadOnly {
+				return nil, fmt.Errorf("mount %q is read-only in the tool and cannot be made writable (mount options can only tighten permissions)", opt.Name)
+			}
+			e.ReadOnly = *opt.ReadOnly
+		}
+	}
+
+	paths := make(map[string]string, len(effective))
+	for _, e := range effective {
+		p := path.Clean(e.MountPath)
+		if other, ok := paths[p]; ok {
+			return nil, fmt.Errorf("mounts %q and %q both mount at %s", other, e.Name, p)
+		}
+		paths[p] = e.Name
+	}
+
+	return effective, nil
+}
+
 // parseKeyValuePairs parses "key=value,key2=value2" format string
 func parseKeyValuePairs(s string) map[string]string {
 	result := make(map[string]string)
@@ -131,18 +215,25 @@ func FormatStorageMountHelp() string {
 COS storage format:
   type=cos,name=<name>,bucket=<bucket>,src=<source-path>,dst=<target-path>[,readonly][,endpoint=<endpoint>]
 
+CFS storage format:
+  type=cfs,name=<name>,fs=<file-system-id>,target=<mount-target>,dst=<target-path>[,src=<source-path>][,readonly]
+
 Parameters:
-  type      Storage type (required): cos
+  type      Storage type (required): cos, cfs
   name      Mount name, DNS-1123 format (required)
   bucket    COS bucket name (required for cos)
-  src       Source path in bucket, must start with / (required for cos)
+  src       Source path in bucket or file system, must start with /
+            (required for cos, defaults to / for cfs)
+  fs        CFS file system ID (required for cfs)
+  target    CFS mount target address, reachable from the tool's network (required for cfs)
   dst       Target mount path in container, must start with / (required)
   readonly  Mount as read-only (optional flag)
   endpoint  COS endpoint (optional, defaults to current region)
 
 Examples:
   --mount "type=cos,name=data,bucket=my-bucket-1250000000,src=/data,dst=/mnt/data"
-  --mount "type=cos,name=models,bucket=model-bucket,src=/models,dst=/mnt/models,readonly"`
+  --mount "type=cos,name=models,bucket=model-bucket,src=/models,dst=/mnt/models,readonly"
+  --mount "type=cfs,name=datasets,fs=cfs-a1b2c3d4,target=10.0.1.12,src=/datasets,dst=/mnt/datasets,readonly"`
 }
 
 // FormatMountOptionHelp returns help text for --mount-option parameter
@@ -155,9 +246,12 @@ Format:
 Parameters:
   name      Storage mount name defined in tool (required)
   dst       Override target mount path in container (optional)
-  subpath   Sub-directory isolation path (optional)
+  subpath   Sub-directory isolation path, relative to the mount source (optional)
   readonly  Force read-only mount (optional, can only tighten permissions)
 
+Options are checked against the tool's storage mounts before the instance
+is created.
+
 Examples:
   --mount-option "name=data,dst=/workspace,subpath=user-123"
   --mount-option "name=models,readonly"`
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStorageMount(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    StorageSource
+		wantErr string
+	}{
+		{
+			name:  "cos",
+			value: "type=cos,name=data,bucket=b-125,src=/data,dst=/mnt/data,endpoint=cos.ap-guangzhou.myqcloud.com",
+			want:  StorageSource{Cos: &CosStorageSource{BucketName: "b-125", BucketPath: "/data", Endpoint: "cos.ap-guangzhou.myqcloud.com"}},
+		},
+		{
+			name:  "cfs",
+			value: "type=cfs,name=ds,fs=cfs-a1b2c3d4,target=10.0.1.12,src=/datasets,dst=/mnt/ds,readonly",
+			want:  StorageSource{Cfs: &CfsStorageSource{FileSystemID: "cfs-a1b2c3d4", MountTarget: "10.0.1.12", Path: "/datasets"}},
+		},
+		{
+			name:  "cfs root",
+			value: "type=cfs,name=ds,fs=cfs-a1b2c3d4,target=10.0.1.12,dst=/mnt/ds",
+			want:  StorageSource{Cfs: &CfsStorageSource{FileSystemID: "cfs-a1b2c3d4", MountTarget: "10.0.1.12", Path: "/"}},
+		},
+		{name: "no type", value: "name=d,dst=/d", wantErr: "type is required"},
+		{name: "unknown type", value: "type=nfs,name=d,dst=/d", wantErr: "unsupported storage type"},
+		{name: "cos without bucket", value: "type=cos,name=d,src=/,dst=/d", wantErr: "bucket is required"},
+		{name: "cfs without fs", value: "type=cfs,name=d,target=10.0.0.1,dst=/d", wantErr: "fs is required"},
+		{name: "cfs without target", value: "type=cfs,name=d,fs=cfs-1,dst=/d", wantErr: "target is required"},
+		{name: "cfs relative src", value: "type=cfs,name=d,fs=cfs-1,target=10.0.0.1,src=data,dst=/d", wantErr: "cfs config error: src must be absolute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStorageMount(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseStorageMount() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseStorageMount() unexpected error: %v", err)
+			}
+			if got.StorageSource.Summary() != tt.want.Summary() || got.StorageSource.GetType() != tt.want.GetType() {
+				t.Errorf("StorageSource = %s, want %s", got.StorageSource.Summary(), tt.want.Summary())
+			}
+			if tt.want.Cfs != nil && *got.StorageSource.Cfs != *tt.want.Cfs {
+				t.Errorf("Cfs = %+v, want %+v", got.StorageSource.Cfs, tt.want.Cfs)
+			}
+			if tt.want.Cos != nil && *got.StorageSource.Cos != *tt.want.Cos {
+				t.Errorf("Cos = %+v, want %+v", got.StorageSource.Cos, tt.want.Cos)
+			}
+		})
+	}
+}
+
+func TestFormatStorageMountSummary(t *testing.T) {
+	mounts := []StorageMount{
+		{Name: "data", StorageSource: &StorageSource{Cos: &CosStorageSource{BucketName: "b"}}},
+		{Name: "ds", StorageSource: &StorageSource{Cfs: &CfsStorageSource{FileSystemID: "cfs-1"}}},
+	}
+	if got, want := FormatStorageMountSummary(mounts), "data(cos), ds(cfs)"; got != want {
+		t.Errorf("FormatStorageMountSummary() = %q, want %q", got, want)
+	}
+}
+
+func TestParseMountOptionReadOnly(t *testing.T) {
+	for value, want := range map[string]*bool{
+		"name=data":                nil,
+		"name=data,readonly":       boolPtr(true),
+		"name=data,readonly=true":  boolPtr(true),
+		"name=data,readonly=false": boolPtr(false),
+	} {
+		opt, err := ParseMountOption(value)
+		if err != nil {
+			t.Fatalf("ParseMountOption(%q) unexpected error: %v", value, err)
+		}
+		if (opt.ReadOnly == nil) != (want == nil) || (want != nil && *opt.ReadOnly != *want) {
+			t.Errorf("ParseMountOption(%q).ReadOnly = %v, want %v", value, opt.ReadOnly, want)
+		}
+	}
+	if _, err := ParseMountOption("name=data,readonly=yes"); err == nil {
+		t.Error("ParseMountOption(readonly=yes) expected error")
+	}
+}
+
+func TestMergeMountOptions(t *testing.T) {
+	mounts := []StorageMount{
+		{Name: "data", MountPath: "/mnt/data", StorageSource: &StorageSource{Cos: &CosStorageSource{BucketName: "b", BucketPath: "/data"}}},
+		{Name: "models", MountPath: "/mnt/models", ReadOnly: true, StorageSource: &StorageSource{Cfs: &CfsStorageSource{FileSystemID: "cfs-1", Path: "/"}}},
+	}
+
+	got, err := MergeMountOptions(mounts, []MountOption{
+		{Name: "data", MountPath: "/workspace", SubPath: "user-1/", ReadOnly: boolPtr(true)},
+		{Name: "models", ReadOnly: boolPtr(true)},
+	})
+	if err != nil {
+		t.Fatalf("MergeMountOptions() unexpected error: %v", err)
+	}
+	want := []EffectiveMount{
+		{Name: "data", Source: "cos://b/data", MountPath: "/workspace", SubPath: "user-1", ReadOnly: true, Overridden: true},
+		{Name: "models", Source: "cfs://cfs-1/", MountPath: "/mnt/models", ReadOnly: true, Overridden: true},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("mount %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mounts  []StorageMount
+		opts    []MountOption
+		wantErr string
+	}{
+		{name: "unknown name", mounts: mounts, opts: []MountOption{{Name: "logs"}}, wantErr: "valid mounts: data, models"},
+		{name: "no mounts", opts: []MountOption{{Name: "data"}}, wantErr: "no storage mounts"},
+		{name: "duplicate", mounts: mounts, opts: []MountOption{{Name: "data"}, {Name: "data"}}, wantErr: "more than once"},
+		{name: "loosen read-only", mounts: mounts, opts: []MountOption{{Name: "models", ReadOnly: boolPtr(false)}}, wantErr: "cannot be made writable"},
+		{name: "absolute subpath", mounts: mounts, opts: []MountOption{{Name: "data", SubPath: "/etc"}}, wantErr: "relative path"},
+		{name: "escaping subpath", mounts: mounts, opts: []MountOption{{Name: "data", SubPath: "a/../../b"}}, wantErr: "relative path"},
+		{name: "path collision", mounts: mounts, opts: []MountOption{{Name: "data", MountPath: "/mnt/models/"}}, wantErr: "both mount at /mnt/models"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MergeMountOptions(tt.mounts, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("MergeMountOptions() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -15,7 +15,7 @@ type StorageType string
 
 const (
 	StorageTypeCos StorageType = "cos"
-	// StorageTypeCfs StorageType = "cfs" // Reserved for future CFS support
+	StorageTypeCfs StorageType = "cfs"
 )
 
 // StorageMount represents storage mount configuration at tool level
@@ -26,10 +26,10 @@ type StorageMount struct {
 	ReadOnly      bool           `json:"read_only"`      // Default read-only permission
 }
 
-// StorageSource represents storage source configuration (COS or future CFS)
+// StorageSource represents storage source configuration (COS or CFS)
 type StorageSource struct {
 	Cos *CosStorageSource `json:"cos,omitempty"` // COS object storage
-	// Cfs *CfsStorageSource `json:"cfs,omitempty"` // Reserved for future CFS support
+	Cfs *CfsStorageSource `json:"cfs,omitempty"` // CFS file system
 }
 
 // CosStorageSource represents COS storage source configuration
@@ -39,22 +39,93 @@ type CosStorageSource struct {
 	BucketPath string `json:"bucket_path"`        // Path in bucket, must start with /
 }
 
+// CfsStorageSource represents CFS storage source configuration
+type CfsStorageSource struct {
+	FileSystemID string `json:"file_system_id"` // CFS file system ID, e.g. cfs-xxxxxxxx
+	MountTarget  string `json:"mount_target"`   // Mount target address, reachable from the tool's network
+	Path         string `json:"path"`           // Path in file system, must start with /
+}
+
 // GetType returns the storage source type
 func (s *StorageSource) GetType() StorageType {
 	if s.Cos != nil {
 		return StorageTypeCos
 	}
+	if s.Cfs != nil {
+		return StorageTypeCfs
+	}
 	return ""
 }
 
 // Validate validates the storage source configuration
 func (s *StorageSource) Validate() error {
-	if s.Cos == nil {
-		return fmt.Errorf("storage source must specify cos configuration")
+	switch {
+	case s.Cos != nil && s.Cfs != nil:
+		return fmt.Errorf("storage source must specify only one of cos or cfs configuration")
+	case s.Cos != nil:
+		if err := s.Cos.Validate(); err != nil {
+			return fmt.Errorf("cos config error: %w", err)
+		}
+	case s.Cfs != nil:
+		if err := s.Cfs.Validate(); err != nil {
+			return fmt.Errorf("cfs config error: %w", err)
+		}
+	default:
+		return fmt.Errorf("storage source must specify cos or cfs configuration")
+	}
+	return nil
+}
+
+// Validate validates the COS storage source configuration
+func (c *CosStorageSource) Validate() error {
+	if c.BucketName == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if c.BucketPath == "" {
+		return fmt.Errorf("src is required")
+	}
+	if !strings.HasPrefix(c.BucketPath, "/") {
+		return fmt.Errorf("src must be absolute path (start with /)")
 	}
 	return nil
 }
 
+// Validate validates the CFS storage source configuration
+func (c *CfsStorageSource) Validate() error {
+	if c.FileSystemID == "" {
+		return fmt.Errorf("fs is required")
+	}
+	if c.MountTarget == "" {
+		return fmt.Errorf("target is required")
+	}
+	if !strings.HasPrefix(c.Path, "/") {
+		return fmt.Errorf("src must be absolute path (start with /)")
+	}
+	return nil
+}
+
+// Summary returns the storage source as a URI-like string, e.g.
+// cos://bucket/path or cfs://cfs-xxx/path
+func (s *StorageSource) Summary() string {
+	switch {
+	case s.Cos != nil:
+		return fmt.Sprintf("cos://%s%s", s.Cos.BucketName, s.Cos.BucketPath)
+	case s.Cfs != nil:
+		return fmt.Sprintf("cfs://%s%s", s.Cfs.FileSystemID, s.Cfs.Path)
+	}
+	return "-"
+}
+
+// HasCosMount reports whether any of the mounts uses COS storage
+func HasCosMount(mounts []StorageMount) bool {
+	for _, m := range mounts {
+		if m.StorageSource != nil && m.StorageSource.Cos != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // MountOption represents mount option at instance level (override tool defaults)
 type MountOption struct {
 	Name      string `json:"name"`                 // Match StorageMount name in tool
@@ -107,22 +178,78 @@ func FormatVPCConfigSummary(vpc *VPCConfig) (subnets, secGroups string) {
 	return
 }
 
+// ============================================================================
+// Custom Image Types
+// ============================================================================
+
+// CustomConfig describes the container image run by a custom tool
+type CustomConfig struct {
+	Image        string          `json:"image"`                   // Image address, e.g. ccr.ccs.tencentyun.com/ns/repo:tag
+	RegistryType string          `json:"registry_type,omitempty"` // Image registry type: personal, enterprise (TCR)
+	Command      []string        `json:"command,omitempty"`       // Entrypoint override
+	Args         []string        `json:"args,omitempty"`          // Arguments passed to the entrypoint
+	Env          []EnvVar        `json:"env,omitempty"`           // Environment variables
+	Ports        []PortConfig    `json:"ports,omitempty"`         // Ports exposed by the container
+	Resources    *ResourceConfig `json:"resources,omitempty"`     // CPU and memory per instance
+	Probe        *ProbeConfig    `json:"probe,omitempty"`         // Readiness probe
+}
+
+// EnvVar is an environment variable set in the container
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PortConfig is a port exposed by the container
+type PortConfig struct {
+	Name     string `json:"name,omitempty"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol,omitempty"` // TCP (default), UDP
+}
+
+// ResourceConfig is the CPU and memory allocated to each instance
+type ResourceConfig struct {
+	CPU    string `json:"cpu,omitempty"`    // e.g. "2"
+	Memory string `json:"memory,omitempty"` // e.g. "4Gi"
+}
+
+// ProbeConfig is an HTTP readiness probe. Zero values use the platform defaults.
+type ProbeConfig struct {
+	Path                string `json:"path"`
+	Port                int    `json:"port"`
+	Scheme              string `json:"scheme,omitempty"` // HTTP (default), HTTPS
+	InitialDelaySeconds int    `json:"initial_delay_seconds,omitempty"`
+	PeriodSeconds       int    `json:"period_seconds,omitempty"`
+	TimeoutSeconds      int    `json:"timeout_seconds,omitempty"`
+	SuccessThreshold    int    `json:"success_threshold,omitempty"`
+	FailureThreshold    int    `json:"failure_threshold,omitempty"`
+}
+
 // ============================================================================
 // Tool Types
 // ============================================================================
 
+// ValidToolTypes lists the tool types accepted by CreateTool.
+var ValidToolTypes = []string{"code-interpreter", "browser", "mobile", "osworld", "custom", "swebench"}
+
+// ValidNetworkModes lists the tool network modes. VPC can only be set at
+// creation time and a VPC tool cannot switch to another mode.
+var ValidNetworkModes = []string{"PUBLIC", "VPC", "SANDBOX", "INTERNAL_SERVICE"}
+
 // Tool represents a sandbox tool type
 type Tool struct {
-	ID            string            `json:"id"`
-	Name          string            `json:"name"`
-	Description   string            `json:"description"`
-	Type          string            `json:"type"`                   // code-interpreter, browser, mobile, osworld, custom, swebench
-	NetworkMode   string            `json:"network_mode,omitempty"` // PUBLIC, VPC, SANDBOX, INTERNAL_SERVICE
-	VPCConfig     *VPCConfig        `json:"vpc_config,omitempty"`   // VPC configuration (only when NetworkMode=VPC)
-	Tags          map[string]string `json:"tags,omitempty"`
-	RoleArn       string            `json:"role_arn,omitempty"`       // Role ARN for storage access
-	StorageMounts []StorageMount    `json:"storage_mounts,omitempty"` // Storage mount configurations
-	CreatedAt     string            `json:"created_at,omitempty"`     // Creation time (ISO8601)
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	Type           string            `json:"type"`                      // code-interpreter, browser, mobile, osworld, custom, swebench
+	DefaultTimeout string            `json:"default_timeout,omitempty"` // Default instance timeout, e.g. "5m"
+	NetworkMode    string            `json:"network_mode,omitempty"`    // PUBLIC, VPC, SANDBOX, INTERNAL_SERVICE
+	VPCConfig      *VPCConfig        `json:"vpc_config,omitempty"`      // VPC configuration (only when NetworkMode=VPC)
+	Tags           map[string]string `json:"tags,omitempty"`
+	RoleArn        string            `json:"role_arn,omitempty"`       // Role ARN for storage access
+	StorageMounts  []StorageMount    `json:"storage_mounts,omitempty"` // Storage mount configurations
+	CustomConfig   *CustomConfig     `json:"custom_config,omitempty"`  // Container configuration (custom tools only)
+	CreatedAt      string            `json:"created_at,omitempty"`     // Creation time (ISO8601)
 }
 
 // CreateToolOptions represents options for creating a tool
@@ -136,6 +263,7 @@ type CreateToolOptions struct {
 	Tags           map[string]string // Tags (optional)
 	RoleArn        string            // Role ARN for COS access (required when StorageMounts is set)
 	StorageMounts  []StorageMount    // Storage mount configurations (optional)
+	CustomConfig   *CustomConfig     // Container configuration (optional, custom tools only)
 }
 
 // UpdateToolOptions represents options for updating a tool
@@ -264,6 +392,23 @@ type ListInstancesResult struct {
 	TotalCount int        // Total count of instances matching the filter
 }
 
+// ErrMetricsUnavailable is returned by GetInstanceMetrics when the backend
+// cannot report resource usage; callers may sample the sandbox directly instead.
+// It is an unsupported-kind error, so errors.Is(err, ErrUnsupported) also matches.
+var ErrMetricsUnavailable = &Error{Kind: KindUnsupported, Message: "instance metrics are not available from the control plane"}
+
+// InstanceMetrics represents a resource usage sample of a sandbox instance
+type InstanceMetrics struct {
+	Timestamp  string  `json:"timestamp"`
+	CPUCount   int     `json:"cpu_count"`
+	CPUUsedPct float64 `json:"cpu_used_pct"`
+	MemUsed    int64   `json:"mem_used"`   // bytes
+	MemTotal   int64   `json:"mem_total"`  // bytes
+	DiskUsed   int64   `json:"disk_used"`  // bytes, 0 if unknown
+	DiskTotal  int64   `json:"disk_total"` // bytes, 0 if unknown
+	Source     string  `json:"source"`     // "api" (control plane) or "proc" (sampled in sandbox)
+}
+
 // ============================================================================
 // API Key Types
 // ============================================================================
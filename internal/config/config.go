@@ -4,21 +4,27 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config represents the CLI configuration
 type Config struct {
+	Profile  string        `mapstructure:"profile"` // Name of the [profiles.<name>] section applied on top of the top-level fields
 	Backend  string        `mapstructure:"backend"`
 	Output   string        `mapstructure:"output"`
 	Region   string        `mapstructure:"region"`   // Unified region for both control plane and data plane
 	Domain   string        `mapstructure:"domain"`   // Unified base domain (normalized: includes "internal." prefix when internal mode is enabled)
 	Internal bool          `mapstructure:"internal"` // Use internal endpoints for both control plane and data plane
+	Debug    bool          `mapstructure:"debug"`    // Print diagnostic messages (such as retries) to stderr
 	E2B      E2BConfig     `mapstructure:"e2b"`
 	Cloud    CloudConfig   `mapstructure:"cloud"`
 	Sandbox  SandboxConfig `mapstructure:"sandbox"`
+	Retry    RetryConfig   `mapstructure:"retry"`
+	Tokens   TokensConfig  `mapstructure:"tokens"`
 }
 
 // E2BConfig represents E2B API configuration
@@ -34,6 +40,11 @@ type E2BConfig struct {
 type CloudConfig struct {
 	SecretID  string `mapstructure:"secret_id"`
 	SecretKey string `mapstructure:"secret_key"`
+	Token     string `mapstructure:"token"` // Session token of temporary credentials
+	// CredentialSource selects a single credential provider; see CredentialSources
+	CredentialSource  string `mapstructure:"credential_source"`
+	CredentialProcess string `mapstructure:"credential_process"` // Command printing credentials as JSON
+	CVMRole           string `mapstructure:"cvm_role"`           // CAM role name for the cvm_role source; discovered when empty
 	// Deprecated: Use top-level "region" instead. Will be removed in a future version.
 	Region string `mapstructure:"region"`
 	// Deprecated: Use top-level "internal" instead. Will be removed in a future version.
@@ -45,9 +56,28 @@ type SandboxConfig struct {
 	DefaultUser string `mapstructure:"default_user"`
 }
 
+// TokensConfig controls the cache of instance access tokens (~/.ags/tokens.json)
+type TokensConfig struct {
+	TTL     time.Duration `mapstructure:"ttl"`     // Age after which `ags token prune` removes a token; 0 keeps tokens of live instances forever
+	Encrypt bool          `mapstructure:"encrypt"` // Encrypt tokens with a key held in the OS keyring
+}
+
+// RetryConfig controls how failed control plane requests are retried
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"` // Total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration `mapstructure:"base_delay"`   // Backoff before the first retry, doubled on each further retry
+	MaxDelay    time.Duration `mapstructure:"max_delay"`    // Upper bound for a single backoff or Retry-After wait
+}
+
 const (
 	defaultRegion = "ap-guangzhou"
 	defaultDomain = "tencentags.com"
+
+	defaultRetryMaxAttempts = 4
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 20 * time.Second
+
+	defaultTokenTTL = 7 * 24 * time.Hour
 )
 
 // ControlPlaneEndpoint returns the control plane API endpoint for cloud backend.
@@ -81,9 +111,21 @@ func (c *Config) E2BControlPlaneEndpoint() string {
 	return fmt.Sprintf("https://api.%s.%s", c.Region, c.Domain)
 }
 
+// DefaultProfile is the name reported when no profile is selected.
+const DefaultProfile = "default"
+
 var (
-	cfg     *Config
-	cfgFile string
+	cfg        *Config
+	cfgFile    string
+	cfgProfile string
+	// profileErr is set when the selected profile could not be applied; it is
+	// reported by Validate and by every client constructor so commands never
+	// silently fall back to the top-level account.
+	profileErr error
+	// baseBackend and baseRegion are the values in effect before a profile is
+	// applied, shown for profiles that do not override them.
+	baseBackend string
+	baseRegion  string
 )
 
 // SetConfigFile sets the config file path
@@ -91,6 +133,14 @@ func SetConfigFile(path string) {
 	cfgFile = path
 }
 
+// SetProfile selects a profile (for command line override). It must be
+// called before Init and takes precedence over AGS_PROFILE and the
+// top-level "profile" field.
+func SetProfile(name string) {
+	cfgProfile = name
+	markFlag("profile")
+}
+
 // Init initializes the configuration
 func Init() error {
 	viper.SetConfigType("toml")
@@ -101,6 +151,12 @@ func Init() error {
 	viper.SetDefault("region", "")
 	viper.SetDefault("domain", "")
 	viper.SetDefault("internal", false)
+	viper.SetDefault("debug", false)
+	viper.SetDefault("retry.max_attempts", defaultRetryMaxAttempts)
+	viper.SetDefault("retry.base_delay", defaultRetryBaseDelay)
+	viper.SetDefault("retry.max_delay", defaultRetryMaxDelay)
+	viper.SetDefault("tokens.ttl", defaultTokenTTL)
+	viper.SetDefault("tokens.encrypt", false)
 
 	// Legacy defaults for backward compatibility
 	viper.SetDefault("e2b.domain", "")
@@ -126,11 +182,15 @@ func Init() error {
 	viper.AutomaticEnv()
 
 	// Bind specific environment variables - top-level unified fields
+	_ = viper.BindEnv("profile", "AGS_PROFILE")
 	_ = viper.BindEnv("backend", "AGS_BACKEND")
 	_ = viper.BindEnv("output", "AGS_OUTPUT")
 	_ = viper.BindEnv("region", "AGS_REGION")
 	_ = viper.BindEnv("domain", "AGS_DOMAIN")
 	_ = viper.BindEnv("internal", "AGS_INTERNAL")
+	_ = viper.BindEnv("debug", "AGS_DEBUG")
+	_ = viper.BindEnv("retry.max_attempts", "AGS_RETRY_MAX_ATTEMPTS")
+	_ = viper.BindEnv("tokens.encrypt", "AGS_TOKENS_ENCRYPT")
 
 	// Legacy environment variable bindings (for backward compatibility)
 	_ = viper.BindEnv("e2b.api_key", "AGS_E2B_API_KEY")
@@ -138,6 +198,9 @@ func Init() error {
 	_ = viper.BindEnv("e2b.region", "AGS_E2B_REGION")
 	_ = viper.BindEnv("cloud.secret_id", "AGS_CLOUD_SECRET_ID")
 	_ = viper.BindEnv("cloud.secret_key", "AGS_CLOUD_SECRET_KEY")
+	_ = viper.BindEnv("cloud.token", "AGS_CLOUD_TOKEN")
+	_ = viper.BindEnv("cloud.credential_source", "AGS_CLOUD_CREDENTIAL_SOURCE")
+	_ = viper.BindEnv("cloud.credential_process", "AGS_CLOUD_CREDENTIAL_PROCESS")
 	_ = viper.BindEnv("cloud.region", "AGS_CLOUD_REGION")
 	_ = viper.BindEnv("cloud.internal", "AGS_CLOUD_INTERNAL")
 	_ = viper.BindEnv("sandbox.default_user", "AGS_SANDBOX_DEFAULT_USER")
@@ -149,6 +212,9 @@ func Init() error {
 		}
 	}
 
+	profileErr = applyProfile()
+	resetCloudCredential()
+
 	cfg = &Config{}
 	if err := viper.Unmarshal(cfg); err != nil {
 		return fmt.Errorf("failed to unmarshal config: %w", err)
@@ -160,6 +226,78 @@ func Init() error {
 	return nil
 }
 
+// applyProfile merges the selected [profiles.<name>] section over the
+// top-level fields of the config file. Environment variables and flags still
+// take precedence over profile values.
+func applyProfile() error {
+	baseBackend = viper.GetString("backend")
+	baseRegion = viper.GetString("region")
+	if baseRegion == "" {
+		baseRegion = defaultRegion
+	}
+
+	name := cfgProfile
+	if name == "" {
+		name = viper.GetString("profile")
+	}
+	if name == "" || name == DefaultProfile {
+		viper.Set("profile", "")
+		return nil
+	}
+	viper.Set("profile", name)
+
+	profiles := viper.GetStringMap("profiles")
+	section, ok := profiles[strings.ToLower(name)].(map[string]any)
+	if !ok {
+		if len(profiles) == 0 {
+			return fmt.Errorf("profile %q not found: no [profiles.<name>] sections in config file", name)
+		}
+		return fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	if _, nested := section["profiles"]; nested {
+		return fmt.Errorf("profile %q must not contain nested profiles", name)
+	}
+	return viper.MergeConfigMap(section)
+}
+
+// ProfileNames returns the names of the [profiles.<name>] sections in the
+// config file, sorted.
+func ProfileNames() []string {
+	profiles := viper.GetStringMap("profiles")
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileSummary describes a configured profile for listing.
+type ProfileSummary struct {
+	Name    string `json:"name"`
+	Backend string `json:"backend"`
+	Region  string `json:"region"`
+	Active  bool   `json:"active"`
+}
+
+// Profiles returns the configured profiles with the backend and region each
+// one sets, falling back to the values in effect without a profile.
+func Profiles() []ProfileSummary {
+	var summaries []ProfileSummary
+	for _, name := range ProfileNames() {
+		section, _ := viper.GetStringMap("profiles")[name].(map[string]any)
+		p := ProfileSummary{Name: name, Backend: baseBackend, Region: baseRegion, Active: name == strings.ToLower(GetProfile())}
+		if v, ok := section["backend"].(string); ok && v != "" {
+			p.Backend = v
+		}
+		if v, ok := section["region"].(string); ok && v != "" {
+			p.Region = v
+		}
+		summaries = append(summaries, p)
+	}
+	return summaries
+}
+
 // resolveDeprecatedFields merges deprecated [e2b]/[cloud] fields into top-level unified fields.
 // Priority: top-level > legacy (based on current backend) > default
 func resolveDeprecatedFields(c *Config) {
@@ -233,11 +371,30 @@ func Get() *Config {
 			Region:   defaultRegion,
 			Domain:   defaultDomain,
 			Internal: false,
+			Retry: RetryConfig{
+				MaxAttempts: defaultRetryMaxAttempts,
+				BaseDelay:   defaultRetryBaseDelay,
+				MaxDelay:    defaultRetryMaxDelay,
+			},
 		}
 	}
 	return cfg
 }
 
+// GetProfile returns the name of the selected profile, or DefaultProfile
+func GetProfile() string {
+	if p := Get().Profile; p != "" {
+		return p
+	}
+	return DefaultProfile
+}
+
+// StoreScope returns the key prefix that separates tunnel store entries of
+// different profiles and regions, e.g. "prod@ap-shanghai".
+func StoreScope() string {
+	return GetProfile() + "@" + GetRegion()
+}
+
 // GetBackend returns the current backend type
 func GetBackend() string {
 	return Get().Backend
@@ -245,6 +402,7 @@ func GetBackend() string {
 
 // SetBackend sets the backend type (for command line override)
 func SetBackend(backend string) {
+	markFlag("backend")
 	Get().Backend = backend
 }
 
@@ -255,6 +413,7 @@ func GetOutput() string {
 
 // SetOutput sets the output format (for command line override)
 func SetOutput(output string) {
+	markFlag("output")
 	Get().Output = output
 }
 
@@ -265,6 +424,7 @@ func GetRegion() string {
 
 // SetRegion sets the unified region (for command line override)
 func SetRegion(region string) {
+	markFlag("region")
 	Get().Region = region
 }
 
@@ -275,6 +435,7 @@ func GetDomain() string {
 
 // SetDomain sets the unified domain (for command line override)
 func SetDomain(domain string) {
+	markFlag("domain")
 	c := Get()
 	c.Domain = domain
 	// Re-normalize: if internal is enabled, ensure domain has the prefix
@@ -290,6 +451,7 @@ func GetInternal() bool {
 
 // SetInternal sets whether to use internal endpoints (for command line override)
 func SetInternal(internal bool) {
+	markFlag("internal")
 	c := Get()
 	c.Internal = internal
 	// Normalize domain based on new internal flag
@@ -300,6 +462,27 @@ func SetInternal(internal bool) {
 	}
 }
 
+// GetDebug returns whether diagnostic messages are enabled
+func GetDebug() bool {
+	return Get().Debug
+}
+
+// SetDebug enables diagnostic messages (for command line override)
+func SetDebug(debug bool) {
+	markFlag("debug")
+	Get().Debug = debug
+}
+
+// GetRetryConfig returns the control plane retry configuration
+func GetRetryConfig() RetryConfig {
+	return Get().Retry
+}
+
+// GetTokensConfig returns the access token cache configuration
+func GetTokensConfig() TokensConfig {
+	return Get().Tokens
+}
+
 // GetE2BConfig returns E2B configuration
 func GetE2BConfig() E2BConfig {
 	return Get().E2B
@@ -307,6 +490,7 @@ func GetE2BConfig() E2BConfig {
 
 // SetE2BAPIKey sets E2B API key (for command line override)
 func SetE2BAPIKey(key string) {
+	markFlag("e2b.api_key")
 	Get().E2B.APIKey = key
 }
 
@@ -318,6 +502,7 @@ func SetE2BAPIKey(key string) {
 // set domain to the default), the unified --domain flag is always applied after legacy flags,
 // so it will correct any over-write.
 func SetE2BDomain(domain string) {
+	markFlag("domain")
 	Get().E2B.Domain = domain
 	// Also update top-level domain if not explicitly set
 	if Get().Domain == defaultDomain || Get().Domain == "internal."+defaultDomain {
@@ -328,6 +513,7 @@ func SetE2BDomain(domain string) {
 // SetE2BRegion sets E2B region (for command line override)
 // Deprecated: Use SetRegion instead.
 func SetE2BRegion(region string) {
+	markFlag("region")
 	Get().E2B.Region = region
 	// Also update top-level region if not explicitly set
 	if Get().Region == defaultRegion {
@@ -342,17 +528,46 @@ func GetCloudConfig() CloudConfig {
 
 // SetCloudSecretID sets Cloud API SecretID (for command line override)
 func SetCloudSecretID(id string) {
+	markFlag("cloud.secret_id")
+	resetCloudCredential()
 	Get().Cloud.SecretID = id
 }
 
 // SetCloudSecretKey sets Cloud API SecretKey (for command line override)
 func SetCloudSecretKey(key string) {
+	markFlag("cloud.secret_key")
+	resetCloudCredential()
 	Get().Cloud.SecretKey = key
 }
 
+// SetCloudCredentialSource selects the cloud credential source (for command
+// line override)
+func SetCloudCredentialSource(source string) {
+	markFlag("cloud.credential_source")
+	resetCloudCredential()
+	Get().Cloud.CredentialSource = source
+}
+
+// SetCloudCredentialProcess sets the credential_process command (for command
+// line override)
+func SetCloudCredentialProcess(command string) {
+	markFlag("cloud.credential_process")
+	resetCloudCredential()
+	Get().Cloud.CredentialProcess = command
+}
+
+// SetCloudCVMRole sets the CAM role of the cvm_role source (for command line
+// override)
+func SetCloudCVMRole(role string) {
+	markFlag("cloud.cvm_role")
+	resetCloudCredential()
+	Get().Cloud.CVMRole = role
+}
+
 // SetCloudRegion sets Cloud API region (for command line override)
 // Deprecated: Use SetRegion instead.
 func SetCloudRegion(region string) {
+	markFlag("region")
 	Get().Cloud.Region = region
 	// Also update top-level region if not explicitly set
 	if Get().Region == defaultRegion {
@@ -375,17 +590,59 @@ func GetSandboxUser() string {
 
 // SetSandboxUser sets the default sandbox user (for command line override)
 func SetSandboxUser(user string) {
+	markFlag("sandbox.default_user")
 	Get().Sandbox.DefaultUser = user
 }
 
+// validOutputFormat reports whether s names a supported output format.
+// Template formats must carry their argument after '='; csv may.
+func validOutputFormat(s string) bool {
+	name, arg, hasArg := strings.Cut(s, "=")
+	switch name {
+	case "text", "json", "yaml":
+		return !hasArg
+	case "csv":
+		return true
+	case "jsonpath", "go-template", "custom-columns":
+		return arg != ""
+	}
+	return false
+}
+
 // Validate validates the configuration
 func Validate() error {
-	c := Get()
-	if c.Backend != "e2b" && c.Backend != "cloud" {
-		return fmt.Errorf("invalid backend: %s (must be 'e2b' or 'cloud')", c.Backend)
+	if err := ProfileError(); err != nil {
+		return err
+	}
+	return ValidateConfig(Get())
+}
+
+// ProfileError returns why the selected profile could not be applied, or nil.
+func ProfileError() error {
+	return profileErr
+}
+
+// AcceptProfile clears the profile error, for commands that create the
+// selected profile from values they set themselves.
+func AcceptProfile() {
+	profileErr = nil
+}
+
+// ValidateConfig validates c without checking that the selected profile
+// exists, for configurations that are being created.
+func ValidateConfig(c *Config) error {
+	if c.Backend != "e2b" && c.Backend != "cloud" && c.Backend != "local" {
+		return fmt.Errorf("invalid backend: %s (must be 'e2b', 'cloud' or 'local')", c.Backend)
+	}
+	if !validOutputFormat(c.Output) {
+		return fmt.Errorf("invalid output format: %s (must be text, json, yaml, csv, jsonpath=<template>, go-template=<template> or custom-columns=<spec>)", c.Output)
+	}
+
+	if c.Retry.MaxAttempts < 0 || c.Retry.BaseDelay < 0 || c.Retry.MaxDelay < 0 {
+		return fmt.Errorf("invalid retry configuration: max_attempts, base_delay and max_delay must not be negative")
 	}
-	if c.Output != "text" && c.Output != "json" {
-		return fmt.Errorf("invalid output format: %s (must be 'text' or 'json')", c.Output)
+	if c.Tokens.TTL < 0 {
+		return fmt.Errorf("invalid tokens.ttl: must not be negative")
 	}
 
 	switch c.Backend {
@@ -394,8 +651,8 @@ func Validate() error {
 			return fmt.Errorf("E2B API key is required (set AGS_E2B_API_KEY or e2b.api_key in config)")
 		}
 	case "cloud":
-		if c.Cloud.SecretID == "" || c.Cloud.SecretKey == "" {
-			return fmt.Errorf("cloud API credentials are required (set AGS_CLOUD_SECRET_ID/AGS_CLOUD_SECRET_KEY or cloud.secret_id/cloud.secret_key in config)")
+		if err := validateCloudCredentials(c.Cloud); err != nil {
+			return err
 		}
 	}
 
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -10,6 +14,11 @@ import (
 func resetGlobals() {
 	cfg = nil
 	cfgFile = ""
+	cfgProfile = ""
+	profileErr = nil
+	flagSet = map[string]bool{}
+	resetCloudCredential()
+	PassphraseFunc = nil
 	viper.Reset()
 }
 
@@ -478,6 +487,26 @@ func TestValidate(t *testing.T) {
 			config:    Config{Backend: "e2b", Output: "xml"},
 			expectErr: true,
 		},
+		{
+			name:      "template output without template",
+			config:    Config{Backend: "e2b", Output: "jsonpath", E2B: E2BConfig{APIKey: "key"}},
+			expectErr: true,
+		},
+		{
+			name:      "jsonpath output",
+			config:    Config{Backend: "e2b", Output: "jsonpath={.id}", E2B: E2BConfig{APIKey: "key"}},
+			expectErr: false,
+		},
+		{
+			name:      "yaml output",
+			config:    Config{Backend: "e2b", Output: "yaml", E2B: E2BConfig{APIKey: "key"}},
+			expectErr: false,
+		},
+		{
+			name:      "negative retry delay",
+			config:    Config{Backend: "e2b", Output: "text", E2B: E2BConfig{APIKey: "key"}, Retry: RetryConfig{MaxAttempts: 3, BaseDelay: -time.Second}},
+			expectErr: true,
+		},
 		{
 			name:      "e2b missing api key",
 			config:    Config{Backend: "e2b", Output: "text"},
@@ -501,6 +530,11 @@ func TestValidate(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name:      "local needs no credentials",
+			config:    Config{Backend: "local", Output: "text"},
+			expectErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -517,3 +551,114 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+const profilesConfig = `
+backend = "e2b"
+region = "ap-guangzhou"
+profile = "dev"
+
+[e2b]
+api_key = "top-key"
+
+[profiles.dev]
+region = "ap-beijing"
+
+[profiles.prod]
+backend = "cloud"
+region = "ap-shanghai"
+internal = true
+
+[profiles.prod.cloud]
+secret_id = "prod-id"
+secret_key = "prod-key"
+`
+
+// initWithConfig writes content to a temporary config file and loads it
+// with the given profile selected.
+func initWithConfig(t *testing.T, content, profile string) {
+	t.Helper()
+	resetGlobals()
+	t.Cleanup(resetGlobals)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	SetConfigFile(path)
+	SetProfile(profile)
+	if err := Init(); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+}
+
+// TestProfiles tests that a selected profile overrides top-level fields.
+func TestProfiles(t *testing.T) {
+	t.Run("profile field selects default profile", func(t *testing.T) {
+		initWithConfig(t, profilesConfig, "")
+		if GetProfile() != "dev" || GetRegion() != "ap-beijing" || GetBackend() != "e2b" {
+			t.Errorf("got profile %q backend %q region %q", GetProfile(), GetBackend(), GetRegion())
+		}
+		if GetE2BConfig().APIKey != "top-key" {
+			t.Errorf("expected top-level api key to be inherited, got %q", GetE2BConfig().APIKey)
+		}
+		if StoreScope() != "dev@ap-beijing" {
+			t.Errorf("unexpected store scope %q", StoreScope())
+		}
+	})
+
+	t.Run("flag overrides profile field", func(t *testing.T) {
+		initWithConfig(t, profilesConfig, "prod")
+		c := Get()
+		if c.Backend != "cloud" || c.Region != "ap-shanghai" || c.Cloud.SecretID != "prod-id" {
+			t.Errorf("profile not applied: %+v", c)
+		}
+		if c.Domain != "internal."+defaultDomain {
+			t.Errorf("expected internal domain, got %q", c.Domain)
+		}
+		if err := Validate(); err != nil {
+			t.Errorf("Validate() failed: %v", err)
+		}
+	})
+
+	t.Run("environment overrides profile values", func(t *testing.T) {
+		t.Setenv("AGS_REGION", "ap-singapore")
+		initWithConfig(t, profilesConfig, "prod")
+		if GetRegion() != "ap-singapore" {
+			t.Errorf("expected env region, got %q", GetRegion())
+		}
+	})
+
+	t.Run("default profile uses top-level fields", func(t *testing.T) {
+		initWithConfig(t, profilesConfig, DefaultProfile)
+		if GetProfile() != DefaultProfile || GetRegion() != "ap-guangzhou" {
+			t.Errorf("got profile %q region %q", GetProfile(), GetRegion())
+		}
+		if StoreScope() != "default@ap-guangzhou" {
+			t.Errorf("unexpected store scope %q", StoreScope())
+		}
+	})
+
+	t.Run("unknown profile fails validation", func(t *testing.T) {
+		initWithConfig(t, profilesConfig, "staging")
+		err := Validate()
+		if err == nil || !strings.Contains(err.Error(), "available: dev, prod") {
+			t.Errorf("expected unknown profile error, got %v", err)
+		}
+	})
+
+	t.Run("list profiles", func(t *testing.T) {
+		initWithConfig(t, profilesConfig, "prod")
+		want := []ProfileSummary{
+			{Name: "dev", Backend: "e2b", Region: "ap-beijing"},
+			{Name: "prod", Backend: "cloud", Region: "ap-shanghai", Active: true},
+		}
+		got := Profiles()
+		if len(got) != len(want) {
+			t.Fatalf("expected %d profiles, got %+v", len(want), got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("profile %d: expected %+v, got %+v", i, want[i], got[i])
+			}
+		}
+	})
+}
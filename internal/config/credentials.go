@@ -0,0 +1,293 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+)
+
+// Credential sources accepted by cloud.credential_source.
+const (
+	CredentialSourceStatic  = "static"   // cloud.secret_id / secret_key, plus cloud.token for temporary keys
+	CredentialSourceProcess = "process"  // JSON printed by cloud.credential_process
+	CredentialSourceKeyring = "keyring"  // OS keyring entry written by `ags config init`
+	CredentialSourceFile    = "file"     // Passphrase-encrypted ~/.ags/credentials.enc
+	CredentialSourceCVMRole = "cvm_role" // CAM role bound to the CVM instance
+)
+
+// CredentialSources lists the valid values of cloud.credential_source.
+var CredentialSources = []string{
+	CredentialSourceStatic,
+	CredentialSourceProcess,
+	CredentialSourceKeyring,
+	CredentialSourceFile,
+	CredentialSourceCVMRole,
+}
+
+const (
+	// credentialProcessTimeout bounds a single run of cloud.credential_process.
+	credentialProcessTimeout = time.Minute
+	// credentialRefreshWindow is how long before expiry temporary
+	// credentials are fetched again.
+	credentialRefreshWindow = 5 * time.Minute
+)
+
+// errNoCredential is returned by a provider whose source is not configured,
+// so that the chain moves on to the next one.
+var errNoCredential = errors.New("no credentials configured")
+
+// CredentialProvider resolves Tencent Cloud API credentials from one source.
+type CredentialProvider interface {
+	Name() string
+	Credential() (common.CredentialIface, error)
+}
+
+// CredentialProviders returns the providers consulted for c, in order. When
+// cloud.credential_source is set only that source is used; otherwise static
+// keys are tried before cloud.credential_process.
+func CredentialProviders(c CloudConfig) []CredentialProvider {
+	switch c.CredentialSource {
+	case CredentialSourceStatic:
+		return []CredentialProvider{staticProvider{c}}
+	case CredentialSourceProcess:
+		return []CredentialProvider{processProvider{c.CredentialProcess}}
+	case CredentialSourceKeyring:
+		return []CredentialProvider{keyringProvider{GetProfile()}}
+	case CredentialSourceFile:
+		return []CredentialProvider{fileProvider{GetProfile()}}
+	case CredentialSourceCVMRole:
+		return []CredentialProvider{cvmRoleProvider{c.CVMRole}}
+	}
+	return []CredentialProvider{staticProvider{c}, processProvider{c.CredentialProcess}}
+}
+
+var (
+	cloudCred       common.CredentialIface
+	cloudCredSource string
+)
+
+// CloudCredential resolves the Tencent Cloud API credentials through the
+// provider chain and reports which source supplied them. The result is
+// cached for the rest of the process; temporary credentials refresh
+// themselves before they expire.
+func CloudCredential() (common.CredentialIface, string, error) {
+	if cloudCred != nil {
+		return cloudCred, cloudCredSource, nil
+	}
+	var tried []string
+	for _, p := range CredentialProviders(GetCloudConfig()) {
+		cred, err := p.Credential()
+		if errors.Is(err, errNoCredential) {
+			tried = append(tried, p.Name())
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get cloud API credentials from %s: %w", p.Name(), err)
+		}
+		cloudCred, cloudCredSource = cred, p.Name()
+		return cred, p.Name(), nil
+	}
+	return nil, "", fmt.Errorf("no cloud API credentials found (tried %s); set cloud.secret_id/cloud.secret_key, cloud.credential_process or cloud.credential_source", strings.Join(tried, ", "))
+}
+
+// resetCloudCredential drops the cached credentials after the cloud
+// configuration changes.
+func resetCloudCredential() {
+	cloudCred, cloudCredSource = nil, ""
+}
+
+// validateCloudCredentials checks that the cloud configuration names a usable
+// credential source. Static keys next to another source are rejected so that
+// plaintext keys cannot linger in a config that is meant to avoid them.
+func validateCloudCredentials(c CloudConfig) error {
+	hasKeys := c.SecretID != "" || c.SecretKey != ""
+	switch c.CredentialSource {
+	case "":
+		if c.SecretID != "" && c.SecretKey != "" || c.CredentialProcess != "" {
+			return nil
+		}
+		return fmt.Errorf("cloud API credentials are required (set AGS_CLOUD_SECRET_ID/AGS_CLOUD_SECRET_KEY or cloud.secret_id/cloud.secret_key in config, or configure cloud.credential_source)")
+	case CredentialSourceStatic:
+		if c.SecretID == "" || c.SecretKey == "" {
+			return fmt.Errorf("cloud.credential_source is static but cloud.secret_id or cloud.secret_key is not set")
+		}
+		return nil
+	case CredentialSourceProcess:
+		if c.CredentialProcess == "" {
+			return fmt.Errorf("cloud.credential_source is process but cloud.credential_process is not set")
+		}
+	case CredentialSourceKeyring, CredentialSourceFile, CredentialSourceCVMRole:
+	default:
+		return fmt.Errorf("invalid cloud.credential_source: %s (must be one of %s)", c.CredentialSource, strings.Join(CredentialSources, ", "))
+	}
+	if hasKeys {
+		return fmt.Errorf("cloud.secret_id/cloud.secret_key must not be set when cloud.credential_source is %s; remove them with 'ags config unset'", c.CredentialSource)
+	}
+	return nil
+}
+
+// staticProvider returns the keys from flags, environment or config file.
+// A session token turns them into temporary credentials.
+type staticProvider struct {
+	c CloudConfig
+}
+
+func (staticProvider) Name() string { return CredentialSourceStatic }
+
+func (p staticProvider) Credential() (common.CredentialIface, error) {
+	switch {
+	case p.c.SecretID == "" && p.c.SecretKey == "":
+		return nil, errNoCredential
+	case p.c.SecretID == "" || p.c.SecretKey == "":
+		return nil, fmt.Errorf("both cloud.secret_id and cloud.secret_key are required")
+	case p.c.Token != "":
+		return common.NewTokenCredential(p.c.SecretID, p.c.SecretKey, p.c.Token), nil
+	}
+	return common.NewCredential(p.c.SecretID, p.c.SecretKey), nil
+}
+
+// processProvider runs an external command that prints credentials as JSON.
+type processProvider struct {
+	command string
+}
+
+func (processProvider) Name() string { return CredentialSourceProcess }
+
+func (p processProvider) Credential() (common.CredentialIface, error) {
+	if p.command == "" {
+		return nil, errNoCredential
+	}
+	v, err := runCredentialProcess(p.command)
+	if err != nil {
+		return nil, err
+	}
+	return &refreshingCredential{value: v, fetch: func() (credentialValue, error) {
+		return runCredentialProcess(p.command)
+	}}, nil
+}
+
+// processOutput is the JSON printed by a credential process. Both the flat
+// form and the response of STS AssumeRole ("Credentials" with TmpSecretId,
+// TmpSecretKey and Token) are accepted.
+type processOutput struct {
+	SecretID     string         `json:"SecretId"`
+	SecretKey    string         `json:"SecretKey"`
+	TmpSecretID  string         `json:"TmpSecretId"`
+	TmpSecretKey string         `json:"TmpSecretKey"`
+	Token        string         `json:"Token"`
+	Expiration   string         `json:"Expiration"`  // RFC 3339
+	ExpiredTime  int64          `json:"ExpiredTime"` // Unix seconds
+	Credentials  *processOutput `json:"Credentials"`
+}
+
+func runCredentialProcess(command string) (credentialValue, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialProcessTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	}
+	// Let the command prompt for MFA codes and report its own errors
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return credentialValue{}, fmt.Errorf("credential_process failed: %w", err)
+	}
+	return parseProcessOutput(out)
+}
+
+func parseProcessOutput(data []byte) (credentialValue, error) {
+	var out processOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return credentialValue{}, fmt.Errorf("credential_process printed invalid JSON: %w", err)
+	}
+	v := credentialValue{SecretID: out.SecretID, SecretKey: out.SecretKey, Token: out.Token}
+	if c := out.Credentials; c != nil {
+		v = credentialValue{SecretID: c.TmpSecretID, SecretKey: c.TmpSecretKey, Token: c.Token}
+		if v.SecretID == "" {
+			v.SecretID, v.SecretKey = c.SecretID, c.SecretKey
+		}
+	}
+	if v.SecretID == "" || v.SecretKey == "" {
+		return credentialValue{}, fmt.Errorf("credential_process output has no SecretId/SecretKey")
+	}
+	switch {
+	case out.Expiration != "":
+		t, err := time.Parse(time.RFC3339, out.Expiration)
+		if err != nil {
+			return credentialValue{}, fmt.Errorf("credential_process printed an invalid Expiration %q: %w", out.Expiration, err)
+		}
+		v.Expiration = t
+	case out.ExpiredTime > 0:
+		v.Expiration = time.Unix(out.ExpiredTime, 0)
+	}
+	return v, nil
+}
+
+// cvmRoleProvider fetches temporary keys of the CAM role bound to this CVM
+// instance from the metadata service. An empty role name is discovered.
+type cvmRoleProvider struct {
+	role string
+}
+
+func (cvmRoleProvider) Name() string { return CredentialSourceCVMRole }
+
+func (p cvmRoleProvider) Credential() (common.CredentialIface, error) {
+	cred, err := common.NewCvmRoleProvider(p.role).GetCredential()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CVM role credentials from the metadata service: %w", err)
+	}
+	return cred, nil
+}
+
+// credentialValue is a snapshot of temporary or long-term keys.
+type credentialValue struct {
+	SecretID   string
+	SecretKey  string
+	Token      string
+	Expiration time.Time // zero when the keys do not expire
+}
+
+// refreshingCredential implements common.CredentialIface for credentials
+// that are fetched again shortly before they expire.
+type refreshingCredential struct {
+	mu    sync.Mutex
+	value credentialValue
+	fetch func() (credentialValue, error)
+}
+
+func (c *refreshingCredential) current() credentialValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.value.Expiration.IsZero() && time.Until(c.value.Expiration) < credentialRefreshWindow {
+		v, err := c.fetch()
+		if err != nil {
+			// Keep the old keys; the API reports them as expired if they are
+			fmt.Fprintf(os.Stderr, "Warning: failed to refresh cloud API credentials: %v\n", err)
+		} else {
+			c.value = v
+		}
+	}
+	return c.value
+}
+
+func (c *refreshingCredential) GetSecretId() string  { return c.current().SecretID }
+func (c *refreshingCredential) GetSecretKey() string { return c.current().SecretKey }
+func (c *refreshingCredential) GetToken() string     { return c.current().Token }
+
+func (c *refreshingCredential) GetCredential() (string, string, string) {
+	v := c.current()
+	return v.SecretID, v.SecretKey, v.Token
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+// TestValidateCloudCredentials tests the consistency checks of the cloud
+// credential settings.
+func TestValidateCloudCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		cloud     CloudConfig
+		expectErr string
+	}{
+		{name: "static keys", cloud: CloudConfig{SecretID: "id", SecretKey: "key"}},
+		{name: "credential process", cloud: CloudConfig{CredentialProcess: "get-creds"}},
+		{name: "nothing configured", cloud: CloudConfig{}, expectErr: "credentials are required"},
+		{name: "keyring", cloud: CloudConfig{CredentialSource: "keyring"}},
+		{name: "cvm role", cloud: CloudConfig{CredentialSource: "cvm_role"}},
+		{name: "static without keys", cloud: CloudConfig{CredentialSource: "static", SecretID: "id"}, expectErr: "not set"},
+		{name: "process without command", cloud: CloudConfig{CredentialSource: "process"}, expectErr: "credential_process is not set"},
+		{name: "plaintext keys next to keyring", cloud: CloudConfig{CredentialSource: "keyring", SecretKey: "key"}, expectErr: "must not be set"},
+		{name: "unknown source", cloud: CloudConfig{CredentialSource: "vault"}, expectErr: "invalid cloud.credential_source"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCloudCredentials(tt.cloud)
+			if tt.expectErr == "" && err != nil {
+				t.Errorf("expected no error but got: %v", err)
+			}
+			if tt.expectErr != "" && (err == nil || !strings.Contains(err.Error(), tt.expectErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+// TestCloudCredentialChain tests the provider order and source reporting.
+func TestCloudCredentialChain(t *testing.T) {
+	t.Run("static keys with session token", func(t *testing.T) {
+		resetGlobals()
+		t.Cleanup(resetGlobals)
+		Get().Cloud = CloudConfig{SecretID: "id", SecretKey: "key", Token: "token", CredentialProcess: "false"}
+		cred, source, err := CloudCredential()
+		if err != nil || source != CredentialSourceStatic {
+			t.Fatalf("CloudCredential() = %v, %q, %v", cred, source, err)
+		}
+		if id, key, token := cred.GetCredential(); id != "id" || key != "key" || token != "token" {
+			t.Errorf("unexpected credential %q %q %q", id, key, token)
+		}
+	})
+
+	t.Run("falls back to credential process", func(t *testing.T) {
+		resetGlobals()
+		t.Cleanup(resetGlobals)
+		Get().Cloud = CloudConfig{CredentialProcess: `echo '{"SecretId":"pid","SecretKey":"pkey","Token":"ptoken"}'`}
+		cred, source, err := CloudCredential()
+		if err != nil || source != CredentialSourceProcess {
+			t.Fatalf("CloudCredential() = %v, %q, %v", cred, source, err)
+		}
+		if cred.GetSecretId() != "pid" || cred.GetToken() != "ptoken" {
+			t.Errorf("unexpected credential %q %q", cred.GetSecretId(), cred.GetToken())
+		}
+	})
+
+	t.Run("failing process is reported", func(t *testing.T) {
+		resetGlobals()
+		t.Cleanup(resetGlobals)
+		Get().Cloud = CloudConfig{CredentialProcess: "exit 3"}
+		if _, _, err := CloudCredential(); err == nil || !strings.Contains(err.Error(), "credential_process failed") {
+			t.Errorf("expected process error, got %v", err)
+		}
+	})
+
+	t.Run("nothing configured", func(t *testing.T) {
+		resetGlobals()
+		t.Cleanup(resetGlobals)
+		if _, _, err := CloudCredential(); err == nil || !strings.Contains(err.Error(), "tried static, process") {
+			t.Errorf("expected no credentials error, got %v", err)
+		}
+	})
+}
+
+// TestParseProcessOutput tests the accepted credential process formats.
+func TestParseProcessOutput(t *testing.T) {
+	v, err := parseProcessOutput([]byte(`{"SecretId":"id","SecretKey":"key","Expiration":"2030-01-02T03:04:05Z"}`))
+	if err != nil || v.SecretID != "id" || v.SecretKey != "key" || v.Expiration.Year() != 2030 {
+		t.Errorf("flat output: got %+v, %v", v, err)
+	}
+
+	v, err = parseProcessOutput([]byte(`{"Credentials":{"TmpSecretId":"tid","TmpSecretKey":"tkey","Token":"tok"},"ExpiredTime":1900000000}`))
+	if err != nil || v.SecretID != "tid" || v.Token != "tok" || v.Expiration.Unix() != 1900000000 {
+		t.Errorf("STS output: got %+v, %v", v, err)
+	}
+
+	for _, out := range []string{`not json`, `{"SecretId":"id"}`, `{"SecretId":"id","SecretKey":"key","Expiration":"soon"}`} {
+		if _, err := parseProcessOutput([]byte(out)); err == nil {
+			t.Errorf("expected error for %s", out)
+		}
+	}
+}
+
+// TestRefreshingCredential tests that expiring credentials are fetched again.
+func TestRefreshingCredential(t *testing.T) {
+	fetches := 0
+	c := &refreshingCredential{
+		value: credentialValue{SecretID: "old", Expiration: time.Now().Add(time.Minute)},
+		fetch: func() (credentialValue, error) {
+			fetches++
+			return credentialValue{SecretID: "new", Expiration: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	if c.GetSecretId() != "new" || c.GetSecretId() != "new" || fetches != 1 {
+		t.Errorf("expected one refresh, got %d and id %q", fetches, c.GetSecretId())
+	}
+}
+
+// TestCredentialStores tests storing and reading keys in the keyring and
+// the encrypted file.
+func TestCredentialStores(t *testing.T) {
+	t.Run("keyring", func(t *testing.T) {
+		keyring.MockInit()
+		initWithConfig(t, profilesConfig, "prod")
+		SetCloudSecretID("")
+		SetCloudSecretKey("")
+		SetCloudCredentialSource(CredentialSourceKeyring)
+		if _, _, err := CloudCredential(); err == nil || !strings.Contains(err.Error(), `profile "prod"`) {
+			t.Fatalf("expected missing entry error, got %v", err)
+		}
+		if err := StoreCloudCredential(CredentialSourceKeyring, "kid", "kkey"); err != nil {
+			t.Fatalf("StoreCloudCredential() failed: %v", err)
+		}
+		cred, source, err := CloudCredential()
+		if err != nil || source != CredentialSourceKeyring || cred.GetSecretId() != "kid" {
+			t.Errorf("CloudCredential() = %v, %q, %v", cred, source, err)
+		}
+	})
+
+	t.Run("encrypted file", func(t *testing.T) {
+		t.Setenv("AGS_CREDENTIALS_PASSPHRASE", "correct horse")
+		initWithConfig(t, profilesConfig, "dev")
+		SetCloudCredentialSource(CredentialSourceFile)
+		if err := StoreCloudCredential(CredentialSourceFile, "fid", "fkey"); err != nil {
+			t.Fatalf("StoreCloudCredential() failed: %v", err)
+		}
+		cred, source, err := CloudCredential()
+		if err != nil || source != CredentialSourceFile || cred.GetSecretKey() != "fkey" {
+			t.Errorf("CloudCredential() = %v, %q, %v", cred, source, err)
+		}
+
+		path := filepath.Join(filepath.Dir(cfgFile), credentialFileName)
+		if data, err := os.ReadFile(path); err != nil || strings.Contains(string(data), "fkey") {
+			t.Errorf("credentials file contains the plaintext key:\n%s", data)
+		}
+
+		t.Setenv("AGS_CREDENTIALS_PASSPHRASE", "wrong")
+		resetCloudCredential()
+		if _, _, err := CloudCredential(); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+			t.Errorf("expected wrong passphrase error, got %v", err)
+		}
+	})
+}
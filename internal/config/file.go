@@ -0,0 +1,397 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+)
+
+// The config file is edited line by line so that comments and layout
+// survive `ags config set/unset`. Values that cannot be edited in place
+// (inline tables, multi-line values) fall back to rewriting the whole file,
+// which drops comments.
+
+var (
+	tableHeaderRe = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]\s*(#.*)?$`)
+	arrayHeaderRe = regexp.MustCompile(`^\s*\[\[`)
+	keyLineRe     = regexp.MustCompile(`^(\s*)((?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*')(?:\s*\.\s*(?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*'))*)(\s*=\s*)`)
+)
+
+// errNotInPlace means a key cannot be edited without rewriting the file.
+var errNotInPlace = errors.New("value cannot be edited in place")
+
+// SetFileValue writes key = value to the config file, creating it if needed.
+// It reports whether comments and formatting were preserved.
+func SetFileValue(key string, value any) (bool, error) {
+	return editFile(func(doc []string) ([]string, error) {
+		return setLine(doc, key, value)
+	}, func(m map[string]any) {
+		setNested(m, strings.Split(key, "."), value)
+	}, key, value, true)
+}
+
+// UnsetFileValue removes key from the config file. It reports whether
+// comments and formatting were preserved, and fails if the key is not set.
+func UnsetFileValue(key string) (bool, error) {
+	if _, ok := FileValue(key); !ok {
+		return false, fmt.Errorf("%s is not set in the config file", key)
+	}
+	return editFile(func(doc []string) ([]string, error) {
+		return unsetLine(doc, key)
+	}, func(m map[string]any) {
+		deleteNested(m, strings.Split(key, "."))
+	}, key, nil, false)
+}
+
+// editFile applies a line edit to the config file, checks that the result
+// parses and holds the expected value, and otherwise rewrites the file from
+// its parsed settings with rewrite applied.
+func editFile(edit func([]string) ([]string, error), rewrite func(map[string]any), key string, value any, set bool) (bool, error) {
+	path, err := FilePath()
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read config file: %w", err)
+	}
+	current, err := parseTOML(data)
+	if err != nil {
+		return false, fmt.Errorf("config file %s is not valid TOML: %w", path, err)
+	}
+
+	doc := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		doc = nil
+	}
+	if lines, err := edit(doc); err == nil {
+		out := []byte(strings.Join(lines, "\n") + "\n")
+		if v, err := parseTOML(out); err == nil && holds(v, key, value, set) {
+			return true, writeFileAtomic(path, out)
+		}
+	} else if !errors.Is(err, errNotInPlace) {
+		return false, err
+	}
+
+	settings := current.AllSettings()
+	rewrite(settings)
+	out, err := toml.Marshal(settings)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode config file: %w", err)
+	}
+	return false, writeFileAtomic(path, out)
+}
+
+func parseTOML(data []byte) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// holds reports whether the parsed file has key set to value (or unset).
+func holds(v *viper.Viper, key string, value any, set bool) bool {
+	if !set {
+		return !v.IsSet(key)
+	}
+	return v.IsSet(key) && reflect.DeepEqual(normalizeTOML(v.Get(key)), normalizeTOML(value))
+}
+
+func normalizeTOML(v any) any {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	}
+	return v
+}
+
+// writeFileAtomic replaces path with data, keeping it private to the user
+// since the config file may hold credentials.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "config-*.toml.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to set temp file permissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace config file: %w", err)
+	}
+	return nil
+}
+
+// tomlLine is a classified line of a TOML document.
+type tomlLine struct {
+	table string // normalized table the line belongs to ("" for top level)
+	key   string // normalized full key for key/value lines
+	// valueStart and valueEnd delimit a single-line scalar value; valueEnd is
+	// -1 when the value is not a scalar that can be replaced in place.
+	valueStart, valueEnd int
+	header               bool
+}
+
+// classify walks the document, skipping the bodies of multi-line strings.
+func classify(doc []string) []tomlLine {
+	lines := make([]tomlLine, len(doc))
+	table := ""
+	closing := ""
+	for i, text := range doc {
+		if closing != "" {
+			lines[i] = tomlLine{table: table, valueEnd: -1}
+			if strings.Contains(text, closing) {
+				closing = ""
+			}
+			continue
+		}
+		if arrayHeaderRe.MatchString(text) {
+			table = "\x00array" // never matches a key
+			lines[i] = tomlLine{table: table, header: true, valueEnd: -1}
+			continue
+		}
+		if m := tableHeaderRe.FindStringSubmatch(text); m != nil {
+			table = normalizeKey(m[1])
+			lines[i] = tomlLine{table: table, header: true, valueEnd: -1}
+			continue
+		}
+		m := keyLineRe.FindStringSubmatchIndex(text)
+		if m == nil {
+			lines[i] = tomlLine{table: table, valueEnd: -1}
+			continue
+		}
+		key := normalizeKey(text[m[4]:m[5]])
+		if table != "" {
+			key = table + "." + key
+		}
+		start := m[1]
+		end := scalarEnd(text[start:])
+		if end >= 0 {
+			end += start
+		}
+		for _, q := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(text[start:], q) && !strings.Contains(text[start+3:], q) {
+				closing = q
+			}
+		}
+		lines[i] = tomlLine{table: table, key: key, valueStart: start, valueEnd: end}
+	}
+	return lines
+}
+
+// normalizeKey lowercases a dotted key and strips quotes and spaces.
+func normalizeKey(k string) string {
+	parts := strings.Split(k, ".")
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+		p = strings.Trim(p, `"'`)
+		parts[i] = strings.ToLower(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// scalarEnd returns the length of the single-line scalar value at the start
+// of s, or -1 for arrays, inline tables and multi-line strings.
+func scalarEnd(s string) int {
+	switch {
+	case s == "", strings.HasPrefix(s, `"""`), strings.HasPrefix(s, `'''`), s[0] == '[', s[0] == '{':
+		return -1
+	case s[0] == '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return -1
+	case s[0] == '\'':
+		if i := strings.IndexByte(s[1:], '\''); i >= 0 {
+			return i + 2
+		}
+		return -1
+	}
+	end := strings.IndexAny(s, " \t#")
+	if end < 0 {
+		return len(s)
+	}
+	return end
+}
+
+// formatTOML renders a scalar as a TOML value.
+func formatTOML(v any) string {
+	switch v := v.(type) {
+	case string:
+		return quoteTOML(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	}
+	return quoteTOML(fmt.Sprint(v))
+}
+
+// quoteTOML renders s as a TOML basic string.
+func quoteTOML(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// setLine replaces the value of key in place, or inserts it at the end of
+// its table, adding the table if the document has none.
+func setLine(doc []string, key string, value any) ([]string, error) {
+	lines := classify(doc)
+	for i, l := range lines {
+		if l.key != key {
+			continue
+		}
+		if l.valueEnd < 0 {
+			return nil, errNotInPlace
+		}
+		text := doc[i]
+		out := append([]string{}, doc...)
+		out[i] = text[:l.valueStart] + formatTOML(value) + text[l.valueEnd:]
+		return out, nil
+	}
+
+	table, leaf := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		table, leaf = key[:i], key[i+1:]
+	}
+	entry := leaf + " = " + formatTOML(value)
+
+	if table == "" {
+		// Top-level keys must precede the first table header
+		insert, firstHeader := -1, len(doc)
+		for i, l := range lines {
+			if l.header {
+				firstHeader = i
+				break
+			}
+			if l.key != "" {
+				insert = i + 1
+			}
+		}
+		if insert < 0 {
+			if firstHeader < len(doc) {
+				return insertLines(doc, firstHeader, entry, ""), nil
+			}
+			insert = len(doc)
+		}
+		return insertLines(doc, insert, entry), nil
+	}
+
+	headerAt := -1
+	for i, l := range lines {
+		if l.header && l.table == table {
+			headerAt = i
+			break
+		}
+	}
+	if headerAt < 0 {
+		var add []string
+		if len(doc) > 0 && strings.TrimSpace(doc[len(doc)-1]) != "" {
+			add = append(add, "")
+		}
+		add = append(add, "["+table+"]", entry)
+		return append(append([]string{}, doc...), add...), nil
+	}
+	insert := headerAt + 1
+	for i := headerAt + 1; i < len(doc) && !lines[i].header; i++ {
+		if trimmed := strings.TrimSpace(doc[i]); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			insert = i + 1
+		}
+	}
+	return insertLines(doc, insert, entry), nil
+}
+
+// unsetLine removes the line assigning key.
+func unsetLine(doc []string, key string) ([]string, error) {
+	for i, l := range classify(doc) {
+		if l.key != key {
+			continue
+		}
+		if l.valueEnd < 0 {
+			return nil, errNotInPlace
+		}
+		return append(append([]string{}, doc[:i]...), doc[i+1:]...), nil
+	}
+	return nil, errNotInPlace
+}
+
+func insertLines(doc []string, at int, add ...string) []string {
+	out := make([]string, 0, len(doc)+len(add))
+	out = append(out, doc[:at]...)
+	out = append(out, add...)
+	return append(out, doc[at:]...)
+}
+
+func setNested(m map[string]any, path []string, value any) {
+	for _, p := range path[:len(path)-1] {
+		next, ok := m[p].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[p] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+func deleteNested(m map[string]any, path []string) {
+	for _, p := range path[:len(path)-1] {
+		next, ok := m[p].(map[string]any)
+		if !ok {
+			return
+		}
+		m = next
+	}
+	delete(m, path[len(path)-1])
+}
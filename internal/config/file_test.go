@@ -0,0 +1,203 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const editConfig = `# AGS config
+backend = "e2b"   # default backend
+
+[e2b]
+# API key from the console
+api_key = "old-key"
+
+[retry]
+max_attempts = 4
+`
+
+// TestSetFileValue tests that config edits keep comments and layout.
+func TestSetFileValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value any
+		want  string
+	}{
+		{
+			name:  "replace value keeps trailing comment",
+			key:   "backend",
+			value: "cloud",
+			want:  `backend = "cloud"   # default backend`,
+		},
+		{
+			name:  "insert into existing table",
+			key:   "e2b.domain",
+			value: "example.com",
+			want:  "api_key = \"old-key\"\ndomain = \"example.com\"\n",
+		},
+		{
+			name:  "top-level key goes before first table",
+			key:   "debug",
+			value: true,
+			want:  "backend = \"e2b\"   # default backend\ndebug = true\n",
+		},
+		{
+			name:  "new table is appended",
+			key:   "profiles.dev.region",
+			value: "ap-beijing",
+			want:  "max_attempts = 4\n\n[profiles.dev]\nregion = \"ap-beijing\"\n",
+		},
+		{
+			name:  "integer value",
+			key:   "retry.max_attempts",
+			value: 6,
+			want:  "max_attempts = 6\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initWithConfig(t, editConfig, "")
+			preserved, err := SetFileValue(tt.key, tt.value)
+			if err != nil {
+				t.Fatalf("SetFileValue() failed: %v", err)
+			}
+			if !preserved {
+				t.Error("expected comments to be preserved")
+			}
+			data := readConfigFile(t)
+			if !strings.Contains(data, tt.want) || !strings.Contains(data, "# API key from the console") {
+				t.Errorf("unexpected config file:\n%s", data)
+			}
+			if v, ok := FileValue(tt.key); !ok || normalizeTOML(v) != normalizeTOML(tt.value) {
+				t.Errorf("FileValue(%q) = %v, %v", tt.key, v, ok)
+			}
+		})
+	}
+}
+
+// TestSetFileValueRewrite tests the fallback for values that cannot be
+// edited in place.
+func TestSetFileValueRewrite(t *testing.T) {
+	initWithConfig(t, "e2b = { api_key = \"old-key\" }  # inline\n", "")
+	preserved, err := SetFileValue("e2b.api_key", "new-key")
+	if err != nil {
+		t.Fatalf("SetFileValue() failed: %v", err)
+	}
+	if preserved {
+		t.Error("expected the file to be rewritten")
+	}
+	if v, _ := FileValue("e2b.api_key"); v != "new-key" {
+		t.Errorf("expected new-key, got %v", v)
+	}
+	info, err := os.Stat(cfgFile)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v (%v)", info.Mode().Perm(), err)
+	}
+}
+
+// TestUnsetFileValue tests removing keys from the config file.
+func TestUnsetFileValue(t *testing.T) {
+	initWithConfig(t, editConfig, "")
+	if _, err := UnsetFileValue("e2b.api_key"); err != nil {
+		t.Fatalf("UnsetFileValue() failed: %v", err)
+	}
+	data := readConfigFile(t)
+	if strings.Contains(data, "old-key") || !strings.Contains(data, "[e2b]") {
+		t.Errorf("unexpected config file:\n%s", data)
+	}
+	if _, err := UnsetFileValue("e2b.api_key"); err == nil {
+		t.Error("expected an error unsetting a missing key")
+	}
+}
+
+// TestSetFileValueCreatesFile tests writing to a config file that does not
+// exist yet.
+func TestSetFileValueCreatesFile(t *testing.T) {
+	resetGlobals()
+	t.Cleanup(resetGlobals)
+	SetConfigFile(filepath.Join(t.TempDir(), "ags", "config.toml"))
+	if _, err := SetFileValue("region", "ap-tokyo"); err != nil {
+		t.Fatalf("SetFileValue() failed: %v", err)
+	}
+	if data := readConfigFile(t); data != "region = \"ap-tokyo\"\n" {
+		t.Errorf("unexpected config file %q", data)
+	}
+}
+
+// TestEffectiveValues tests the reported source of each value.
+func TestEffectiveValues(t *testing.T) {
+	t.Setenv("AGS_REGION", "ap-singapore")
+	initWithConfig(t, profilesConfig, "prod")
+	SetOutput("json")
+
+	want := map[string]string{
+		"profile":          "flag",
+		"backend":          "profile prod",
+		"output":           "flag",
+		"region":           "env AGS_REGION",
+		"e2b.api_key":      "file",
+		"cloud.secret_key": "profile prod",
+		"debug":            "default",
+	}
+	values := map[string]Value{}
+	for _, v := range EffectiveValues(false) {
+		values[v.Key] = v
+	}
+	for key, source := range want {
+		if values[key].Source != source {
+			t.Errorf("%s: expected source %q, got %q", key, source, values[key].Source)
+		}
+	}
+	if got := values["cloud.secret_key"].Value; got != "********" {
+		t.Errorf("expected masked secret, got %q", got)
+	}
+}
+
+// TestParseValue tests conversion and validation of command line values.
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		key    string
+		raw    string
+		want   any
+		hasErr bool
+	}{
+		{key: "debug", raw: "true", want: true},
+		{key: "debug", raw: "yes", hasErr: true},
+		{key: "retry.max_attempts", raw: "3", want: 3},
+		{key: "retry.max_attempts", raw: "-1", hasErr: true},
+		{key: "retry.base_delay", raw: "500ms", want: "500ms"},
+		{key: "retry.base_delay", raw: "soon", hasErr: true},
+		{key: "backend", raw: "local", want: "local"},
+		{key: "backend", raw: "aws", hasErr: true},
+		{key: "profiles.dev.region", raw: "ap-tokyo", want: "ap-tokyo"},
+	}
+	for _, tt := range tests {
+		s, _, err := LookupSetting(tt.key)
+		if err != nil {
+			t.Fatalf("LookupSetting(%q) failed: %v", tt.key, err)
+		}
+		got, err := ParseValue(s, tt.raw)
+		if (err != nil) != tt.hasErr || (!tt.hasErr && got != tt.want) {
+			t.Errorf("ParseValue(%s, %q) = %v, %v", tt.key, tt.raw, got, err)
+		}
+	}
+
+	for _, key := range []string{"nope", "profiles.dev", "profiles.dev.profile"} {
+		if _, _, err := LookupSetting(key); err == nil {
+			t.Errorf("LookupSetting(%q) should fail", key)
+		}
+	}
+}
+
+func readConfigFile(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
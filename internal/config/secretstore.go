@@ -0,0 +1,264 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/zalando/go-keyring"
+)
+
+// keyringService is the service name of the OS keyring entries; each profile
+// stores its keys under the account "cloud/<profile>".
+const keyringService = "ags-cli"
+
+// tokenKeyAccount is the keyring account of the key that encrypts cached
+// access tokens. It is shared by all profiles, like the token cache file.
+const tokenKeyAccount = "token-cache-key"
+
+const (
+	credentialFileName    = "credentials.enc"
+	credentialFileVersion = 1
+	// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-SHA256.
+	pbkdf2Iterations = 600000
+)
+
+// PassphraseFunc prompts for the passphrase of the encrypted credentials
+// file when AGS_CREDENTIALS_PASSPHRASE is not set. confirm asks for the new
+// passphrase twice when the file is created. It is nil when no terminal is
+// available.
+var PassphraseFunc func(confirm bool) (string, error)
+
+// storedCredential is the secret kept in the keyring or encrypted file.
+type storedCredential struct {
+	SecretID  string `json:"secret_id"`
+	SecretKey string `json:"secret_key"`
+}
+
+// StoreCloudCredential saves keys for the selected profile in the OS keyring
+// or the encrypted credentials file, depending on source.
+func StoreCloudCredential(source, secretID, secretKey string) error {
+	resetCloudCredential()
+	cred := storedCredential{SecretID: secretID, SecretKey: secretKey}
+	switch source {
+	case CredentialSourceKeyring:
+		data, err := json.Marshal(cred)
+		if err != nil {
+			return err
+		}
+		if err := keyring.Set(keyringService, keyringAccount(GetProfile()), string(data)); err != nil {
+			return fmt.Errorf("failed to write to the OS keyring: %w", err)
+		}
+		return nil
+	case CredentialSourceFile:
+		path, err := credentialFilePath()
+		if err != nil {
+			return err
+		}
+		creds, passphrase, err := readCredentialFile(path, true)
+		if err != nil {
+			return err
+		}
+		creds[GetProfile()] = cred
+		return writeCredentialFile(path, creds, passphrase)
+	}
+	return fmt.Errorf("credentials cannot be stored in %q (must be %s or %s)", source, CredentialSourceKeyring, CredentialSourceFile)
+}
+
+// TokenCacheKey returns the key that encrypts cached access tokens, kept in
+// the OS keyring. A missing key is generated and stored when create is set.
+func TokenCacheKey(create bool) ([]byte, error) {
+	encoded, err := keyring.Get(keyringService, tokenKeyAccount)
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("keyring entry %s/%s is malformed; delete it and run 'ags token clear'", keyringService, tokenKeyAccount)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read the token key from the OS keyring: %w", err)
+	}
+	if !create {
+		return nil, fmt.Errorf("no token key in the OS keyring")
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := keyring.Set(keyringService, tokenKeyAccount, base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("failed to store the token key in the OS keyring: %w", err)
+	}
+	return key, nil
+}
+
+func keyringAccount(profile string) string {
+	return "cloud/" + profile
+}
+
+// keyringProvider reads keys stored in the OS keyring (macOS Keychain,
+// Windows Credential Manager or the Secret Service on Linux).
+type keyringProvider struct {
+	profile string
+}
+
+func (keyringProvider) Name() string { return CredentialSourceKeyring }
+
+func (p keyringProvider) Credential() (common.CredentialIface, error) {
+	data, err := keyring.Get(keyringService, keyringAccount(p.profile))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("no keys stored for profile %q; run 'ags config init' to store them", p.profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the OS keyring: %w", err)
+	}
+	var cred storedCredential
+	if err := json.Unmarshal([]byte(data), &cred); err != nil || cred.SecretID == "" || cred.SecretKey == "" {
+		return nil, fmt.Errorf("keyring entry %s/%s is malformed; run 'ags config init' to store the keys again", keyringService, keyringAccount(p.profile))
+	}
+	return common.NewCredential(cred.SecretID, cred.SecretKey), nil
+}
+
+// fileProvider reads keys from the passphrase-encrypted credentials file
+// next to the config file.
+type fileProvider struct {
+	profile string
+}
+
+func (fileProvider) Name() string { return CredentialSourceFile }
+
+func (p fileProvider) Credential() (common.CredentialIface, error) {
+	path, err := credentialFilePath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s does not exist; run 'ags config init' to create it", path)
+	}
+	creds, _, err := readCredentialFile(path, false)
+	if err != nil {
+		return nil, err
+	}
+	cred, ok := creds[p.profile]
+	if !ok {
+		return nil, fmt.Errorf("%s has no keys for profile %q; run 'ags config init' to store them", path, p.profile)
+	}
+	return common.NewCredential(cred.SecretID, cred.SecretKey), nil
+}
+
+// credentialFile is the on-disk format of the encrypted credentials file.
+// The plaintext is a JSON object of storedCredential keyed by profile,
+// sealed with AES-256-GCM under a key derived from the passphrase.
+type credentialFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func credentialFilePath() (string, error) {
+	path, err := FilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), credentialFileName), nil
+}
+
+// readCredentialFile decrypts the credentials file. A missing file yields
+// no credentials when create is set, and the passphrase for the new file.
+func readCredentialFile(path string, create bool) (map[string]storedCredential, string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && create {
+		passphrase, err := credentialPassphrase(true)
+		return map[string]storedCredential{}, passphrase, err
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	var f credentialFile
+	if err := json.Unmarshal(data, &f); err != nil || f.Version != credentialFileVersion || f.KDF != "pbkdf2-sha256" {
+		return nil, "", fmt.Errorf("%s is not a supported credentials file", path)
+	}
+	passphrase, err := credentialPassphrase(false)
+	if err != nil {
+		return nil, "", err
+	}
+	gcm, err := credentialCipher(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return nil, "", err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt %s: wrong passphrase or corrupted file", path)
+	}
+	creds := map[string]storedCredential{}
+	if err := json.Unmarshal(plain, &creds); err != nil {
+		return nil, "", fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return creds, passphrase, nil
+}
+
+func writeCredentialFile(path string, creds map[string]storedCredential, passphrase string) error {
+	plain, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	f := credentialFile{Version: credentialFileVersion, KDF: "pbkdf2-sha256", Iterations: pbkdf2Iterations, Salt: make([]byte, 16)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	gcm, err := credentialCipher(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, plain, nil)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+func credentialCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func credentialPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv("AGS_CREDENTIALS_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	if PassphraseFunc == nil {
+		return "", fmt.Errorf("the credentials file needs a passphrase: set AGS_CREDENTIALS_PASSPHRASE or run in a terminal")
+	}
+	p, err := PassphraseFunc(confirm)
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", fmt.Errorf("the credentials file passphrase must not be empty")
+	}
+	return p, nil
+}
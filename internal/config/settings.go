@@ -0,0 +1,314 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Setting describes a configuration key that can be read and written with
+// `ags config get/set/unset`.
+type Setting struct {
+	Key     string
+	Kind    string   // "string", "bool", "int" or "duration"
+	Env     string   // Environment variable bound to the key, if any
+	Secret  bool     // Masked by `ags config view` and `ags config get`
+	Aliases []string // Deprecated keys that also feed this one
+	Legacy  bool     // Deprecated key, not shown by `ags config view`
+}
+
+// settings lists the supported configuration keys in display order.
+var settings = []Setting{
+	{Key: "profile", Kind: "string", Env: "AGS_PROFILE"},
+	{Key: "backend", Kind: "string", Env: "AGS_BACKEND"},
+	{Key: "output", Kind: "string", Env: "AGS_OUTPUT"},
+	{Key: "region", Kind: "string", Env: "AGS_REGION", Aliases: []string{"cloud.region", "e2b.region"}},
+	{Key: "domain", Kind: "string", Env: "AGS_DOMAIN", Aliases: []string{"e2b.domain"}},
+	{Key: "internal", Kind: "bool", Env: "AGS_INTERNAL", Aliases: []string{"cloud.internal"}},
+	{Key: "debug", Kind: "bool", Env: "AGS_DEBUG"},
+	{Key: "e2b.api_key", Kind: "string", Env: "AGS_E2B_API_KEY", Secret: true},
+	{Key: "e2b.domain", Kind: "string", Env: "AGS_E2B_DOMAIN", Legacy: true},
+	{Key: "e2b.region", Kind: "string", Env: "AGS_E2B_REGION", Legacy: true},
+	{Key: "cloud.secret_id", Kind: "string", Env: "AGS_CLOUD_SECRET_ID", Secret: true},
+	{Key: "cloud.secret_key", Kind: "string", Env: "AGS_CLOUD_SECRET_KEY", Secret: true},
+	{Key: "cloud.token", Kind: "string", Env: "AGS_CLOUD_TOKEN", Secret: true},
+	{Key: "cloud.credential_source", Kind: "string", Env: "AGS_CLOUD_CREDENTIAL_SOURCE"},
+	{Key: "cloud.credential_process", Kind: "string", Env: "AGS_CLOUD_CREDENTIAL_PROCESS"},
+	{Key: "cloud.cvm_role", Kind: "string"},
+	{Key: "cloud.region", Kind: "string", Env: "AGS_CLOUD_REGION", Legacy: true},
+	{Key: "cloud.internal", Kind: "bool", Env: "AGS_CLOUD_INTERNAL", Legacy: true},
+	{Key: "sandbox.default_user", Kind: "string", Env: "AGS_SANDBOX_DEFAULT_USER"},
+	{Key: "retry.max_attempts", Kind: "int", Env: "AGS_RETRY_MAX_ATTEMPTS"},
+	{Key: "retry.base_delay", Kind: "duration"},
+	{Key: "retry.max_delay", Kind: "duration"},
+	{Key: "tokens.ttl", Kind: "duration"},
+	{Key: "tokens.encrypt", Kind: "bool", Env: "AGS_TOKENS_ENCRYPT"},
+}
+
+// flagSet records the keys overridden on the command line, for reporting
+// sources in `ags config view`.
+var flagSet = map[string]bool{}
+
+func markFlag(key string) {
+	flagSet[key] = true
+}
+
+// LookupSetting resolves a key as accepted by `ags config get/set/unset`.
+// Keys of a profile are written as "profiles.<name>.<key>"; the returned
+// profile name is empty for top-level keys.
+func LookupSetting(key string) (Setting, string, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	lookupKey, profile := key, ""
+	if rest, ok := strings.CutPrefix(key, "profiles."); ok {
+		name, sub, found := strings.Cut(rest, ".")
+		if !found || name == "" {
+			return Setting{}, "", fmt.Errorf("invalid key %q: profile keys have the form profiles.<name>.<key>", key)
+		}
+		if sub == "profile" {
+			return Setting{}, "", fmt.Errorf("invalid key %q: a profile cannot select another profile", key)
+		}
+		lookupKey, profile = sub, name
+	}
+	for _, s := range settings {
+		if s.Key == lookupKey {
+			return s, profile, nil
+		}
+	}
+	return Setting{}, "", fmt.Errorf("unknown config key %q (known keys: %s)", key, strings.Join(SettingKeys(), ", "))
+}
+
+// SettingKeys returns the supported keys, excluding deprecated ones.
+func SettingKeys() []string {
+	var keys []string
+	for _, s := range settings {
+		if !s.Legacy {
+			keys = append(keys, s.Key)
+		}
+	}
+	return keys
+}
+
+// ParseValue converts a command line value to the type stored for the
+// setting, rejecting values the CLI would refuse to load. Durations are
+// stored as strings such as "500ms".
+func ParseValue(s Setting, raw string) (any, error) {
+	switch s.Kind {
+	case "bool":
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %q is not a boolean", s.Key, raw)
+		}
+		return v, nil
+	case "int":
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid value for %s: %q is not a non-negative integer", s.Key, raw)
+		}
+		return v, nil
+	case "duration":
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid value for %s: %q is not a duration such as 500ms or 20s", s.Key, raw)
+		}
+		return raw, nil
+	}
+	switch s.Key {
+	case "backend":
+		if raw != "e2b" && raw != "cloud" && raw != "local" {
+			return nil, fmt.Errorf("invalid backend: %s (must be 'e2b', 'cloud' or 'local')", raw)
+		}
+	case "output":
+		if !validOutputFormat(raw) {
+			return nil, fmt.Errorf("invalid output format: %s", raw)
+		}
+	case "cloud.credential_source":
+		if !slices.Contains(CredentialSources, raw) {
+			return nil, fmt.Errorf("invalid cloud.credential_source: %s (must be one of %s)", raw, strings.Join(CredentialSources, ", "))
+		}
+	}
+	return raw, nil
+}
+
+// Value is an effective configuration value and where it came from.
+type Value struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// EffectiveValues returns the value in effect for every non-deprecated key
+// after merging the config file, the selected profile, environment variables
+// and command line flags. Secrets are masked unless showSecrets is set.
+func EffectiveValues(showSecrets bool) []Value {
+	c := Get()
+	file := fileSettings()
+	values := make([]Value, 0, len(settings))
+	for _, s := range settings {
+		if s.Legacy {
+			continue
+		}
+		v := formatValue(effectiveValue(c, s.Key))
+		if s.Secret && !showSecrets {
+			v = MaskSecret(v)
+		}
+		values = append(values, Value{Key: s.Key, Value: v, Source: valueSource(s, file)})
+	}
+	return values
+}
+
+func effectiveValue(c *Config, key string) any {
+	switch key {
+	case "profile":
+		return GetProfile()
+	case "backend":
+		return c.Backend
+	case "output":
+		return c.Output
+	case "region":
+		return c.Region
+	case "domain":
+		return c.Domain
+	case "internal":
+		return c.Internal
+	case "debug":
+		return c.Debug
+	case "e2b.api_key":
+		return c.E2B.APIKey
+	case "cloud.secret_id":
+		return c.Cloud.SecretID
+	case "cloud.secret_key":
+		return c.Cloud.SecretKey
+	case "cloud.token":
+		return c.Cloud.Token
+	case "cloud.credential_source":
+		return c.Cloud.CredentialSource
+	case "cloud.credential_process":
+		return c.Cloud.CredentialProcess
+	case "cloud.cvm_role":
+		return c.Cloud.CVMRole
+	case "sandbox.default_user":
+		return c.Sandbox.DefaultUser
+	case "retry.max_attempts":
+		return c.Retry.MaxAttempts
+	case "retry.base_delay":
+		return c.Retry.BaseDelay
+	case "retry.max_delay":
+		return c.Retry.MaxDelay
+	case "tokens.ttl":
+		return c.Tokens.TTL
+	case "tokens.encrypt":
+		return c.Tokens.Encrypt
+	}
+	return nil
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Duration:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// valueSource reports where the effective value of a setting came from,
+// following the precedence flag > environment > profile > file > default.
+func valueSource(s Setting, file *viper.Viper) string {
+	keys := append([]string{s.Key}, s.Aliases...)
+	if flagSet[s.Key] {
+		return "flag"
+	}
+	for _, k := range keys {
+		if set, ok := lookupSetting(k); ok && set.Env != "" {
+			if v, ok := os.LookupEnv(set.Env); ok && v != "" {
+				return "env " + set.Env
+			}
+		}
+	}
+	if file == nil {
+		return "default"
+	}
+	if p := Get().Profile; p != "" && s.Key != "profile" {
+		for _, k := range keys {
+			if file.IsSet("profiles." + strings.ToLower(p) + "." + k) {
+				return "profile " + p
+			}
+		}
+	}
+	for _, k := range keys {
+		if file.IsSet(k) {
+			return "file"
+		}
+	}
+	return "default"
+}
+
+func lookupSetting(key string) (Setting, bool) {
+	for _, s := range settings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// FileValue returns the value of key as written in the config file, without
+// environment variables, flags or defaults.
+func FileValue(key string) (any, bool) {
+	file := fileSettings()
+	if file == nil || !file.IsSet(key) {
+		return nil, false
+	}
+	return file.Get(key), true
+}
+
+// fileSettings loads the config file on its own, or returns nil when there
+// is none.
+func fileSettings() *viper.Viper {
+	path, err := FilePath()
+	if err != nil {
+		return nil
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil
+	}
+	return v
+}
+
+// FilePath returns the path of the config file in use, which may not exist
+// yet.
+func FilePath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".ags", "config.toml"), nil
+}
+
+// MaskSecret hides all but the last four characters of a secret.
+func MaskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	if len(s) < 12 {
+		return "********"
+	}
+	return "********" + s[len(s)-4:]
+}
@@ -0,0 +1,259 @@
+package localsandbox
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// pythonDriver runs the code read from stdin like a notebook cell: the value
+// of a trailing expression is reported as the result, and exceptions are
+// reported as errors. Both go to fd 3 as JSON lines, keeping them apart from
+// the output of the code itself.
+const pythonDriver = `
+import ast, json, os, sys, traceback
+_ags = os.fdopen(3, "w")
+def _ags_emit(**kw):
+    _ags.write(json.dumps(kw) + "\n")
+    _ags.flush()
+_ags_src = sys.stdin.read()
+sys.stdin = open(os.devnull)
+_ags_globals = {"__name__": "__main__"}
+try:
+    _ags_tree = ast.parse(_ags_src, "<cell>", "exec")
+    _ags_last = None
+    if _ags_tree.body and isinstance(_ags_tree.body[-1], ast.Expr):
+        _ags_last = ast.Expression(_ags_tree.body.pop().value)
+    exec(compile(_ags_tree, "<cell>", "exec"), _ags_globals)
+    if _ags_last is not None:
+        _ags_value = eval(compile(_ags_last, "<cell>", "eval"), _ags_globals)
+        if _ags_value is not None:
+            _ags_emit(type="result", text=repr(_ags_value), is_main_result=True)
+except SystemExit as e:
+    if e.code not in (None, 0):
+        _ags_emit(type="error", name="SystemExit", value=str(e.code), traceback="")
+except BaseException as e:
+    _ags_emit(type="error", name=type(e).__name__, value=str(e), traceback="".join(traceback.format_exception(type(e), e, e.__traceback__.tb_next)))
+`
+
+// interpreters maps languages to the host command reading code on stdin.
+var interpreters = map[string][]string{
+	"python":     {"python3", "-c", pythonDriver},
+	"javascript": {"node", "-"},
+	"js":         {"node", "-"},
+	"r":          {"Rscript", "-"},
+	"bash":       {"bash", "-s"},
+	"sh":         {"sh", "-s"},
+}
+
+// codeContext is a named language and working directory. Unlike a real
+// interpreter context it keeps no state between executions.
+type codeContext struct {
+	ID       string `json:"id"`
+	Language string `json:"language"`
+	Cwd      string `json:"cwd"`
+}
+
+// codeService implements the /execute and /contexts endpoints of the code
+// interpreter by running each execution in a fresh host interpreter.
+type codeService struct {
+	dir string
+	env []string
+
+	mu       sync.Mutex
+	contexts map[string]*codeContext
+}
+
+func newCodeService(dir string, env []string) *codeService {
+	return &codeService{dir: dir, env: env, contexts: make(map[string]*codeContext)}
+}
+
+// serveContexts creates a context (POST /contexts).
+func (s *codeService) serveContexts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Language *string `json:"language"`
+		Cwd      *string `json:"cwd"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	c := &codeContext{ID: randomID(), Language: "python", Cwd: s.dir}
+	if req.Language != nil && *req.Language != "" {
+		c.Language = strings.ToLower(*req.Language)
+	}
+	if _, ok := interpreters[c.Language]; !ok {
+		http.Error(w, fmt.Sprintf("language %q is not supported by the local backend", c.Language), http.StatusBadRequest)
+		return
+	}
+	if req.Cwd != nil && *req.Cwd != "" {
+		c.Cwd = resolvePath(s.dir, *req.Cwd)
+	}
+
+	s.mu.Lock()
+	s.contexts[c.ID] = c
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+// serveExecute runs code (POST /execute) and streams its output, results
+// and errors as JSON lines.
+func (s *codeService) serveExecute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Code      string            `json:"code"`
+		ContextID *string           `json:"context_id"`
+		Language  *string           `json:"language"`
+		EnvVars   map[string]string `json:"env_vars"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	language, cwd := "python", s.dir
+	if req.ContextID != nil && *req.ContextID != "" {
+		s.mu.Lock()
+		c, ok := s.contexts[*req.ContextID]
+		s.mu.Unlock()
+		if !ok {
+			http.Error(w, "context not found: "+*req.ContextID, http.StatusNotFound)
+			return
+		}
+		language, cwd = c.Language, c.Cwd
+	} else if req.Language != nil && *req.Language != "" {
+		language = strings.ToLower(*req.Language)
+	}
+	argv, ok := interpreters[language]
+	if !ok {
+		http.Error(w, fmt.Sprintf("language %q is not supported by the local backend", language), http.StatusBadRequest)
+		return
+	}
+
+	cmd := exec.CommandContext(r.Context(), argv[0], argv[1:]...)
+	cmd.Dir = cwd
+	cmd.Env = append(append([]string{}, s.env...), "PYTHONUNBUFFERED=1")
+	for k, v := range req.EnvVars {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdin = strings.NewReader(req.Code)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resultsR, resultsW, err := os.Pipe()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer resultsR.Close()
+	cmd.ExtraFiles = []*os.File{resultsW}
+	if err := cmd.Start(); err != nil {
+		resultsW.Close()
+		http.Error(w, fmt.Sprintf("failed to start %s: %v", argv[0], err), http.StatusInternalServerError)
+		return
+	}
+	resultsW.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	out := &lineWriter{w: w}
+	out.flush()
+
+	var wg sync.WaitGroup
+	stream := func(typ string, r io.Reader) {
+		defer wg.Done()
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if line != "" {
+				out.emit(map[string]any{"type": typ, "text": line})
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+	wg.Add(2)
+	go stream("stdout", stdout)
+	go stream("stderr", stderr)
+
+	var reported bool
+	scanner := bufio.NewScanner(resultsR)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var msg map[string]any
+		if json.Unmarshal(scanner.Bytes(), &msg) != nil {
+			continue
+		}
+		if msg["type"] == "error" {
+			reported = true
+		}
+		out.emit(msg)
+	}
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil && !reported {
+		name := "ExecutionError"
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			name = "ExitError"
+		}
+		out.emit(map[string]any{"type": "error", "name": name, "value": err.Error(), "traceback": ""})
+	}
+}
+
+// lineWriter writes JSON lines to a streaming response from several
+// goroutines.
+type lineWriter struct {
+	mu sync.Mutex
+	w  http.ResponseWriter
+}
+
+func (l *lineWriter) emit(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(append(data, '\n'))
+	l.flush()
+}
+
+func (l *lineWriter) flush() {
+	if f, ok := l.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
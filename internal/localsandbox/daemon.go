@@ -0,0 +1,164 @@
+package localsandbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// readyTimeout bounds how long Start waits for the daemon to listen.
+	readyTimeout = 10 * time.Second
+	// stopTimeout is how long Stop waits for a graceful daemon shutdown
+	// before killing its process group.
+	stopTimeout = 5 * time.Second
+)
+
+// readyMessage is the single JSON line a daemon writes to stdout once it
+// serves the data plane, or when it fails to start.
+type readyMessage struct {
+	Status  string `json:"status"` // "ready" or "error"
+	PID     int    `json:"pid,omitempty"`
+	Port    int    `json:"port,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Start launches the daemon of a saved instance in a new process group and
+// waits until it serves the data plane, then records its PID and port.
+func (s *Store) Start(inst *Instance) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	cmd := exec.Command(exe, ServeCommand, inst.ID)
+	cmd.Dir = inst.Dir
+	cmd.Env = os.Environ()
+	if err := setProcessGroup(cmd); err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(s.LogPath(inst.ID), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create daemon log: %w", err)
+	}
+	defer logFile.Close()
+	cmd.Stderr = logFile
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start local sandbox daemon: %w", err)
+	}
+
+	readyCh := make(chan readyMessage, 1)
+	go func() {
+		var msg readyMessage
+		scanner := bufio.NewScanner(stdout)
+		if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &msg) != nil {
+			msg = readyMessage{Status: "error", Message: "daemon exited without ready message, see " + s.LogPath(inst.ID)}
+		}
+		readyCh <- msg
+	}()
+
+	var ready readyMessage
+	select {
+	case ready = <-readyCh:
+	case <-time.After(readyTimeout):
+		ready = readyMessage{Status: "error", Message: fmt.Sprintf("daemon did not become ready within %s", readyTimeout)}
+	}
+	if ready.Status != "ready" || ready.Port == 0 {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("failed to start local sandbox: %s", ready.Message)
+	}
+	// The daemon outlives this process; reap it if we are still around
+	go func() { _ = cmd.Wait() }()
+
+	inst.PID = ready.PID
+	inst.ExePath = exe
+	inst.Port = ready.Port
+	return s.Save(inst)
+}
+
+// Stop terminates the daemon of inst together with every process it
+// started, and removes the instance directory, record and log.
+func (s *Store) Stop(inst *Instance) error {
+	if inst.Running() {
+		if err := terminateGroup(inst.PID, inst.ExePath, stopTimeout); err != nil {
+			return fmt.Errorf("failed to stop local sandbox %s: %w", inst.ID, err)
+		}
+	}
+	s.cleanup(inst)
+	return nil
+}
+
+// Serve runs the daemon of instance id. It serves the data plane on a
+// loopback port until the instance expires or the daemon is terminated, then
+// stops every process it started and removes the instance.
+func Serve(id string) error {
+	fail := func(err error) error {
+		_ = json.NewEncoder(os.Stdout).Encode(readyMessage{Status: "error", Message: err.Error()})
+		return err
+	}
+
+	store, err := NewStore()
+	if err != nil {
+		return fail(err)
+	}
+	inst, err := store.Get(id)
+	if err != nil {
+		return fail(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fail(fmt.Errorf("failed to listen: %w", err))
+	}
+
+	// The parent stops reading stdout after the ready message and the
+	// terminal may go away; neither should take the daemon down.
+	ignoreHangup()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithDeadline(ctx, inst.ExpiresAt)
+	defer cancel()
+
+	srv := NewServer(inst)
+	httpServer := &http.Server{Handler: srv, ReadHeaderTimeout: 30 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- httpServer.Serve(listener) }()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	if err := json.NewEncoder(os.Stdout).Encode(readyMessage{Status: "ready", PID: os.Getpid(), Port: port}); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "local sandbox %s serving on 127.0.0.1:%d until %s\n", id, port, inst.ExpiresAt.Format(time.RFC3339))
+
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			fmt.Fprintf(os.Stderr, "local sandbox %s expired\n", id)
+		}
+	case err = <-serveErr:
+		fmt.Fprintf(os.Stderr, "local sandbox %s: %v\n", id, err)
+	}
+
+	// Kill the commands first so that their streaming responses end and
+	// Shutdown does not wait for them.
+	srv.Close()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelShutdown()
+	_ = httpServer.Shutdown(shutdownCtx)
+	store.cleanup(inst)
+	return nil
+}
@@ -0,0 +1,107 @@
+package localsandbox
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/constant"
+)
+
+// localAddr maps a data plane host name, <port>-<instance id>.<domain>, to
+// the loopback address serving it. The envd and code interpreter ports go to
+// the instance daemon; any other port is a service the user started in the
+// instance, listening on the host directly. ok is false for hosts that do not
+// belong to a local instance.
+func localAddr(host string) (addr string, ok bool, err error) {
+	label, _, _ := strings.Cut(host, ".")
+	portStr, id, found := strings.Cut(label, "-")
+	if !found || !strings.HasPrefix(id, IDPrefix) {
+		return "", false, nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return "", true, fmt.Errorf("invalid port in local sandbox host %s", host)
+	}
+	if port != constant.EnvdPort && port != constant.CodePort {
+		return net.JoinHostPort("127.0.0.1", portStr), true, nil
+	}
+
+	store, err := NewStore()
+	if err != nil {
+		return "", true, err
+	}
+	inst, err := store.Get(id)
+	if err != nil {
+		return "", true, err
+	}
+	if !inst.Running() {
+		return "", true, fmt.Errorf("%w: %s is not running", ErrNotFound, id)
+	}
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(inst.Port)), true, nil
+}
+
+// IsLocalHost reports whether host is a data plane host of a local instance.
+func IsLocalHost(host string) bool {
+	label, _, _ := strings.Cut(host, ".")
+	_, id, found := strings.Cut(label, "-")
+	return found && strings.HasPrefix(id, IDPrefix)
+}
+
+// DialTLSContext dials addr like a TLS dialer, except that hosts of local
+// instances are connected to their loopback address in plain text. It can be
+// used wherever a TLS dial function is accepted (http.Transport,
+// websocket.Dialer) to reach local instances through their https and wss
+// URLs.
+func DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	target, ok, err := localAddr(host)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", target)
+	}
+	d := tls.Dialer{Config: &tls.Config{ServerName: host}}
+	return d.DialContext(ctx, network, addr)
+}
+
+// transport sends requests for local instances over plain loopback
+// connections and everything else through base.
+type transport struct {
+	base  http.RoundTripper
+	local http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if IsLocalHost(req.URL.Hostname()) {
+		return t.local.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// InstallTransport makes http.DefaultTransport route requests for local
+// instances to their daemons. The data plane clients of ags-go-sdk use the
+// default transport and always build https URLs, so this is what lets them
+// talk to local instances unchanged. Repeated calls have no effect.
+func InstallTransport() {
+	if _, ok := http.DefaultTransport.(*transport); ok {
+		return
+	}
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return
+	}
+	local := base.Clone()
+	local.DialTLSContext = DialTLSContext
+	local.ForceAttemptHTTP2 = false
+	http.DefaultTransport = &transport{base: base, local: local}
+}
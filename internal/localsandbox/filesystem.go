@@ -0,0 +1,291 @@
+package localsandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/filesystem"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/filesystem/filesystemconnect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxUploadMemory is how much of a multipart upload is buffered in memory
+// before spilling to a temporary file.
+const maxUploadMemory = 32 << 20
+
+// filesystemService implements the envd filesystem service and the /files
+// transfer endpoint. Watchers are not supported.
+type filesystemService struct {
+	filesystemconnect.UnimplementedFilesystemHandler
+
+	dir string
+}
+
+func (s *filesystemService) path(p string) (string, error) {
+	if p == "" {
+		return "", connect.NewError(connect.CodeInvalidArgument, errors.New("path is required"))
+	}
+	return resolvePath(s.dir, p), nil
+}
+
+// entryInfo describes the file at path without following a final symlink.
+func entryInfo(path string) (*filesystem.EntryInfo, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	entry := &filesystem.EntryInfo{
+		Name:         fi.Name(),
+		Type:         filesystem.FileType_FILE_TYPE_FILE,
+		Path:         path,
+		Size:         fi.Size(),
+		Mode:         uint32(fi.Mode().Perm()),
+		Permissions:  fi.Mode().String(),
+		ModifiedTime: timestamppb.New(fi.ModTime()),
+	}
+	entry.Owner, entry.Group = fileOwner(fi)
+	if fi.Mode()&fs.ModeSymlink != 0 {
+		if target, err := os.Readlink(path); err == nil {
+			entry.SymlinkTarget = &target
+		}
+		if st, err := os.Stat(path); err == nil {
+			fi = st
+		}
+	}
+	if fi.IsDir() {
+		entry.Type = filesystem.FileType_FILE_TYPE_DIRECTORY
+	}
+	return entry, nil
+}
+
+// fsError maps filesystem errors onto connect codes.
+func fsError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return connect.NewError(connect.CodeNotFound, err)
+	case errors.Is(err, fs.ErrExist):
+		return connect.NewError(connect.CodeAlreadyExists, err)
+	case errors.Is(err, fs.ErrPermission):
+		return connect.NewError(connect.CodePermissionDenied, err)
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
+
+func (s *filesystemService) Stat(ctx context.Context, req *connect.Request[filesystem.StatRequest]) (*connect.Response[filesystem.StatResponse], error) {
+	path, err := s.path(req.Msg.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	entry, err := entryInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&filesystem.StatResponse{Entry: entry}), nil
+}
+
+// MakeDir creates a directory and its parents. The entry is omitted when the
+// directory already existed.
+func (s *filesystemService) MakeDir(ctx context.Context, req *connect.Request[filesystem.MakeDirRequest]) (*connect.Response[filesystem.MakeDirResponse], error) {
+	path, err := s.path(req.Msg.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(path); err == nil {
+		if !fi.IsDir() {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("path is not a directory: %s", path))
+		}
+		return connect.NewResponse(&filesystem.MakeDirResponse{}), nil
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fsError(err)
+	}
+	entry, err := entryInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&filesystem.MakeDirResponse{Entry: entry}), nil
+}
+
+func (s *filesystemService) Move(ctx context.Context, req *connect.Request[filesystem.MoveRequest]) (*connect.Response[filesystem.MoveResponse], error) {
+	src, err := s.path(req.Msg.GetSource())
+	if err != nil {
+		return nil, err
+	}
+	dst, err := s.path(req.Msg.GetDestination())
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, fsError(err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return nil, fsError(err)
+	}
+	entry, err := entryInfo(dst)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&filesystem.MoveResponse{Entry: entry}), nil
+}
+
+// ListDir lists a directory down to the requested depth (default 1).
+func (s *filesystemService) ListDir(ctx context.Context, req *connect.Request[filesystem.ListDirRequest]) (*connect.Response[filesystem.ListDirResponse], error) {
+	root, err := s.path(req.Msg.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	depth := int(req.Msg.GetDepth())
+	if depth == 0 {
+		depth = 1
+	}
+	fi, err := os.Stat(root)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	if !fi.IsDir() {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("path is not a directory: %s", root))
+	}
+
+	var entries []*filesystem.EntryInfo
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		level := strings.Count(rel, string(filepath.Separator)) + 1
+		if level > depth {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		entry, err := entryInfo(path)
+		if err != nil {
+			return nil
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fsError(err)
+	}
+	return connect.NewResponse(&filesystem.ListDirResponse{Entries: entries}), nil
+}
+
+func (s *filesystemService) Remove(ctx context.Context, req *connect.Request[filesystem.RemoveRequest]) (*connect.Response[filesystem.RemoveResponse], error) {
+	path, err := s.path(req.Msg.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return nil, fsError(err)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return nil, fsError(err)
+	}
+	return connect.NewResponse(&filesystem.RemoveResponse{}), nil
+}
+
+// writeInfo is an element of the /files upload response.
+type writeInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// serveFiles downloads (GET) or uploads (POST, multipart field "file") the
+// file named by the path query parameter.
+func (s *filesystemService) serveFiles(w http.ResponseWriter, r *http.Request) {
+	p := r.URL.Query().Get("path")
+	if p == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+	path := resolvePath(s.dir, p)
+
+	switch r.Method {
+	case http.MethodGet:
+		f, err := os.Open(path)
+		if err != nil {
+			http.Error(w, err.Error(), httpStatus(err))
+			return
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			http.Error(w, err.Error(), httpStatus(err))
+			return
+		}
+		if fi.IsDir() {
+			http.Error(w, "path is a directory: "+p, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+
+	case http.MethodPost:
+		if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+			http.Error(w, "invalid multipart upload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = r.MultipartForm.RemoveAll() }()
+		src, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "file field is required", http.StatusBadRequest)
+			return
+		}
+		defer src.Close()
+		if err := writeFile(path, src); err != nil {
+			http.Error(w, err.Error(), httpStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]writeInfo{{Name: filepath.Base(path), Type: "file", Path: path}})
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeFile replaces path with the contents of src, creating parent
+// directories as needed.
+func writeFile(path string, src io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,443 @@
+package localsandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/process"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/process/processconnect"
+)
+
+// keepaliveInterval is how often idle process streams get a keepalive event.
+const keepaliveInterval = 30 * time.Second
+
+// processService implements the envd process service. Processes keep running
+// when the stream that started them goes away and can be reattached with
+// Connect until they exit.
+type processService struct {
+	processconnect.UnimplementedProcessHandler
+
+	dir string
+	env []string
+
+	mu    sync.Mutex
+	procs map[uint32]*managedProcess
+}
+
+func newProcessService(dir string, env []string) *processService {
+	return &processService{dir: dir, env: env, procs: make(map[uint32]*managedProcess)}
+}
+
+// managedProcess is a running command and the streams following its output.
+type managedProcess struct {
+	config *process.ProcessConfig
+	tag    *string
+	pid    uint32
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser // nil for PTY processes
+	pty    *os.File       // nil for plain processes
+
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+	end  *process.ProcessEvent // set once the process has exited
+}
+
+// subscriber receives the events of a process until its context ends.
+type subscriber struct {
+	ctx    context.Context
+	events chan *process.ProcessEvent
+}
+
+func (p *managedProcess) subscribe(ctx context.Context) (*subscriber, *process.ProcessEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.end != nil {
+		return nil, p.end
+	}
+	s := &subscriber{ctx: ctx, events: make(chan *process.ProcessEvent, 64)}
+	p.subs[s] = struct{}{}
+	return s, nil
+}
+
+func (p *managedProcess) unsubscribe(s *subscriber) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.subs, s)
+}
+
+// publish delivers ev to every subscriber, skipping those that went away.
+func (p *managedProcess) publish(ev *process.ProcessEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for s := range p.subs {
+		select {
+		case s.events <- ev:
+		case <-s.ctx.Done():
+		}
+	}
+	if _, ok := ev.Event.(*process.ProcessEvent_End); ok {
+		p.end = ev
+		for s := range p.subs {
+			close(s.events)
+		}
+		p.subs = nil
+	}
+}
+
+func (p *managedProcess) info() *process.ProcessInfo {
+	return &process.ProcessInfo{Config: p.config, Pid: p.pid, Tag: p.tag}
+}
+
+// Start runs a command and streams its events until it exits or the client
+// goes away.
+func (s *processService) Start(ctx context.Context, req *connect.Request[process.StartRequest], stream *connect.ServerStream[process.StartResponse]) error {
+	cfg := req.Msg.GetProcess()
+	if cfg == nil || cfg.GetCmd() == "" {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("process cmd is required"))
+	}
+	proc, err := s.start(cfg, req.Msg.GetPty(), req.Msg.Tag)
+	if err != nil {
+		return err
+	}
+	sub, end := proc.subscribe(ctx)
+	return streamEvents(ctx, proc, sub, end, func(ev *process.ProcessEvent) error {
+		return stream.Send(&process.StartResponse{Event: ev})
+	})
+}
+
+// Connect attaches to a running process and streams its further events.
+func (s *processService) Connect(ctx context.Context, req *connect.Request[process.ConnectRequest], stream *connect.ServerStream[process.ConnectResponse]) error {
+	proc, err := s.lookup(req.Msg.GetProcess())
+	if err != nil {
+		return err
+	}
+	sub, end := proc.subscribe(ctx)
+	return streamEvents(ctx, proc, sub, end, func(ev *process.ProcessEvent) error {
+		return stream.Send(&process.ConnectResponse{Event: ev})
+	})
+}
+
+// streamEvents sends the start event, then the events of sub (or end, if the
+// process had already exited) with keepalives in between.
+func streamEvents(ctx context.Context, proc *managedProcess, sub *subscriber, end *process.ProcessEvent, send func(*process.ProcessEvent) error) error {
+	if sub != nil {
+		defer proc.unsubscribe(sub)
+	}
+	start := &process.ProcessEvent{Event: &process.ProcessEvent_Start{Start: &process.ProcessEvent_StartEvent{Pid: proc.pid}}}
+	if err := send(start); err != nil {
+		return err
+	}
+	if sub == nil {
+		return send(end)
+	}
+
+	keepalive := time.NewTicker(keepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.events:
+			if !ok {
+				return nil
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+		case <-keepalive.C:
+			ka := &process.ProcessEvent{Event: &process.ProcessEvent_Keepalive{Keepalive: &process.ProcessEvent_KeepAlive{}}}
+			if err := send(ka); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// start launches cfg, optionally on a new PTY, and registers it.
+func (s *processService) start(cfg *process.ProcessConfig, pty *process.PTY, tag *string) (*managedProcess, error) {
+	cmd := exec.Command(cfg.GetCmd(), cfg.GetArgs()...)
+	cmd.Dir = s.dir
+	if cwd := cfg.GetCwd(); cwd != "" {
+		cmd.Dir = resolvePath(s.dir, cwd)
+	}
+	cmd.Env = append([]string{}, s.env...)
+	for k, v := range cfg.GetEnvs() {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	proc := &managedProcess{config: cfg, tag: tag, cmd: cmd, subs: make(map[*subscriber]struct{})}
+	var readers []io.Reader
+	if pty != nil {
+		cmd.Env = append(cmd.Env, "TERM=xterm-256color")
+		size := pty.GetSize()
+		master, err := startPTY(cmd, uint16(size.GetCols()), uint16(size.GetRows()))
+		if err != nil {
+			return nil, startError(err)
+		}
+		proc.pty = master
+		readers = []io.Reader{master}
+	} else {
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, startError(err)
+		}
+		proc.stdin = stdin
+		readers = []io.Reader{stdout, stderr}
+	}
+	proc.pid = uint32(cmd.Process.Pid)
+
+	s.mu.Lock()
+	s.procs[proc.pid] = proc
+	s.mu.Unlock()
+
+	go s.wait(proc, readers)
+	return proc, nil
+}
+
+func startError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return connect.NewError(connect.CodeNotFound, err)
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
+
+// wait forwards the output of proc until it is closed, then publishes the
+// end event and forgets the process.
+func (s *processService) wait(proc *managedProcess, readers []io.Reader) {
+	var wg sync.WaitGroup
+	for i, r := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := r.Read(buf)
+				if n > 0 {
+					data := append([]byte(nil), buf[:n]...)
+					proc.publish(dataEvent(proc.pty != nil, i == 1, data))
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	err := proc.cmd.Wait()
+	if proc.pty != nil {
+		_ = proc.pty.Close()
+	}
+
+	end := &process.ProcessEvent_EndEvent{Exited: true}
+	if state := proc.cmd.ProcessState; state != nil {
+		end.Status = state.String()
+		if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			end.ExitCode = -1
+			end.Exited = false
+			msg := state.String()
+			end.Error = &msg
+		} else {
+			end.ExitCode = int32(state.ExitCode())
+		}
+	} else if err != nil {
+		msg := err.Error()
+		end.ExitCode = -1
+		end.Status = msg
+		end.Error = &msg
+	}
+
+	s.mu.Lock()
+	delete(s.procs, proc.pid)
+	s.mu.Unlock()
+	proc.publish(&process.ProcessEvent{Event: &process.ProcessEvent_End{End: end}})
+}
+
+func dataEvent(pty, stderr bool, data []byte) *process.ProcessEvent {
+	var out process.ProcessEvent_DataEvent
+	switch {
+	case pty:
+		out.Output = &process.ProcessEvent_DataEvent_Pty{Pty: data}
+	case stderr:
+		out.Output = &process.ProcessEvent_DataEvent_Stderr{Stderr: data}
+	default:
+		out.Output = &process.ProcessEvent_DataEvent_Stdout{Stdout: data}
+	}
+	return &process.ProcessEvent{Event: &process.ProcessEvent_Data{Data: &out}}
+}
+
+// lookup finds a running process by PID or tag.
+func (s *processService) lookup(sel *process.ProcessSelector) (*managedProcess, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch v := sel.GetSelector().(type) {
+	case *process.ProcessSelector_Pid:
+		if proc, ok := s.procs[v.Pid]; ok {
+			return proc, nil
+		}
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("process with pid %d not found", v.Pid))
+	case *process.ProcessSelector_Tag:
+		for _, proc := range s.procs {
+			if proc.tag != nil && *proc.tag == v.Tag {
+				return proc, nil
+			}
+		}
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("process with tag %q not found", v.Tag))
+	}
+	return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("process selector is required"))
+}
+
+// List returns the running processes.
+func (s *processService) List(ctx context.Context, req *connect.Request[process.ListRequest]) (*connect.Response[process.ListResponse], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &process.ListResponse{}
+	for _, proc := range s.procs {
+		resp.Processes = append(resp.Processes, proc.info())
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// SendInput writes to the stdin or PTY of a process.
+func (s *processService) SendInput(ctx context.Context, req *connect.Request[process.SendInputRequest]) (*connect.Response[process.SendInputResponse], error) {
+	proc, err := s.lookup(req.Msg.GetProcess())
+	if err != nil {
+		return nil, err
+	}
+	if err := proc.write(req.Msg.GetInput()); err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&process.SendInputResponse{}), nil
+}
+
+// StreamInput writes a stream of inputs to the process selected by the
+// first message.
+func (s *processService) StreamInput(ctx context.Context, stream *connect.ClientStream[process.StreamInputRequest]) (*connect.Response[process.StreamInputResponse], error) {
+	var proc *managedProcess
+	for stream.Receive() {
+		switch ev := stream.Msg().GetEvent().(type) {
+		case *process.StreamInputRequest_Start:
+			p, err := s.lookup(ev.Start.GetProcess())
+			if err != nil {
+				return nil, err
+			}
+			proc = p
+		case *process.StreamInputRequest_Data:
+			if proc == nil {
+				return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("input stream must start with a start event"))
+			}
+			if err := proc.write(ev.Data.GetInput()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&process.StreamInputResponse{}), nil
+}
+
+func (p *managedProcess) write(in *process.ProcessInput) error {
+	var err error
+	switch v := in.GetInput().(type) {
+	case *process.ProcessInput_Stdin:
+		if p.stdin == nil {
+			return connect.NewError(connect.CodeFailedPrecondition, errors.New("process has a PTY, send pty input instead"))
+		}
+		_, err = p.stdin.Write(v.Stdin)
+	case *process.ProcessInput_Pty:
+		if p.pty == nil {
+			return connect.NewError(connect.CodeFailedPrecondition, errors.New("process has no PTY, send stdin input instead"))
+		}
+		_, err = p.pty.Write(v.Pty)
+	default:
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("input is required"))
+	}
+	if err != nil {
+		return connect.NewError(connect.CodeInternal, fmt.Errorf("failed to write input: %w", err))
+	}
+	return nil
+}
+
+// SendSignal delivers SIGTERM or SIGKILL to a process.
+func (s *processService) SendSignal(ctx context.Context, req *connect.Request[process.SendSignalRequest]) (*connect.Response[process.SendSignalResponse], error) {
+	proc, err := s.lookup(req.Msg.GetProcess())
+	if err != nil {
+		return nil, err
+	}
+	var sig syscall.Signal
+	switch req.Msg.GetSignal() {
+	case process.Signal_SIGNAL_SIGTERM:
+		sig = syscall.SIGTERM
+	case process.Signal_SIGNAL_SIGKILL:
+		sig = syscall.SIGKILL
+	default:
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unsupported signal %s", req.Msg.GetSignal()))
+	}
+	if err := killProcessTree(int(proc.pid), sig); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to signal process %d: %w", proc.pid, err))
+	}
+	return connect.NewResponse(&process.SendSignalResponse{}), nil
+}
+
+// Update resizes the PTY of a process.
+func (s *processService) Update(ctx context.Context, req *connect.Request[process.UpdateRequest]) (*connect.Response[process.UpdateResponse], error) {
+	proc, err := s.lookup(req.Msg.GetProcess())
+	if err != nil {
+		return nil, err
+	}
+	if size := req.Msg.GetPty().GetSize(); size != nil {
+		if proc.pty == nil {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("process has no PTY"))
+		}
+		if err := resizePTY(proc.pty, uint16(size.GetCols()), uint16(size.GetRows())); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+	}
+	return connect.NewResponse(&process.UpdateResponse{}), nil
+}
+
+// closeAll kills every running process.
+func (s *processService) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for pid := range s.procs {
+		_ = killProcessTree(int(pid), syscall.SIGKILL)
+	}
+}
+
+// resolvePath resolves p against the instance directory unless it is
+// absolute. A leading "~" refers to the instance directory as well, as it
+// is the HOME of every command.
+func resolvePath(dir, p string) string {
+	if p == "~" {
+		return dir
+	}
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		return filepath.Join(dir, rest)
+	}
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(dir, p)
+}
@@ -0,0 +1,114 @@
+//go:build !windows
+
+package localsandbox
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/signal"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup makes the daemon the leader of a new process group, which
+// the commands it runs inherit, so that stopping the instance reaches all of
+// them.
+func setProcessGroup(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return nil
+}
+
+// processAlive reports whether pid exists by sending signal 0.
+func processAlive(pid int) bool {
+	return pid > 0 && syscall.Kill(pid, 0) == nil
+}
+
+// daemonAlive reports whether pid is still the daemon started from exePath.
+// It compares /proc/<pid>/exe on Linux and argv[0] elsewhere, so that a pid
+// reused by an unrelated process is not mistaken for the daemon. Records
+// without an executable path only get the liveness check.
+func daemonAlive(pid int, exePath string) bool {
+	if !processAlive(pid) {
+		return false
+	}
+	if exePath == "" {
+		return true
+	}
+	if link, err := os.Readlink("/proc/" + strconv.Itoa(pid) + "/exe"); err == nil {
+		// An upgraded binary still runs as the replaced file
+		return strings.TrimSuffix(link, " (deleted)") == exePath
+	}
+	out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "args=").Output()
+	if err != nil {
+		return false
+	}
+	argv0, _, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	return argv0 == exePath
+}
+
+// terminateGroup asks the daemon pid to shut down, then kills its process
+// group if it is still alive after timeout. A pid that no longer leads its
+// own group or no longer runs exePath has been reused by an unrelated
+// process and is left alone.
+func terminateGroup(pid int, exePath string, timeout time.Duration) error {
+	if pgid, err := syscall.Getpgid(pid); err != nil || pgid != pid {
+		return nil
+	}
+	if !daemonAlive(pid, exePath) {
+		return nil
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return nil
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if !daemonAlive(pid, exePath) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	// The group outlives its leader, so it is killed even when the daemon
+	// exited; only a group whose leader pid was reused is left alone.
+	if processAlive(pid) && !daemonAlive(pid, exePath) {
+		return nil
+	}
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		return err
+	}
+	return nil
+}
+
+// ignoreHangup keeps the daemon alive when its terminal or stdout reader
+// goes away.
+func ignoreHangup() {
+	signal.Ignore(syscall.SIGHUP, syscall.SIGPIPE)
+}
+
+// killProcessTree kills a command and, when it leads its own process group
+// (PTY sessions), the rest of that group.
+func killProcessTree(pid int, sig syscall.Signal) error {
+	if pgid, err := syscall.Getpgid(pid); err == nil && pgid == pid {
+		return syscall.Kill(-pid, sig)
+	}
+	return syscall.Kill(pid, sig)
+}
+
+// fileOwner returns the user and group names owning a file.
+func fileOwner(fi fs.FileInfo) (owner, group string) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+	owner, group = strconv.FormatUint(uint64(st.Uid), 10), strconv.FormatUint(uint64(st.Gid), 10)
+	if u, err := user.LookupId(owner); err == nil {
+		owner = u.Username
+	}
+	if g, err := user.LookupGroupId(group); err == nil {
+		group = g.Name
+	}
+	return owner, group
+}
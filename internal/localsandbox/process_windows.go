@@ -0,0 +1,41 @@
+//go:build windows
+
+package localsandbox
+
+import (
+	"errors"
+	"io/fs"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// errUnsupportedPlatform is returned on Windows, which lacks the process
+// groups the local backend relies on.
+var errUnsupportedPlatform = errors.New("the local backend is not supported on Windows")
+
+func setProcessGroup(cmd *exec.Cmd) error {
+	return errUnsupportedPlatform
+}
+
+func processAlive(pid int) bool {
+	return false
+}
+
+func daemonAlive(pid int, exePath string) bool {
+	return false
+}
+
+func terminateGroup(pid int, exePath string, timeout time.Duration) error {
+	return errUnsupportedPlatform
+}
+
+func ignoreHangup() {}
+
+func killProcessTree(pid int, sig syscall.Signal) error {
+	return errUnsupportedPlatform
+}
+
+func fileOwner(fi fs.FileInfo) (owner, group string) {
+	return "", ""
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package localsandbox
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var errPTYUnsupported = errors.New("PTY sessions are only supported by the local backend on Linux")
+
+func startPTY(cmd *exec.Cmd, cols, rows uint16) (*os.File, error) {
+	return nil, errPTYUnsupported
+}
+
+func resizePTY(master *os.File, cols, rows uint16) error {
+	return errPTYUnsupported
+}
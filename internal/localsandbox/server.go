@@ -0,0 +1,65 @@
+package localsandbox
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/filesystem/filesystemconnect"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/process/processconnect"
+)
+
+// Server is the data plane of a local instance. It serves the envd services
+// and the code interpreter endpoints on a single listener, since both are
+// routed to the same daemon regardless of the port in the host name.
+type Server struct {
+	token   string
+	mux     *http.ServeMux
+	process *processService
+}
+
+// NewServer returns the data plane of inst. Commands run in inst.Dir with
+// HOME pointing to it.
+func NewServer(inst *Instance) *Server {
+	env := append(os.Environ(), "HOME="+inst.Dir)
+
+	token := inst.Token
+	if inst.Open {
+		token = ""
+	}
+	s := &Server{
+		token:   token,
+		mux:     http.NewServeMux(),
+		process: newProcessService(inst.Dir, env),
+	}
+	fsSvc := &filesystemService{dir: inst.Dir}
+	codeSvc := newCodeService(inst.Dir, env)
+
+	s.mux.Handle(processconnect.NewProcessHandler(s.process))
+	s.mux.Handle(filesystemconnect.NewFilesystemHandler(fsSvc))
+	s.mux.HandleFunc("/files", fsSvc.serveFiles)
+	s.mux.HandleFunc("/execute", codeSvc.serveExecute)
+	s.mux.HandleFunc("/contexts", codeSvc.serveContexts)
+	s.mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return s
+}
+
+// ServeHTTP checks the access token like the sandbox gateway does, then
+// dispatches the request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" && r.URL.Path != "/health" {
+		got := r.Header.Get("X-Access-Token")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			http.Error(w, "invalid or missing access token", http.StatusUnauthorized)
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close kills every process started through the server.
+func (s *Server) Close() {
+	s.process.closeAll()
+}
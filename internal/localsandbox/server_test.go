@@ -0,0 +1,278 @@
+package localsandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/filesystem"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/filesystem/filesystemconnect"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/process"
+	"github.com/TencentCloudAgentRuntime/ags-go-sdk/pb/process/processconnect"
+)
+
+const testToken = "test-token"
+
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	srv := NewServer(&Instance{ID: "local-test", Dir: dir, Token: testToken})
+	ts := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		srv.Close()
+		ts.Close()
+	})
+	return ts, dir
+}
+
+func withToken[T any](msg *T) *connect.Request[T] {
+	req := connect.NewRequest(msg)
+	req.Header().Set("X-Access-Token", testToken)
+	return req
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	ts, _ := newTestServer(t)
+	cli := filesystemconnect.NewFilesystemClient(ts.Client(), ts.URL, connect.WithProtoJSON())
+	_, err := cli.Stat(context.Background(), connect.NewRequest(&filesystem.StatRequest{Path: "."}))
+	if connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("Stat() without token error = %v, want unauthenticated", err)
+	}
+}
+
+func TestServerOpen(t *testing.T) {
+	srv := NewServer(&Instance{ID: "local-open", Dir: t.TempDir(), Token: testToken, Open: true})
+	ts := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		srv.Close()
+		ts.Close()
+	})
+	cli := filesystemconnect.NewFilesystemClient(ts.Client(), ts.URL, connect.WithProtoJSON())
+	if _, err := cli.Stat(context.Background(), connect.NewRequest(&filesystem.StatRequest{Path: "."})); err != nil {
+		t.Errorf("Stat() without token on an open instance error = %v", err)
+	}
+	if _, err := cli.Stat(context.Background(), withToken(&filesystem.StatRequest{Path: "."})); err != nil {
+		t.Errorf("Stat() with token on an open instance error = %v", err)
+	}
+}
+
+func TestProcessStart(t *testing.T) {
+	ts, dir := newTestServer(t)
+	cli := processconnect.NewProcessClient(ts.Client(), ts.URL, connect.WithProtoJSON())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := cli.Start(ctx, withToken(&process.StartRequest{Process: &process.ProcessConfig{
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", `pwd; echo "$GREETING" >&2; exit 3`},
+		Envs: map[string]string{"GREETING": "hello"},
+	}}))
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	var stdout, stderr string
+	var end *process.ProcessEvent_EndEvent
+	var gotStart bool
+	for stream.Receive() {
+		ev := stream.Msg().GetEvent()
+		switch {
+		case ev.GetStart() != nil:
+			gotStart = ev.GetStart().GetPid() > 0
+		case ev.GetData() != nil:
+			stdout += string(ev.GetData().GetStdout())
+			stderr += string(ev.GetData().GetStderr())
+		case ev.GetEnd() != nil:
+			end = ev.GetEnd()
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("stream error: %v", err)
+	}
+	if !gotStart {
+		t.Error("expected a start event with the PID")
+	}
+	if strings.TrimSpace(stdout) != dir {
+		t.Errorf("stdout = %q, want the instance directory %q", stdout, dir)
+	}
+	if strings.TrimSpace(stderr) != "hello" {
+		t.Errorf("stderr = %q, want %q", stderr, "hello")
+	}
+	if end == nil || end.GetExitCode() != 3 || end.Error != nil {
+		t.Errorf("end event = %v, want exit code 3 without error", end)
+	}
+}
+
+func TestProcessSignal(t *testing.T) {
+	ts, _ := newTestServer(t)
+	cli := processconnect.NewProcessClient(ts.Client(), ts.URL, connect.WithProtoJSON())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := cli.Start(ctx, withToken(&process.StartRequest{Process: &process.ProcessConfig{Cmd: "sleep", Args: []string{"30"}}}))
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if !stream.Receive() {
+		t.Fatalf("no start event: %v", stream.Err())
+	}
+	pid := stream.Msg().GetEvent().GetStart().GetPid()
+
+	list, err := cli.List(ctx, withToken(&process.ListRequest{}))
+	if err != nil || len(list.Msg.GetProcesses()) != 1 || list.Msg.GetProcesses()[0].GetPid() != pid {
+		t.Fatalf("List() = %v, %v; want the sleeping process", list, err)
+	}
+
+	_, err = cli.SendSignal(ctx, withToken(&process.SendSignalRequest{
+		Process: &process.ProcessSelector{Selector: &process.ProcessSelector_Pid{Pid: pid}},
+		Signal:  process.Signal_SIGNAL_SIGKILL,
+	}))
+	if err != nil {
+		t.Fatalf("SendSignal() failed: %v", err)
+	}
+	var end *process.ProcessEvent_EndEvent
+	for stream.Receive() {
+		if e := stream.Msg().GetEvent().GetEnd(); e != nil {
+			end = e
+		}
+	}
+	if end == nil || end.Error == nil || !strings.Contains(end.GetError(), "killed") {
+		t.Errorf("end event = %v, want a killed error", end)
+	}
+}
+
+func TestFilesystem(t *testing.T) {
+	ts, dir := newTestServer(t)
+	cli := filesystemconnect.NewFilesystemClient(ts.Client(), ts.URL, connect.WithProtoJSON())
+	ctx := context.Background()
+
+	mk, err := cli.MakeDir(ctx, withToken(&filesystem.MakeDirRequest{Path: "a/b"}))
+	if err != nil || mk.Msg.GetEntry().GetType() != filesystem.FileType_FILE_TYPE_DIRECTORY {
+		t.Fatalf("MakeDir() = %v, %v", mk, err)
+	}
+	if mk, err = cli.MakeDir(ctx, withToken(&filesystem.MakeDirRequest{Path: "a/b"})); err != nil || mk.Msg.GetEntry() != nil {
+		t.Errorf("MakeDir() of an existing directory = %v, %v; want no entry", mk, err)
+	}
+
+	// Upload through /files like the SDK does
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "hello.txt")
+	_, _ = part.Write([]byte("hello"))
+	_ = mw.Close()
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/files?path=a/b/hello.txt", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("X-Access-Token", testToken)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	var infos []writeInfo
+	_ = json.NewDecoder(resp.Body).Decode(&infos)
+	resp.Body.Close()
+	want := filepath.Join(dir, "a", "b", "hello.txt")
+	if resp.StatusCode != http.StatusOK || len(infos) != 1 || infos[0].Path != want {
+		t.Fatalf("upload = %d %v, want path %s", resp.StatusCode, infos, want)
+	}
+
+	list, err := cli.ListDir(ctx, withToken(&filesystem.ListDirRequest{Path: "a", Depth: 2}))
+	if err != nil {
+		t.Fatalf("ListDir() failed: %v", err)
+	}
+	if got := len(list.Msg.GetEntries()); got != 2 {
+		t.Errorf("ListDir(depth 2) returned %d entries, want 2", got)
+	}
+
+	if _, err := cli.Move(ctx, withToken(&filesystem.MoveRequest{Source: "a/b/hello.txt", Destination: "c/moved.txt"})); err != nil {
+		t.Fatalf("Move() failed: %v", err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, ts.URL+"/files?path="+filepath.Join(dir, "c", "moved.txt"), nil)
+	req.Header.Set("X-Access-Token", testToken)
+	resp, err = ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != "hello" {
+		t.Errorf("download = %q, want %q", data, "hello")
+	}
+
+	if _, err := cli.Remove(ctx, withToken(&filesystem.RemoveRequest{Path: "c"})); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	_, err = cli.Stat(ctx, withToken(&filesystem.StatRequest{Path: "c"}))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("Stat() of removed path error = %v, want not found", err)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+	ts, _ := newTestServer(t)
+
+	execute := func(code string) []map[string]any {
+		t.Helper()
+		body, _ := json.Marshal(map[string]any{"code": code, "language": "python"})
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/execute", bytes.NewReader(body))
+		req.Header.Set("X-Access-Token", testToken)
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("execute failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var lines []map[string]any
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var line map[string]any
+			if err := dec.Decode(&line); err != nil {
+				break
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+
+	// stdout and results arrive on separate pipes, so their order is not fixed
+	lines := execute("import os\nprint(os.environ['HOME'] == os.getcwd())\n6 * 7")
+	got := make(map[string]any)
+	for _, line := range lines {
+		got[line["type"].(string)] = line["text"]
+	}
+	if len(lines) != 2 || got["stdout"] != "True\n" || got["result"] != "42" {
+		t.Errorf("execute output = %v", lines)
+	}
+
+	lines = execute("raise ValueError('boom')")
+	if len(lines) != 1 || lines[0]["type"] != "error" || lines[0]["name"] != "ValueError" || lines[0]["value"] != "boom" {
+		t.Errorf("execute error output = %v", lines)
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	dir := string(os.PathSeparator) + filepath.Join("tmp", "inst")
+	tests := map[string]string{
+		"~":        dir,
+		"~/a":      filepath.Join(dir, "a"),
+		"a/../b":   filepath.Join(dir, "b"),
+		"/etc/x/.": "/etc/x",
+	}
+	for in, want := range tests {
+		if got := resolvePath(dir, in); got != want {
+			t.Errorf("resolvePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
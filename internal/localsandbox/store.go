@@ -0,0 +1,216 @@
+// Package localsandbox runs sandboxes on the local host for the "local"
+// backend, so that the CLI can be developed and tested without credentials.
+//
+// An instance is a temporary directory plus a daemon process started in its
+// own process group. The daemon serves an envd-compatible data plane (the
+// process and filesystem connect-RPC services, file transfer and code
+// execution) on a loopback port, and every command it runs joins its process
+// group. Instance records are kept in ~/.ags/local/<id>.json.
+//
+// There is no isolation: commands run as the current user and absolute paths
+// refer to the host filesystem. Relative paths resolve inside the instance
+// directory, which is also the working directory and HOME of every command.
+package localsandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// IDPrefix starts the ID of every local instance. Data plane hosts are
+	// routed to the local daemon by this prefix.
+	IDPrefix = "local-"
+
+	// ServeCommand is the hidden CLI command that runs an instance daemon.
+	ServeCommand = "local-sandbox"
+
+	// storeDir is the directory under the user home holding instance records.
+	storeDir = ".ags/local"
+
+	// startGrace is how long a record may exist without a daemon before it
+	// is considered abandoned (covers the window between Save and Start).
+	startGrace = time.Minute
+)
+
+// ErrNotFound is returned for unknown instance IDs.
+var ErrNotFound = errors.New("local instance not found")
+
+// Instance is the record of a local sandbox instance.
+type Instance struct {
+	ID       string `json:"id"`
+	ToolID   string `json:"tool_id"`
+	ToolName string `json:"tool_name"`
+	Dir      string `json:"dir"`
+	// Token is required in X-Access-Token by the data plane unless Open is
+	// set; an open instance accepts unauthenticated requests.
+	Token     string    `json:"token,omitempty"`
+	Open      bool      `json:"open,omitempty"`
+	PID       int       `json:"pid,omitempty"`      // Daemon PID, also its process group ID
+	ExePath   string    `json:"exe_path,omitempty"` // Daemon executable, for PID reuse protection
+	Port      int       `json:"port,omitempty"`     // Loopback port of the data plane
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Running reports whether the daemon of the instance is alive.
+func (i *Instance) Running() bool {
+	return i.PID > 0 && daemonAlive(i.PID, i.ExePath)
+}
+
+// Store reads and writes instance records.
+type Store struct {
+	dir string
+}
+
+// NewStore returns the store in ~/.ags/local, creating the directory.
+func NewStore() (*Store, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return NewStoreAt(filepath.Join(home, storeDir))
+}
+
+// NewStoreAt returns a store keeping its records in dir.
+func NewStoreAt(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create local instance directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// recordPath returns the record file of id, rejecting IDs that could escape
+// the store directory.
+func (s *Store) recordPath(id string) (string, error) {
+	if !strings.HasPrefix(id, IDPrefix) || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// LogPath returns the daemon log file of id.
+func (s *Store) LogPath(id string) string {
+	return filepath.Join(s.dir, id+".log")
+}
+
+// Save writes the record of inst atomically.
+func (s *Store) Save(inst *Instance) error {
+	path, err := s.recordPath(inst.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(inst, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write instance record: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write instance record: %w", err)
+	}
+	return nil
+}
+
+// Get returns the record of id, or an error wrapping ErrNotFound.
+func (s *Store) Get(id string) (*Instance, error) {
+	path, err := s.recordPath(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance record: %w", err)
+	}
+	var inst Instance
+	if err := json.Unmarshal(data, &inst); err != nil {
+		return nil, fmt.Errorf("failed to parse instance record %s: %w", id, err)
+	}
+	return &inst, nil
+}
+
+// List returns the live instances sorted by creation time. Records whose
+// daemon is gone (killed, or the host rebooted) are removed along with their
+// directories.
+func (s *Store) List() ([]*Instance, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local instances: %w", err)
+	}
+	var instances []*Instance
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		inst, err := s.Get(id)
+		if err != nil {
+			continue
+		}
+		if s.abandoned(inst) {
+			s.cleanup(inst)
+			continue
+		}
+		instances = append(instances, inst)
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].CreatedAt.Before(instances[j].CreatedAt)
+	})
+	return instances, nil
+}
+
+// abandoned reports whether inst has no daemon and is not being started.
+func (s *Store) abandoned(inst *Instance) bool {
+	if inst.PID == 0 {
+		return time.Since(inst.CreatedAt) > startGrace
+	}
+	return !inst.Running()
+}
+
+// Remove deletes the record of id. A missing record is not an error.
+func (s *Store) Remove(id string) error {
+	path, err := s.recordPath(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove instance record: %w", err)
+	}
+	return nil
+}
+
+// cleanup removes the directory, record and log of an instance whose daemon
+// is gone.
+func (s *Store) cleanup(inst *Instance) {
+	if inst.Dir != "" {
+		if isInstanceDir(inst) {
+			_ = os.RemoveAll(inst.Dir)
+		} else {
+			fmt.Fprintf(os.Stderr, "local sandbox %s: not removing %s, which is not an instance directory\n", inst.ID, inst.Dir)
+		}
+	}
+	_ = s.Remove(inst.ID)
+	_ = os.Remove(s.LogPath(inst.ID))
+}
+
+// isInstanceDir reports whether inst.Dir is a directory CreateInstance made
+// for inst: os.TempDir()/ags-<id>-<random>. Records can be edited by hand,
+// so any other path is never removed.
+func isInstanceDir(inst *Instance) bool {
+	dir := filepath.Clean(inst.Dir)
+	return filepath.IsAbs(dir) &&
+		filepath.Dir(dir) == filepath.Clean(os.TempDir()) &&
+		strings.HasPrefix(filepath.Base(dir), "ags-"+inst.ID+"-")
+}
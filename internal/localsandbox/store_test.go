@@ -0,0 +1,140 @@
+package localsandbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStoreAt(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStoreAt() failed: %v", err)
+	}
+	return store
+}
+
+func TestStoreSaveAndGet(t *testing.T) {
+	store := newTestStore(t)
+	inst := &Instance{
+		ID:        "local-aaa",
+		Dir:       t.TempDir(),
+		Token:     "secret",
+		PID:       os.Getpid(),
+		Port:      12345,
+		CreatedAt: time.Now().Truncate(time.Second),
+		ExpiresAt: time.Now().Add(time.Minute).Truncate(time.Second),
+	}
+	if err := store.Save(inst); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	got, err := store.Get("local-aaa")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if got.Token != inst.Token || got.Port != inst.Port || !got.ExpiresAt.Equal(inst.ExpiresAt) {
+		t.Errorf("Get() = %+v, want %+v", got, inst)
+	}
+	if !got.Running() {
+		t.Error("instance with the test process PID should be running")
+	}
+}
+
+func TestInstanceRunningChecksExecutable(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable() failed: %v", err)
+	}
+	if inst := (&Instance{PID: os.Getpid(), ExePath: exe}); !inst.Running() {
+		t.Error("instance running the recorded executable should be running")
+	}
+	// A reused PID runs a different executable
+	reused := &Instance{PID: os.Getpid(), ExePath: "/nonexistent/ags"}
+	if reused.Running() {
+		t.Error("instance whose PID runs another executable should not be running")
+	}
+	if err := terminateGroup(reused.PID, reused.ExePath, time.Second); err != nil {
+		t.Errorf("terminateGroup() on a reused PID = %v, want nil", err)
+	}
+}
+
+func TestStoreGetRejectsInvalidIDs(t *testing.T) {
+	store := newTestStore(t)
+	for _, id := range []string{"sandbox-aaa", "local-../x", "local-a/b", "local-missing"} {
+		if _, err := store.Get(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestStoreListRemovesAbandoned(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+	live := &Instance{ID: "local-live", PID: os.Getpid(), CreatedAt: now}
+	starting := &Instance{ID: "local-starting", CreatedAt: now}
+	staleDir, err := os.MkdirTemp("", "ags-local-stale-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(staleDir) })
+	stale := &Instance{ID: "local-stale", Dir: staleDir, CreatedAt: now.Add(-2 * startGrace)}
+	// A record pointing elsewhere, e.g. edited by hand
+	foreignDir := t.TempDir()
+	foreign := &Instance{ID: "local-foreign", Dir: foreignDir, CreatedAt: now.Add(-2 * startGrace)}
+	for _, inst := range []*Instance{live, starting, stale, foreign} {
+		if err := store.Save(inst); err != nil {
+			t.Fatalf("Save(%s) failed: %v", inst.ID, err)
+		}
+	}
+
+	list, err := store.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	var ids []string
+	for _, inst := range list {
+		ids = append(ids, inst.ID)
+	}
+	if len(ids) != 2 || ids[0] != "local-live" || ids[1] != "local-starting" {
+		t.Errorf("List() = %v, want [local-live local-starting]", ids)
+	}
+	if _, err := os.Stat(filepath.Join(store.dir, "local-stale.json")); !os.IsNotExist(err) {
+		t.Error("record of abandoned instance should be removed")
+	}
+	if _, err := os.Stat(staleDir); !os.IsNotExist(err) {
+		t.Error("directory of abandoned instance should be removed")
+	}
+	if _, err := os.Stat(foreignDir); err != nil {
+		t.Errorf("directory outside the instance pattern was removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.dir, "local-foreign.json")); !os.IsNotExist(err) {
+		t.Error("record of abandoned instance with a foreign directory should be removed")
+	}
+}
+
+func TestLocalAddr(t *testing.T) {
+	tests := []struct {
+		host   string
+		addr   string
+		local  bool
+		hasErr bool
+	}{
+		{host: "8080-local-abc.ap-guangzhou.tencentags.com", addr: "127.0.0.1:8080", local: true},
+		{host: "70000-local-abc.ap-guangzhou.tencentags.com", local: true, hasErr: true},
+		{host: "8080-sandbox-abc.ap-guangzhou.tencentags.com"},
+		{host: "api.ap-guangzhou.tencentags.com"},
+	}
+	for _, tt := range tests {
+		addr, local, err := localAddr(tt.host)
+		if addr != tt.addr || local != tt.local || (err != nil) != tt.hasErr {
+			t.Errorf("localAddr(%q) = %q, %v, %v; want %q, %v, error %v", tt.host, addr, local, err, tt.addr, tt.local, tt.hasErr)
+		}
+		if IsLocalHost(tt.host) != tt.local {
+			t.Errorf("IsLocalHost(%q) = %v, want %v", tt.host, !tt.local, tt.local)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Package manifest loads declarative sandbox environment files (sandbox.yaml)
+// used by 'ags up' and 'ags down', and persists the state of environments that
+// are currently up in ~/.ags/envs/<profile>@<region>/<name>.json.
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+)
+
+// DefaultFile is the manifest file name used when none is given.
+const DefaultFile = "sandbox.yaml"
+
+// DefaultTimeout is the instance timeout in seconds when the manifest omits it.
+const DefaultTimeout = 300
+
+// namePattern restricts environment names to something safe to use as a file name.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Manifest describes a sandbox environment.
+type Manifest struct {
+	Name      string            `yaml:"name"`
+	Tool      string            `yaml:"tool"`
+	ToolID    string            `yaml:"toolId"`
+	Timeout   int               `yaml:"timeout"`
+	AuthMode  string            `yaml:"authMode"`
+	User      string            `yaml:"user"`
+	Workdir   string            `yaml:"workdir"`
+	Env       map[string]string `yaml:"env"`
+	Mounts    []Mount           `yaml:"mounts"`
+	Uploads   []Upload          `yaml:"uploads"`
+	Setup     []string          `yaml:"setup"`
+	Processes []Process         `yaml:"processes"`
+	Forwards  []string          `yaml:"forwards"`
+
+	// Path is the absolute path of the manifest file. Relative upload
+	// sources are resolved against its directory.
+	Path string `yaml:"-"`
+}
+
+// Mount overrides a storage mount declared by the tool.
+type Mount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	SubPath   string `yaml:"subPath"`
+	ReadOnly  *bool  `yaml:"readOnly"`
+}
+
+// Upload copies a local file or directory into the sandbox.
+type Upload struct {
+	Src string `yaml:"src"`
+	Dst string `yaml:"dst"`
+}
+
+// Process is a long-running command started in the background after setup.
+type Process struct {
+	Name    string            `yaml:"name"`
+	Command string            `yaml:"command"`
+	Cwd     string            `yaml:"cwd"`
+	Env     map[string]string `yaml:"env"`
+}
+
+// Load reads, parses and validates a manifest file. Unknown keys are
+// rejected so that typos do not silently drop configuration.
+func Load(path string) (*Manifest, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve manifest path: %w", err)
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	m, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	m.Path = absPath
+
+	if m.Name == "" {
+		m.Name = filepath.Base(filepath.Dir(absPath))
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Parse decodes manifest YAML and applies defaults. It does not validate.
+func Parse(data []byte) (*Manifest, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var m Manifest
+	if err := dec.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if m.Timeout == 0 {
+		m.Timeout = DefaultTimeout
+	}
+	return &m, nil
+}
+
+// Validate checks the manifest for missing or conflicting fields.
+func (m *Manifest) Validate() error {
+	if !namePattern.MatchString(m.Name) {
+		return fmt.Errorf("name %q must contain only letters, digits, '.', '_' or '-'", m.Name)
+	}
+
+	if m.Tool != "" && m.ToolID != "" {
+		return fmt.Errorf("cannot specify both tool and toolId")
+	}
+	if m.Tool == "" && m.ToolID == "" {
+		return fmt.Errorf("must specify either tool or toolId")
+	}
+	if m.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative, got %d", m.Timeout)
+	}
+
+	authMode, err := client.NormalizeAuthMode(m.AuthMode)
+	if err != nil {
+		return err
+	}
+	m.AuthMode = authMode
+
+	if m.Workdir != "" && !strings.HasPrefix(m.Workdir, "/") {
+		return fmt.Errorf("workdir must be an absolute path")
+	}
+
+	for i, mount := range m.Mounts {
+		if mount.Name == "" {
+			return fmt.Errorf("mounts[%d]: name is required", i)
+		}
+		if mount.MountPath != "" && !strings.HasPrefix(mount.MountPath, "/") {
+			return fmt.Errorf("mounts[%d]: mountPath must be an absolute path", i)
+		}
+	}
+
+	for i, upload := range m.Uploads {
+		if upload.Src == "" || upload.Dst == "" {
+			return fmt.Errorf("uploads[%d]: src and dst are required", i)
+		}
+		if !strings.HasPrefix(upload.Dst, "/") {
+			return fmt.Errorf("uploads[%d]: dst must be an absolute path", i)
+		}
+	}
+
+	for i, cmd := range m.Setup {
+		if strings.TrimSpace(cmd) == "" {
+			return fmt.Errorf("setup[%d]: command is empty", i)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, p := range m.Processes {
+		if p.Name == "" || strings.TrimSpace(p.Command) == "" {
+			return fmt.Errorf("processes[%d]: name and command are required", i)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("processes[%d]: duplicate name %q", i, p.Name)
+		}
+		names[p.Name] = true
+	}
+
+	return nil
+}
+
+// CreateOptions returns the instance creation options described by the manifest.
+func (m *Manifest) CreateOptions() *client.CreateInstanceOptions {
+	opts := &client.CreateInstanceOptions{
+		ToolID:   m.ToolID,
+		ToolName: m.Tool,
+		Timeout:  m.Timeout,
+		AuthMode: m.AuthMode,
+	}
+	for _, mount := range m.Mounts {
+		opts.MountOptions = append(opts.MountOptions, client.MountOption{
+			Name:      mount.Name,
+			MountPath: mount.MountPath,
+			SubPath:   mount.SubPath,
+			ReadOnly:  mount.ReadOnly,
+		})
+	}
+	return opts
+}
+
+// SourcePath resolves an upload source relative to the manifest directory.
+func (m *Manifest) SourcePath(src string) string {
+	if filepath.IsAbs(src) || m.Path == "" {
+		return src
+	}
+	return filepath.Join(filepath.Dir(m.Path), src)
+}
@@ -0,0 +1,212 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleManifest = `
+name: demo
+tool: code-interpreter-v1
+authMode: token
+workdir: /home/user/app
+env:
+  APP_ENV: dev
+mounts:
+  - name: data
+    mountPath: /data
+    readOnly: true
+uploads:
+  - src: ./src
+    dst: /home/user/app
+setup:
+  - pip install -r requirements.txt
+processes:
+  - name: web
+    command: python -m http.server 8080
+forwards:
+  - "3000:8080"
+`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultFile)
+	if err := os.WriteFile(path, []byte(sampleManifest), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if m.Name != "demo" || m.Tool != "code-interpreter-v1" {
+		t.Errorf("name/tool = %s/%s", m.Name, m.Tool)
+	}
+	if m.Timeout != DefaultTimeout {
+		t.Errorf("Timeout = %d, want %d", m.Timeout, DefaultTimeout)
+	}
+	if m.AuthMode != "TOKEN" {
+		t.Errorf("AuthMode = %q, want TOKEN", m.AuthMode)
+	}
+	if got, want := m.SourcePath("./src"), filepath.Join(dir, "src"); got != want {
+		t.Errorf("SourcePath() = %q, want %q", got, want)
+	}
+
+	opts := m.CreateOptions()
+	if len(opts.MountOptions) != 1 || opts.MountOptions[0].MountPath != "/data" || !*opts.MountOptions[0].ReadOnly {
+		t.Errorf("MountOptions = %+v", opts.MountOptions)
+	}
+}
+
+func TestLoadDefaultName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my-project")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, DefaultFile)
+	if err := os.WriteFile(path, []byte("tool: code-interpreter-v1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if m.Name != "my-project" {
+		t.Errorf("Name = %q, want my-project", m.Name)
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	if _, err := Parse([]byte("tool: x\nsetpu: []\n")); err == nil {
+		t.Error("Parse() expected error for unknown field")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "no tool", yaml: "name: a", wantErr: "either tool or toolId"},
+		{name: "both tools", yaml: "name: a\ntool: x\ntoolId: y", wantErr: "both tool and toolId"},
+		{name: "bad name", yaml: "name: ../a\ntool: x", wantErr: "name"},
+		{name: "bad auth", yaml: "name: a\ntool: x\nauthMode: open", wantErr: "auth mode"},
+		{name: "relative dst", yaml: "name: a\ntool: x\nuploads:\n  - src: a\n    dst: b", wantErr: "absolute"},
+		{name: "dup process", yaml: "name: a\ntool: x\nprocesses:\n  - {name: p, command: c}\n  - {name: p, command: d}", wantErr: "duplicate"},
+		{name: "negative timeout", yaml: "name: a\ntool: x\ntimeout: -1", wantErr: "must not be negative"},
+		{name: "default timeout", yaml: "name: a\ntool: x\ntimeout: 0"},
+		{name: "valid", yaml: "name: a\ntoolId: sdt-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			err = m.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStateStore(t *testing.T) {
+	store, err := newStateStoreAt(filepath.Join(t.TempDir(), "envs"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, err := store.Get("demo"); err != nil || ok {
+		t.Fatalf("Get() on empty store = %v, %v", ok, err)
+	}
+
+	state := &State{
+		Name:       "demo",
+		InstanceID: "sbi-1",
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		Forwards:   []ForwardState{{LocalPort: 3000, RemotePort: 8080, PID: 42}},
+	}
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, ok, err := store.Get("demo")
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v", ok, err)
+	}
+	if got.InstanceID != "sbi-1" || len(got.Forwards) != 1 || got.Forwards[0].PID != 42 {
+		t.Errorf("Get() = %+v", got)
+	}
+
+	info, err := os.Stat(filepath.Join(store.Dir(), "demo.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("state file permissions = %o, want 600", perm)
+	}
+
+	if err := store.Remove("demo"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := store.Remove("demo"); err != nil {
+		t.Errorf("Remove() twice error = %v", err)
+	}
+	if _, ok, _ := store.Get("demo"); ok {
+		t.Error("Get() after Remove() still found state")
+	}
+}
+
+func TestStateStoreScopes(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "envs")
+	dev, err := newStateStoreAt(filepath.Join(root, "dev@ap-guangzhou"), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, err := newStateStoreAt(filepath.Join(root, "prod@ap-guangzhou"), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dev.Save(&State{Name: "app", InstanceID: "sbi-dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := prod.Get("app"); ok {
+		t.Error("environment of another scope is visible")
+	}
+
+	// State written before stores were scoped is visible everywhere and
+	// moves into the scope that saves it.
+	legacy := []byte(`{"name":"old","instance_id":"sbi-old","backend":"cloud"}`)
+	if err := os.WriteFile(filepath.Join(root, "old.json"), legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, store := range []*StateStore{dev, prod} {
+		if got, ok, err := store.Get("old"); err != nil || !ok || got.InstanceID != "sbi-old" {
+			t.Fatalf("Get(old) = %+v, %v, %v", got, ok, err)
+		}
+	}
+	got, _, _ := prod.Get("old")
+	if err := prod.Save(got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "old.json")); !os.IsNotExist(err) {
+		t.Errorf("legacy state file kept after save: %v", err)
+	}
+	if _, ok, _ := dev.Get("old"); ok {
+		t.Error("migrated environment still visible in another scope")
+	}
+}
@@ -0,0 +1,156 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// stateDir is the directory under user home holding environment state.
+	stateDir = ".ags/envs"
+)
+
+// State records everything 'ags up' created so 'ags down' can tear it down.
+type State struct {
+	Name         string         `json:"name"`
+	ManifestPath string         `json:"manifest_path"`
+	InstanceID   string         `json:"instance_id"`
+	Backend      string         `json:"backend"`
+	Profile      string         `json:"profile,omitempty"`
+	Region       string         `json:"region,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Processes    []ProcessState `json:"processes,omitempty"`
+	Forwards     []ForwardState `json:"forwards,omitempty"`
+}
+
+// ProcessState is a background process started inside the sandbox.
+type ProcessState struct {
+	Name string `json:"name"`
+	PID  uint32 `json:"pid"`
+}
+
+// ForwardState is a local port-forward process spawned by 'ags up'.
+type ForwardState struct {
+	LocalPort  int    `json:"local_port"`
+	RemotePort int    `json:"remote_port"`
+	PID        int    `json:"pid"`
+	ExePath    string `json:"exe_path,omitempty"` // Executable path for PID reuse protection
+	LogPath    string `json:"log_path,omitempty"`
+}
+
+// StateStore reads and writes environment state files.
+type StateStore struct {
+	dir string
+	// legacyDir holds state files written before stores were scoped; they
+	// are visible in every scope and move into the scope on the next save.
+	legacyDir string
+}
+
+// NewScopedStateStore creates a StateStore rooted at ~/.ags/envs/<scope>,
+// e.g. the profile and region (config.StoreScope()), so that environments
+// brought up under one account are not torn down through another.
+func NewScopedStateStore(scope string) (*StateStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	root := filepath.Join(homeDir, stateDir)
+	return newStateStoreAt(filepath.Join(root, url.PathEscape(scope)), root)
+}
+
+func newStateStoreAt(dir, legacyDir string) (*StateStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return &StateStore{dir: dir, legacyDir: legacyDir}, nil
+}
+
+// Dir returns the directory holding state and forward log files.
+func (s *StateStore) Dir() string {
+	return s.dir
+}
+
+func (s *StateStore) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// legacyPath returns the unscoped state file of name, or "".
+func (s *StateStore) legacyPath(name string) string {
+	if s.legacyDir == "" || s.legacyDir == s.dir {
+		return ""
+	}
+	return filepath.Join(s.legacyDir, name+".json")
+}
+
+// Get returns the state of the named environment. The boolean is false when
+// the environment is not up.
+func (s *StateStore) Get(name string) (*State, bool, error) {
+	path := s.path(name)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && s.legacyPath(name) != "" {
+		path = s.legacyPath(name)
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, false, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return &state, true, nil
+}
+
+// Save writes the state atomically (temp file + rename).
+func (s *StateStore) Save(state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(s.dir, state.Name+"-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path(state.Name)); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	if legacy := s.legacyPath(state.Name); legacy != "" {
+		_ = os.Remove(legacy)
+	}
+	return nil
+}
+
+// Remove deletes the state of the named environment. Removing an
+// environment that is not up is not an error.
+func (s *StateStore) Remove(name string) error {
+	for _, path := range []string{s.path(name), s.legacyPath(name)} {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove state file: %w", err)
+		}
+	}
+	return nil
+}
@@ -1,7 +1,6 @@
 package output
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -27,9 +26,11 @@ func NewFormatter() *Formatter {
 	}
 }
 
-// IsJSON returns true if output format is JSON
+// IsJSON returns true if a structured output format is selected (json, yaml,
+// jsonpath, go-template, custom-columns or csv). Callers then pass structured
+// data to PrintJSON, which renders it in the selected format.
 func (f *Formatter) IsJSON() bool {
-	return f.format == "json"
+	return isStructured(f.format)
 }
 
 // SetWriter sets the output writer
@@ -37,23 +38,22 @@ func (f *Formatter) SetWriter(w io.Writer) {
 	f.writer = w
 }
 
-// PrintJSON outputs data as JSON
+// PrintJSON outputs structured data in the selected output format
+// (JSON unless another structured format was chosen)
 func (f *Formatter) PrintJSON(data any) error {
-	encoder := json.NewEncoder(f.writer)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(data)
+	return f.render(data)
 }
 
 // PrintTiming prints timing info to stderr (text mode only)
 func (f *Formatter) PrintTiming(timing *Timing) {
-	if timing != nil && f.format != "json" {
+	if timing != nil && !f.IsJSON() {
 		fmt.Fprintf(f.errWriter, "Time: %dms\n", timing.TotalMs)
 	}
 }
 
 // PrintExecResult prints code execution result
 func (f *Formatter) PrintExecResult(result *ExecResult) error {
-	if f.format == "json" {
+	if f.IsJSON() {
 		return f.PrintJSON(result)
 	}
 
@@ -84,7 +84,7 @@ func (f *Formatter) PrintExecResult(result *ExecResult) error {
 
 // PrintMultiTaskResult prints multiple task execution results
 func (f *Formatter) PrintMultiTaskResult(result *MultiTaskResult) error {
-	if f.format == "json" {
+	if f.IsJSON() {
 		return f.PrintJSON(result)
 	}
 
@@ -167,7 +167,7 @@ func (f *Formatter) PrintSummaryToStderr(summary TaskSummary) {
 
 // PrintCommandResult prints shell command result
 func (f *Formatter) PrintCommandResult(result *CommandResult) error {
-	if f.format == "json" {
+	if f.IsJSON() {
 		return f.PrintJSON(result)
 	}
 
@@ -188,9 +188,14 @@ func (f *Formatter) PrintCommandResult(result *CommandResult) error {
 	return nil
 }
 
-// PrintTable outputs data as a table with optional pagination
+// PrintTable outputs data as a table with optional pagination. The rows are
+// display strings, so the only structured format they are rendered in is
+// json; callers that support the other formats use PrintList or PrintJSON.
 func (f *Formatter) PrintTable(headers []string, rows [][]string, pagination *Pagination) error {
-	if f.format == "json" {
+	if f.IsJSON() {
+		if err := f.requireJSON(); err != nil {
+			return err
+		}
 		result := &ListResult{
 			Items:      make([]map[string]string, len(rows)),
 			Pagination: pagination,
@@ -226,9 +231,25 @@ func (f *Formatter) PrintTable(headers []string, rows [][]string, pagination *Pa
 	return nil
 }
 
+// PrintList outputs a list. Text output is the table built from headers and
+// rows; structured output renders items, the unformatted objects behind the
+// rows, so that every field is addressable by its JSON name.
+func (f *Formatter) PrintList(headers []string, rows [][]string, items any, pagination *Pagination) error {
+	if f.IsJSON() {
+		return f.PrintJSON(&struct {
+			Items      any         `json:"items"`
+			Pagination *Pagination `json:"pagination,omitempty"`
+		}{Items: items, Pagination: pagination})
+	}
+	return f.PrintTable(headers, rows, pagination)
+}
+
 // PrintTableNoHeader outputs data as a table without headers
 func (f *Formatter) PrintTableNoHeader(rows [][]string) error {
-	if f.format == "json" {
+	if f.IsJSON() {
+		if err := f.requireJSON(); err != nil {
+			return err
+		}
 		return f.PrintJSON(rows)
 	}
 
@@ -239,9 +260,13 @@ func (f *Formatter) PrintTableNoHeader(rows [][]string) error {
 	return w.Flush()
 }
 
-// PrintKeyValue prints key-value pairs in order
+// PrintKeyValue prints key-value pairs in order. Like PrintTable, it only
+// renders json among the structured formats.
 func (f *Formatter) PrintKeyValue(pairs []KeyValue) error {
-	if f.format == "json" {
+	if f.IsJSON() {
+		if err := f.requireJSON(); err != nil {
+			return err
+		}
 		m := make(map[string]string, len(pairs))
 		for _, kv := range pairs {
 			m[kv.Key] = kv.Value
@@ -262,9 +287,18 @@ func (f *Formatter) PrintKeyValue(pairs []KeyValue) error {
 	return nil
 }
 
+// requireJSON rejects structured formats other than json for output that
+// only exists as display strings.
+func (f *Formatter) requireJSON() error {
+	if name, _ := splitFormat(f.format); name != FormatJSON {
+		return fmt.Errorf("output format %q is not supported here; use -o json or -o text", name)
+	}
+	return nil
+}
+
 // PrintSuccess prints a success message
 func (f *Formatter) PrintSuccess(message string) {
-	if f.format == "json" {
+	if f.IsJSON() {
 		_ = f.PrintJSON(&OperationResult{
 			Status:  "success",
 			Message: message,
@@ -276,7 +310,7 @@ func (f *Formatter) PrintSuccess(message string) {
 
 // PrintSuccessWithData prints a success message with additional data
 func (f *Formatter) PrintSuccessWithData(message string, data map[string]any, timing *Timing) {
-	if f.format == "json" {
+	if f.IsJSON() {
 		_ = f.PrintJSON(&OperationResult{
 			Status:  "success",
 			Message: message,
@@ -290,43 +324,55 @@ func (f *Formatter) PrintSuccessWithData(message string, data map[string]any, ti
 
 // PrintError prints an error message
 func (f *Formatter) PrintError(err error) {
-	if f.format == "json" {
+	if f.format == FormatJSON {
 		_ = f.PrintJSON(&OperationResult{
 			Status:  "error",
 			Message: err.Error(),
 		})
 		return
 	}
+	if f.IsJSON() {
+		fmt.Fprintln(f.errWriter, "✗", err.Error())
+		return
+	}
 	fmt.Fprintln(f.writer, "✗", err.Error())
 }
 
 // PrintInfo prints an info message
 func (f *Formatter) PrintInfo(message string) {
-	if f.format == "json" {
+	if f.format == FormatJSON {
 		_ = f.PrintJSON(map[string]any{
 			"status":  "info",
 			"message": message,
 		})
 		return
 	}
+	if f.IsJSON() {
+		fmt.Fprintln(f.errWriter, "ℹ", message)
+		return
+	}
 	fmt.Fprintln(f.writer, "ℹ", message)
 }
 
 // PrintWarning prints a warning message
 func (f *Formatter) PrintWarning(message string) {
-	if f.format == "json" {
+	if f.format == FormatJSON {
 		_ = f.PrintJSON(map[string]any{
 			"status":  "warning",
 			"message": message,
 		})
 		return
 	}
+	if f.IsJSON() {
+		fmt.Fprintln(f.errWriter, "⚠", message)
+		return
+	}
 	fmt.Fprintln(f.writer, "⚠", message)
 }
 
 // PrintFileOperation prints file operation result
 func (f *Formatter) PrintFileOperation(op *FileOperation) error {
-	if f.format == "json" {
+	if f.IsJSON() {
 		return f.PrintJSON(op)
 	}
 
@@ -349,7 +395,7 @@ func (f *Formatter) PrintFileOperation(op *FileOperation) error {
 
 // PrintFileContent prints file content
 func (f *Formatter) PrintFileContent(content *FileContent) error {
-	if f.format == "json" {
+	if f.IsJSON() {
 		return f.PrintJSON(content)
 	}
 	fmt.Fprint(f.writer, content.Content)
@@ -402,9 +448,9 @@ func FormatSize(bytes int64) string {
 
 // Global helper functions for convenience
 
-// IsJSON returns true if global output format is JSON
+// IsJSON returns true if the global output format is structured
 func IsJSON() bool {
-	return config.GetOutput() == "json"
+	return isStructured(config.GetOutput())
 }
 
 // PrintSuccess prints a success message using default formatter
@@ -442,7 +488,7 @@ func PrintKeyValue(pairs []KeyValue) error {
 	return NewFormatter().PrintKeyValue(pairs)
 }
 
-// Print outputs data as JSON using default formatter
+// Print outputs structured data using default formatter
 func Print(data any) error {
 	return NewFormatter().PrintJSON(data)
 }
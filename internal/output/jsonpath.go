@@ -0,0 +1,501 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a parsed kubectl-style JSONPath template such as
+// "{.items[*].id}" or "{range .items[*]}{.id}{\"\\n\"}{end}".
+//
+// Supported syntax: text outside braces is printed as-is; inside braces
+// paths ($, @, .field, ['field'], [n], [a:b], [*], .*, ..field,
+// [?(@.path == "value")]), quoted string literals and range/end blocks.
+type jsonPath struct {
+	nodes []jpNode
+}
+
+type jpNode interface{}
+
+type jpText string
+
+type jpRange struct {
+	path []jpSegment
+	body []jpNode
+}
+
+type jpSegmentKind int
+
+const (
+	jpField jpSegmentKind = iota
+	jpRecursive
+	jpWildcard
+	jpIndex
+	jpSlice
+	jpFilter
+)
+
+type jpSegment struct {
+	kind       jpSegmentKind
+	name       string
+	index      int
+	start, end *int
+	filter     *jpCondition
+}
+
+// jpCondition is a filter expression: @.path [op literal]
+type jpCondition struct {
+	path  []jpSegment
+	op    string // "" means existence check
+	value string
+}
+
+// parseJSONPath parses a JSONPath template. A template without braces is
+// treated as a single expression, so ".items[*].id" works as well.
+func parseJSONPath(template string) (*jsonPath, error) {
+	if !strings.Contains(template, "{") {
+		template = "{" + template + "}"
+	}
+
+	root := &jpRange{}
+	stack := []*jpRange{root}
+	current := func() *jpRange { return stack[len(stack)-1] }
+
+	for len(template) > 0 {
+		open := strings.IndexByte(template, '{')
+		if open < 0 {
+			current().body = append(current().body, jpText(template))
+			break
+		}
+		if open > 0 {
+			current().body = append(current().body, jpText(template[:open]))
+		}
+		closeIdx, err := matchBrace(template, open)
+		if err != nil {
+			return nil, err
+		}
+		expr := strings.TrimSpace(template[open+1 : closeIdx])
+		template = template[closeIdx+1:]
+
+		switch {
+		case expr == "end":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("jsonpath: unexpected {end}")
+			}
+			stack = stack[:len(stack)-1]
+		case strings.HasPrefix(expr, "range "):
+			path, err := parseJSONPathExpr(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, err
+			}
+			r := &jpRange{path: path}
+			current().body = append(current().body, r)
+			stack = append(stack, r)
+		case strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "'"):
+			lit, err := unquoteLiteral(expr)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath: invalid literal %s: %w", expr, err)
+			}
+			current().body = append(current().body, jpText(lit))
+		default:
+			path, err := parseJSONPathExpr(expr)
+			if err != nil {
+				return nil, err
+			}
+			current().body = append(current().body, path)
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("jsonpath: missing {end}")
+	}
+	return &jsonPath{nodes: root.body}, nil
+}
+
+// matchBrace returns the index of the brace closing the one at open,
+// skipping braces inside quoted strings.
+func matchBrace(s string, open int) (int, error) {
+	var quote byte
+	for i := open + 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '}':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("jsonpath: unclosed '{' in %q", s)
+}
+
+// unquoteLiteral unquotes a double or single quoted string literal.
+func unquoteLiteral(s string) (string, error) {
+	if strings.HasPrefix(s, "'") {
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : len(s)-1], nil
+	}
+	return strconv.Unquote(s)
+}
+
+// parseJSONPathExpr parses a path expression into segments. A leading $ or @
+// is accepted and ignored: paths always start at the current context.
+func parseJSONPathExpr(expr string) ([]jpSegment, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(expr, "$"), "@")
+	var segs []jpSegment
+
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			name, rest := splitName(s[2:])
+			if name == "" {
+				return nil, fmt.Errorf("jsonpath: expected field name after '..' in %q", expr)
+			}
+			segs = append(segs, jpSegment{kind: jpRecursive, name: name})
+			s = rest
+		case s[0] == '.':
+			if strings.HasPrefix(s, ".*") {
+				segs = append(segs, jpSegment{kind: jpWildcard})
+				s = s[2:]
+				continue
+			}
+			name, rest := splitName(s[1:])
+			if name == "" {
+				// A lone "." refers to the current object
+				s = rest
+				continue
+			}
+			segs = append(segs, jpSegment{kind: jpField, name: name})
+			s = rest
+		case s[0] == '[':
+			end, err := matchBracket(s)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath: %w in %q", err, expr)
+			}
+			seg, err := parseBracket(strings.TrimSpace(s[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath: %w in %q", err, expr)
+			}
+			segs = append(segs, seg)
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("jsonpath: unexpected %q in %q", s, expr)
+		}
+	}
+	return segs, nil
+}
+
+// splitName splits a leading field name off s.
+func splitName(s string) (string, string) {
+	i := strings.IndexAny(s, ".[")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// matchBracket returns the index of the bracket closing s[0].
+func matchBracket(s string) (int, error) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed '['")
+}
+
+// parseBracket parses the inside of a [...] segment.
+func parseBracket(s string) (jpSegment, error) {
+	switch {
+	case s == "*":
+		return jpSegment{kind: jpWildcard}, nil
+	case strings.HasPrefix(s, "?(") && strings.HasSuffix(s, ")"):
+		cond, err := parseCondition(strings.TrimSpace(s[2 : len(s)-1]))
+		if err != nil {
+			return jpSegment{}, err
+		}
+		return jpSegment{kind: jpFilter, filter: cond}, nil
+	case strings.HasPrefix(s, "'") || strings.HasPrefix(s, `"`):
+		name, err := unquoteLiteral(s)
+		if err != nil {
+			return jpSegment{}, fmt.Errorf("invalid field name %s", s)
+		}
+		return jpSegment{kind: jpField, name: name}, nil
+	case strings.Contains(s, ":"):
+		parts := strings.SplitN(s, ":", 2)
+		seg := jpSegment{kind: jpSlice}
+		for i, p := range parts {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return jpSegment{}, fmt.Errorf("invalid slice %q", s)
+			}
+			if i == 0 {
+				seg.start = &n
+			} else {
+				seg.end = &n
+			}
+		}
+		return seg, nil
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return jpSegment{}, fmt.Errorf("invalid index %q", s)
+		}
+		return jpSegment{kind: jpIndex, index: n}, nil
+	}
+}
+
+// parseCondition parses a filter such as @.status == "RUNNING" or @.name.
+func parseCondition(s string) (*jpCondition, error) {
+	i := findOperator(s)
+	if i < 0 {
+		path, err := parseJSONPathExpr(s)
+		if err != nil {
+			return nil, err
+		}
+		return &jpCondition{path: path}, nil
+	}
+	op := s[i : i+2]
+	path, err := parseJSONPathExpr(strings.TrimSpace(s[:i]))
+	if err != nil {
+		return nil, err
+	}
+	value := strings.TrimSpace(s[i+len(op):])
+	if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+		if value, err = unquoteLiteral(value); err != nil {
+			return nil, fmt.Errorf("invalid filter value in %q", s)
+		}
+	}
+	return &jpCondition{path: path, op: op, value: value}, nil
+}
+
+// findOperator returns the index of the first == or != in a filter outside
+// quoted strings, or -1.
+func findOperator(s string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case (c == '=' || c == '!') && i+1 < len(s) && s[i+1] == '=':
+			return i
+		}
+	}
+	return -1
+}
+
+// Execute renders the template against data, which must be composed of
+// JSON-decoded values (map[string]any, []any, string, json.Number, bool, nil).
+func (j *jsonPath) Execute(w io.Writer, data any) error {
+	return executeJSONPathNodes(w, j.nodes, data)
+}
+
+func executeJSONPathNodes(w io.Writer, nodes []jpNode, data any) error {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case jpText:
+			if _, err := io.WriteString(w, string(n)); err != nil {
+				return err
+			}
+		case []jpSegment:
+			values := evalJSONPath(n, data)
+			parts := make([]string, len(values))
+			for i, v := range values {
+				parts[i] = formatScalar(v)
+			}
+			if _, err := io.WriteString(w, strings.Join(parts, " ")); err != nil {
+				return err
+			}
+		case *jpRange:
+			for _, item := range evalJSONPath(n.path, data) {
+				if err := executeJSONPathNodes(w, n.body, item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// evalJSONPath returns every value selected by the path. Missing fields
+// select nothing rather than failing.
+func evalJSONPath(segs []jpSegment, data any) []any {
+	values := []any{data}
+	for _, seg := range segs {
+		var next []any
+		for _, v := range values {
+			next = append(next, applySegment(seg, v)...)
+		}
+		values = next
+	}
+	return values
+}
+
+func applySegment(seg jpSegment, v any) []any {
+	switch seg.kind {
+	case jpField:
+		if m, ok := v.(map[string]any); ok {
+			if child, ok := m[seg.name]; ok {
+				return []any{child}
+			}
+		}
+	case jpRecursive:
+		var out []any
+		walkJSON(v, func(node any) {
+			if m, ok := node.(map[string]any); ok {
+				if child, ok := m[seg.name]; ok {
+					out = append(out, child)
+				}
+			}
+		})
+		return out
+	case jpWildcard:
+		return children(v)
+	case jpIndex:
+		if arr, ok := v.([]any); ok {
+			i := seg.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				return []any{arr[i]}
+			}
+		}
+	case jpSlice:
+		if arr, ok := v.([]any); ok {
+			start, end := 0, len(arr)
+			if seg.start != nil {
+				start = clampIndex(*seg.start, len(arr))
+			}
+			if seg.end != nil {
+				end = clampIndex(*seg.end, len(arr))
+			}
+			if start < end {
+				return arr[start:end]
+			}
+		}
+	case jpFilter:
+		var out []any
+		for _, child := range children(v) {
+			if seg.filter.match(child) {
+				out = append(out, child)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (c *jpCondition) match(v any) bool {
+	values := evalJSONPath(c.path, v)
+	if c.op == "" {
+		return len(values) > 0
+	}
+	found := false
+	for _, val := range values {
+		if formatScalar(val) == c.value {
+			found = true
+			break
+		}
+	}
+	if c.op == "==" {
+		return found
+	}
+	return !found
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	return max(0, min(i, n))
+}
+
+// children returns the elements of an array or the values of an object
+// (ordered by key).
+func children(v any) []any {
+	switch t := v.(type) {
+	case []any:
+		return t
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]any, len(keys))
+		for i, k := range keys {
+			out[i] = t[k]
+		}
+		return out
+	}
+	return nil
+}
+
+// walkJSON calls fn for v and every value nested inside it.
+func walkJSON(v any, fn func(any)) {
+	fn(v)
+	for _, child := range children(v) {
+		walkJSON(child, fn)
+	}
+}
+
+// formatScalar renders a JSON value for text output: strings are printed
+// raw, nil as an empty string and objects/arrays as compact JSON.
+func formatScalar(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		data, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,278 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Output format names. Every format except text is structured: commands hand
+// structured data to the formatter, which renders it in the selected format.
+// jsonpath, go-template and custom-columns take an argument after '='
+// (e.g. "jsonpath={.id}"); csv optionally takes a custom-columns spec.
+const (
+	FormatText          = "text"
+	FormatJSON          = "json"
+	FormatYAML          = "yaml"
+	FormatCSV           = "csv"
+	FormatJSONPath      = "jsonpath"
+	FormatGoTemplate    = "go-template"
+	FormatCustomColumns = "custom-columns"
+)
+
+// splitFormat splits "name=arg" into its parts.
+func splitFormat(format string) (string, string) {
+	name, arg, _ := strings.Cut(format, "=")
+	return name, arg
+}
+
+// isStructured reports whether format renders structured data.
+func isStructured(format string) bool {
+	name, _ := splitFormat(format)
+	return name != "" && name != FormatText
+}
+
+// column is one column of custom-columns or csv output.
+type column struct {
+	header string
+	path   []jpSegment
+}
+
+// render writes data in the formatter's structured format.
+func (f *Formatter) render(data any) error {
+	name, arg := splitFormat(f.format)
+
+	if name == FormatJSON || name == FormatText || name == "" {
+		encoder := json.NewEncoder(f.writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	}
+
+	value, err := normalize(data)
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case FormatYAML:
+		encoder := yaml.NewEncoder(f.writer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(numbersToNative(value)); err != nil {
+			return err
+		}
+		return encoder.Close()
+
+	case FormatJSONPath:
+		if arg == "" {
+			return fmt.Errorf("jsonpath output requires a template, e.g. -o jsonpath='{.id}'")
+		}
+		jp, err := parseJSONPath(arg)
+		if err != nil {
+			return err
+		}
+		return jp.Execute(f.writer, value)
+
+	case FormatGoTemplate:
+		if arg == "" {
+			return fmt.Errorf("go-template output requires a template, e.g. -o go-template='{{.id}}'")
+		}
+		tmpl, err := template.New("output").Parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid go-template: %w", err)
+		}
+		return tmpl.Execute(f.writer, value)
+
+	case FormatCustomColumns:
+		cols, err := parseColumns(arg)
+		if err != nil {
+			return err
+		}
+		if len(cols) == 0 {
+			return fmt.Errorf("custom-columns output requires a spec, e.g. -o custom-columns=ID:.id,STATUS:.status")
+		}
+		return f.renderColumns(listItems(value), cols)
+
+	case FormatCSV:
+		cols, err := parseColumns(arg)
+		if err != nil {
+			return err
+		}
+		items := listItems(value)
+		if len(cols) == 0 {
+			cols = defaultColumns(items)
+		}
+		return f.renderCSV(items, cols)
+	}
+
+	return fmt.Errorf("unsupported output format: %s", f.format)
+}
+
+// normalize converts data into generic JSON values so that every renderer
+// sees the same field names as -o json.
+func normalize(data any) (any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode output: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to encode output: %w", err)
+	}
+	return value, nil
+}
+
+// numbersToNative replaces json.Number values with int64 or float64 so that
+// YAML renders them as numbers rather than strings.
+func numbersToNative(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if n, err := v.Float64(); err == nil {
+			return n
+		}
+		return v.String()
+	case map[string]any:
+		for k, child := range v {
+			v[k] = numbersToNative(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = numbersToNative(child)
+		}
+	}
+	return value
+}
+
+// parseColumns parses "NAME:.path,NAME2:.path2".
+func parseColumns(spec string) ([]column, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	var cols []column
+	for _, part := range strings.Split(spec, ",") {
+		header, path, ok := strings.Cut(part, ":")
+		if !ok || header == "" || path == "" {
+			return nil, fmt.Errorf("invalid column %q: expected NAME:.path", part)
+		}
+		path = strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}")
+		segs, err := parseJSONPathExpr(path)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, column{header: header, path: segs})
+	}
+	return cols, nil
+}
+
+// listItems returns the rows of a list result: the "items" array, a bare
+// array, or the only array field of an object. Anything else is one row.
+func listItems(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case map[string]any:
+		if items, ok := v["items"].([]any); ok {
+			return items
+		}
+		var arrays [][]any
+		for _, field := range v {
+			if arr, ok := field.([]any); ok {
+				arrays = append(arrays, arr)
+			}
+		}
+		if len(arrays) == 1 {
+			return arrays[0]
+		}
+	}
+	return []any{value}
+}
+
+// defaultColumns returns the sorted union of the top-level keys of all items.
+func defaultColumns(items []any) []column {
+	var names []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if m, ok := item.(map[string]any); ok {
+			for k := range m {
+				if !seen[k] {
+					seen[k] = true
+					names = append(names, k)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+
+	cols := make([]column, len(names))
+	for i, name := range names {
+		cols[i] = column{header: name, path: []jpSegment{{kind: jpField, name: name}}}
+	}
+	return cols
+}
+
+// cell evaluates a column against one item.
+func cell(item any, col column) (string, bool) {
+	values := evalJSONPath(col.path, item)
+	if len(values) == 0 {
+		return "", false
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatScalar(v)
+	}
+	return strings.Join(parts, ","), true
+}
+
+func (f *Formatter) renderColumns(items []any, cols []column) error {
+	w := tabwriter.NewWriter(f.writer, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(cols))
+	for i, col := range cols {
+		headers[i] = col.header
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, item := range items {
+		row := make([]string, len(cols))
+		for i, col := range cols {
+			value, ok := cell(item, col)
+			if !ok || value == "" {
+				value = "<none>"
+			}
+			row[i] = value
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func (f *Formatter) renderCSV(items []any, cols []column) error {
+	w := csv.NewWriter(f.writer)
+	headers := make([]string, len(cols))
+	for i, col := range cols {
+		headers[i] = col.header
+	}
+	if err := w.Write(headers); err != nil {
+		return err
+	}
+	for _, item := range items {
+		row := make([]string, len(cols))
+		for i, col := range cols {
+			row[i], _ = cell(item, col)
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
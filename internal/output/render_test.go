@@ -0,0 +1,162 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type testInstance struct {
+	ID     string            `json:"id"`
+	Status string            `json:"status"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	Ports  []int             `json:"ports,omitempty"`
+}
+
+func newTestFormatter(format string) (*Formatter, *bytes.Buffer, *bytes.Buffer) {
+	var out, errOut bytes.Buffer
+	return &Formatter{format: format, writer: &out, errWriter: &errOut}, &out, &errOut
+}
+
+func testList() any {
+	return map[string]any{
+		"items": []testInstance{
+			{ID: "sbi-1", Status: "RUNNING", Tags: map[string]string{"env": "dev"}, Ports: []int{80, 443}},
+			{ID: "sbi-2", Status: "STOPPED"},
+		},
+		"pagination": &Pagination{Total: 2},
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "jsonpath={.items[0].id}", want: "sbi-1"},
+		{format: "jsonpath={.items[*].id}", want: "sbi-1 sbi-2"},
+		{format: "jsonpath=.pagination.total", want: "2"},
+		{format: `jsonpath={range .items[*]}{.id}{"\t"}{.status}{"\n"}{end}`, want: "sbi-1\tRUNNING\nsbi-2\tSTOPPED\n"},
+		{format: `jsonpath={.items[?(@.status=="STOPPED")].id}`, want: "sbi-2"},
+		{format: `jsonpath={.items[?(@.tags)].id}`, want: "sbi-1"},
+		{format: `jsonpath={.items[?(@.status!="a==b")].id}`, want: "sbi-1 sbi-2"},
+		{format: `jsonpath={.items[?(@.tags.env=="d!=v")].id}`, want: ""},
+		{format: `jsonpath={.items[?(@['status']=='RUNNING')].id}`, want: "sbi-1"},
+		{format: "jsonpath={.items[-1].id}", want: "sbi-2"},
+		{format: "jsonpath={.items[0].ports[0:1]}", want: "80"},
+		{format: "jsonpath={..env}", want: "dev"},
+		{format: "jsonpath={.items[0]['tags'].env}", want: "dev"},
+		{format: "jsonpath={.missing}", want: ""},
+		{format: "go-template={{range .items}}{{.id}},{{end}}", want: "sbi-1,sbi-2,"},
+		{format: "custom-columns=ID:.id,PORTS:.ports[*]", want: "ID     PORTS\nsbi-1  80,443\nsbi-2  <none>\n"},
+		{format: "csv=ID:.id,ENV:.tags.env", want: "ID,ENV\nsbi-1,dev\nsbi-2,\n"},
+		{format: "csv", want: "id,ports,status,tags\nsbi-1,\"[80,443]\",RUNNING,\"{\"\"env\"\":\"\"dev\"\"}\"\nsbi-2,,STOPPED,\n"},
+		{format: "yaml", want: "items:\n  - id: sbi-1\n    ports:\n      - 80\n      - 443\n    status: RUNNING\n    tags:\n      env: dev\n  - id: sbi-2\n    status: STOPPED\npagination:\n  limit: 0\n  offset: 0\n  total: 2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			f, out, _ := newTestFormatter(tt.format)
+			if err := f.PrintJSON(testList()); err != nil {
+				t.Fatalf("PrintJSON() error = %v", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	for _, format := range []string{
+		"jsonpath={.items[0}",
+		"jsonpath={range .items[*]}{.id}",
+		"go-template={{.id",
+		"custom-columns=ID",
+		"xml",
+	} {
+		f, _, _ := newTestFormatter(format)
+		if err := f.PrintJSON(testList()); err == nil {
+			t.Errorf("format %q: expected error", format)
+		}
+	}
+}
+
+func TestPrintTableStructured(t *testing.T) {
+	headers, rows := []string{"ID", "STATUS"}, [][]string{{"sbi-1", "RUNNING"}}
+
+	f, out, _ := newTestFormatter("json")
+	if err := f.PrintTable(headers, rows, nil); err != nil {
+		t.Fatalf("PrintTable() error = %v", err)
+	}
+	if !strings.Contains(out.String(), `"STATUS": "RUNNING"`) {
+		t.Errorf("json output = %q", out.String())
+	}
+
+	// Display strings are not rendered in the other structured formats
+	for _, format := range []string{"yaml", "csv", "jsonpath={.items[0].ID}", "custom-columns=ID:.ID"} {
+		f, out, _ := newTestFormatter(format)
+		if err := f.PrintTable(headers, rows, nil); err == nil {
+			t.Errorf("PrintTable(%q): expected error, got %q", format, out.String())
+		}
+		if err := f.PrintKeyValue([]KeyValue{{Key: "ID", Value: "sbi-1"}}); err == nil {
+			t.Errorf("PrintKeyValue(%q): expected error", format)
+		}
+	}
+}
+
+func TestPrintListStructured(t *testing.T) {
+	items := []testInstance{{ID: "sbi-1", Status: "RUNNING"}}
+	rows := [][]string{{"sbi-1", "Running (formatted)"}}
+
+	f, out, _ := newTestFormatter("jsonpath={.items[0].status}")
+	if err := f.PrintList([]string{"ID", "STATUS"}, rows, items, nil); err != nil {
+		t.Fatalf("PrintList() error = %v", err)
+	}
+	if got := out.String(); got != "RUNNING" {
+		t.Errorf("output = %q, want RUNNING (rendered from items, not rows)", got)
+	}
+
+	f, out, _ = newTestFormatter("text")
+	if err := f.PrintList([]string{"ID", "STATUS"}, rows, items, nil); err != nil {
+		t.Fatalf("PrintList() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Running (formatted)") {
+		t.Errorf("text output = %q, want formatted rows", out.String())
+	}
+}
+
+func TestMessagesGoToStderrForTemplates(t *testing.T) {
+	f, out, errOut := newTestFormatter("jsonpath={.id}")
+	f.PrintInfo("hello")
+	f.PrintWarning("careful")
+	if out.Len() != 0 {
+		t.Errorf("stdout = %q, want empty", out.String())
+	}
+	if !strings.Contains(errOut.String(), "hello") || !strings.Contains(errOut.String(), "careful") {
+		t.Errorf("stderr = %q", errOut.String())
+	}
+
+	f, out, _ = newTestFormatter("json")
+	f.PrintInfo("hello")
+	if !strings.Contains(out.String(), `"status": "info"`) {
+		t.Errorf("json info = %q, want JSON object on stdout", out.String())
+	}
+}
+
+func TestIsStructured(t *testing.T) {
+	for format, want := range map[string]bool{
+		"":                   false,
+		"text":               false,
+		"json":               true,
+		"yaml":               true,
+		"csv":                true,
+		"jsonpath={.id}":     true,
+		"go-template={{.}}":  true,
+		"custom-columns=A:.": true,
+	} {
+		if got := isStructured(format); got != want {
+			t.Errorf("isStructured(%q) = %v, want %v", format, got, want)
+		}
+	}
+}
@@ -117,6 +117,15 @@ type OperationResult struct {
 	Timing  *Timing        `json:"timing,omitempty"`
 }
 
+// ErrorResult is the JSON form of a failed command under -o json
+type ErrorResult struct {
+	Status   string `json:"status"` // always "error"
+	Message  string `json:"message"`
+	Kind     string `json:"kind,omitempty"` // not_found, auth, quota, conflict, unsupported, network, timeout
+	Code     string `json:"code,omitempty"` // backend error code or HTTP status
+	ExitCode int    `json:"exit_code"`
+}
+
 // KeyValue represents an ordered key-value pair
 type KeyValue struct {
 	Key   string
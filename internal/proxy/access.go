@@ -0,0 +1,212 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// selfSignedValidity is how long a generated listener certificate is valid.
+const selfSignedValidity = 30 * 24 * time.Hour
+
+// AccessOptions restricts who can use the local listener. Credentials are
+// checked against the Authorization header, which is not forwarded to the
+// sandbox once it matched.
+type AccessOptions struct {
+	BasicAuthUser     string
+	BasicAuthPassword string
+	BearerToken       string
+	// AllowedNets limits the client addresses that are served. Loopback
+	// clients are always allowed; an empty list allows every client.
+	AllowedNets []*net.IPNet
+	// Certificate makes the listener serve HTTPS.
+	Certificate *tls.Certificate
+}
+
+// AuthEnabled reports whether clients must authenticate.
+func (o AccessOptions) AuthEnabled() bool {
+	return o.BasicAuthUser != "" || o.BearerToken != ""
+}
+
+// restricted reports whether any access control is configured.
+func (o AccessOptions) restricted() bool {
+	return o.AuthEnabled() || len(o.AllowedNets) > 0
+}
+
+// guard rejects clients outside the allowlist with 403 and requests without
+// valid credentials with 401.
+func (p *Proxy) guard(next http.Handler) http.Handler {
+	opts := p.options.Access
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !opts.allows(r.RemoteAddr) {
+			if p.options.Verbose {
+				p.logger.Printf("[DENIED] %s %s %s: address not allowed", r.RemoteAddr, r.Method, r.URL.Path)
+			}
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if opts.AuthEnabled() {
+			if !opts.authenticates(r.Header.Get("Authorization")) {
+				if p.options.Verbose {
+					p.logger.Printf("[DENIED] %s %s %s: missing or invalid credentials", r.RemoteAddr, r.Method, r.URL.Path)
+				}
+				if opts.BasicAuthUser != "" {
+					w.Header().Add("WWW-Authenticate", `Basic realm="ags proxy", charset="UTF-8"`)
+				}
+				if opts.BearerToken != "" {
+					w.Header().Add("WWW-Authenticate", `Bearer realm="ags proxy"`)
+				}
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			r = r.Clone(r.Context())
+			r.Header.Del("Authorization")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allows reports whether a client at remoteAddr ("host:port") may connect.
+func (o AccessOptions) allows(remoteAddr string) bool {
+	if len(o.AllowedNets) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+	for _, n := range o.AllowedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticates reports whether an Authorization header value carries the
+// configured basic auth credentials or bearer token.
+func (o AccessOptions) authenticates(header string) bool {
+	scheme, credentials, ok := strings.Cut(header, " ")
+	if !ok {
+		return false
+	}
+	credentials = strings.TrimSpace(credentials)
+	switch {
+	case strings.EqualFold(scheme, "Basic") && o.BasicAuthUser != "":
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return false
+		}
+		user, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return false
+		}
+		userOK := subtle.ConstantTimeCompare([]byte(user), []byte(o.BasicAuthUser))
+		passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(o.BasicAuthPassword))
+		return userOK&passwordOK == 1
+	case strings.EqualFold(scheme, "Bearer") && o.BearerToken != "":
+		return subtle.ConstantTimeCompare([]byte(credentials), []byte(o.BearerToken)) == 1
+	}
+	return false
+}
+
+// ParseAllowList parses IP addresses and CIDR ranges, e.g. "192.168.1.20"
+// or "10.0.0.0/8".
+func ParseAllowList(specs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if strings.Contains(spec, "/") {
+			_, n, err := net.ParseCIDR(spec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR range %q", spec)
+			}
+			nets = append(nets, n)
+			continue
+		}
+		ip := net.ParseIP(spec)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", spec)
+		}
+		bits := 8 * net.IPv6len
+		if v4 := ip.To4(); v4 != nil {
+			ip, bits = v4, 8*net.IPv4len
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, nil
+}
+
+// SelfSignedCertificate generates an ECDSA P-256 certificate for the given
+// host names and IP addresses, valid for 30 days.
+func SelfSignedCertificate(hosts []string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"ags proxy"}, CommonName: "ags proxy"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if h != "" {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a certificate as
+// colon-separated hex, the form browsers show.
+func Fingerprint(cert tls.Certificate) string {
+	if len(cert.Certificate) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(cert.Certificate[0])
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,215 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startAccessProxy starts a proxy with access control in front of upstream.
+func startAccessProxy(t *testing.T, upstream *httptest.Server, access AccessOptions) string {
+	t.Helper()
+	p, err := New(Options{
+		InstanceID:    "test-sandbox",
+		Domain:        "test.example.com",
+		RemotePort:    3000,
+		Token:         "secret-token",
+		ListenAddress: "127.0.0.1:0",
+		Insecure:      true,
+		Logger:        log.New(io.Discard, "", 0),
+		Access:        access,
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	p.targetHost = strings.TrimPrefix(upstream.URL, "https://")
+	addr, err := p.Start()
+	if err != nil {
+		t.Fatalf("failed to start proxy: %v", err)
+	}
+	t.Cleanup(p.Stop)
+	return addr
+}
+
+func TestProxyAuth(t *testing.T) {
+	var upstreamAuth []string
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamAuth = append(upstreamAuth, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	addr := startAccessProxy(t, upstream, AccessOptions{
+		BasicAuthUser:     "alice",
+		BasicAuthPassword: "s3cret",
+		BearerToken:       "local-token",
+	})
+
+	tests := []struct {
+		name   string
+		setup  func(r *http.Request)
+		status int
+	}{
+		{"no credentials", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("alice", "wrong") }, http.StatusUnauthorized},
+		{"wrong user", func(r *http.Request) { r.SetBasicAuth("bob", "s3cret") }, http.StatusUnauthorized},
+		{"wrong bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer other") }, http.StatusUnauthorized},
+		{"basic", func(r *http.Request) { r.SetBasicAuth("alice", "s3cret") }, http.StatusOK},
+		{"bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer local-token") }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/", nil)
+			tt.setup(req)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == http.StatusUnauthorized && len(resp.Header.Values("WWW-Authenticate")) != 2 {
+				t.Errorf("WWW-Authenticate = %v, want basic and bearer challenges", resp.Header.Values("WWW-Authenticate"))
+			}
+		})
+	}
+
+	if len(upstreamAuth) != 2 {
+		t.Fatalf("upstream received %d requests, want 2", len(upstreamAuth))
+	}
+	for _, auth := range upstreamAuth {
+		if auth != "" {
+			t.Errorf("local credentials forwarded upstream: %q", auth)
+		}
+	}
+}
+
+func TestProxyWebSocketOrigin(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer upstream.Close()
+
+	addr := startAccessProxy(t, upstream, AccessOptions{BasicAuthUser: "alice", BasicAuthPassword: "s3cret"})
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:s3cret"))
+
+	tests := []struct {
+		origin string
+		ok     bool
+	}{
+		{"", true},
+		{"http://" + addr, true},
+		{"http://evil.example.com", false},
+		{"http://127.0.0.1:1", false},
+	}
+	for _, tt := range tests {
+		header := http.Header{"Authorization": {auth}}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		dialer := &websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+		conn, resp, err := dialer.Dial("ws://"+addr+"/", header)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("origin %q: dial failed: %v", tt.origin, err)
+				continue
+			}
+			_ = conn.Close()
+			continue
+		}
+		if !errors.Is(err, websocket.ErrBadHandshake) || resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Errorf("origin %q: err = %v, want 403 handshake failure", tt.origin, err)
+		}
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}
+}
+
+func TestAccessAllows(t *testing.T) {
+	nets, err := ParseAllowList([]string{"192.168.1.20", "10.0.0.0/8", "fd00::/8"})
+	if err != nil {
+		t.Fatalf("ParseAllowList: %v", err)
+	}
+	opts := AccessOptions{AllowedNets: nets}
+
+	tests := []struct {
+		remote string
+		want   bool
+	}{
+		{"192.168.1.20:5000", true},
+		{"192.168.1.21:5000", false},
+		{"10.1.2.3:5000", true},
+		{"[fd00::1]:5000", true},
+		{"[2001:db8::1]:5000", false},
+		{"127.0.0.1:5000", true},
+		{"[::1]:5000", true},
+		{"not-an-address", false},
+	}
+	for _, tt := range tests {
+		if got := opts.allows(tt.remote); got != tt.want {
+			t.Errorf("allows(%q) = %v, want %v", tt.remote, got, tt.want)
+		}
+	}
+	if !(AccessOptions{}).allows("203.0.113.5:5000") {
+		t.Error("empty allowlist should allow every client")
+	}
+
+	for _, spec := range []string{"", "10.0.0.0/33", "example.com"} {
+		if _, err := ParseAllowList([]string{spec}); err == nil {
+			t.Errorf("ParseAllowList(%q) should fail", spec)
+		}
+	}
+}
+
+func TestProxyTLS(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer upstream.Close()
+
+	cert, err := SelfSignedCertificate([]string{"localhost", "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("SelfSignedCertificate: %v", err)
+	}
+	if fp := Fingerprint(cert); len(fp) != 95 {
+		t.Errorf("Fingerprint = %q, want 32 colon-separated bytes", fp)
+	}
+	addr := startAccessProxy(t, upstream, AccessOptions{Certificate: &cert})
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get("https://" + addr + "/")
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "hello" {
+		t.Errorf("body = %q, want %q", body, "hello")
+	}
+
+	// Plain HTTP is not served on a TLS listener.
+	if resp, err := http.Get("http://" + addr + "/"); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Error("plain HTTP request succeeded on a TLS listener")
+		}
+	}
+}
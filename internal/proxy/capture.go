@@ -0,0 +1,657 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// AccessLogCombined is the Apache Combined Log Format, followed by the
+	// request time in seconds.
+	AccessLogCombined = "combined"
+	// AccessLogJSON writes one JSON object per line.
+	AccessLogJSON = "json"
+
+	// DefaultMaxBodySize is the default size HAR bodies and WebSocket
+	// messages are truncated to.
+	DefaultMaxBodySize = 64 << 10
+
+	// harFlushInterval is how often a changed HAR archive is rewritten.
+	harFlushInterval = time.Second
+
+	// redacted replaces access tokens in captured traffic.
+	redacted = "[REDACTED]"
+
+	// combinedTimeFormat is the timestamp layout of the Combined Log Format.
+	combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+)
+
+// CaptureOptions configures traffic capture. Access tokens are redacted from
+// everything that is captured.
+type CaptureOptions struct {
+	AccessLog       io.Writer // Receives one line per request, WebSocket session and message
+	AccessLogFormat string    // AccessLogCombined (default) or AccessLogJSON
+	HARFile         string    // HAR 1.2 archive, rewritten while requests complete
+	MaxBodySize     int       // Truncates HAR bodies and messages; defaults to DefaultMaxBodySize
+	Creator         string    // Version recorded as the HAR creator
+}
+
+// enabled reports whether any capture is configured.
+func (o CaptureOptions) enabled() bool {
+	return o.AccessLog != nil || o.HARFile != ""
+}
+
+// capture records the traffic of a proxy.
+type capture struct {
+	p    *Proxy
+	opts CaptureOptions
+
+	mu       sync.Mutex // guards the access log, entries and dirty
+	entries  []harEntry
+	dirty    bool
+	inflight sync.WaitGroup // exchanges not yet recorded
+}
+
+// exchangeKey is the context key of the *exchange of a request.
+type exchangeKey struct{}
+
+// exchange is a request and its response, or a WebSocket session, as seen
+// by the proxy.
+type exchange struct {
+	start    time.Time
+	reqBody  bodyBuffer
+	respBody bodyBuffer
+	status   int
+	header   http.Header // response header
+	hijacked bool
+
+	mu       sync.Mutex // guards reqBody, messages and the byte counts of a session
+	messages []harWebSocketMessage
+	wsIn     int64
+	wsOut    int64
+}
+
+// bodyBuffer keeps the first limit bytes of a body and counts all of them.
+// limit exceeds the size bodies are truncated to by the longest access
+// token, so that a token crossing the cut is redacted before truncation.
+type bodyBuffer struct {
+	limit int
+	data  []byte
+	size  int64
+}
+
+func (b *bodyBuffer) add(p []byte) {
+	b.size += int64(len(p))
+	if room := b.limit - len(b.data); room > 0 {
+		b.data = append(b.data, p[:min(room, len(p))]...)
+	}
+}
+
+// teeBody records a request body while the reverse proxy reads it. The
+// transport may still be reading when the response is complete.
+type teeBody struct {
+	io.ReadCloser
+	ex *exchange
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.ex.mu.Lock()
+	t.ex.reqBody.add(p[:n])
+	t.ex.mu.Unlock()
+	return n, err
+}
+
+// captureWriter records the response written to the client.
+type captureWriter struct {
+	http.ResponseWriter
+	ex *exchange
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	if w.ex.status == 0 {
+		w.ex.status = status
+		w.ex.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if w.ex.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.ex.respBody.add(p[:n])
+	return n, err
+}
+
+func (w *captureWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection to the WebSocket upgrader.
+func (w *captureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response does not implement http.Hijacker")
+	}
+	w.ex.hijacked = true
+	return h.Hijack()
+}
+
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// newCapture validates the options and writes an empty HAR archive, so that
+// an unwritable path fails before the proxy starts.
+func newCapture(p *Proxy, opts CaptureOptions) (*capture, error) {
+	switch opts.AccessLogFormat {
+	case "":
+		opts.AccessLogFormat = AccessLogCombined
+	case AccessLogCombined, AccessLogJSON:
+	default:
+		return nil, fmt.Errorf("unknown access log format %q (want %s or %s)", opts.AccessLogFormat, AccessLogCombined, AccessLogJSON)
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	c := &capture{p: p, opts: opts, dirty: true}
+	if err := c.flush(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// wrap records every request served by next.
+func (c *capture) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.inflight.Add(1)
+		defer c.inflight.Done()
+		limit := c.opts.MaxBodySize + c.p.maxTokenLen()
+		ex := &exchange{
+			start:    time.Now(),
+			reqBody:  bodyBuffer{limit: limit},
+			respBody: bodyBuffer{limit: limit},
+		}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = &teeBody{ReadCloser: r.Body, ex: ex}
+		}
+		next.ServeHTTP(&captureWriter{ResponseWriter: w, ex: ex}, r.WithContext(context.WithValue(r.Context(), exchangeKey{}, ex)))
+		c.finish(r, ex)
+	})
+}
+
+// recorder returns the function that records the WebSocket messages of a
+// request, or nil when nothing is captured.
+func (c *capture) recorder(r *http.Request) func(direction string, msgType int, data []byte) {
+	if c == nil {
+		return nil
+	}
+	ex, _ := r.Context().Value(exchangeKey{}).(*exchange)
+	if ex == nil {
+		return nil
+	}
+	return func(direction string, msgType int, data []byte) {
+		now := time.Now()
+		ex.mu.Lock()
+		if direction == directionToUpstream {
+			ex.wsIn += int64(len(data))
+		} else {
+			ex.wsOut += int64(len(data))
+		}
+		if c.opts.HARFile != "" {
+			ex.messages = append(ex.messages, c.harMessage(now, direction, msgType, data))
+		}
+		ex.mu.Unlock()
+		c.logMessage(now, r, direction, msgType, len(data))
+	}
+}
+
+// finish writes the access log line and HAR entry of a completed exchange.
+func (c *capture) finish(r *http.Request, ex *exchange) {
+	status := ex.status
+	if ex.hijacked {
+		status = http.StatusSwitchingProtocols
+	} else if status == 0 {
+		status = http.StatusOK
+	}
+	latency := time.Since(ex.start)
+	ex.mu.Lock()
+	reqBody := ex.reqBody
+	bytesIn, bytesOut := reqBody.size+ex.wsIn, ex.respBody.size+ex.wsOut
+	messages := ex.messages
+	ex.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.opts.AccessLog != nil {
+		path := c.p.redact(r.URL.RequestURI())
+		if c.opts.AccessLogFormat == AccessLogJSON {
+			c.writeJSONLine(accessLogEntry{
+				Time:       ex.start,
+				RemoteAddr: remoteHost(r),
+				Method:     r.Method,
+				Path:       path,
+				Proto:      r.Proto,
+				Status:     status,
+				BytesIn:    bytesIn,
+				BytesOut:   bytesOut,
+				LatencyMS:  float64(latency.Microseconds()) / 1000,
+				Referer:    r.Referer(),
+				UserAgent:  r.UserAgent(),
+			})
+		} else {
+			size := "-"
+			if bytesOut > 0 {
+				size = fmt.Sprint(bytesOut)
+			}
+			fmt.Fprintf(c.opts.AccessLog, "%s - - [%s] \"%s %s %s\" %d %s \"%s\" \"%s\" %.3f\n",
+				remoteHost(r), ex.start.Format(combinedTimeFormat), r.Method, escapeLog(path), r.Proto,
+				status, size, escapeLog(r.Referer()), escapeLog(r.UserAgent()), latency.Seconds())
+		}
+	}
+	if c.opts.HARFile != "" {
+		c.entries = append(c.entries, c.harEntry(r, ex, reqBody, status, latency, messages))
+		c.dirty = true
+	}
+}
+
+// logMessage writes the access log line of a WebSocket message.
+func (c *capture) logMessage(t time.Time, r *http.Request, direction string, msgType, size int) {
+	if c.opts.AccessLog == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	path := c.p.redact(r.URL.RequestURI())
+	if c.opts.AccessLogFormat == AccessLogJSON {
+		c.writeJSONLine(messageLogEntry{
+			Time:       t,
+			RemoteAddr: remoteHost(r),
+			Type:       "ws_message",
+			Path:       path,
+			Direction:  direction,
+			Opcode:     opcodeName(msgType),
+			Size:       size,
+		})
+		return
+	}
+	fmt.Fprintf(c.opts.AccessLog, "%s - - [%s] \"WS %s\" %s %s %d\n",
+		remoteHost(r), t.Format(combinedTimeFormat), escapeLog(path), direction, opcodeName(msgType), size)
+}
+
+// writeJSONLine writes v as one line. Must be called with c.mu held.
+func (c *capture) writeJSONLine(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	_, _ = c.opts.AccessLog.Write(append(data, '\n'))
+}
+
+// close waits up to timeout for exchanges in flight, e.g. WebSocket sessions
+// that are shutting down, and writes the HAR archive a last time.
+func (c *capture) close(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+	return c.flush()
+}
+
+// flushLoop rewrites the HAR archive while it changes, until ctx is done.
+func (c *capture) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(harFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.flush(); err != nil {
+				c.p.logger.Printf("[ERROR] Failed to write HAR file: %v", err)
+			}
+		}
+	}
+}
+
+// flush writes the HAR archive if it changed since the last write. The file
+// is replaced atomically, so it is complete whenever it is read.
+func (c *capture) flush() error {
+	if c.opts.HARFile == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	entries := c.entries
+	if entries == nil {
+		entries = []harEntry{}
+	}
+	data, err := json.MarshalIndent(harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "ags-cli", Version: c.opts.Creator},
+		Entries: entries,
+	}}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.opts.HARFile), ".har-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.opts.HARFile); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// harEntry builds the HAR entry of an exchange. The request is recorded as
+// sent upstream, with the injected access token redacted.
+func (c *capture) harEntry(r *http.Request, ex *exchange, reqBody bodyBuffer, status int, latency time.Duration, messages []harWebSocketMessage) harEntry {
+	reqHeader := r.Header.Clone()
+	reqHeader.Set("Host", c.p.targetHost)
+	reqHeader.Set("X-Access-Token", redacted)
+
+	query := []harNameValue{}
+	for name, values := range r.URL.Query() {
+		for _, v := range values {
+			query = append(query, harNameValue{Name: name, Value: c.p.redact(v)})
+		}
+	}
+
+	entry := harEntry{
+		StartedDateTime: ex.start,
+		Time:            float64(latency.Microseconds()) / 1000,
+		Request: harRequest{
+			Method:      r.Method,
+			URL:         c.p.redact("https://" + c.p.targetHost + r.URL.RequestURI()),
+			HTTPVersion: r.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     c.harHeaders(reqHeader),
+			QueryString: query,
+			HeadersSize: -1,
+			BodySize:    reqBody.size,
+		},
+		Response: harResponse{
+			Status:      status,
+			StatusText:  http.StatusText(status),
+			HTTPVersion: r.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     c.harHeaders(ex.header),
+			Content:     harContent{Size: ex.respBody.size, MimeType: ex.header.Get("Content-Type")},
+			HeadersSize: -1,
+			BodySize:    ex.respBody.size,
+		},
+		Timings:           harTimings{Send: 0, Wait: float64(latency.Microseconds()) / 1000, Receive: 0},
+		WebSocketMessages: messages,
+	}
+	if reqBody.size > 0 {
+		text, encoding, comment := c.bodyText(reqBody, r.Header.Get("Content-Encoding"))
+		entry.Request.PostData = &harPostData{
+			MimeType: r.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+			Comment:  comment,
+		}
+	}
+	if ex.respBody.size > 0 {
+		content := &entry.Response.Content
+		content.Text, content.Encoding, content.Comment = c.bodyText(ex.respBody, ex.header.Get("Content-Encoding"))
+	}
+	return entry
+}
+
+// harHeaders converts headers to HAR, redacting access tokens and the
+// credentials of the local listener.
+func (c *capture) harHeaders(h http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range h {
+		for _, v := range values {
+			if strings.EqualFold(name, "X-Access-Token") ||
+				(strings.EqualFold(name, "Authorization") && c.p.options.Access.AuthEnabled()) {
+				v = redacted
+			}
+			headers = append(headers, harNameValue{Name: name, Value: c.p.redact(v)})
+		}
+	}
+	return headers
+}
+
+// bodyText returns a captured body as HAR text, redacted and truncated:
+// plain when it is uncompressed UTF-8, base64 otherwise. comment notes a
+// truncation.
+func (c *capture) bodyText(b bodyBuffer, contentEncoding string) (text, encoding, comment string) {
+	data := c.p.redactBytes(b.data)
+	if b.size > int64(c.opts.MaxBodySize) {
+		data = data[:min(len(data), c.opts.MaxBodySize)]
+		comment = fmt.Sprintf("truncated to %d of %d bytes", len(data), b.size)
+	}
+	if (contentEncoding == "" || contentEncoding == "identity") && utf8.Valid(data) {
+		return string(data), "", comment
+	}
+	return base64.StdEncoding.EncodeToString(data), "base64", comment
+}
+
+// harMessage converts a WebSocket message to the HAR representation used by
+// browsers; binary data is base64 encoded.
+func (c *capture) harMessage(t time.Time, direction string, msgType int, data []byte) harWebSocketMessage {
+	kind := "receive"
+	if direction == directionToUpstream {
+		kind = "send"
+	}
+	data = c.p.redactBytes(data)
+	data = data[:min(len(data), c.opts.MaxBodySize)]
+	text := string(data)
+	if msgType != websocket.TextMessage || !utf8.Valid(data) {
+		text = base64.StdEncoding.EncodeToString(data)
+	}
+	return harWebSocketMessage{
+		Type:   kind,
+		Time:   float64(t.UnixMicro()) / 1e6,
+		Opcode: msgType,
+		Data:   text,
+	}
+}
+
+// remoteHost returns the client address without its port.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// escapeLog escapes a quoted field of a Combined Log Format line.
+func escapeLog(s string) string {
+	return logEscaper.Replace(s)
+}
+
+var logEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+// opcodeName names the opcode of a WebSocket data message.
+func opcodeName(msgType int) string {
+	if msgType == websocket.TextMessage {
+		return "text"
+	}
+	return "binary"
+}
+
+// redact replaces the access tokens used by the proxy in s.
+func (p *Proxy) redact(s string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, t := range p.tokens {
+		s = strings.ReplaceAll(s, t, redacted)
+	}
+	return s
+}
+
+// maxTokenLen returns the length of the longest access token used.
+func (p *Proxy) maxTokenLen() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, t := range p.tokens {
+		n = max(n, len(t))
+	}
+	return n
+}
+
+// redactBytes replaces the access tokens used by the proxy in data.
+func (p *Proxy) redactBytes(data []byte) []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, t := range p.tokens {
+		data = bytes.ReplaceAll(data, []byte(t), []byte(redacted))
+	}
+	return data
+}
+
+// accessLogEntry is a request line of the JSON access log.
+type accessLogEntry struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remote_addr"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Proto      string    `json:"proto"`
+	Status     int       `json:"status"`
+	BytesIn    int64     `json:"bytes_in"`
+	BytesOut   int64     `json:"bytes_out"`
+	LatencyMS  float64   `json:"latency_ms"`
+	Referer    string    `json:"referer,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+}
+
+// messageLogEntry is a WebSocket message line of the JSON access log.
+type messageLogEntry struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remote_addr"`
+	Type       string    `json:"type"`
+	Path       string    `json:"path"`
+	Direction  string    `json:"direction"`
+	Opcode     string    `json:"opcode"`
+	Size       int       `json:"size"`
+}
+
+// HAR 1.2 (http://www.softwareishard.com/blog/har-12-spec/); WebSocket
+// messages use the _webSocketMessages extension of browser dev tools.
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime   time.Time             `json:"startedDateTime"`
+	Time              float64               `json:"time"`
+	Request           harRequest            `json:"request"`
+	Response          harResponse           `json:"response"`
+	Cache             struct{}              `json:"cache"`
+	Timings           harTimings            `json:"timings"`
+	WebSocketMessages []harWebSocketMessage `json:"_webSocketMessages,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"_encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harWebSocketMessage struct {
+	Type   string  `json:"type"`
+	Time   float64 `json:"time"`
+	Opcode int     `json:"opcode"`
+	Data   string  `json:"data"`
+}
@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes and reads.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// startCaptureProxy starts a proxy with capture in front of upstream.
+func startCaptureProxy(t *testing.T, upstream *httptest.Server, capture CaptureOptions) (*Proxy, string) {
+	t.Helper()
+	p, err := New(Options{
+		InstanceID:    "test-sandbox",
+		Domain:        "test.example.com",
+		RemotePort:    3000,
+		Token:         "secret-token",
+		ListenAddress: "127.0.0.1:0",
+		Insecure:      true,
+		Logger:        log.New(io.Discard, "", 0),
+		Capture:       capture,
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	p.targetHost = strings.TrimPrefix(upstream.URL, "https://")
+	addr, err := p.Start()
+	if err != nil {
+		t.Fatalf("failed to start proxy: %v", err)
+	}
+	return p, addr
+}
+
+func readHAR(t *testing.T, path string) harFile {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read HAR file: %v", err)
+	}
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("invalid HAR file: %v", err)
+	}
+	if strings.Contains(string(data), "secret-token") {
+		t.Errorf("HAR file contains the access token:\n%s", data)
+	}
+	return har
+}
+
+// TestCaptureHTTP verifies the JSON access log and HAR entries of HTTP
+// requests, including body truncation and token redaction.
+func TestCaptureHTTP(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// Echo the token to check that it is redacted from responses
+		w.Header().Set("X-Echo-Token", r.Header.Get("X-Access-Token"))
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "token=%s body=%d", r.Header.Get("X-Access-Token"), len(body))
+	}))
+	defer upstream.Close()
+
+	var accessLog syncBuffer
+	harPath := filepath.Join(t.TempDir(), "traffic.har")
+	p, addr := startCaptureProxy(t, upstream, CaptureOptions{
+		AccessLog:       &accessLog,
+		AccessLogFormat: AccessLogJSON,
+		HARFile:         harPath,
+		MaxBodySize:     8,
+		Creator:         "test",
+	})
+
+	resp, err := http.Post(fmt.Sprintf("http://%s/submit?q=1", addr), "text/plain", strings.NewReader("0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	p.Stop()
+
+	var line accessLogEntry
+	if err := json.Unmarshal([]byte(strings.TrimSpace(accessLog.String())), &line); err != nil {
+		t.Fatalf("invalid access log line %q: %v", accessLog.String(), err)
+	}
+	if line.Method != http.MethodPost || line.Path != "/submit?q=1" || line.Status != http.StatusOK || line.BytesIn != 16 || line.BytesOut == 0 {
+		t.Errorf("unexpected access log entry: %+v", line)
+	}
+
+	har := readHAR(t, harPath)
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatalf("unexpected HAR log: %+v", har.Log)
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.PostData == nil || entry.Request.PostData.Text != "01234567" || entry.Request.BodySize != 16 {
+		t.Errorf("request body not truncated to 8 bytes: %+v", entry.Request.PostData)
+	}
+	if !strings.Contains(entry.Request.PostData.Comment, "truncated") {
+		t.Errorf("missing truncation comment: %+v", entry.Request.PostData)
+	}
+	headers := map[string]string{}
+	for _, h := range entry.Request.Headers {
+		headers[h.Name] = h.Value
+	}
+	if headers["X-Access-Token"] != redacted {
+		t.Errorf("X-Access-Token = %q, want %q", headers["X-Access-Token"], redacted)
+	}
+	if entry.Response.Status != http.StatusOK || !strings.HasPrefix(entry.Response.Content.Text, "token=[R") {
+		t.Errorf("unexpected response: %+v", entry.Response)
+	}
+}
+
+// TestCaptureWebSocket verifies that WebSocket messages are logged with
+// their direction and recorded in the HAR entry of the session.
+func TestCaptureWebSocket(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(msgType, append([]byte("echo:"), msg...)); err != nil {
+				return
+			}
+		}
+	}))
+	defer upstream.Close()
+
+	var accessLog syncBuffer
+	harPath := filepath.Join(t.TempDir(), "ws.har")
+	p, addr := startCaptureProxy(t, upstream, CaptureOptions{AccessLog: &accessLog, HARFile: harPath})
+
+	dialer := &websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+	conn, _, err := dialer.DialContext(context.Background(), fmt.Sprintf("ws://%s/ws", addr), nil)
+	if err != nil {
+		t.Fatalf("WebSocket dial failed: %v", err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("hi secret-token")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	_ = conn.Close()
+	p.Stop()
+
+	logged := accessLog.String()
+	for _, want := range []string{`"WS /ws" client->upstream text 15`, `"WS /ws" upstream->client text 20`, `"GET /ws HTTP/1.1" 101 20`} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("access log should contain %q, got:\n%s", want, logged)
+		}
+	}
+
+	har := readHAR(t, harPath)
+	if len(har.Log.Entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(har.Log.Entries))
+	}
+	messages := har.Log.Entries[0].WebSocketMessages
+	if len(messages) != 2 || messages[0].Type != "send" || messages[1].Type != "receive" {
+		t.Fatalf("unexpected WebSocket messages: %+v", messages)
+	}
+	if messages[0].Data != "hi "+redacted {
+		t.Errorf("sent data = %q, want the token redacted", messages[0].Data)
+	}
+}
+
+// TestCaptureInvalidFormat verifies that an unknown access log format is
+// rejected.
+func TestCaptureInvalidFormat(t *testing.T) {
+	_, err := New(Options{
+		InstanceID: "test-sandbox",
+		Domain:     "test.example.com",
+		RemotePort: 3000,
+		Token:      "t",
+		Capture:    CaptureOptions{AccessLog: io.Discard, AccessLogFormat: "common"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "common") {
+		t.Errorf("New() error = %v, want unknown format", err)
+	}
+}
@@ -3,12 +3,23 @@
 // It bridges local HTTP/WebSocket connections to a remote sandbox service through
 // the AGS TLS-encrypted gateway. The proxy automatically injects access tokens
 // into all requests, supporting both HTTP and WebSocket protocols seamlessly.
+//
+// Long-lived forwards recover on their own: a token the gateway rejects is
+// replaced through the TokenProvider (idempotent requests are replayed with
+// the new token), and WebSocket bridges whose upstream drops are redialed
+// with exponential backoff while the local client stays connected.
+//
+// A listener reachable from the network can be protected with
+// AccessOptions: basic or bearer authentication, a client allowlist and HTTPS.
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
@@ -21,19 +32,61 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// maxBackoff is the upper bound for the WebSocket reconnection delay.
+	maxBackoff = 30 * time.Second
+
+	// maxDialFailures is the number of consecutive failed upstream dials after
+	// which a dropped WebSocket bridge is given up.
+	maxDialFailures = 5
+
+	// minRefreshInterval limits token refreshes, so that a gateway rejecting
+	// every token (e.g. for a port that is not open) does not cause a control
+	// plane call per request.
+	minRefreshInterval = 10 * time.Second
+
+	// rejectionPeekSize is how much of a 401/403 body is inspected to tell a
+	// gateway rejection from a response of the forwarded service.
+	rejectionPeekSize = 512
+
+	// directionToUpstream and directionToClient name the direction of a
+	// WebSocket message in logs and captures.
+	directionToUpstream = "client->upstream"
+	directionToClient   = "upstream->client"
+)
+
+// ErrInstanceGone is wrapped by TokenProvider errors when the sandbox
+// instance no longer exists. The proxy then answers every request with
+// 410 Gone and closes Gone().
+var ErrInstanceGone = errors.New("sandbox instance no longer exists")
+
+// errTokenRejected reports a rejected token that could not be replaced.
+var errTokenRejected = errors.New("access token rejected by the gateway")
+
 // Options defines configuration for the port-forward proxy.
 type Options struct {
 	InstanceID string // e.g. "sandbox-xxx"
 	Domain     string // e.g. "ap-guangzhou.tencentags.com" (region-qualified)
 	RemotePort int    // Port on the remote sandbox to proxy to
-	// Token is the access token for the sandbox. Its lifetime is bound to the
-	// sandbox instance lifecycle — it stays valid as long as the instance is
-	// running, so no refresh or 401-retry logic is required.
-	Token         string
+	// Token is the initial access token for the sandbox.
+	Token string
+	// TokenProvider returns a new access token after the gateway rejected the
+	// current one with 401 or 403. Calls are serialized. Without it the
+	// initial token is used for the lifetime of the proxy.
+	TokenProvider func() (string, error)
 	ListenAddress string      // e.g. "127.0.0.1:3000"
 	Logger        *log.Logger // Optional logger; defaults to log.Default()
 	Insecure      bool        // Skip TLS verification
 	Verbose       bool        // Enable verbose request logging
+	// DialTLSContext optionally replaces the TLS dial to the sandbox gateway
+	// for both HTTP and WebSocket upstream connections (e.g. to reach local
+	// backend instances). TLS settings above do not apply when it is set.
+	DialTLSContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Capture optionally records the proxied traffic (access log, HAR).
+	Capture CaptureOptions
+	// Access optionally protects the local listener (authentication, client
+	// allowlist, HTTPS) when it is reachable from the network.
+	Access AccessOptions
 }
 
 // Proxy manages an active HTTP/WebSocket reverse proxy that forwards local
@@ -46,6 +99,20 @@ type Proxy struct {
 	cancel     context.CancelFunc
 	logger     *log.Logger
 	targetHost string // e.g. "3000-sandbox-xxx.ap-guangzhou.tencentags.com"
+
+	mu          sync.Mutex // guards token and tokens
+	token       string
+	tokens      []string   // every token used, redacted from captures
+	refreshMu   sync.Mutex // serializes refreshes; guards refreshedAt
+	refreshedAt time.Time
+
+	gone     chan struct{} // closed once the instance is gone
+	goneOnce sync.Once
+	goneErr  error
+
+	reconnectDelay time.Duration // initial WebSocket reconnection delay
+
+	capture *capture // nil when no capture is configured
 }
 
 // New creates and initializes a new port-forward proxy but does not start it.
@@ -67,15 +134,24 @@ func New(opts Options) (*Proxy, error) {
 
 	targetHost := fmt.Sprintf("%d-%s.%s", opts.RemotePort, opts.InstanceID, opts.Domain)
 
-	ctx, cancel := context.WithCancel(context.Background())
-
-	return &Proxy{
-		options:    opts,
-		ctx:        ctx,
-		cancel:     cancel,
-		logger:     logger,
-		targetHost: targetHost,
-	}, nil
+	p := &Proxy{
+		options:        opts,
+		logger:         logger,
+		targetHost:     targetHost,
+		token:          opts.Token,
+		tokens:         []string{opts.Token},
+		gone:           make(chan struct{}),
+		reconnectDelay: time.Second,
+	}
+	if opts.Capture.enabled() {
+		c, err := newCapture(p, opts.Capture)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up traffic capture: %w", err)
+		}
+		p.capture = c
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p, nil
 }
 
 // Start binds to the local address and begins serving proxy requests.
@@ -85,6 +161,12 @@ func (p *Proxy) Start() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to bind local address: %w", err)
 	}
+	if cert := p.options.Access.Certificate; cert != nil {
+		listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{*cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
 	p.listener = listener
 
 	// Build the HTTP reverse proxy
@@ -95,25 +177,24 @@ func (p *Proxy) Start() (string, error) {
 
 	reverseProxy := httputil.NewSingleHostReverseProxy(targetURL)
 
-	// Customize the transport for TLS
-	reverseProxy.Transport = &http.Transport{
+	// Customize the transport for TLS; tokenTransport injects the access token
+	reverseProxy.Transport = &tokenTransport{p: p, base: &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: p.options.Insecure, //nolint:gosec
 		},
+		DialTLSContext:        p.options.DialTLSContext,
 		MaxIdleConns:          100,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ResponseHeaderTimeout: 30 * time.Second,
-	}
+	}}
 
-	// Customize the Director to inject token and fix Host header
+	// Customize the Director to fix the Host header
 	originalDirector := reverseProxy.Director
 	reverseProxy.Director = func(req *http.Request) {
 		originalDirector(req)
 		// Set the correct Host header (changeOrigin equivalent)
 		req.Host = p.targetHost
-		// Inject access token. The sandbox gateway authenticates via X-Access-Token only.
-		req.Header.Set("X-Access-Token", p.options.Token)
 		if p.options.Verbose {
 			p.logger.Printf("[HTTP] %s %s", req.Method, req.URL.Path)
 		}
@@ -123,6 +204,10 @@ func (p *Proxy) Start() (string, error) {
 	// to the client when verbose mode is enabled to avoid leaking internal
 	// host names or network topology to network-accessible clients.
 	reverseProxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if errors.Is(err, ErrInstanceGone) {
+			p.writeGone(w)
+			return
+		}
 		p.logger.Printf("[ERROR] Proxy error: %v", err)
 		w.WriteHeader(http.StatusBadGateway)
 		if p.options.Verbose {
@@ -142,15 +227,33 @@ func (p *Proxy) Start() (string, error) {
 			return true // Allow all origins for local proxy
 		},
 	}
+	if p.options.Access.AuthEnabled() {
+		// Browsers resend basic auth credentials on cross-site WebSocket
+		// handshakes, so other pages must not reach an authenticated
+		// proxy. A nil CheckOrigin accepts only a missing or same-host Origin.
+		wsUpgrader.CheckOrigin = nil
+	}
 
 	// Build the HTTP handler that routes between HTTP proxy and WebSocket proxy
-	mux := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	var mux http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.Err() != nil {
+			p.writeGone(w)
+			return
+		}
 		if isWebSocketRequest(r) {
 			p.handleWebSocket(w, r, wsUpgrader)
 			return
 		}
 		reverseProxy.ServeHTTP(w, r)
 	})
+	if p.options.Access.restricted() {
+		mux = p.guard(mux)
+	}
+	// Capture wraps the guard so that rejected requests are logged too.
+	if p.capture != nil {
+		mux = p.capture.wrap(mux)
+		go p.capture.flushLoop(p.ctx)
+	}
 
 	p.server = &http.Server{
 		Handler:           mux,
@@ -191,19 +294,184 @@ func (p *Proxy) Stop() {
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer shutdownCancel()
 		_ = p.server.Shutdown(shutdownCtx)
+		if p.capture != nil {
+			if err := p.capture.close(3 * time.Second); err != nil {
+				p.logger.Printf("[ERROR] Failed to write HAR file: %v", err)
+			}
+		}
 		p.logger.Println("Proxy stopped.")
 	}
 }
 
+// Gone returns a channel that is closed when the proxy learns that the
+// sandbox instance no longer exists.
+func (p *Proxy) Gone() <-chan struct{} {
+	return p.gone
+}
+
+// Err returns the error that closed Gone(), or nil.
+func (p *Proxy) Err() error {
+	select {
+	case <-p.gone:
+		return p.goneErr
+	default:
+		return nil
+	}
+}
+
+// markGone records that the instance no longer exists.
+func (p *Proxy) markGone(err error) {
+	p.goneOnce.Do(func() {
+		p.goneErr = err
+		p.logger.Printf("[ERROR] %v", err)
+		close(p.gone)
+	})
+}
+
+// writeGone answers a request after the instance is gone.
+func (p *Proxy) writeGone(w http.ResponseWriter) {
+	http.Error(w, fmt.Sprintf("Gone: sandbox %s no longer exists", p.options.InstanceID), http.StatusGone)
+}
+
+// currentToken returns the access token to send to the gateway.
+func (p *Proxy) currentToken() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.token
+}
+
+// refreshToken replaces the rejected token and returns the token to retry
+// with. Callers that saw the same rejected token share a single refresh.
+func (p *Proxy) refreshToken(rejected string) (string, error) {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	if current := p.currentToken(); current != rejected {
+		return current, nil // refreshed by another request meanwhile
+	}
+	if p.options.TokenProvider == nil || time.Since(p.refreshedAt) < minRefreshInterval {
+		return "", errTokenRejected
+	}
+	p.refreshedAt = time.Now()
+
+	token, err := p.options.TokenProvider()
+	if err != nil {
+		if errors.Is(err, ErrInstanceGone) {
+			p.markGone(err)
+		} else {
+			p.logger.Printf("[ERROR] Access token refresh failed: %v", err)
+		}
+		return "", err
+	}
+	if token == rejected {
+		return "", errTokenRejected
+	}
+	p.mu.Lock()
+	p.token = token
+	p.tokens = append(p.tokens, token)
+	p.mu.Unlock()
+	p.logger.Printf("[INFO] Access token rejected by the gateway; refreshed")
+	return token, nil
+}
+
+// isTokenRejection reports whether the gateway rejected the access token.
+// A 401 or 403 from the forwarded service itself (e.g. a login page) passes
+// through untouched: only rejections whose body mentions the access token
+// count, the way the sandbox gateway and envd word them. The body is left
+// readable.
+func isTokenRejection(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+		return false
+	}
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return false
+	}
+	peek, err := io.ReadAll(io.LimitReader(resp.Body, rejectionPeekSize))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+	text := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(string(peek)))
+	return strings.Contains(text, "accesstoken")
+}
+
+// tokenTransport injects the access token into upstream requests. When the
+// gateway rejects the token it is refreshed, and requests that are
+// idempotent and have no body are replayed with the new token; others
+// receive the rejection, and the next request uses the new token.
+type tokenTransport struct {
+	p    *Proxy
+	base http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := t.p.currentToken()
+	req = req.Clone(req.Context())
+	// The sandbox gateway authenticates via X-Access-Token only.
+	req.Header.Set("X-Access-Token", token)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !isTokenRejection(resp) {
+		return resp, err
+	}
+
+	fresh, err := t.p.refreshToken(token)
+	if errors.Is(err, ErrInstanceGone) {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	if err != nil || !isReplayable(req) {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	if t.p.options.Verbose {
+		t.p.logger.Printf("[HTTP] %s %s replayed with a refreshed token", req.Method, req.URL.Path)
+	}
+	req.Header.Set("X-Access-Token", fresh)
+	return t.base.RoundTrip(req)
+}
+
+// isReplayable reports whether a request can be sent again safely.
+func isReplayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody
+	}
+	return false
+}
+
+// dialUpstream dials the upstream WebSocket with the current access token.
+// A handshake the gateway rejects is retried once with a refreshed token.
+func (p *Proxy) dialUpstream(dialer *websocket.Dialer, upstreamURL string, headers http.Header) (*websocket.Conn, *http.Response, error) {
+	for retried := false; ; retried = true {
+		token := p.currentToken()
+		h := headers.Clone()
+		// The sandbox gateway authenticates via X-Access-Token only.
+		h.Set("X-Access-Token", token)
+		conn, resp, err := dialer.DialContext(p.ctx, upstreamURL, h)
+		rejected := err != nil && resp != nil && isTokenRejection(resp)
+		// Close the HTTP response body if present (dial failure with a non-101 HTTP response).
+		if resp != nil && resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+		if err == nil || retried || !rejected {
+			return conn, resp, err
+		}
+		if _, refreshErr := p.refreshToken(token); refreshErr != nil {
+			return nil, resp, fmt.Errorf("%w: %w", err, refreshErr)
+		}
+	}
+}
+
 // handleWebSocket bridges a WebSocket connection from the local client to the remote sandbox.
 func (p *Proxy) handleWebSocket(w http.ResponseWriter, r *http.Request, upgrader *websocket.Upgrader) {
 	// Build upstream WebSocket URL
 	upstreamURL := fmt.Sprintf("wss://%s%s", p.targetHost, r.URL.RequestURI())
 
-	// Prepare upstream headers with the pre-acquired token.
-	// The sandbox gateway authenticates via X-Access-Token only.
+	// Prepare upstream headers; dialUpstream adds the access token.
 	upstreamHeaders := http.Header{}
-	upstreamHeaders.Set("X-Access-Token", p.options.Token)
 	upstreamHeaders.Set("Host", p.targetHost)
 
 	// Copy relevant headers from the original request
@@ -222,14 +490,15 @@ func (p *Proxy) handleWebSocket(w http.ResponseWriter, r *http.Request, upgrader
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: p.options.Insecure, //nolint:gosec
 		},
+		NetDialTLSContext: p.options.DialTLSContext,
 	}
 
-	upstreamConn, upstreamResp, err := dialer.DialContext(p.ctx, upstreamURL, upstreamHeaders)
-	// Close the HTTP response body if present (dial failure with a non-101 HTTP response).
-	if upstreamResp != nil && upstreamResp.Body != nil {
-		defer func() { _ = upstreamResp.Body.Close() }()
-	}
+	upstreamConn, upstreamResp, err := p.dialUpstream(dialer, upstreamURL, upstreamHeaders)
 	if err != nil {
+		if errors.Is(err, ErrInstanceGone) {
+			p.writeGone(w)
+			return
+		}
 		p.logger.Printf("[ERROR] WebSocket upstream dial failed: %v", err)
 		// Only expose error details in verbose mode to avoid leaking internal
 		// host names or network topology to network-accessible clients.
@@ -240,19 +509,22 @@ func (p *Proxy) handleWebSocket(w http.ResponseWriter, r *http.Request, upgrader
 		http.Error(w, errMsg, http.StatusBadGateway)
 		return
 	}
-	defer func() { _ = upstreamConn.Close() }()
 
-	// Pass negotiated subprotocol back to client
+	// Pass negotiated subprotocol back to client, and request only that one
+	// when reconnecting
 	responseHeader := http.Header{}
+	upstreamHeaders.Del("Sec-WebSocket-Protocol")
 	if upstreamResp != nil {
 		if proto := upstreamResp.Header.Get("Sec-WebSocket-Protocol"); proto != "" {
 			responseHeader.Set("Sec-WebSocket-Protocol", proto)
+			upstreamHeaders.Set("Sec-WebSocket-Protocol", proto)
 		}
 	}
 
 	// Upgrade client connection to WebSocket
 	clientConn, err := upgrader.Upgrade(w, r, responseHeader)
 	if err != nil {
+		_ = upstreamConn.Close()
 		p.logger.Printf("[ERROR] WebSocket client upgrade failed: %v", err)
 		return
 	}
@@ -262,72 +534,197 @@ func (p *Proxy) handleWebSocket(w http.ResponseWriter, r *http.Request, upgrader
 		p.logger.Printf("[WS] WebSocket connection established: %s", r.URL.Path)
 	}
 
-	// Bridge the two WebSocket connections bidirectionally.
-	// When the proxy is stopped (p.ctx cancelled), force-unblock any pending
-	// ReadMessage calls by setting an immediate read deadline on both sides.
-	var wg sync.WaitGroup
-	wg.Add(2)
+	p.bridgeWebSocket(clientConn, upstreamConn, func() (*websocket.Conn, error) {
+		conn, _, err := p.dialUpstream(dialer, upstreamURL, upstreamHeaders)
+		return conn, err
+	}, p.capture.recorder(r))
+
+	if p.options.Verbose {
+		p.logger.Printf("[WS] WebSocket connection closed: %s", r.URL.Path)
+	}
+}
+
+// wsMessage is a data message read from a WebSocket connection.
+type wsMessage struct {
+	msgType int
+	data    []byte
+}
 
-	stopCh := make(chan struct{})
+// errClientWrite wraps a failure to write to the local client, which ends
+// the bridge.
+var errClientWrite = errors.New("client write failed")
+
+// bridgeWebSocket relays messages between the client and the upstream
+// connection until either side closes or the proxy stops. When the upstream
+// drops, it is redialed with backoff while the client stays connected;
+// messages the client sends meanwhile are delivered after the reconnect.
+// record, if not nil, is called with every message relayed.
+func (p *Proxy) bridgeWebSocket(clientConn, upstreamConn *websocket.Conn, redial func() (*websocket.Conn, error), record func(direction string, msgType int, data []byte)) {
+	done := make(chan struct{})
+	defer close(done)
+
+	// Client -> Upstream messages, read by a single goroutine for the whole
+	// bridge. clientErr is set before fromClient is closed.
+	fromClient := make(chan wsMessage)
+	var clientErr error
 	go func() {
-		select {
-		case <-p.ctx.Done():
-			// Proxy is shutting down: unblock bridgeWebSocket goroutines immediately.
-			_ = clientConn.SetReadDeadline(time.Now())
-			_ = upstreamConn.SetReadDeadline(time.Now())
-		case <-stopCh:
+		defer close(fromClient)
+		for {
+			msgType, msg, err := clientConn.ReadMessage()
+			if err != nil {
+				clientErr = err
+				return
+			}
+			if record != nil {
+				record(directionToUpstream, msgType, msg)
+			}
+			select {
+			case fromClient <- wsMessage{msgType, msg}:
+			case <-done:
+				return
+			}
 		}
 	}()
 
-	// Client -> Upstream
-	go func() {
-		defer wg.Done()
-		p.bridgeWebSocket(clientConn, upstreamConn, "client->upstream")
-		_ = upstreamConn.Close() // signal the peer goroutine to exit
-	}()
+	var pending *wsMessage // client message whose upstream write failed
+	for {
+		// Upstream -> Client
+		fromUpstream := make(chan error, 1)
+		go func(up *websocket.Conn) { fromUpstream <- pumpWebSocket(up, clientConn, record) }(upstreamConn)
 
-	// Upstream -> Client
-	go func() {
-		defer wg.Done()
-		p.bridgeWebSocket(upstreamConn, clientConn, "upstream->client")
-		_ = clientConn.Close() // signal the peer goroutine to exit
-	}()
+		if pending != nil {
+			if err := upstreamConn.WriteMessage(pending.msgType, pending.data); err == nil {
+				pending = nil
+			}
+		}
 
-	wg.Wait()
-	close(stopCh) // both bridges finished; stop the deadline-setter goroutine
-	if p.options.Verbose {
-		p.logger.Printf("[WS] WebSocket connection closed: %s", r.URL.Path)
+		var upstreamErr error
+	relay:
+		for {
+			select {
+			case msg, ok := <-fromClient:
+				if !ok {
+					if websocket.IsUnexpectedCloseError(clientErr, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+						p.logger.Printf("[WS] %s read error: %v", directionToUpstream, clientErr)
+					}
+					forwardClose(upstreamConn, clientErr)
+					_ = upstreamConn.Close()
+					return
+				}
+				if err := upstreamConn.WriteMessage(msg.msgType, msg.data); err != nil {
+					pending = &msg
+					_ = upstreamConn.Close()
+					upstreamErr = <-fromUpstream
+					break relay
+				}
+			case upstreamErr = <-fromUpstream:
+				break relay
+			case <-p.ctx.Done():
+				// Proxy is shutting down: close both sides immediately.
+				goingAway := websocket.FormatCloseMessage(websocket.CloseGoingAway, "proxy stopped")
+				_ = clientConn.WriteControl(websocket.CloseMessage, goingAway, time.Now().Add(time.Second))
+				_ = upstreamConn.WriteControl(websocket.CloseMessage, goingAway, time.Now().Add(time.Second))
+				_ = upstreamConn.Close()
+				return
+			}
+		}
+		_ = upstreamConn.Close()
+
+		if errors.Is(upstreamErr, errClientWrite) {
+			p.logger.Printf("[WS] %s write error: %v", directionToClient, upstreamErr)
+			return
+		}
+		if !shouldReconnect(upstreamErr) {
+			forwardClose(clientConn, upstreamErr)
+			return
+		}
+
+		var err error
+		if upstreamConn, err = p.reconnect(redial, upstreamErr); err != nil {
+			code, reason := websocket.CloseTryAgainLater, "sandbox unreachable"
+			if errors.Is(err, ErrInstanceGone) {
+				code, reason = websocket.CloseGoingAway, "sandbox no longer exists"
+			}
+			if p.ctx.Err() == nil {
+				p.logger.Printf("[ERROR] WebSocket upstream reconnection failed: %v", err)
+			}
+			_ = clientConn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(3*time.Second))
+			return
+		}
 	}
 }
 
-// bridgeWebSocket copies messages from src to dst WebSocket connection.
-func (p *Proxy) bridgeWebSocket(src, dst *websocket.Conn, direction string) {
+// reconnect redials a dropped upstream connection with exponential backoff.
+// It gives up after maxDialFailures consecutive failures, when the instance
+// is gone or when the proxy stops.
+func (p *Proxy) reconnect(redial func() (*websocket.Conn, error), cause error) (*websocket.Conn, error) {
+	delay := p.reconnectDelay
+	for attempt := 1; ; attempt++ {
+		p.logger.Printf("[WARN] WebSocket upstream lost: %v. Reconnecting in %v... (attempt %d)", cause, delay, attempt)
+		select {
+		case <-p.ctx.Done():
+			return nil, p.ctx.Err()
+		case <-time.After(delay):
+		}
+
+		conn, err := redial()
+		if err == nil {
+			p.logger.Printf("[INFO] WebSocket upstream reconnected")
+			return conn, nil
+		}
+		if errors.Is(err, ErrInstanceGone) || attempt >= maxDialFailures || p.ctx.Err() != nil {
+			return nil, err
+		}
+		cause = err
+		delay = min(delay*2, maxBackoff)
+	}
+}
+
+// shouldReconnect reports whether an upstream read error is a dropped
+// connection rather than a close initiated by the sandbox service.
+func shouldReconnect(err error) bool {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return true
+	}
+	switch closeErr.Code {
+	case websocket.CloseAbnormalClosure, websocket.CloseGoingAway, websocket.CloseServiceRestart, websocket.CloseTryAgainLater:
+		return true
+	}
+	return false
+}
+
+// pumpWebSocket copies messages from the upstream to the client connection
+// and returns the error that ended it.
+func pumpWebSocket(src, dst *websocket.Conn, record func(direction string, msgType int, data []byte)) error {
 	for {
 		msgType, msg, err := src.ReadMessage()
 		if err != nil {
-			// Normal close or error
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
-				p.logger.Printf("[WS] %s read error: %v", direction, err)
-			}
-			// Forward the original close code if available, otherwise use NormalClosure
-			closeCode := websocket.CloseNormalClosure
-			closeText := ""
-			if closeErr, ok := err.(*websocket.CloseError); ok {
-				closeCode = closeErr.Code
-				closeText = closeErr.Text
-			}
-			closeMsg := websocket.FormatCloseMessage(closeCode, closeText)
-			_ = dst.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(3*time.Second))
-			return
+			return err
+		}
+		if record != nil {
+			record(directionToClient, msgType, msg)
 		}
-
 		if err := dst.WriteMessage(msgType, msg); err != nil {
-			p.logger.Printf("[WS] %s write error: %v", direction, err)
-			return
+			return fmt.Errorf("%w: %w", errClientWrite, err)
 		}
 	}
 }
 
+// forwardClose sends the close code of err to dst, or a normal closure when
+// err carries no code that may be sent on the wire.
+func forwardClose(dst *websocket.Conn, err error) {
+	closeCode := websocket.CloseNormalClosure
+	closeText := ""
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) && closeErr.Code != websocket.CloseNoStatusReceived && closeErr.Code != websocket.CloseAbnormalClosure {
+		closeCode = closeErr.Code
+		closeText = closeErr.Text
+	}
+	closeMsg := websocket.FormatCloseMessage(closeCode, closeText)
+	_ = dst.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(3*time.Second))
+}
+
 // isWebSocketRequest checks if the HTTP request is a WebSocket upgrade request.
 // Per RFC 6455, a valid WebSocket handshake must have both:
 //   - Upgrade: websocket
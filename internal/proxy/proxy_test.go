@@ -3,6 +3,7 @@ package proxy
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -723,3 +724,276 @@ func TestProxyVerboseLogging(t *testing.T) {
 		t.Errorf("verbose log should contain method and path, got: %q", logged)
 	}
 }
+
+// TestProxyTokenRefresh verifies that a rejected token is replaced once and
+// that idempotent requests are replayed with the new token.
+func TestProxyTokenRefresh(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Access-Token") != "fresh-token" {
+			http.Error(w, "invalid or missing access token", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, "%s ok", r.Method)
+	}))
+	defer upstream.Close()
+
+	var mu sync.Mutex
+	refreshes := 0
+	p, err := New(Options{
+		InstanceID:    "test-sandbox",
+		Domain:        "test.example.com",
+		RemotePort:    3000,
+		Token:         "stale-token",
+		ListenAddress: "127.0.0.1:0",
+		Insecure:      true,
+		Logger:        log.New(io.Discard, "", 0),
+		TokenProvider: func() (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			refreshes++
+			return "fresh-token", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	p.targetHost = strings.TrimPrefix(upstream.URL, "https://")
+	addr, err := p.Start()
+	if err != nil {
+		t.Fatalf("failed to start proxy: %v", err)
+	}
+	defer p.Stop()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(fmt.Sprintf("http://%s/", addr))
+			if err != nil {
+				t.Errorf("GET failed: %v", err)
+				return
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("GET status = %d, want replay with the refreshed token", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Requests with a body are not replayed, but use the new token
+	resp, err := client.Post(fmt.Sprintf("http://%s/", addr), "text/plain", strings.NewReader("data"))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("POST status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if refreshes != 1 {
+		t.Errorf("token refreshed %d times, want 1", refreshes)
+	}
+}
+
+// TestProxyServiceUnauthorized verifies that a 401 or 403 of the forwarded
+// service is passed through without refreshing the token or replaying.
+func TestProxyServiceUnauthorized(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		if r.URL.Path == "/admin" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="app"`)
+		http.Error(w, "login required", http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	refreshes := 0
+	p, err := New(Options{
+		InstanceID:    "test-sandbox",
+		Domain:        "test.example.com",
+		RemotePort:    3000,
+		Token:         "token",
+		ListenAddress: "127.0.0.1:0",
+		Insecure:      true,
+		Logger:        log.New(io.Discard, "", 0),
+		TokenProvider: func() (string, error) {
+			refreshes++
+			return "other-token", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	p.targetHost = strings.TrimPrefix(upstream.URL, "https://")
+	addr, err := p.Start()
+	if err != nil {
+		t.Fatalf("failed to start proxy: %v", err)
+	}
+	defer p.Stop()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	for _, tc := range []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/", http.StatusUnauthorized, "login required\n"},
+		{"/admin", http.StatusForbidden, "forbidden\n"},
+	} {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://%s%s", addr, tc.path), nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("DELETE %s failed: %v", tc.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != tc.status || string(body) != tc.body {
+			t.Errorf("DELETE %s = %d %q, want %d %q", tc.path, resp.StatusCode, body, tc.status, tc.body)
+		}
+	}
+	if refreshes != 0 {
+		t.Errorf("token refreshed %d times, want 0", refreshes)
+	}
+	if requests != 2 {
+		t.Errorf("upstream received %d requests, want 2 (no replays)", requests)
+	}
+}
+
+// TestProxyInstanceGone verifies that requests fail with 410 once the token
+// provider reports that the instance no longer exists.
+func TestProxyInstanceGone(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid or missing access token", http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	p, err := New(Options{
+		InstanceID:    "test-sandbox",
+		Domain:        "test.example.com",
+		RemotePort:    3000,
+		Token:         "token",
+		ListenAddress: "127.0.0.1:0",
+		Insecure:      true,
+		Logger:        log.New(io.Discard, "", 0),
+		TokenProvider: func() (string, error) {
+			return "", fmt.Errorf("%w: test-sandbox", ErrInstanceGone)
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	p.targetHost = strings.TrimPrefix(upstream.URL, "https://")
+	addr, err := p.Start()
+	if err != nil {
+		t.Fatalf("failed to start proxy: %v", err)
+	}
+	defer p.Stop()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	for range 2 {
+		resp, err := client.Get(fmt.Sprintf("http://%s/", addr))
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusGone {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusGone)
+		}
+	}
+	select {
+	case <-p.Gone():
+	default:
+		t.Fatal("Gone() should be closed")
+	}
+	if !errors.Is(p.Err(), ErrInstanceGone) {
+		t.Errorf("Err() = %v, want ErrInstanceGone", p.Err())
+	}
+}
+
+// TestProxyWebSocketReconnect verifies that a dropped upstream connection is
+// redialed while the client connection stays open.
+func TestProxyWebSocketReconnect(t *testing.T) {
+	var mu sync.Mutex
+	connections := 0
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		mu.Lock()
+		connections++
+		first := connections == 1
+		mu.Unlock()
+
+		for {
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(msgType, msg); err != nil {
+				return
+			}
+			if first {
+				// Drop the connection without a close frame
+				_ = conn.NetConn().Close()
+				return
+			}
+		}
+	}))
+	defer upstream.Close()
+
+	p, err := New(Options{
+		InstanceID:    "test-sandbox",
+		Domain:        "test.example.com",
+		RemotePort:    3000,
+		Token:         "token",
+		ListenAddress: "127.0.0.1:0",
+		Insecure:      true,
+		Logger:        log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	p.targetHost = strings.TrimPrefix(upstream.URL, "https://")
+	p.reconnectDelay = 10 * time.Millisecond
+	addr, err := p.Start()
+	if err != nil {
+		t.Fatalf("failed to start proxy: %v", err)
+	}
+	defer p.Stop()
+
+	dialer := &websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+	conn, _, err := dialer.DialContext(context.Background(), fmt.Sprintf("ws://%s/ws", addr), nil)
+	if err != nil {
+		t.Fatalf("WebSocket dial failed: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	for _, text := range []string{"one", "two"} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(text)); err != nil {
+			t.Fatalf("failed to write %q: %v", text, err)
+		}
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read echo of %q: %v", text, err)
+		}
+		if string(msg) != text {
+			t.Errorf("echo = %q, want %q", msg, text)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if connections != 2 {
+		t.Errorf("upstream connections = %d, want 2", connections)
+	}
+}
@@ -22,12 +22,20 @@ var (
 		{Text: "tool get", Description: "Get tool details"},
 		{Text: "tool create", Description: "Create a new tool"},
 		{Text: "tool update", Description: "Update a tool"},
+		{Text: "tool apply", Description: "Create or update a tool from a spec file"},
+		{Text: "tool export", Description: "Export a tool as a spec file"},
+		{Text: "tool clone", Description: "Copy a tool, optionally into another region"},
+		{Text: "tool test", Description: "Smoke-test a tool in a temporary instance"},
 		{Text: "tool delete", Description: "Delete a tool"},
 		{Text: "t", Description: "Alias for tool"},
 		{Text: "t list", Description: "List available tools"},
 		{Text: "t ls", Description: "List available tools"},
 		{Text: "t create", Description: "Create a new tool"},
 		{Text: "t update", Description: "Update a tool"},
+		{Text: "t apply", Description: "Create or update a tool from a spec file"},
+		{Text: "t export", Description: "Export a tool as a spec file"},
+		{Text: "t clone", Description: "Copy a tool, optionally into another region"},
+		{Text: "t test", Description: "Smoke-test a tool in a temporary instance"},
 		{Text: "t delete", Description: "Delete a tool"},
 		{Text: "t rm", Description: "Delete a tool"},
 		{Text: "t del", Description: "Delete a tool"},
@@ -52,6 +60,16 @@ var (
 		{Text: "i delete", Description: "Delete an instance"},
 		{Text: "i stop", Description: "Stop an instance"},
 		{Text: "i rm", Description: "Delete an instance"},
+		{Text: "instance pause", Description: "Pause instances"},
+		{Text: "instance resume", Description: "Resume paused instances"},
+		{Text: "i pause", Description: "Pause instances"},
+		{Text: "i resume", Description: "Resume paused instances"},
+		{Text: "instance metrics", Description: "Show instance resource usage"},
+		{Text: "instance top", Description: "Live resource usage of running instances"},
+		{Text: "i metrics", Description: "Show instance resource usage"},
+		{Text: "i top", Description: "Live resource usage of running instances"},
+		{Text: "instance prune", Description: "Delete instances matching filters"},
+		{Text: "i prune", Description: "Delete instances matching filters"},
 
 		// Run command
 		{Text: "run", Description: "Execute code in a sandbox"},
@@ -125,6 +143,28 @@ var (
 		// Proxy commands
 		{Text: "proxy", Description: "Forward a sandbox port to localhost"},
 
+		// Environment commands
+		{Text: "up", Description: "Create and provision a sandbox from a manifest"},
+		{Text: "down", Description: "Tear down a sandbox environment created by up"},
+
+		// Config commands
+		{Text: "config", Description: "Manage CLI configuration"},
+		{Text: "config init", Description: "Interactively set up the configuration"},
+		{Text: "config get", Description: "Print a configuration value"},
+		{Text: "config set", Description: "Write a value to the config file"},
+		{Text: "config unset", Description: "Remove a value from the config file"},
+		{Text: "config view", Description: "Show the effective configuration"},
+
+		// Diagnostics
+		{Text: "doctor", Description: "Diagnose configuration and connectivity problems"},
+
+		// Token commands
+		{Text: "token", Description: "Manage cached instance access tokens"},
+		{Text: "token list", Description: "List cached access tokens"},
+		{Text: "token prune", Description: "Remove tokens of deleted instances and old tokens"},
+		{Text: "token clear", Description: "Remove all cached access tokens"},
+		{Text: "config profiles", Description: "List configured profiles"},
+
 		// Other commands
 		{Text: "help", Description: "Show help"},
 		{Text: "history", Description: "Show command history"},
@@ -139,6 +179,10 @@ var (
 		{Text: "get", Description: "Get tool details"},
 		{Text: "create", Description: "Create a new tool"},
 		{Text: "update", Description: "Update a tool"},
+		{Text: "apply", Description: "Create or update a tool from a spec file"},
+		{Text: "export", Description: "Export a tool as a spec file"},
+		{Text: "clone", Description: "Copy a tool, optionally into another region"},
+		{Text: "test", Description: "Smoke-test a tool in a temporary instance"},
 		{Text: "delete", Description: "Delete a tool"},
 		{Text: "rm", Description: "Delete a tool"},
 		{Text: "del", Description: "Delete a tool"},
@@ -156,6 +200,11 @@ var (
 		{Text: "stop", Description: "Stop an instance"},
 		{Text: "rm", Description: "Delete an instance"},
 		{Text: "del", Description: "Delete an instance"},
+		{Text: "pause", Description: "Pause instances"},
+		{Text: "resume", Description: "Resume paused instances"},
+		{Text: "metrics", Description: "Show instance resource usage"},
+		{Text: "top", Description: "Live resource usage of running instances"},
+		{Text: "prune", Description: "Delete instances matching filters"},
 	}
 
 	runFlags = []prompt.Suggest{
@@ -197,6 +246,7 @@ var (
 		{Text: "--tool-id", Description: "Tool ID (cloud backend only)"},
 		{Text: "--timeout", Description: "Instance timeout in seconds"},
 		{Text: "--mount-option", Description: "Mount option to override tool storage"},
+		{Text: "--dry-run", Description: "Check mount options without creating an instance"},
 		{Text: "--time", Description: "Print elapsed time to stderr"},
 	}
 
@@ -208,6 +258,45 @@ var (
 		{Text: "--no-header", Description: "Hide table header"},
 		{Text: "--offset", Description: "Pagination offset"},
 		{Text: "--limit", Description: "Pagination limit"},
+		{Text: "--instance-id", Description: "Specific instance IDs to query"},
+		{Text: "--created-since", Description: "Filter by relative time"},
+		{Text: "--created-since-time", Description: "Filter by absolute time (RFC3339)"},
+		{Text: "--all", Description: "Fetch all pages"},
+		{Text: "--sort-by", Description: "Sort by: created, expires, status"},
+		{Text: "-w", Description: "Watch for status changes"},
+		{Text: "--watch", Description: "Watch for status changes"},
+		{Text: "--watch-interval", Description: "Polling interval for --watch"},
+		{Text: "--wide", Description: "Show additional columns"},
+		{Text: "--time", Description: "Print elapsed time to stderr"},
+	}
+
+	instanceResumeFlags = []prompt.Suggest{
+		{Text: "--timeout", Description: "Instance timeout in seconds after resume"},
+		{Text: "--time", Description: "Print elapsed time to stderr"},
+	}
+
+	instanceMetricsFlags = []prompt.Suggest{
+		{Text: "-w", Description: "Refresh metrics continuously"},
+		{Text: "--watch", Description: "Refresh metrics continuously"},
+		{Text: "--interval", Description: "Refresh interval"},
+	}
+
+	instanceTopFlags = []prompt.Suggest{
+		{Text: "--interval", Description: "Refresh interval"},
+		{Text: "--once", Description: "Print a single snapshot and exit"},
+		{Text: "--parallel", Description: "Maximum concurrent metrics requests"},
+	}
+
+	instancePruneFlags = []prompt.Suggest{
+		{Text: "-s", Description: "Filter by status"},
+		{Text: "--status", Description: "Filter by status"},
+		{Text: "--tool-id", Description: "Filter by tool ID"},
+		{Text: "--created-before", Description: "Created before age or time"},
+		{Text: "--created-after", Description: "Created after age or time"},
+		{Text: "--stop-reason", Description: "Filter by stop reason"},
+		{Text: "-y", Description: "Delete matching instances"},
+		{Text: "--yes", Description: "Delete matching instances"},
+		{Text: "--parallel", Description: "Maximum concurrent deletions"},
 		{Text: "--time", Description: "Print elapsed time to stderr"},
 	}
 
@@ -248,6 +337,15 @@ var (
 		{Text: "--tag", Description: "Tags in key=value format"},
 		{Text: "--role-arn", Description: "CAM Role ARN for COS access"},
 		{Text: "--mount", Description: "Storage mount config"},
+		{Text: "--image", Description: "Container image (custom tools only)"},
+		{Text: "--image-registry-type", Description: "Image registry type: personal, enterprise"},
+		{Text: "--command", Description: "Container entrypoint element"},
+		{Text: "--arg", Description: "Container argument"},
+		{Text: "--env", Description: "Container environment variable NAME=value"},
+		{Text: "--port", Description: "Container port [name:]port[/protocol]"},
+		{Text: "--cpu", Description: "CPU per instance"},
+		{Text: "--memory", Description: "Memory per instance"},
+		{Text: "--probe", Description: "HTTP readiness probe"},
 		{Text: "--time", Description: "Print elapsed time"},
 	}
 
@@ -260,6 +358,49 @@ var (
 		{Text: "--time", Description: "Print elapsed time"},
 	}
 
+	toolApplyFlags = []prompt.Suggest{
+		{Text: "-f", Description: "Tool spec file (YAML or JSON)"},
+		{Text: "--file", Description: "Tool spec file (YAML or JSON)"},
+		{Text: "--dry-run", Description: "Show the diff without applying it"},
+		{Text: "--time", Description: "Print elapsed time"},
+	}
+
+	toolExportFlags = []prompt.Suggest{
+		{Text: "-f", Description: "Write the spec to a file"},
+		{Text: "--file", Description: "Write the spec to a file"},
+		{Text: "--time", Description: "Print elapsed time"},
+	}
+
+	toolCloneFlags = []prompt.Suggest{
+		{Text: "-n", Description: "Name of the new tool"},
+		{Text: "--name", Description: "Name of the new tool"},
+		{Text: "--region", Description: "Region to create the new tool in"},
+		{Text: "-d", Description: "Override the description"},
+		{Text: "--description", Description: "Override the description"},
+		{Text: "--timeout", Description: "Override the default timeout"},
+		{Text: "--network", Description: "Override the network mode"},
+		{Text: "--vpc-subnet", Description: "VPC subnet ID in the target region"},
+		{Text: "--vpc-sg", Description: "Security group ID in the target region"},
+		{Text: "--tag", Description: "Tags in key=value format, merged into the copied tags"},
+		{Text: "--role-arn", Description: "Override the role ARN"},
+		{Text: "--dry-run", Description: "Print the spec without creating the tool"},
+		{Text: "--time", Description: "Print elapsed time"},
+	}
+
+	toolTestFlags = []prompt.Suggest{
+		{Text: "--code", Description: "Code snippet that must run without error"},
+		{Text: "-l", Description: "Language of --code snippets"},
+		{Text: "--language", Description: "Language of --code snippets"},
+		{Text: "--cmd", Description: "Shell command that must exit with code 0"},
+		{Text: "--expect-file", Description: "File or directory that must exist"},
+		{Text: "--http", Description: "HTTP endpoint as <port>[/path]"},
+		{Text: "--user", Description: "User to run checks as"},
+		{Text: "--wait-timeout", Description: "Maximum time to wait for the instance to start"},
+		{Text: "--check-timeout", Description: "Timeout of each check"},
+		{Text: "--instance-timeout", Description: "Instance timeout in seconds"},
+		{Text: "--time", Description: "Print elapsed time"},
+	}
+
 	toolGetFlags = []prompt.Suggest{
 		{Text: "--time", Description: "Print elapsed time"},
 	}
@@ -282,6 +423,21 @@ var (
 		{Text: "--name", Description: "API key name"},
 	}
 
+	configSubcommands = []prompt.Suggest{
+		{Text: "init", Description: "Interactively set up the configuration"},
+		{Text: "get", Description: "Print a configuration value"},
+		{Text: "set", Description: "Write a value to the config file"},
+		{Text: "unset", Description: "Remove a value from the config file"},
+		{Text: "view", Description: "Show the effective configuration"},
+		{Text: "profiles", Description: "List configured profiles"},
+	}
+
+	tokenSubcommands = []prompt.Suggest{
+		{Text: "list", Description: "List cached access tokens"},
+		{Text: "prune", Description: "Remove tokens of deleted instances and old tokens"},
+		{Text: "clear", Description: "Remove all cached access tokens"},
+	}
+
 	// Mobile command
 	mobileSubcommands = []prompt.Suggest{
 		{Text: "connect", Description: "Connect to mobile sandbox"},
@@ -309,6 +465,30 @@ var (
 	proxyFlags = []prompt.Suggest{
 		{Text: "--address", Description: "Local address to bind to (default: 127.0.0.1)"},
 		{Text: "--verbose", Description: "Enable verbose request logging"},
+		{Text: "--basic-auth", Description: "Require HTTP basic auth (user:password)"},
+		{Text: "--bearer-token", Description: "Require this bearer token"},
+		{Text: "--generate-token", Description: "Require a randomly generated bearer token and print it"},
+		{Text: "--allow", Description: "Only serve clients from this IP or CIDR range (repeatable)"},
+		{Text: "--tls", Description: "Serve HTTPS with a self-signed certificate"},
+		{Text: "--tls-cert", Description: "Serve HTTPS with this PEM certificate"},
+		{Text: "--tls-key", Description: "PEM private key for --tls-cert"},
+		{Text: "--access-log", Description: "Append an access log to this file"},
+		{Text: "--access-log-format", Description: "Access log format: combined or json (default: combined)"},
+		{Text: "--har", Description: "Record traffic to this HAR file"},
+		{Text: "--har-max-body", Description: "Truncate HAR bodies to this many bytes (default: 65536)"},
+	}
+
+	// Up/down commands
+	upFlags = []prompt.Suggest{
+		{Text: "-f", Description: "Manifest file (short form, default: sandbox.yaml)"},
+		{Text: "--file", Description: "Manifest file (default: sandbox.yaml)"},
+		{Text: "--time", Description: "Print elapsed time"},
+	}
+
+	downFlags = []prompt.Suggest{
+		{Text: "-f", Description: "Manifest file (short form, default: sandbox.yaml)"},
+		{Text: "--file", Description: "Manifest file (default: sandbox.yaml)"},
+		{Text: "--keep-instance", Description: "Only stop local port forwards"},
 	}
 
 	browserVNCFlags = []prompt.Suggest{
@@ -376,12 +556,14 @@ var (
 	}
 
 	globalFlags = []prompt.Suggest{
-		{Text: "--backend", Description: "API backend (e2b or cloud)"},
-		{Text: "-o", Description: "Output format (text or json)"},
-		{Text: "--output", Description: "Output format (text or json)"},
+		{Text: "--profile", Description: "Config profile to use"},
+		{Text: "--backend", Description: "API backend (e2b, cloud or local)"},
+		{Text: "-o", Description: "Output format (text, json, yaml, csv, jsonpath=, go-template=, custom-columns=)"},
+		{Text: "--output", Description: "Output format (text, json, yaml, csv, jsonpath=, go-template=, custom-columns=)"},
 		{Text: "--region", Description: "Region for API access"},
 		{Text: "--domain", Description: "Base domain"},
 		{Text: "--internal", Description: "Use internal endpoints"},
+		{Text: "--debug", Description: "Print diagnostic messages such as request retries"},
 	}
 )
 
@@ -503,6 +685,46 @@ func completer(d prompt.Document) []prompt.Suggest {
 				return toolUpdateFlags
 			}
 		}
+		// Handle flags for apply subcommand
+		if len(words) >= 2 && words[1] == "apply" {
+			lastWord := words[len(words)-1]
+			if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+				return prompt.FilterHasPrefix(toolApplyFlags, lastWord, true)
+			}
+			if strings.HasSuffix(text, " ") {
+				return toolApplyFlags
+			}
+		}
+		// Handle flags for export subcommand
+		if len(words) >= 2 && words[1] == "export" {
+			lastWord := words[len(words)-1]
+			if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+				return prompt.FilterHasPrefix(toolExportFlags, lastWord, true)
+			}
+			if strings.HasSuffix(text, " ") {
+				return toolExportFlags
+			}
+		}
+		// Handle flags for clone subcommand
+		if len(words) >= 2 && words[1] == "clone" {
+			lastWord := words[len(words)-1]
+			if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+				return prompt.FilterHasPrefix(toolCloneFlags, lastWord, true)
+			}
+			if strings.HasSuffix(text, " ") {
+				return toolCloneFlags
+			}
+		}
+		// Handle flags for test subcommand
+		if len(words) >= 2 && words[1] == "test" {
+			lastWord := words[len(words)-1]
+			if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+				return prompt.FilterHasPrefix(toolTestFlags, lastWord, true)
+			}
+			if strings.HasSuffix(text, " ") {
+				return toolTestFlags
+			}
+		}
 		// Handle flags for get subcommand
 		if len(words) >= 2 && words[1] == "get" {
 			lastWord := words[len(words)-1]
@@ -554,6 +776,46 @@ func completer(d prompt.Document) []prompt.Suggest {
 				return instanceListFlags
 			}
 		}
+		// Handle flags for resume subcommand
+		if len(words) >= 2 && words[1] == "resume" {
+			lastWord := words[len(words)-1]
+			if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+				return prompt.FilterHasPrefix(instanceResumeFlags, lastWord, true)
+			}
+			if strings.HasSuffix(text, " ") {
+				return instanceResumeFlags
+			}
+		}
+		// Handle flags for metrics subcommand
+		if len(words) >= 2 && words[1] == "metrics" {
+			lastWord := words[len(words)-1]
+			if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+				return prompt.FilterHasPrefix(instanceMetricsFlags, lastWord, true)
+			}
+			if strings.HasSuffix(text, " ") {
+				return instanceMetricsFlags
+			}
+		}
+		// Handle flags for top subcommand
+		if len(words) >= 2 && words[1] == "top" {
+			lastWord := words[len(words)-1]
+			if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+				return prompt.FilterHasPrefix(instanceTopFlags, lastWord, true)
+			}
+			if strings.HasSuffix(text, " ") {
+				return instanceTopFlags
+			}
+		}
+		// Handle flags for prune subcommand
+		if len(words) >= 2 && words[1] == "prune" {
+			lastWord := words[len(words)-1]
+			if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+				return prompt.FilterHasPrefix(instancePruneFlags, lastWord, true)
+			}
+			if strings.HasSuffix(text, " ") {
+				return instancePruneFlags
+			}
+		}
 		// Handle flags for login subcommand
 		if len(words) >= 2 && words[1] == "login" {
 			lastWord := words[len(words)-1]
@@ -726,6 +988,43 @@ func completer(d prompt.Document) []prompt.Suggest {
 				return proxyFlags
 			}
 		}
+
+	case "up":
+		lastWord := words[len(words)-1]
+		if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+			return prompt.FilterHasPrefix(upFlags, lastWord, true)
+		}
+		if strings.HasSuffix(text, " ") {
+			return upFlags
+		}
+
+	case "config":
+		if len(words) == 1 && strings.HasSuffix(text, " ") {
+			return configSubcommands
+		}
+		if len(words) == 2 && !strings.HasSuffix(text, " ") {
+			return prompt.FilterHasPrefix(configSubcommands, words[1], true)
+		}
+
+	case "token":
+		if len(words) == 1 && strings.HasSuffix(text, " ") {
+			return tokenSubcommands
+		}
+		if len(words) == 2 && !strings.HasSuffix(text, " ") {
+			return prompt.FilterHasPrefix(tokenSubcommands, words[1], true)
+		}
+
+	case "down":
+		lastWord := words[len(words)-1]
+		if strings.HasPrefix(lastWord, "-") && !strings.HasSuffix(text, " ") {
+			return prompt.FilterHasPrefix(downFlags, lastWord, true)
+		}
+		if strings.HasSuffix(text, " ") {
+			if len(words) == 1 {
+				return append([]prompt.Suggest{{Text: "<name>", Description: "Environment name (default: from manifest)"}}, downFlags...)
+			}
+			return downFlags
+		}
 	}
 
 	// Default: filter from all commands
@@ -873,11 +1172,27 @@ Tool Management:
     -d, --description <desc>    Tool description
     --role-arn <arn>            CAM Role ARN for COS access
     --mount <config>            Storage mount config
+    --image <image>             Container image (custom tools only)
+    --env, --port, --probe      Container environment, ports and readiness probe
   tool update <id>, t update  Update a tool
     -d, --description <desc>    Tool description
     --network <mode>            Network mode: PUBLIC, SANDBOX, INTERNAL_SERVICE
     --tag <key=value>           Tags in key=value format
     --clear-tags                Clear all tags
+  tool apply, t apply         Create or update a tool from a spec file
+    -f, --file <file>           Tool spec file (YAML or JSON)
+    --dry-run                   Show the diff without applying it
+  tool export <id>, t export  Export a tool as a spec file
+    -f, --file <file>           Write the spec to a file
+  tool clone <id>, t clone    Copy a tool, optionally into another region
+    -n, --name <name>           Name of the new tool
+    --region <region>           Region to create the new tool in
+    --vpc-subnet, --vpc-sg      VPC subnet and security group in the target region
+    --dry-run                   Print the spec without creating the tool
+  tool test <id>, t test      Smoke-test a tool in a temporary instance
+    --cmd, --code <snippet>     Shell command or code snippet that must succeed
+    --expect-file <path>        File or directory that must exist
+    --http <port>[/path]        HTTP endpoint that must return a status below 400
   tool delete <id>, t rm <id> Delete a tool
 
 Instance Management:
@@ -887,6 +1202,7 @@ Instance Management:
     --tool-id <id>                  Tool ID (cloud backend only)
     --timeout <seconds>             Instance timeout (default: 300)
     --mount-option <config>         Mount option to override tool storage
+    --dry-run                       Check mount options without creating an instance
     --time                          Print elapsed time to stderr
   
   instance list, i list, i ls       List all instances
@@ -895,9 +1211,33 @@ Instance Management:
     --short                         Only show instance IDs
     --offset <n>                    Pagination offset
     --limit <n>                     Pagination limit
+    --instance-id <id>              Query specific instance IDs
+    --created-since <duration>      Filter by relative time (e.g., 1h)
+    --created-since-time <time>     Filter by absolute time (RFC3339)
+    --all                           Fetch all pages
+    --sort-by <key>                 Sort by created, expires or status
+    -w, --watch                     Redraw when instance status changes
+    --wide                          Show tool ID, mounts and endpoints
     --time                          Print elapsed time to stderr
   instance get <id>, i get <id>     Get instance details
   instance delete <id>, i rm <id>   Delete an instance
+  instance pause <id>               Pause an instance (E2B backend only)
+  instance resume <id>              Resume a paused instance (E2B backend only)
+    --timeout <seconds>             Instance timeout after resume (default: 300)
+  instance metrics <id>             Show CPU, memory and disk usage
+    -w, --watch                     Refresh continuously
+    --interval <duration>           Refresh interval (default: 2s)
+  instance top                      Live usage of running instances
+    --interval <duration>           Refresh interval (default: 5s)
+    --once                          Print a single snapshot
+  instance prune                    Delete instances matching filters (dry run without --yes)
+    -s, --status <status>           Filter by status
+    --tool-id <id>                  Filter by tool ID
+    --created-before <age|time>     Created before age (e.g., 2h, 7d) or RFC3339 time
+    --created-after <age|time>      Created after age or RFC3339 time
+    --stop-reason <reason>          Filter by stop reason
+    -y, --yes                       Delete matching instances
+    --parallel <n>                  Maximum concurrent deletions (default: 5)
 
 Code Execution:
   run -c "<code>"             Execute code string
@@ -1004,22 +1344,65 @@ Port Forwarding:
   Options:
     --address <addr>                Local address to bind to (default: 127.0.0.1)
     --verbose                       Enable verbose request logging
+    --basic-auth <user:password>    Require HTTP basic auth
+    --bearer-token <token>          Require this bearer token
+    --generate-token                Require a generated bearer token and print it
+    --allow <ip|cidr>               Only serve clients from this range (repeatable)
+    --tls                           Serve HTTPS with a self-signed certificate
+    --tls-cert <file>               Serve HTTPS with this certificate (with --tls-key)
+    --access-log <file>             Append an access log to this file
+    --access-log-format <fmt>       Access log format: combined or json (default: combined)
+    --har <file>                    Record traffic to this HAR file
+    --har-max-body <bytes>          Truncate HAR bodies to this many bytes (default: 65536)
 
   Examples:
     proxy sandbox-xxx 8080                  # Forward port 8080 to localhost:8080
     proxy sandbox-xxx 3000:8080             # Forward port 8080 to localhost:3000
     proxy sandbox-xxx 8080 --address 0.0.0.0  # Bind to all interfaces
+    proxy sandbox-xxx 8080 --address 0.0.0.0 --generate-token --tls  # Share safely
+
+Environments:
+  up [-f sandbox.yaml]              Create and provision a sandbox from a manifest
+  down [name] [-f sandbox.yaml]     Stop forwards and delete the environment's instance
+
+  Options:
+    -f, --file <path>               Manifest file (default: sandbox.yaml)
+    --keep-instance                 (down) Only stop local port forwards
+
+  Examples:
+    up                                      # Bring up ./sandbox.yaml
+    up -f envs/dev.yaml                     # Bring up another manifest
+    down my-app                             # Tear down by name
+
+Configuration:
+  config init [--skip-validation]   Interactively set up backend, credentials and region
+  config get <key>                  Print the effective value of a key
+  config set <key> <value>          Write a value to the config file ("-" reads stdin)
+  config unset <key>                Remove a value from the config file
+  config view [--show-secrets]      Show effective values and their sources
+  config profiles                   List configured profiles (active one marked with *)
+
+Diagnostics:
+  doctor [--timeout <duration>]     Check config, endpoints, auth, local state files and tools
+  token list                        List cached access tokens (values are never shown)
+  token prune [--ttl <d>] [--dry-run] [--offline]
+                                    Remove tokens of gone or stopped instances and old tokens
+  token clear                       Remove all cached access tokens
 
 Global Flags:
-  --backend <e2b|cloud>       API backend to use
-  -o, --output <text|json>    Output format
+  --profile <name>            Config profile to use
+  --backend <e2b|cloud|local> API backend to use
+  -o, --output <format>       Output format: text, json, yaml, csv[=<spec>],
+                              jsonpath=<tpl>, go-template=<tpl>, custom-columns=<spec>
   --region <region>           Region for API access (default: ap-guangzhou)
   --domain <domain>           Base domain (default: tencentags.com)
   --internal                  Use internal endpoints (Tencent Cloud internal network)
+  --debug                     Print diagnostic messages such as request retries
 
 JSON Output:
   Commands with --time flag include timing info in JSON output.
   List commands include pagination info: {"items": [...], "pagination": {...}}
+  Other structured formats render the same data, e.g. -o jsonpath='{.items[*].id}'.
 
 Other:
   help                        Show this help
@@ -8,14 +8,40 @@
 // This cache provides a persistent file-based storage to save instance ID to access token
 // mappings, allowing CLI commands to retrieve tokens across invocations.
 // Cross-process safety is ensured via flock file locking and atomic writes.
+//
+// A scoped cache (NewScopedCache) keys its tokens as
+// "<backend>/<region>/<domain>/<profile>/<instance ID>" and records the scope
+// in every entry, so that a token is never used against another backend,
+// region, data plane domain or profile than the one it was issued for.
+//
+// Version 1 files keyed tokens by "<profile>@<region>/<instance ID>", or by
+// the bare instance ID before that. They are migrated when the file is first
+// read: such an entry keeps the profile and region known from its key and is
+// found by every scope that matches them, until a scoped cache writes the file
+// and claims it with its own backend and domain.
+//
+// With encryption enabled (SetEncryption) access tokens are sealed with
+// AES-256-GCM before they are written, and every plaintext token in the file
+// is sealed on the next write. Sealed tokens are stored as "enc:v1:<base64>";
+// instance IDs and creation times stay readable so that tokens can be listed
+// and pruned without the key.
 package token
 
 import (
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gofrs/flock"
@@ -27,17 +53,45 @@ const (
 	// CacheFile is the filename for token cache
 	CacheFile = "tokens.json"
 	// CacheVersion is the current version of cache file format
-	CacheVersion = 1
+	CacheVersion = 2
 	// lockTimeout is the maximum wait time to acquire the file lock.
 	lockTimeout = 3 * time.Second
 	// lockRetryDelay is the interval between lock acquisition attempts.
 	lockRetryDelay = 100 * time.Millisecond
+	// sealedPrefix marks an access token encrypted with the cache key.
+	sealedPrefix = "enc:v1:"
 )
 
-// TokenEntry represents a cached access token
+// KeyFunc returns the 32-byte key that encrypts access tokens. create is set
+// when a token is about to be sealed, allowing a missing key to be generated.
+type KeyFunc func(create bool) ([]byte, error)
+
+// ErrCorrupt is returned by Check when the cache file cannot be parsed.
+// Other operations discard such a file and start with an empty cache.
+var ErrCorrupt = errors.New("token cache file is corrupted")
+
+// Scope identifies the context an access token was issued in. The profile
+// stands in for the account, whose credentials it holds.
+type Scope struct {
+	Profile string `json:"profile,omitempty"`
+	Backend string `json:"backend,omitempty"`
+	Region  string `json:"region,omitempty"`
+	Domain  string `json:"domain,omitempty"` // region-qualified data plane domain
+}
+
+// String returns the scope as it prefixes cache keys.
+func (s Scope) String() string {
+	return strings.Join([]string{s.Backend, s.Region, s.Domain, s.Profile}, "/")
+}
+
+// TokenEntry represents a cached access token. AccessToken is sealed when the
+// cache is encrypted. An entry migrated from version 1 has no Backend until
+// a scoped cache claims it.
 type TokenEntry struct {
 	AccessToken string    `json:"access_token"`
 	CreatedAt   time.Time `json:"created_at"`
+	InstanceID  string    `json:"instance_id"`
+	Scope
 }
 
 // CacheData represents the structure of the cache file
@@ -50,8 +104,22 @@ type CacheData struct {
 // It is safe for concurrent use both within a single process and across
 // multiple processes via flock-based file locking.
 type Cache struct {
-	path     string // path to tokens.json
-	lockPath string // path to tokens.json.lock
+	path     string  // path to tokens.json
+	lockPath string  // path to tokens.json.lock
+	scope    Scope   // zero for an unscoped cache
+	encrypt  bool    // seal tokens before writing them
+	keyFunc  KeyFunc // source of the sealing key; nil when unavailable
+	sealKey  []byte  // key returned by keyFunc, loaded on first use
+}
+
+// Entry describes a cached token without revealing it.
+type Entry struct {
+	InstanceID string    `json:"instance_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Encrypted  bool      `json:"encrypted"`
+	// Unclaimed is set for tokens cached by an older version, which are
+	// not known to belong to this backend.
+	Unclaimed bool `json:"unclaimed,omitempty"`
 }
 
 // NewCache creates a new token cache.
@@ -75,6 +143,74 @@ func NewCache() (*Cache, error) {
 	}, nil
 }
 
+// NewScopedCache creates a token cache whose entries are separated from those
+// of other scopes.
+func NewScopedCache(scope Scope) (*Cache, error) {
+	c, err := NewCache()
+	if err != nil {
+		return nil, err
+	}
+	c.scope = scope
+	return c, nil
+}
+
+// SetEncryption makes the cache seal the tokens it writes when encrypt is set.
+// Sealed tokens are opened with the key from keyFunc whether or not encrypt
+// is set, so that turning encryption off keeps existing tokens readable.
+func (c *Cache) SetEncryption(encrypt bool, keyFunc KeyFunc) {
+	c.encrypt = encrypt
+	c.keyFunc = keyFunc
+	c.sealKey = nil
+}
+
+// Path returns the location of the cache file.
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// Scope returns the scope of the cache; it is zero for an unscoped cache.
+func (c *Cache) Scope() Scope {
+	return c.scope
+}
+
+// key returns the cache key of an instance in this cache's scope.
+func (c *Cache) key(instanceID string) string {
+	if c.scope == (Scope{}) {
+		return instanceID
+	}
+	return c.scope.String() + "/" + instanceID
+}
+
+// owns reports whether an entry belongs to this cache's scope. Unclaimed
+// entries, migrated from version 1, belong to every scope matching the
+// profile and region they know. They are never moved into a scope, since
+// the backend they were issued by is unknown: Set replaces and Delete
+// removes the entry of the given instance only.
+func (c *Cache) owns(e *TokenEntry) bool {
+	if c.scope == (Scope{}) {
+		return true
+	}
+	if e.Backend == "" {
+		return (e.Profile == "" || e.Profile == c.scope.Profile) &&
+			(e.Region == "" || e.Region == c.scope.Region)
+	}
+	return e.Scope == c.scope
+}
+
+// find returns the key and entry of an instance's token in this cache's
+// scope, preferring a claimed entry over an unclaimed one.
+func (c *Cache) find(cache *CacheData, instanceID string) (string, *TokenEntry) {
+	if entry, ok := cache.Tokens[c.key(instanceID)]; ok && c.owns(entry) {
+		return c.key(instanceID), entry
+	}
+	for key, entry := range cache.Tokens {
+		if entry.InstanceID == instanceID && entry.Backend == "" && c.owns(entry) {
+			return key, entry
+		}
+	}
+	return "", nil
+}
+
 // withLock acquires the file lock, runs fn, then releases the lock.
 func (c *Cache) withLock(fn func() error) error {
 	fl := flock.New(c.lockPath)
@@ -111,21 +247,53 @@ func (c *Cache) loadLocked() (*CacheData, error) {
 		}, nil
 	}
 
-	// Handle version migration if needed
-	if cache.Version < CacheVersion {
-		cache.Version = CacheVersion
+	if migrate(&cache) {
+		// Best effort: the file is migrated again on the next write if
+		// this fails, e.g. because the encryption key is unavailable
+		_ = c.saveLocked(&cache)
 	}
 
+	return &cache, nil
+}
+
+// migrate upgrades cache data read from an older file in place and reports
+// whether it did. Version 1 keys carry the profile and region, if anything.
+func migrate(cache *CacheData) bool {
 	if cache.Tokens == nil {
 		cache.Tokens = make(map[string]*TokenEntry)
 	}
-
-	return &cache, nil
+	maps.DeleteFunc(cache.Tokens, func(_ string, e *TokenEntry) bool { return e == nil })
+	if cache.Version >= CacheVersion {
+		return false
+	}
+	for key, entry := range cache.Tokens {
+		scope, id, ok := strings.Cut(key, "/")
+		if !ok {
+			scope, id = "", key
+		}
+		entry.InstanceID = id
+		entry.Profile, entry.Region, _ = strings.Cut(scope, "@")
+	}
+	cache.Version = CacheVersion
+	return true
 }
 
 // saveLocked writes the cache data to a temp file then atomically renames it.
 // Must be called while holding the file lock.
 func (c *Cache) saveLocked(cache *CacheData) error {
+	if c.encrypt {
+		for _, entry := range cache.Tokens {
+			if strings.HasPrefix(entry.AccessToken, sealedPrefix) {
+				continue
+			}
+			sealed, err := c.seal(entry.InstanceID, entry.AccessToken)
+			if err != nil {
+				return err
+			}
+			entry.AccessToken = sealed
+		}
+	}
+
 	data, err := json.MarshalIndent(cache, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal cache data: %w", err)
@@ -172,10 +340,13 @@ func (c *Cache) Get(instanceID string) (string, bool) {
 		if err != nil {
 			return err
 		}
-		entry, ok := cache.Tokens[instanceID]
-		if ok && entry != nil {
-			token = entry.AccessToken
-			found = true
+		if _, entry := c.find(cache, instanceID); entry != nil {
+			// A token that cannot be opened is as good as missing; the
+			// cloud backend acquires a new one
+			if t, err := c.open(instanceID, entry.AccessToken); err == nil {
+				token = t
+				found = true
+			}
 		}
 		return nil
 	})
@@ -189,9 +360,14 @@ func (c *Cache) Set(instanceID, accessToken string) error {
 		if err != nil {
 			return err
 		}
-		cache.Tokens[instanceID] = &TokenEntry{
+		if key, _ := c.find(cache, instanceID); key != "" {
+			delete(cache.Tokens, key) // replace an unclaimed entry
+		}
+		cache.Tokens[c.key(instanceID)] = &TokenEntry{
 			AccessToken: accessToken,
 			CreatedAt:   time.Now(),
+			InstanceID:  instanceID,
+			Scope:       c.scope,
 		}
 		return c.saveLocked(cache)
 	})
@@ -204,23 +380,23 @@ func (c *Cache) Delete(instanceID string) error {
 		if err != nil {
 			return err
 		}
-		delete(cache.Tokens, instanceID)
+		for key, _ := c.find(cache, instanceID); key != ""; key, _ = c.find(cache, instanceID) {
+			delete(cache.Tokens, key)
+		}
 		return c.saveLocked(cache)
 	})
 }
 
-// Clear removes all cached tokens.
-func (c *Cache) Clear() error {
-	return c.withLock(func() error {
-		cache := &CacheData{
-			Version: CacheVersion,
-			Tokens:  make(map[string]*TokenEntry),
-		}
-		return c.saveLocked(cache)
+// Clear removes all cached tokens of this cache's scope and returns the
+// sorted IDs of their instances. Unclaimed tokens are kept, as they may
+// belong to another backend.
+func (c *Cache) Clear() ([]string, error) {
+	return c.Prune(func(e Entry) bool {
+		return !e.Unclaimed || c.scope == (Scope{})
 	})
 }
 
-// List returns all cached instance IDs.
+// List returns the instance IDs cached in this cache's scope.
 func (c *Cache) List() ([]string, error) {
 	var ids []string
 	err := c.withLock(func() error {
@@ -228,11 +404,162 @@ func (c *Cache) List() ([]string, error) {
 		if err != nil {
 			return err
 		}
+		seen := make(map[string]bool, len(cache.Tokens))
 		ids = make([]string, 0, len(cache.Tokens))
-		for id := range cache.Tokens {
-			ids = append(ids, id)
+		for _, entry := range cache.Tokens {
+			if c.owns(entry) && !seen[entry.InstanceID] {
+				seen[entry.InstanceID] = true
+				ids = append(ids, entry.InstanceID)
+			}
 		}
 		return nil
 	})
 	return ids, err
 }
+
+// Check verifies that the cache lock can be acquired and that the cache file,
+// if it exists, parses. It returns the number of tokens in this cache's scope
+// and never modifies the file.
+func (c *Cache) Check() (int, error) {
+	count := 0
+	err := c.withLock(func() error {
+		data, err := os.ReadFile(c.path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read cache file: %w", err)
+		}
+		var cache CacheData
+		if err := json.Unmarshal(data, &cache); err != nil {
+			return fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		migrate(&cache)
+		for _, entry := range cache.Tokens {
+			if c.owns(entry) {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Entries returns the tokens cached in this cache's scope, oldest first.
+func (c *Cache) Entries() ([]Entry, error) {
+	var entries []Entry
+	err := c.withLock(func() error {
+		cache, err := c.loadLocked()
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(cache.Tokens))
+		for _, entry := range cache.Tokens {
+			if !c.owns(entry) || seen[entry.InstanceID] {
+				continue
+			}
+			seen[entry.InstanceID] = true
+			entries = append(entries, entryOf(entry))
+		}
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, err
+}
+
+// Prune removes the tokens of this cache's scope for which remove returns
+// true, and returns the IDs of the instances whose tokens were removed.
+func (c *Cache) Prune(remove func(Entry) bool) ([]string, error) {
+	var removed []string
+	err := c.withLock(func() error {
+		cache, err := c.loadLocked()
+		if err != nil {
+			return err
+		}
+		for key, entry := range cache.Tokens {
+			if !c.owns(entry) || !remove(entryOf(entry)) {
+				continue
+			}
+			delete(cache.Tokens, key)
+			if !slices.Contains(removed, entry.InstanceID) {
+				removed = append(removed, entry.InstanceID)
+			}
+		}
+		if len(removed) == 0 {
+			return nil
+		}
+		return c.saveLocked(cache)
+	})
+	sort.Strings(removed)
+	return removed, err
+}
+
+func entryOf(e *TokenEntry) Entry {
+	return Entry{
+		InstanceID: e.InstanceID,
+		CreatedAt:  e.CreatedAt,
+		Encrypted:  strings.HasPrefix(e.AccessToken, sealedPrefix),
+		Unclaimed:  e.Backend == "",
+	}
+}
+
+// aead returns the AEAD for the cache key, requesting the key on first use.
+func (c *Cache) aead(create bool) (cipher.AEAD, error) {
+	if c.sealKey == nil {
+		if c.keyFunc == nil {
+			return nil, fmt.Errorf("no token encryption key available")
+		}
+		key, err := c.keyFunc(create)
+		if err != nil {
+			return nil, err
+		}
+		c.sealKey = key
+	}
+	block, err := aes.NewCipher(c.sealKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts an access token. The instance ID is authenticated with it,
+// so a sealed token cannot be moved to another instance's entry.
+func (c *Cache) seal(instanceID, token string) (string, error) {
+	gcm, err := c.aead(true)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(token), []byte(instanceID))
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open returns the plaintext of a sealed access token; plaintext tokens are
+// returned unchanged.
+func (c *Cache) open(instanceID, value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, sealedPrefix)
+	if !ok {
+		return value, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted access token: %w", err)
+	}
+	gcm, err := c.aead(false)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt access token: %w", err)
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted access token")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(instanceID))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt access token: %w", err)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,222 @@
+package token
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestCache creates a scoped Cache backed by a temp directory.
+func newTestCache(t *testing.T, scope Scope) *Cache {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), CacheFile)
+	return &Cache{path: path, lockPath: path + ".lock", scope: scope}
+}
+
+// testScope returns a scope of the e2b backend in region.
+func testScope(profile, region string) Scope {
+	return Scope{Profile: profile, Backend: "e2b", Region: region, Domain: region + ".tencentags.com"}
+}
+
+func testKey(create bool) ([]byte, error) {
+	return bytes.Repeat([]byte{7}, 32), nil
+}
+
+func TestEntriesAndPrune(t *testing.T) {
+	c := newTestCache(t, testScope("dev", "ap-guangzhou"))
+	for _, id := range []string{"sb-1", "sb-2", "sb-3"} {
+		if err := c.Set(id, "token-"+id); err != nil {
+			t.Fatalf("Set(%s) failed: %v", id, err)
+		}
+	}
+	other := &Cache{path: c.path, lockPath: c.lockPath, scope: testScope("prod", "ap-guangzhou")}
+	_ = other.Set("sb-1", "prod-token")
+
+	entries, err := c.Entries()
+	if err != nil || len(entries) != 3 || entries[0].InstanceID != "sb-1" {
+		t.Fatalf("Entries() = %+v, %v", entries, err)
+	}
+
+	removed, err := c.Prune(func(e Entry) bool { return e.InstanceID != "sb-2" })
+	if err != nil || strings.Join(removed, ",") != "sb-1,sb-3" {
+		t.Fatalf("Prune() = %v, %v", removed, err)
+	}
+	if _, ok := c.Get("sb-2"); !ok {
+		t.Error("sb-2 should be kept")
+	}
+	if tok, ok := other.Get("sb-1"); !ok || tok != "prod-token" {
+		t.Error("Prune() must not touch other scopes")
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	c := newTestCache(t, testScope("dev", "ap-guangzhou"))
+	_ = c.Set("sb-plain", "plain-token")
+
+	c.SetEncryption(true, testKey)
+	if err := c.Set("sb-1", "secret-token"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-token") || strings.Contains(string(data), "plain-token") {
+		t.Errorf("cache file contains plaintext tokens:\n%s", data)
+	}
+	entries, _ := c.Entries()
+	for _, e := range entries {
+		if !e.Encrypted {
+			t.Errorf("%s is not encrypted", e.InstanceID)
+		}
+	}
+
+	// Turning encryption off keeps sealed tokens readable
+	c.SetEncryption(false, testKey)
+	if tok, ok := c.Get("sb-1"); !ok || tok != "secret-token" {
+		t.Errorf("Get() = %q, %v", tok, ok)
+	}
+	if tok, ok := c.Get("sb-plain"); !ok || tok != "plain-token" {
+		t.Errorf("Get() = %q, %v", tok, ok)
+	}
+
+	// Without the key sealed tokens are treated as missing
+	c.SetEncryption(false, func(bool) ([]byte, error) { return nil, errors.New("no keyring") })
+	if _, ok := c.Get("sb-1"); ok {
+		t.Error("expected a sealed token to be unreadable without the key")
+	}
+}
+
+func TestSealBindsInstanceID(t *testing.T) {
+	c := newTestCache(t, Scope{})
+	c.SetEncryption(true, testKey)
+	sealed, err := c.seal("sb-1", "secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.open("sb-2", sealed); err == nil {
+		t.Error("a token sealed for sb-1 must not open for sb-2")
+	}
+	if got, err := c.open("sb-1", sealed); err != nil || got != "secret-token" {
+		t.Errorf("open() = %q, %v", got, err)
+	}
+}
+
+func TestScopeSeparation(t *testing.T) {
+	c := newTestCache(t, testScope("dev", "ap-guangzhou"))
+	if err := c.Set("sb-1", "e2b-token"); err != nil {
+		t.Fatal(err)
+	}
+	scopes := map[string]Scope{
+		"backend": {Profile: "dev", Backend: "cloud", Region: "ap-guangzhou", Domain: "ap-guangzhou.tencentags.com"},
+		"region":  testScope("dev", "ap-shanghai"),
+		"domain":  {Profile: "dev", Backend: "e2b", Region: "ap-guangzhou", Domain: "ap-guangzhou.internal.tencentags.com"},
+		"profile": testScope("prod", "ap-guangzhou"),
+	}
+	for name, scope := range scopes {
+		other := &Cache{path: c.path, lockPath: c.lockPath, scope: scope}
+		if tok, ok := other.Get("sb-1"); ok {
+			t.Errorf("a different %s read token %q", name, tok)
+		}
+	}
+	if tok, ok := c.Get("sb-1"); !ok || tok != "e2b-token" {
+		t.Errorf("Get() = %q, %v", tok, ok)
+	}
+}
+
+func TestMigrateV1(t *testing.T) {
+	c := newTestCache(t, testScope("dev", "ap-guangzhou"))
+	v1 := `{"version": 1, "tokens": {
+  "dev@ap-guangzhou/sb-1": {"access_token": "dev-token", "created_at": "2026-01-02T03:04:05Z"},
+  "prod@ap-guangzhou/sb-2": {"access_token": "prod-token", "created_at": "2026-01-02T03:04:05Z"},
+  "sb-3": {"access_token": "legacy-token", "created_at": "2026-01-02T03:04:05Z"}
+}}`
+	if err := os.WriteFile(c.path, []byte(v1), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if tok, ok := c.Get("sb-1"); !ok || tok != "dev-token" {
+		t.Fatalf("Get(sb-1) = %q, %v", tok, ok)
+	}
+	if _, ok := c.Get("sb-2"); ok {
+		t.Error("a token of another profile must not be found")
+	}
+	if tok, ok := c.Get("sb-3"); !ok || tok != "legacy-token" {
+		t.Errorf("Get(sb-3) = %q, %v", tok, ok)
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cache CacheData
+	if err := json.Unmarshal(data, &cache); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Version != CacheVersion {
+		t.Errorf("version = %d, want %d", cache.Version, CacheVersion)
+	}
+	if e := cache.Tokens["dev@ap-guangzhou/sb-1"]; e == nil || e.Backend != "" || e.InstanceID != "sb-1" {
+		t.Errorf("sb-1 should stay unclaimed after a read: %+v", cache.Tokens)
+	}
+	if e := cache.Tokens["prod@ap-guangzhou/sb-2"]; e == nil || e.Backend != "" || e.Profile != "prod" {
+		t.Errorf("sb-2 should stay unclaimed for profile prod: %+v", e)
+	}
+
+	prod := &Cache{path: c.path, lockPath: c.lockPath, scope: testScope("prod", "ap-guangzhou")}
+	if tok, ok := prod.Get("sb-2"); !ok || tok != "prod-token" {
+		t.Errorf("Get(sb-2) = %q, %v", tok, ok)
+	}
+}
+
+// TestMigrateV1Backends verifies that writes in one backend's scope leave the
+// migrated tokens of other backends alone.
+func TestMigrateV1Backends(t *testing.T) {
+	e2b := newTestCache(t, Scope{Backend: "e2b", Region: "ap-guangzhou", Domain: "e2b.example.com"})
+	cloud := &Cache{path: e2b.path, lockPath: e2b.lockPath, scope: Scope{Backend: "cloud", Region: "ap-guangzhou", Domain: "tencentags.com"}}
+	v1 := `{"version": 1, "tokens": {
+  "e2b-1": {"access_token": "e2b-token", "created_at": "2026-01-02T03:04:05Z"},
+  "e2b-2": {"access_token": "other-token", "created_at": "2026-01-02T03:04:05Z"}
+}}`
+	if err := os.WriteFile(e2b.path, []byte(v1), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cloud.Set("sbi-1", "cloud-token"); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := cloud.Clear(); err != nil {
+		t.Fatal(err)
+	} else if len(removed) != 1 || removed[0] != "sbi-1" {
+		t.Errorf("Clear() removed %v, want [sbi-1]", removed)
+	}
+	if tok, ok := e2b.Get("e2b-1"); !ok || tok != "e2b-token" {
+		t.Fatalf("Get(e2b-1) after writes in another backend = %q, %v", tok, ok)
+	}
+	if _, ok := cloud.Get("sbi-1"); ok {
+		t.Error("Clear() kept a token of its own scope")
+	}
+
+	// Setting a token claims that instance only
+	if err := e2b.Set("e2b-1", "new-token"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := e2b.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unclaimed := map[string]bool{}
+	for _, e := range entries {
+		unclaimed[e.InstanceID] = e.Unclaimed
+	}
+	if len(unclaimed) != 2 || unclaimed["e2b-1"] || !unclaimed["e2b-2"] {
+		t.Errorf("entries = %+v, want e2b-1 claimed and e2b-2 unclaimed", entries)
+	}
+	if tok, ok := cloud.Get("e2b-2"); !ok || tok != "other-token" {
+		t.Errorf("unclaimed token should stay readable from every scope, got %q, %v", tok, ok)
+	}
+}
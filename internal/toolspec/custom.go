@@ -0,0 +1,176 @@
+package toolspec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+)
+
+// Custom is the container configuration of a custom tool.
+type Custom struct {
+	Image        string            `yaml:"image,omitempty" json:"image,omitempty"`
+	RegistryType string            `yaml:"registryType,omitempty" json:"registryType,omitempty"`
+	Command      []string          `yaml:"command,omitempty" json:"command,omitempty"`
+	Args         []string          `yaml:"args,omitempty" json:"args,omitempty"`
+	Env          map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	Ports        []Port            `yaml:"ports,omitempty" json:"ports,omitempty"`
+	CPU          string            `yaml:"cpu,omitempty" json:"cpu,omitempty"`
+	Memory       string            `yaml:"memory,omitempty" json:"memory,omitempty"`
+	Probe        *Probe            `yaml:"probe,omitempty" json:"probe,omitempty"`
+}
+
+// Port is a container port.
+type Port struct {
+	Name     string `yaml:"name,omitempty" json:"name,omitempty"`
+	Port     int    `yaml:"port,omitempty" json:"port,omitempty"`
+	Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+}
+
+// Probe is an HTTP readiness probe.
+type Probe struct {
+	Path                string `yaml:"path,omitempty" json:"path,omitempty"`
+	Port                int    `yaml:"port,omitempty" json:"port,omitempty"`
+	Scheme              string `yaml:"scheme,omitempty" json:"scheme,omitempty"`
+	InitialDelaySeconds int    `yaml:"initialDelaySeconds,omitempty" json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int    `yaml:"periodSeconds,omitempty" json:"periodSeconds,omitempty"`
+	TimeoutSeconds      int    `yaml:"timeoutSeconds,omitempty" json:"timeoutSeconds,omitempty"`
+	SuccessThreshold    int    `yaml:"successThreshold,omitempty" json:"successThreshold,omitempty"`
+	FailureThreshold    int    `yaml:"failureThreshold,omitempty" json:"failureThreshold,omitempty"`
+}
+
+// customConfig converts the spec's container configuration to the client
+// format. Environment variables are sorted by name.
+func (s *Spec) customConfig() *client.CustomConfig {
+	c := s.Custom
+	if c == nil {
+		return nil
+	}
+
+	cfg := &client.CustomConfig{
+		Image:        c.Image,
+		RegistryType: c.RegistryType,
+		Command:      c.Command,
+		Args:         c.Args,
+	}
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cfg.Env = append(cfg.Env, client.EnvVar{Name: name, Value: c.Env[name]})
+	}
+	for _, p := range c.Ports {
+		cfg.Ports = append(cfg.Ports, client.PortConfig{Name: p.Name, Port: p.Port, Protocol: strings.ToUpper(p.Protocol)})
+	}
+	if c.CPU != "" || c.Memory != "" {
+		cfg.Resources = &client.ResourceConfig{CPU: c.CPU, Memory: c.Memory}
+	}
+	if p := c.Probe; p != nil {
+		cfg.Probe = &client.ProbeConfig{
+			Path:                p.Path,
+			Port:                p.Port,
+			Scheme:              strings.ToUpper(p.Scheme),
+			InitialDelaySeconds: p.InitialDelaySeconds,
+			PeriodSeconds:       p.PeriodSeconds,
+			TimeoutSeconds:      p.TimeoutSeconds,
+			SuccessThreshold:    p.SuccessThreshold,
+			FailureThreshold:    p.FailureThreshold,
+		}
+	}
+	return cfg
+}
+
+// fromCustomConfig converts a tool's container configuration to the spec format.
+func fromCustomConfig(cfg *client.CustomConfig) *Custom {
+	if cfg == nil {
+		return nil
+	}
+
+	c := &Custom{
+		Image:        cfg.Image,
+		RegistryType: cfg.RegistryType,
+		Command:      cfg.Command,
+		Args:         cfg.Args,
+	}
+	for _, e := range cfg.Env {
+		if c.Env == nil {
+			c.Env = make(map[string]string)
+		}
+		c.Env[e.Name] = e.Value
+	}
+	for _, p := range cfg.Ports {
+		c.Ports = append(c.Ports, Port{Name: p.Name, Port: p.Port, Protocol: p.Protocol})
+	}
+	if cfg.Resources != nil {
+		c.CPU = cfg.Resources.CPU
+		c.Memory = cfg.Resources.Memory
+	}
+	if p := cfg.Probe; p != nil {
+		c.Probe = &Probe{
+			Path:                p.Path,
+			Port:                p.Port,
+			Scheme:              p.Scheme,
+			InitialDelaySeconds: p.InitialDelaySeconds,
+			PeriodSeconds:       p.PeriodSeconds,
+			TimeoutSeconds:      p.TimeoutSeconds,
+			SuccessThreshold:    p.SuccessThreshold,
+			FailureThreshold:    p.FailureThreshold,
+		}
+	}
+	return c
+}
+
+// customFields renders each container setting for comparison. Defaults are
+// normalized so that an omitted protocol or scheme matches the API value.
+func customFields(cfg *client.CustomConfig) map[string]string {
+	if cfg == nil {
+		return nil
+	}
+
+	var env, ports []string
+	for _, e := range cfg.Env {
+		env = append(env, e.Name+"="+e.Value)
+	}
+	sort.Strings(env)
+	for _, p := range cfg.Ports {
+		ports = append(ports, client.FormatPort(p))
+	}
+	sort.Strings(ports)
+
+	fields := map[string]string{
+		"image":        cfg.Image,
+		"registryType": cfg.RegistryType,
+		"command":      strings.Join(cfg.Command, " "),
+		"args":         strings.Join(cfg.Args, " "),
+		"env":          strings.Join(env, ","),
+		"ports":        strings.Join(ports, ","),
+	}
+	if cfg.Resources != nil {
+		fields["cpu"] = cfg.Resources.CPU
+		fields["memory"] = cfg.Resources.Memory
+	}
+	if cfg.Probe != nil {
+		fields["probe"] = client.FormatProbe(cfg.Probe)
+	}
+	return fields
+}
+
+// validateCustom checks the container configuration of a custom tool.
+func (s *Spec) validateCustom() error {
+	if s.Custom == nil {
+		return nil
+	}
+	if s.Type != "custom" {
+		return fmt.Errorf("custom can only be set when type is custom")
+	}
+	if err := s.customConfig().Validate(); err != nil {
+		return fmt.Errorf("custom: %w", err)
+	}
+	if s.Custom.RegistryType != "" && s.RoleArn == "" {
+		return fmt.Errorf("roleArn is required when custom.registryType is set")
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package toolspec
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+)
+
+// Change is a field-level difference between a spec and an existing tool.
+// An empty Current or Desired value means the field (or tag, or mount) is
+// absent on that side. Immutable changes cannot be applied by UpdateTool.
+type Change struct {
+	Field     string `json:"field"`
+	Current   string `json:"current"`
+	Desired   string `json:"desired"`
+	Immutable bool   `json:"immutable,omitempty"`
+}
+
+// Diff compares the spec against an existing tool. Only description,
+// network mode (outside VPC) and tags can be updated in place; every other
+// difference is reported as immutable.
+func (s *Spec) Diff(tool *client.Tool) []Change {
+	var changes []Change
+	add := func(field, current, desired string, immutable bool) {
+		if current != desired {
+			changes = append(changes, Change{Field: field, Current: current, Desired: desired, Immutable: immutable})
+		}
+	}
+
+	add("type", tool.Type, s.Type, true)
+	add("description", tool.Description, s.Description, false)
+
+	// The backend fills in a default timeout, so only compare when the spec sets one
+	if s.DefaultTimeout != "" && !sameDuration(tool.DefaultTimeout, s.DefaultTimeout) {
+		add("defaultTimeout", tool.DefaultTimeout, s.DefaultTimeout, true)
+	}
+
+	currentMode := tool.NetworkMode
+	if currentMode == "" {
+		currentMode = "PUBLIC"
+	}
+	add("networkMode", currentMode, s.NetworkMode, currentMode == "VPC" || s.NetworkMode == "VPC")
+
+	var currentVPC, desiredVPC client.VPCConfig
+	if tool.VPCConfig != nil {
+		currentVPC = *tool.VPCConfig
+	}
+	if s.VPC != nil {
+		desiredVPC = client.VPCConfig{SubnetIds: s.VPC.SubnetIDs, SecurityGroupIds: s.VPC.SecurityGroupIDs}
+	}
+	add("vpc.subnetIds", joinSorted(currentVPC.SubnetIds), joinSorted(desiredVPC.SubnetIds), true)
+	add("vpc.securityGroupIds", joinSorted(currentVPC.SecurityGroupIds), joinSorted(desiredVPC.SecurityGroupIds), true)
+
+	for _, key := range unionKeys(tool.Tags, s.Tags) {
+		add("tags."+key, tool.Tags[key], s.Tags[key], false)
+	}
+
+	add("roleArn", tool.RoleArn, s.RoleArn, true)
+
+	current := make(map[string]client.StorageMount)
+	for _, m := range tool.StorageMounts {
+		current[m.Name] = m
+	}
+	desired := make(map[string]client.StorageMount)
+	for _, m := range s.storageMounts() {
+		desired[m.Name] = m
+	}
+	for _, name := range unionKeys(current, desired) {
+		cur, hasCur := current[name]
+		want, hasWant := desired[name]
+		// An endpoint left out of the spec defaults to the tool's region
+		withEndpoint := hasWant && want.StorageSource.Cos != nil && want.StorageSource.Cos.Endpoint != ""
+		var curStr, wantStr string
+		if hasCur {
+			curStr = formatMount(cur, withEndpoint || !hasWant)
+		}
+		if hasWant {
+			wantStr = formatMount(want, withEndpoint)
+		}
+		add("storageMounts."+name, curStr, wantStr, true)
+	}
+
+	currentCustom := customFields(tool.CustomConfig)
+	desiredCustom := customFields(s.customConfig())
+	for _, key := range unionKeys(currentCustom, desiredCustom) {
+		add("custom."+key, currentCustom[key], desiredCustom[key], true)
+	}
+
+	return changes
+}
+
+// ImmutableFields returns the fields of changes that cannot be updated in place.
+func ImmutableFields(changes []Change) []string {
+	var fields []string
+	for _, c := range changes {
+		if c.Immutable {
+			fields = append(fields, c.Field)
+		}
+	}
+	return fields
+}
+
+// UpdateOptions returns the options that apply the mutable changes to the
+// tool, or nil when there is nothing to update. Tags are replaced as a whole.
+func (s *Spec) UpdateOptions(toolID string, changes []Change) *client.UpdateToolOptions {
+	opts := &client.UpdateToolOptions{ToolID: toolID}
+	updated := false
+	for _, c := range changes {
+		if c.Immutable {
+			continue
+		}
+		updated = true
+		switch {
+		case c.Field == "description":
+			opts.Description = &s.Description
+		case c.Field == "networkMode":
+			opts.NetworkMode = &s.NetworkMode
+		case strings.HasPrefix(c.Field, "tags."):
+			opts.Tags = make(map[string]string, len(s.Tags))
+			for k, v := range s.Tags {
+				opts.Tags[k] = v
+			}
+		}
+	}
+	if !updated {
+		return nil
+	}
+	return opts
+}
+
+// formatMount renders a storage mount for comparison and display.
+func formatMount(m client.StorageMount, withEndpoint bool) string {
+	source := "-"
+	if m.StorageSource != nil {
+		source = m.StorageSource.Summary()
+		if cos := m.StorageSource.Cos; cos != nil && withEndpoint && cos.Endpoint != "" {
+			source += " (" + cos.Endpoint + ")"
+		}
+		if cfs := m.StorageSource.Cfs; cfs != nil {
+			source += " @" + cfs.MountTarget
+		}
+	}
+	mode := "rw"
+	if m.ReadOnly {
+		mode = "ro"
+	}
+	return fmt.Sprintf("%s -> %s:%s", source, m.MountPath, mode)
+}
+
+// sameDuration compares two timeouts such as "5m" and "300s".
+func sameDuration(a, b string) bool {
+	da, errA := time.ParseDuration(a)
+	db, errB := time.ParseDuration(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return da == db
+}
+
+func joinSorted(values []string) string {
+	sorted := slices.Clone(values)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]V{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
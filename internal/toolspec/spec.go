@@ -0,0 +1,281 @@
+// Package toolspec loads declarative tool definitions (tool.yaml) used by
+// 'ags tool apply', compares them against tools that already exist, and
+// exports existing tools in the same format for 'ags tool export' and
+// 'ags tool clone'.
+package toolspec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+)
+
+// Spec describes a sandbox tool. Fields map to client.CreateToolOptions;
+// JSON specs are accepted as well since JSON is valid YAML. The same format
+// is produced by FromTool, so an exported tool can be applied elsewhere.
+type Spec struct {
+	Name           string            `yaml:"name,omitempty" json:"name,omitempty"`
+	Type           string            `yaml:"type,omitempty" json:"type,omitempty"`
+	Description    string            `yaml:"description,omitempty" json:"description,omitempty"`
+	DefaultTimeout string            `yaml:"defaultTimeout,omitempty" json:"defaultTimeout,omitempty"`
+	NetworkMode    string            `yaml:"networkMode,omitempty" json:"networkMode,omitempty"`
+	VPC            *VPC              `yaml:"vpc,omitempty" json:"vpc,omitempty"`
+	Tags           map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	RoleArn        string            `yaml:"roleArn,omitempty" json:"roleArn,omitempty"`
+	StorageMounts  []StorageMount    `yaml:"storageMounts,omitempty" json:"storageMounts,omitempty"`
+	Custom         *Custom           `yaml:"custom,omitempty" json:"custom,omitempty"`
+}
+
+// VPC is the VPC network configuration, required when networkMode is VPC.
+type VPC struct {
+	SubnetIDs        []string `yaml:"subnetIds,omitempty" json:"subnetIds,omitempty"`
+	SecurityGroupIDs []string `yaml:"securityGroupIds,omitempty" json:"securityGroupIds,omitempty"`
+}
+
+// StorageMount is a storage mount declared by the tool.
+type StorageMount struct {
+	Name      string `yaml:"name,omitempty" json:"name,omitempty"`
+	MountPath string `yaml:"mountPath,omitempty" json:"mountPath,omitempty"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty" json:"readOnly,omitempty"`
+	Cos       *Cos   `yaml:"cos,omitempty" json:"cos,omitempty"`
+	Cfs       *Cfs   `yaml:"cfs,omitempty" json:"cfs,omitempty"`
+}
+
+// Cos is a COS bucket storage source.
+type Cos struct {
+	Bucket   string `yaml:"bucket,omitempty" json:"bucket,omitempty"`
+	Path     string `yaml:"path,omitempty" json:"path,omitempty"`
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+}
+
+// Cfs is a CFS file system storage source. Path defaults to the file
+// system root.
+type Cfs struct {
+	FileSystemID string `yaml:"fileSystemId,omitempty" json:"fileSystemId,omitempty"`
+	MountTarget  string `yaml:"mountTarget,omitempty" json:"mountTarget,omitempty"`
+	Path         string `yaml:"path,omitempty" json:"path,omitempty"`
+}
+
+// FromTool builds the spec that recreates tool. Server-assigned fields such
+// as the ID and creation time are left out.
+func FromTool(tool *client.Tool) *Spec {
+	s := &Spec{
+		Name:           tool.Name,
+		Type:           tool.Type,
+		Description:    tool.Description,
+		DefaultTimeout: tool.DefaultTimeout,
+		NetworkMode:    tool.NetworkMode,
+		RoleArn:        tool.RoleArn,
+	}
+	if s.NetworkMode == "" {
+		s.NetworkMode = "PUBLIC"
+	}
+	if s.NetworkMode == "VPC" && tool.VPCConfig != nil {
+		s.VPC = &VPC{SubnetIDs: tool.VPCConfig.SubnetIds, SecurityGroupIDs: tool.VPCConfig.SecurityGroupIds}
+	}
+	if len(tool.Tags) > 0 {
+		s.Tags = maps.Clone(tool.Tags)
+	}
+	for _, m := range tool.StorageMounts {
+		mount := StorageMount{Name: m.Name, MountPath: m.MountPath, ReadOnly: m.ReadOnly}
+		if m.StorageSource != nil && m.StorageSource.Cos != nil {
+			mount.Cos = &Cos{
+				Bucket:   m.StorageSource.Cos.BucketName,
+				Path:     m.StorageSource.Cos.BucketPath,
+				Endpoint: m.StorageSource.Cos.Endpoint,
+			}
+		}
+		if m.StorageSource != nil && m.StorageSource.Cfs != nil {
+			mount.Cfs = &Cfs{
+				FileSystemID: m.StorageSource.Cfs.FileSystemID,
+				MountTarget:  m.StorageSource.Cfs.MountTarget,
+				Path:         m.StorageSource.Cfs.Path,
+			}
+		}
+		s.StorageMounts = append(s.StorageMounts, mount)
+	}
+	s.Custom = fromCustomConfig(tool.CustomConfig)
+	return s
+}
+
+// Marshal encodes the spec as YAML.
+func (s *Spec) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Load reads, parses and validates a spec file. Unknown keys are rejected
+// so that typos do not silently drop configuration.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tool spec: %w", err)
+	}
+
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tool spec %s: %w", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tool spec %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse decodes a YAML or JSON spec and applies defaults. It does not validate.
+func Parse(data []byte) (*Spec, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var s Spec
+	if err := dec.Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if s.NetworkMode == "" {
+		s.NetworkMode = "PUBLIC"
+	}
+	s.NetworkMode = strings.ToUpper(s.NetworkMode)
+	return &s, nil
+}
+
+// Validate checks the spec for missing or conflicting fields, mirroring the
+// checks done by 'ags tool create'.
+func (s *Spec) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !slices.Contains(client.ValidToolTypes, s.Type) {
+		return fmt.Errorf("invalid type %q: must be one of %s", s.Type, strings.Join(client.ValidToolTypes, ", "))
+	}
+	if !slices.Contains(client.ValidNetworkModes, s.NetworkMode) {
+		return fmt.Errorf("invalid networkMode %q: must be one of %s", s.NetworkMode, strings.Join(client.ValidNetworkModes, ", "))
+	}
+
+	if s.NetworkMode == "VPC" {
+		if s.VPC == nil || len(s.VPC.SubnetIDs) == 0 || len(s.VPC.SecurityGroupIDs) == 0 {
+			return fmt.Errorf("vpc.subnetIds and vpc.securityGroupIds are required when networkMode is VPC")
+		}
+	} else if s.VPC != nil {
+		return fmt.Errorf("vpc can only be set when networkMode is VPC")
+	}
+
+	names := make(map[string]bool)
+	hasCos := false
+	for i, m := range s.StorageMounts {
+		if m.Name == "" {
+			return fmt.Errorf("storageMounts[%d]: name is required", i)
+		}
+		if names[m.Name] {
+			return fmt.Errorf("storageMounts[%d]: duplicate name %q", i, m.Name)
+		}
+		names[m.Name] = true
+		if !strings.HasPrefix(m.MountPath, "/") {
+			return fmt.Errorf("storageMounts[%d]: mountPath must be an absolute path", i)
+		}
+		switch {
+		case m.Cos != nil && m.Cfs != nil:
+			return fmt.Errorf("storageMounts[%d]: only one of cos or cfs can be set", i)
+		case m.Cos != nil:
+			if m.Cos.Bucket == "" {
+				return fmt.Errorf("storageMounts[%d]: cos.bucket is required", i)
+			}
+			if !strings.HasPrefix(m.Cos.Path, "/") {
+				return fmt.Errorf("storageMounts[%d]: cos.path must be an absolute path", i)
+			}
+			hasCos = true
+		case m.Cfs != nil:
+			if m.Cfs.FileSystemID == "" {
+				return fmt.Errorf("storageMounts[%d]: cfs.fileSystemId is required", i)
+			}
+			if m.Cfs.MountTarget == "" {
+				return fmt.Errorf("storageMounts[%d]: cfs.mountTarget is required", i)
+			}
+			if m.Cfs.Path != "" && !strings.HasPrefix(m.Cfs.Path, "/") {
+				return fmt.Errorf("storageMounts[%d]: cfs.path must be an absolute path", i)
+			}
+		default:
+			return fmt.Errorf("storageMounts[%d]: cos or cfs is required", i)
+		}
+	}
+	if hasCos && s.RoleArn == "" {
+		return fmt.Errorf("roleArn is required when storageMounts has a cos mount")
+	}
+
+	return s.validateCustom()
+}
+
+// CreateOptions returns the tool creation options described by the spec.
+func (s *Spec) CreateOptions() *client.CreateToolOptions {
+	return &client.CreateToolOptions{
+		Name:           s.Name,
+		Type:           s.Type,
+		Description:    s.Description,
+		DefaultTimeout: s.DefaultTimeout,
+		NetworkMode:    s.NetworkMode,
+		VPCConfig:      s.vpcConfig(),
+		Tags:           s.Tags,
+		RoleArn:        s.RoleArn,
+		StorageMounts:  s.storageMounts(),
+		CustomConfig:   s.customConfig(),
+	}
+}
+
+func (s *Spec) vpcConfig() *client.VPCConfig {
+	if s.VPC == nil {
+		return nil
+	}
+	return &client.VPCConfig{
+		SubnetIds:        s.VPC.SubnetIDs,
+		SecurityGroupIds: s.VPC.SecurityGroupIDs,
+	}
+}
+
+func (s *Spec) storageMounts() []client.StorageMount {
+	var mounts []client.StorageMount
+	for _, m := range s.StorageMounts {
+		source := &client.StorageSource{}
+		if m.Cos != nil {
+			source.Cos = &client.CosStorageSource{
+				Endpoint:   m.Cos.Endpoint,
+				BucketName: m.Cos.Bucket,
+				BucketPath: m.Cos.Path,
+			}
+		}
+		if m.Cfs != nil {
+			path := m.Cfs.Path
+			if path == "" {
+				path = "/"
+			}
+			source.Cfs = &client.CfsStorageSource{
+				FileSystemID: m.Cfs.FileSystemID,
+				MountTarget:  m.Cfs.MountTarget,
+				Path:         path,
+			}
+		}
+		mounts = append(mounts, client.StorageMount{
+			Name:          m.Name,
+			MountPath:     m.MountPath,
+			ReadOnly:      m.ReadOnly,
+			StorageSource: source,
+		})
+	}
+	return mounts
+}
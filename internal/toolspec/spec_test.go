@@ -0,0 +1,364 @@
+package toolspec
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TencentCloudAgentRuntime/ags-cli/internal/client"
+)
+
+const sampleSpec = `
+name: my-tool
+type: code-interpreter
+description: Data analysis sandbox
+defaultTimeout: 10m
+networkMode: vpc
+vpc:
+  subnetIds: [subnet-2, subnet-1]
+  securityGroupIds: [sg-1]
+tags:
+  team: ai
+roleArn: qcs::cam::uin/1:roleName/r
+storageMounts:
+  - name: data
+    mountPath: /mnt/data
+    readOnly: true
+    cos:
+      bucket: b-125
+      path: /data
+`
+
+// existingTool is the tool as returned by the API for sampleSpec.
+func existingTool() *client.Tool {
+	return &client.Tool{
+		ID:             "sdt-1",
+		Name:           "my-tool",
+		Type:           "code-interpreter",
+		Description:    "Data analysis sandbox",
+		DefaultTimeout: "600s",
+		NetworkMode:    "VPC",
+		VPCConfig:      &client.VPCConfig{SubnetIds: []string{"subnet-1", "subnet-2"}, SecurityGroupIds: []string{"sg-1"}},
+		Tags:           map[string]string{"team": "ai"},
+		RoleArn:        "qcs::cam::uin/1:roleName/r",
+		StorageMounts: []client.StorageMount{{
+			Name:          "data",
+			MountPath:     "/mnt/data",
+			ReadOnly:      true,
+			StorageSource: &client.StorageSource{Cos: &client.CosStorageSource{Endpoint: "cos.ap-guangzhou.myqcloud.com", BucketName: "b-125", BucketPath: "/data"}},
+		}},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for name, data := range map[string]string{
+		"tool.yaml": sampleSpec,
+		"tool.json": `{"name": "my-tool", "type": "browser", "tags": {"env": "dev"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			s, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if s.Name != "my-tool" {
+				t.Errorf("Name = %q", s.Name)
+			}
+		})
+	}
+
+	s, err := Parse([]byte(sampleSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NetworkMode != "VPC" {
+		t.Errorf("NetworkMode = %q, want VPC", s.NetworkMode)
+	}
+	opts := s.CreateOptions()
+	if opts.VPCConfig == nil || len(opts.StorageMounts) != 1 || opts.StorageMounts[0].StorageSource.Cos.BucketName != "b-125" {
+		t.Errorf("CreateOptions() = %+v", opts)
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	if _, err := Parse([]byte("name: a\ntype: custom\nmounts: []\n")); err == nil {
+		t.Error("Parse() expected error for unknown field")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "no name", yaml: "type: custom", wantErr: "name is required"},
+		{name: "bad type", yaml: "name: a\ntype: vm", wantErr: "invalid type"},
+		{name: "bad network", yaml: "name: a\ntype: custom\nnetworkMode: open", wantErr: "invalid networkMode"},
+		{name: "vpc without config", yaml: "name: a\ntype: custom\nnetworkMode: VPC", wantErr: "vpc.subnetIds"},
+		{name: "vpc config without vpc", yaml: "name: a\ntype: custom\nvpc: {subnetIds: [s]}", wantErr: "only be set"},
+		{name: "mount without role", yaml: "name: a\ntype: custom\nstorageMounts:\n  - {name: d, mountPath: /d, cos: {bucket: b, path: /}}", wantErr: "roleArn"},
+		{name: "relative mount", yaml: "name: a\ntype: custom\nroleArn: r\nstorageMounts:\n  - {name: d, mountPath: d, cos: {bucket: b, path: /}}", wantErr: "absolute"},
+		{name: "mount without source", yaml: "name: a\ntype: custom\nstorageMounts:\n  - {name: d, mountPath: /d}", wantErr: "cos or cfs is required"},
+		{name: "mount with both sources", yaml: "name: a\ntype: custom\nroleArn: r\nstorageMounts:\n  - {name: d, mountPath: /d, cos: {bucket: b, path: /}, cfs: {fileSystemId: cfs-1, mountTarget: 10.0.0.1}}", wantErr: "only one of"},
+		{name: "cfs without target", yaml: "name: a\ntype: custom\nstorageMounts:\n  - {name: d, mountPath: /d, cfs: {fileSystemId: cfs-1}}", wantErr: "cfs.mountTarget"},
+		{name: "cfs without role", yaml: "name: a\ntype: custom\nstorageMounts:\n  - {name: d, mountPath: /d, cfs: {fileSystemId: cfs-1, mountTarget: 10.0.0.1}}"},
+		{name: "valid", yaml: "name: a\ntype: custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			err = s.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	s, err := Parse([]byte(sampleSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reordered VPC IDs, an equivalent timeout and the default COS endpoint are not drift
+	if changes := s.Diff(existingTool()); len(changes) != 0 {
+		t.Errorf("Diff() = %+v, want no changes", changes)
+	}
+}
+
+func TestDiffMutable(t *testing.T) {
+	s := &Spec{Name: "my-tool", Type: "custom", Description: "new", NetworkMode: "SANDBOX", Tags: map[string]string{"env": "prod"}}
+	tool := &client.Tool{ID: "sdt-1", Name: "my-tool", Type: "custom", Description: "old", NetworkMode: "PUBLIC", Tags: map[string]string{"env": "dev", "old": "x"}}
+
+	changes := s.Diff(tool)
+	want := []Change{
+		{Field: "description", Current: "old", Desired: "new"},
+		{Field: "networkMode", Current: "PUBLIC", Desired: "SANDBOX"},
+		{Field: "tags.env", Current: "dev", Desired: "prod"},
+		{Field: "tags.old", Current: "x", Desired: ""},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Diff() = %+v, want %+v", changes, want)
+	}
+
+	opts := s.UpdateOptions(tool.ID, changes)
+	if opts == nil || *opts.Description != "new" || *opts.NetworkMode != "SANDBOX" {
+		t.Fatalf("UpdateOptions() = %+v", opts)
+	}
+	if !reflect.DeepEqual(opts.Tags, map[string]string{"env": "prod"}) {
+		t.Errorf("UpdateOptions().Tags = %v", opts.Tags)
+	}
+
+	if s.UpdateOptions(tool.ID, nil) != nil {
+		t.Error("UpdateOptions() with no changes should be nil")
+	}
+}
+
+func TestDiffClearTags(t *testing.T) {
+	s := &Spec{Name: "my-tool", Type: "custom", NetworkMode: "PUBLIC"}
+	tool := &client.Tool{Type: "custom", Tags: map[string]string{"env": "dev"}}
+
+	opts := s.UpdateOptions("sdt-1", s.Diff(tool))
+	if opts == nil || opts.Tags == nil || len(opts.Tags) != 0 {
+		t.Errorf("UpdateOptions().Tags = %#v, want empty non-nil map", opts)
+	}
+}
+
+func TestDiffImmutable(t *testing.T) {
+	s, err := Parse([]byte(sampleSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := existingTool()
+	tool.Type = "browser"
+	tool.VPCConfig.SubnetIds = []string{"subnet-3"}
+	tool.StorageMounts[0].ReadOnly = false
+	tool.Tags = nil
+
+	changes := s.Diff(tool)
+	got := ImmutableFields(changes)
+	want := []string{"type", "vpc.subnetIds", "storageMounts.data"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ImmutableFields() = %v, want %v", got, want)
+	}
+	// Mutable changes are still reported alongside immutable ones
+	if len(changes) != len(want)+1 {
+		t.Errorf("Diff() = %+v", changes)
+	}
+}
+
+func TestDiffNetworkModeVPC(t *testing.T) {
+	s := &Spec{Name: "a", Type: "custom", NetworkMode: "PUBLIC"}
+	tool := &client.Tool{Type: "custom", NetworkMode: "VPC", VPCConfig: &client.VPCConfig{SubnetIds: []string{"s"}, SecurityGroupIds: []string{"g"}}}
+
+	for _, c := range s.Diff(tool) {
+		if !c.Immutable {
+			t.Errorf("change %+v should be immutable", c)
+		}
+	}
+}
+
+func TestFromToolRoundTrip(t *testing.T) {
+	tool := existingTool()
+	data, err := FromTool(tool).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v\n%s", err, data)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v\n%s", err, data)
+	}
+	if changes := s.Diff(tool); len(changes) != 0 {
+		t.Errorf("Diff() = %+v, want no changes", changes)
+	}
+	if strings.Contains(string(data), "sdt-1") {
+		t.Errorf("exported spec contains the tool ID:\n%s", data)
+	}
+}
+
+func TestFromToolDefaults(t *testing.T) {
+	// A stale VPC config is dropped outside VPC mode so the spec stays valid
+	s := FromTool(&client.Tool{Name: "a", Type: "custom", VPCConfig: &client.VPCConfig{SubnetIds: []string{"s"}}})
+	if s.NetworkMode != "PUBLIC" || s.VPC != nil || s.Tags != nil {
+		t.Errorf("FromTool() = %+v", s)
+	}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestCfsMount(t *testing.T) {
+	tool := &client.Tool{
+		ID:          "sdt-1",
+		Name:        "datasets",
+		Type:        "code-interpreter",
+		NetworkMode: "PUBLIC",
+		StorageMounts: []client.StorageMount{{
+			Name:          "ds",
+			MountPath:     "/mnt/ds",
+			StorageSource: &client.StorageSource{Cfs: &client.CfsStorageSource{FileSystemID: "cfs-1", MountTarget: "10.0.0.1", Path: "/"}},
+		}},
+	}
+	data, err := FromTool(tool).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v\n%s", err, data)
+	}
+	// CFS mounts do not need a role ARN
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v\n%s", err, data)
+	}
+	if changes := s.Diff(tool); len(changes) != 0 {
+		t.Errorf("Diff() = %+v, want no changes", changes)
+	}
+
+	// An omitted path mounts the file system root
+	s.StorageMounts[0].Cfs.Path = ""
+	if changes := s.Diff(tool); len(changes) != 0 {
+		t.Errorf("Diff() with default path = %+v, want no changes", changes)
+	}
+
+	s.StorageMounts[0].Cfs.MountTarget = "10.0.0.2"
+	changes := s.Diff(tool)
+	if len(changes) != 1 || changes[0].Field != "storageMounts.ds" || !changes[0].Immutable {
+		t.Errorf("Diff() with new mount target = %+v", changes)
+	}
+}
+
+const customSpec = `
+name: my-runtime
+type: custom
+roleArn: qcs::cam::uin/1:roleName/r
+custom:
+  image: ccr.ccs.tencentyun.com/ns/runtime:v1
+  registryType: personal
+  command: [/app/server]
+  args: [--listen, ":8080"]
+  env:
+    LOG_LEVEL: info
+    MODE: prod
+  ports:
+    - {name: http, port: 8080}
+  cpu: "2"
+  memory: 4Gi
+  probe: {path: /healthz, port: 8080, initialDelaySeconds: 5}
+`
+
+func TestCustom(t *testing.T) {
+	s, err := Parse([]byte(customSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	cfg := s.CreateOptions().CustomConfig
+	want := []client.EnvVar{{Name: "LOG_LEVEL", Value: "info"}, {Name: "MODE", Value: "prod"}}
+	if cfg == nil || cfg.Image != "ccr.ccs.tencentyun.com/ns/runtime:v1" || !reflect.DeepEqual(cfg.Env, want) {
+		t.Fatalf("CreateOptions().CustomConfig = %+v", cfg)
+	}
+
+	// The API fills in the default protocol and scheme
+	tool := &client.Tool{Name: "my-runtime", Type: "custom", NetworkMode: "PUBLIC", RoleArn: s.RoleArn, CustomConfig: cfg}
+	cfg.Ports[0].Protocol = "TCP"
+	cfg.Probe.Scheme = "HTTP"
+	if changes := s.Diff(tool); len(changes) != 0 {
+		t.Errorf("Diff() = %+v, want no changes", changes)
+	}
+	if changes := FromTool(tool).Diff(tool); len(changes) != 0 {
+		t.Errorf("FromTool().Diff() = %+v, want no changes", changes)
+	}
+
+	s.Custom.Image = "ccr.ccs.tencentyun.com/ns/runtime:v2"
+	if got := ImmutableFields(s.Diff(tool)); !reflect.DeepEqual(got, []string{"custom.image"}) {
+		t.Errorf("ImmutableFields() = %v", got)
+	}
+}
+
+func TestValidateCustom(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "wrong type", yaml: "name: a\ntype: browser\ncustom: {image: i}", wantErr: "type is custom"},
+		{name: "no image", yaml: "name: a\ntype: custom\ncustom: {cpu: '1'}", wantErr: "image is required"},
+		{name: "registry without role", yaml: "name: a\ntype: custom\ncustom: {image: i, registryType: personal}", wantErr: "roleArn"},
+		{name: "bad port", yaml: "name: a\ntype: custom\ncustom: {image: i, ports: [{port: 70000}]}", wantErr: "invalid port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if err := s.Validate(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,11 +4,17 @@
 // file-level locking (flock on Unix, LockFileEx on Windows) and atomic writes.
 // Zombie tunnel entries (where the backing process has died) are automatically
 // cleaned on List and Get operations.
+//
+// A scoped store (NewScopedStore) keys its entries as "<scope>/<sandbox ID>"
+// so that tunnels of different profiles and regions do not collide. Entries
+// written before scoping was introduced have bare keys and are visible in
+// every scope.
 package tunnelstore
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -29,6 +35,10 @@ const (
 	lockRetryDelay = 100 * time.Millisecond
 )
 
+// ErrCorrupt is returned by Check when the store file cannot be parsed.
+// Other operations discard such a file and start with an empty registry.
+var ErrCorrupt = errors.New("tunnel store file is corrupted")
+
 // TunnelEntry represents a single active tunnel mapping.
 type TunnelEntry struct {
 	PID       int       `json:"pid"`
@@ -41,6 +51,7 @@ type TunnelEntry struct {
 type Store struct {
 	path     string // path to tunnels.json
 	lockPath string // path to tunnels.json.lock
+	scope    string // key prefix; empty for an unscoped store
 }
 
 // NewStore creates a Store instance. The registry is stored at ~/.ags/tunnels.json.
@@ -64,6 +75,53 @@ func NewStore() (*Store, error) {
 	}, nil
 }
 
+// NewScopedStore creates a Store whose entries are separated from those of
+// other scopes (see config.StoreScope).
+func NewScopedStore(scope string) (*Store, error) {
+	s, err := NewStore()
+	if err != nil {
+		return nil, err
+	}
+	s.scope = scope
+	return s, nil
+}
+
+// Path returns the location of the store file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// key returns the registry key of a sandbox in this store's scope.
+func (s *Store) key(sandboxID string) string {
+	if s.scope == "" {
+		return sandboxID
+	}
+	return s.scope + "/" + sandboxID
+}
+
+// owns reports whether the registry key belongs to this store's scope and
+// returns the sandbox ID it refers to. Unscoped (legacy) keys belong to
+// every scope.
+func (s *Store) owns(key string) (string, bool) {
+	if s.scope == "" || !strings.Contains(key, "/") {
+		return key, true
+	}
+	id, ok := strings.CutPrefix(key, s.scope+"/")
+	return id, ok
+}
+
+// lookupLocked returns the registry key under which the sandbox's entry is
+// stored, preferring the scoped key over a legacy one.
+func (s *Store) lookupLocked(entries map[string]TunnelEntry, sandboxID string) (string, bool) {
+	if _, ok := entries[s.key(sandboxID)]; ok {
+		return s.key(sandboxID), true
+	}
+	if _, ok := entries[sandboxID]; ok {
+		return sandboxID, true
+	}
+	return "", false
+}
+
 // Save registers or updates a tunnel entry for the given sandbox ID.
 // Uses exclusive file lock + atomic write for cross-process safety.
 func (s *Store) Save(sandboxID string, entry TunnelEntry) error {
@@ -81,7 +139,8 @@ func (s *Store) Save(sandboxID string, entry TunnelEntry) error {
 		return err
 	}
 
-	entries[sandboxID] = entry
+	delete(entries, sandboxID) // drop a legacy entry for the same sandbox
+	entries[s.key(sandboxID)] = entry
 	return s.saveLocked(entries)
 }
 
@@ -102,6 +161,7 @@ func (s *Store) Remove(sandboxID string) error {
 		return err
 	}
 
+	delete(entries, s.key(sandboxID))
 	delete(entries, sandboxID)
 	return s.saveLocked(entries)
 }
@@ -118,8 +178,9 @@ func (s *Store) Get(sandboxID string) (TunnelEntry, bool, error) {
 	return entry, ok, nil
 }
 
-// List returns all live tunnel entries. Dead entries (where PID is no longer
-// alive) are automatically cleaned up.
+// List returns the live tunnel entries of this store's scope keyed by sandbox
+// ID. Dead entries (where PID is no longer alive) of every scope are
+// automatically cleaned up.
 func (s *Store) List() (map[string]TunnelEntry, error) {
 	fl := flock.New(s.lockPath)
 	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
@@ -148,7 +209,19 @@ func (s *Store) List() (map[string]TunnelEntry, error) {
 		_ = s.saveLocked(entries) // best-effort cleanup
 	}
 
-	return entries, nil
+	scoped := make(map[string]TunnelEntry, len(entries))
+	for key, entry := range entries {
+		id, ok := s.owns(key)
+		if !ok {
+			continue
+		}
+		// A scoped entry wins over a legacy one for the same sandbox
+		if _, seen := scoped[id]; seen && key == id {
+			continue
+		}
+		scoped[id] = entry
+	}
+	return scoped, nil
 }
 
 // Cleanup kills the tunnel process for the given sandbox ID (if alive)
@@ -170,21 +243,23 @@ func (s *Store) Cleanup(sandboxID string) error {
 		return err
 	}
 
-	if entry, ok := entries[sandboxID]; ok {
+	if key, ok := s.lookupLocked(entries, sandboxID); ok {
+		entry := entries[key]
 		if !killProcess(entry.PID, entry.ExePath) {
 			// Process could not be killed (PID reused or still alive).
 			// Keep the entry so the user knows the tunnel may still be running.
 			return fmt.Errorf("tunnel process (PID %d) could not be terminated — it may have been replaced by another process; entry preserved for manual cleanup", entry.PID)
 		}
-		delete(entries, sandboxID)
+		delete(entries, key)
 		return s.saveLocked(entries)
 	}
 
 	return nil
 }
 
-// CleanupAll kills all tunnel processes and clears the store.
-// Entries whose processes cannot be confirmed dead are preserved.
+// CleanupAll kills all tunnel processes of this store's scope and removes
+// their entries. Entries whose processes cannot be confirmed dead are
+// preserved.
 func (s *Store) CleanupAll() error {
 	fl := flock.New(s.lockPath)
 	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
@@ -201,9 +276,13 @@ func (s *Store) CleanupAll() error {
 	}
 
 	var warnings []string
-	for id, entry := range entries {
+	for key, entry := range entries {
+		id, ok := s.owns(key)
+		if !ok {
+			continue
+		}
 		if killProcess(entry.PID, entry.ExePath) {
-			delete(entries, id)
+			delete(entries, key)
 		} else {
 			warnings = append(warnings, fmt.Sprintf("PID %d (%s)", entry.PID, id))
 		}
@@ -219,6 +298,53 @@ func (s *Store) CleanupAll() error {
 	return nil
 }
 
+// StopProcess terminates a background process spawned by the CLI, verifying
+// its executable path first so a reused PID is never killed. It returns true
+// when the process is confirmed gone.
+func StopProcess(pid int, exePath string) bool {
+	return killProcess(pid, exePath)
+}
+
+// Check verifies that the store lock can be acquired and that the store file,
+// if it exists, is a regular file that parses. It returns the number of
+// entries in this store's scope and how many of them belong to processes that
+// are no longer running. Unlike List, it does not clean those entries up.
+func (s *Store) Check() (entries, stale int, err error) {
+	fl := flock.New(s.lockPath)
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+	locked, err := fl.TryLockContext(ctx, lockRetryDelay)
+	if err != nil || !locked {
+		return 0, 0, fmt.Errorf("failed to acquire store lock: %w", err)
+	}
+	defer func() { _ = fl.Unlock() }()
+
+	if info, err := os.Lstat(s.path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return 0, 0, fmt.Errorf("store file is a symlink (rejected for security): %s", s.path)
+	}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read store file: %w", err)
+	}
+	all := make(map[string]TunnelEntry)
+	if err := json.Unmarshal(data, &all); err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	for key, entry := range all {
+		if _, ok := s.owns(key); !ok {
+			continue
+		}
+		entries++
+		if !isProcessAlive(entry.PID) {
+			stale++
+		}
+	}
+	return entries, stale, nil
+}
+
 // loadLocked reads the store file. Must be called while holding the lock.
 func (s *Store) loadLocked() (map[string]TunnelEntry, error) {
 	// Defense-in-depth: reject symlinks to prevent redirection attacks
@@ -2,8 +2,10 @@ package tunnelstore
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -328,3 +330,73 @@ func TestSymlinkRejection(t *testing.T) {
 		t.Error("List() should reject symlink store file")
 	}
 }
+
+func TestScopedEntries(t *testing.T) {
+	base := newTestStore(t)
+	dev := &Store{path: base.path, lockPath: base.lockPath, scope: "dev@ap-guangzhou"}
+	prod := &Store{path: base.path, lockPath: base.lockPath, scope: "prod@ap-shanghai"}
+	pid := os.Getpid()
+
+	// Legacy entry written before scoping, visible in every scope
+	_ = base.Save("sandbox-old", TunnelEntry{PID: pid, Port: 15555, CreatedAt: time.Now()})
+	_ = dev.Save("sandbox-aaa", TunnelEntry{PID: pid, Port: 15556, CreatedAt: time.Now()})
+	_ = prod.Save("sandbox-aaa", TunnelEntry{PID: pid, Port: 15557, CreatedAt: time.Now()})
+
+	entry, ok, err := dev.Get("sandbox-aaa")
+	if err != nil || !ok || entry.Port != 15556 {
+		t.Errorf("dev Get() = %+v, %v, %v; want port 15556", entry, ok, err)
+	}
+	entry, ok, err = prod.Get("sandbox-aaa")
+	if err != nil || !ok || entry.Port != 15557 {
+		t.Errorf("prod Get() = %+v, %v, %v; want port 15557", entry, ok, err)
+	}
+	if _, ok, _ := prod.Get("sandbox-old"); !ok {
+		t.Error("legacy entry should be visible in every scope")
+	}
+
+	entries, err := dev.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("dev List() returned %d entries, want 2: %v", len(entries), entries)
+	}
+
+	if err := dev.Remove("sandbox-aaa"); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	if _, ok, _ := prod.Get("sandbox-aaa"); !ok {
+		t.Error("Remove() in one scope should not affect another")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	store := newTestStore(t)
+
+	if entries, stale, err := store.Check(); err != nil || entries != 0 || stale != 0 {
+		t.Fatalf("Check() on missing file = %d, %d, %v", entries, stale, err)
+	}
+
+	all := map[string]TunnelEntry{
+		"sandbox-live": {PID: os.Getpid(), Port: 15555},
+		"sandbox-dead": {PID: 999999999, Port: 15556},
+	}
+	data, _ := json.Marshal(all)
+	if err := os.WriteFile(store.path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if entries, stale, err := store.Check(); err != nil || entries != 2 || stale != 1 {
+		t.Errorf("Check() = %d, %d, %v; want 2, 1, nil", entries, stale, err)
+	}
+	// Check must not clean up the dead entry
+	if data, _ := os.ReadFile(store.path); !strings.Contains(string(data), "sandbox-dead") {
+		t.Error("Check() modified the store file")
+	}
+
+	if err := os.WriteFile(store.path, []byte("not json{{{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Check(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Check() on corrupted file = %v, want ErrCorrupt", err)
+	}
+}